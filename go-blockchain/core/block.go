@@ -43,16 +43,23 @@ type Block struct {
 	hash types.Hash
 }
 
-// Initialize a new Block
+// Initialize a new Block. The header's Datahash is set to the Merkle root
+// of the given transactions.
 func NewBlock(h *Header, txx []Transaction) *Block {
+	h.Datahash = CalculateDataHash(txx)
+
 	return &Block{
 		Header:       h,
 		Transactions: txx,
 	}
 }
 
+// AddTransaction appends tx to the block and recomputes the header's
+// Datahash. The block has to be (re)signed afterwards.
 func (b *Block) AddTransaction(tx *Transaction) {
 	b.Transactions = append(b.Transactions, *tx)
+	b.Header.Datahash = CalculateDataHash(b.Transactions)
+	b.hash = types.Hash{}
 }
 
 // Sign signs the block's header with the provided private key. It computes a
@@ -79,6 +86,10 @@ func (b *Block) Verify() error {
 		return fmt.Errorf("block has invalid signature")
 	}
 
+	if dataHash := CalculateDataHash(b.Transactions); dataHash != b.Datahash {
+		return fmt.Errorf("block (%d) has an invalid data hash", b.Height)
+	}
+
 	for _, tx := range b.Transactions {
 		if err := tx.Verify(); err != nil {
 			return err
@@ -88,6 +99,12 @@ func (b *Block) Verify() error {
 	return nil
 }
 
+// MerkleProof returns an inclusion proof for the transaction at index i that
+// can be verified against the block header alone.
+func (b *Block) MerkleProof(i int) (*MerkleProof, error) {
+	return NewMerkleProof(b.Transactions, i)
+}
+
 // Decode reads a Block from r using the supplied Decoder implementation.
 // The decoded data is written into the receiver. Any decoding error is
 // returned to the caller.
@@ -32,6 +32,21 @@ func TestVerifyBlock(t *testing.T) {
 	assert.NotNil(t, b.Verify())
 }
 
+func TestVerifyBlockDataHash(t *testing.T) {
+	privKey := crypto.GeneratePrivateKey()
+	b := randomBlockWithSignature(t, 0, types.Hash{})
+	assert.False(t, b.Datahash.IsZero())
+	assert.Equal(t, CalculateDataHash(b.Transactions), b.Datahash)
+	assert.Nil(t, b.Verify())
+
+	b.Transactions = append(b.Transactions, *randomTxWithSignature(t))
+	assert.NotNil(t, b.Verify())
+
+	b.AddTransaction(randomTxWithSignature(t))
+	assert.Nil(t, b.Sign(privKey))
+	assert.Nil(t, b.Verify())
+}
+
 func randomBlock(height uint32, prevBlockHash types.Hash) *Block {
 	header := &Header{
 		Version:       1,
@@ -51,3 +66,13 @@ func randomBlockWithSignature(t *testing.T, height uint32, prevBlockHash types.H
 	assert.Nil(t, b.Sign(privKey))
 	return b
 }
+
+func TestVerifyBlockWithTransactionWithoutSender(t *testing.T) {
+	tx := randomTxWithSignature(t)
+	tx.From = crypto.PublicKey{}
+
+	b := randomBlock(0, types.Hash{})
+	b.AddTransaction(tx)
+	assert.Nil(t, b.Sign(crypto.GeneratePrivateKey()))
+	assert.NotNil(t, b.Verify())
+}
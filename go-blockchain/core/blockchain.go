@@ -2,20 +2,32 @@ package core
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/sirupsen/logrus"
+	"github.com/thutasann/projectx/types"
 )
 
 type BlockChain struct {
+	// lock protects headers and txIndex, which are read concurrently by
+	// API handlers while new blocks are added.
+	lock      sync.RWMutex
 	store     Storage
 	headers   []*Header
 	validator Validator
+	// txIndex maps a transaction hash to the hash of the block containing it.
+	txIndex map[types.Hash]types.Hash
+	// state holds the committed contract storage and account balances,
+	// updated atomically each time a block is added.
+	state *State
 }
 
 func NewBlockChain(genesis *Block) (*BlockChain, error) {
 	bc := &BlockChain{
 		headers: []*Header{},
 		store:   NewMemoryStore(),
+		txIndex: make(map[types.Hash]types.Hash),
+		state:   NewState(),
 	}
 	bc.validator = NewBlockValidator(bc)
 	err := bc.addBlockWithoutValidation(genesis)
@@ -35,11 +47,60 @@ func (bc *BlockChain) AddBlock(b *Block) error {
 }
 
 func (bc *BlockChain) GetHeader(height uint32) (*Header, error) {
-	if height > bc.Height() {
-		return nil, fmt.Errorf("given height (%d) too high", height)
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+
+	return bc.getHeader(height)
+}
+
+// GetBlock returns the block at the given height.
+func (bc *BlockChain) GetBlock(height uint32) (*Block, error) {
+	header, err := bc.GetHeader(height)
+	if err != nil {
+		return nil, err
 	}
 
-	return bc.headers[height], nil
+	return bc.store.Get(BlockHasher{}.Hash(header))
+}
+
+// GetBlockByHash returns the block whose header hashes to hash.
+func (bc *BlockChain) GetBlockByHash(hash types.Hash) (*Block, error) {
+	return bc.store.Get(hash)
+}
+
+// GetTransaction returns the transaction with the given hash together with
+// the block that contains it.
+func (bc *BlockChain) GetTransaction(hash types.Hash) (*Transaction, *Block, error) {
+	bc.lock.RLock()
+	blockHash, ok := bc.txIndex[hash]
+	bc.lock.RUnlock()
+	if !ok {
+		return nil, nil, fmt.Errorf("transaction with hash (%s) not found", hash)
+	}
+
+	b, err := bc.store.Get(blockHash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := range b.Transactions {
+		if b.Transactions[i].Hash(TxHasher{}) == hash {
+			return &b.Transactions[i], b, nil
+		}
+	}
+
+	return nil, nil, fmt.Errorf("transaction with hash (%s) not found in block (%s)", hash, blockHash)
+}
+
+// GetBalance returns the committed balance of addr.
+func (bc *BlockChain) GetBalance(addr types.Address) uint64 {
+	return bc.state.Balance(addr)
+}
+
+// GetNonce returns the committed nonce of addr, which is the nonce its next
+// transaction must carry.
+func (bc *BlockChain) GetNonce(addr types.Address) uint64 {
+	return bc.state.Nonce(addr)
 }
 
 func (bc *BlockChain) HasBlock(height uint32) bool {
@@ -48,16 +109,109 @@ func (bc *BlockChain) HasBlock(height uint32) bool {
 
 // [0, 1, 2, 3] => 4 len => 3 height
 func (bc *BlockChain) Height() uint32 {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+
+	return bc.height()
+}
+
+func (bc *BlockChain) height() uint32 {
 	return uint32(len(bc.headers) - 1)
 }
 
+func (bc *BlockChain) getHeader(height uint32) (*Header, error) {
+	if height > bc.height() {
+		return nil, fmt.Errorf("given height (%d) too high", height)
+	}
+
+	return bc.headers[height], nil
+}
+
 func (bc *BlockChain) addBlockWithoutValidation(b *Block) error {
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+
+	genesis := len(bc.headers) == 0
+
+	batch := bc.state.Batch()
+	for i := range b.Transactions {
+		bc.executeTransaction(batch, &b.Transactions[i], genesis)
+	}
+
+	if err := bc.store.Put(b); err != nil {
+		return err
+	}
+
+	batch.Commit()
 	bc.headers = append(bc.headers, b.Header)
 
+	blockHash := b.Hash(BlockHasher{})
+	for i := range b.Transactions {
+		bc.txIndex[b.Transactions[i].Hash(TxHasher{})] = blockHash
+	}
+
 	logrus.WithFields(logrus.Fields{
 		"height": b.Height,
-		"hash":   b.Hash(BlockHasher{}),
+		"hash":   blockHash,
 	}).Info("adding new block")
 
-	return bc.store.Put(b)
+	return nil
+}
+
+// executeTransaction transfers the transaction's value and runs its bytecode
+// against the storage of its target contract. The writes of a successful run
+// are merged into the block batch; a failing run (insufficient balance,
+// invalid bytecode, out of gas, ...) is reverted so it has no effect on
+// state, identically on every node. Values in the genesis block are minted
+// rather than transferred, which is how initial balances are allocated.
+//
+// Outside genesis a transaction whose nonce isn't the sender's current nonce
+// is skipped entirely. Otherwise the nonce is used up even if the run fails,
+// so the same signed transaction can never be applied twice.
+func (bc *BlockChain) executeTransaction(blockBatch *StateBatch, tx *Transaction, genesis bool) {
+	if !genesis {
+		from := tx.From.Address()
+		if nonce := blockBatch.Nonce(from); tx.Nonce != nonce {
+			logrus.WithFields(logrus.Fields{
+				"hash":     tx.Hash(TxHasher{}),
+				"nonce":    tx.Nonce,
+				"expected": nonce,
+			}).Warn("transaction has invalid nonce")
+			return
+		}
+		blockBatch.IncrementNonce(from)
+	}
+
+	txBatch := blockBatch.Batch()
+
+	if err := applyTransaction(txBatch, tx, genesis); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"hash":  tx.Hash(TxHasher{}),
+			"error": err,
+		}).Warn("transaction execution failed")
+		return
+	}
+
+	txBatch.Commit()
+}
+
+func applyTransaction(batch *StateBatch, tx *Transaction, genesis bool) error {
+	if tx.Value > 0 {
+		var err error
+		if genesis {
+			err = batch.Mint(tx.To, tx.Value)
+		} else {
+			err = batch.Transfer(tx.From.Address(), tx.To, tx.Value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	vm := NewVM(tx.Data, batch.Storage(tx.To), DefaultGasLimit)
+	if err := vm.Run(); err != nil {
+		return fmt.Errorf("%w (gas used %d)", err, vm.GasUsed())
+	}
+
+	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/thutasann/projectx/crypto"
 	"github.com/thutasann/projectx/types"
 )
 
@@ -64,3 +65,125 @@ func getPrevBlockHash(t *testing.T, bc *BlockChain, height uint32) types.Hash {
 	assert.Nil(t, err)
 	return BlockHasher{}.Hash(prevHeader)
 }
+
+func TestGetBlockAndTransaction(t *testing.T) {
+	bc := newBlockChainWithGenesis(t)
+	block := randomBlockWithSignature(t, 1, getPrevBlockHash(t, bc, 1))
+	assert.Nil(t, bc.AddBlock(block))
+
+	b, err := bc.GetBlock(1)
+	assert.Nil(t, err)
+	assert.Equal(t, block, b)
+
+	b, err = bc.GetBlockByHash(block.Hash(BlockHasher{}))
+	assert.Nil(t, err)
+	assert.Equal(t, block, b)
+
+	_, err = bc.GetBlock(2)
+	assert.NotNil(t, err)
+
+	txHash := block.Transactions[0].Hash(TxHasher{})
+	tx, b, err := bc.GetTransaction(txHash)
+	assert.Nil(t, err)
+	assert.Equal(t, &block.Transactions[0], tx)
+	assert.Equal(t, block, b)
+
+	_, _, err = bc.GetTransaction(types.RandomHash())
+	assert.NotNil(t, err)
+}
+
+func TestTransferBalances(t *testing.T) {
+	alice := crypto.GeneratePrivateKey()
+	bob := crypto.GeneratePrivateKey().PublicKey().Address()
+
+	genesis := randomBlock(0, types.Hash{})
+	genesis.AddTransaction(&Transaction{To: alice.PublicKey().Address(), Value: 100})
+	bc, err := NewBlockChain(genesis)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(100), bc.GetBalance(alice.PublicKey().Address()))
+
+	transfer := &Transaction{To: bob, Value: 30}
+	assert.Nil(t, transfer.Sign(alice))
+	overdraft := &Transaction{To: bob, Value: 1000, Nonce: 1}
+	assert.Nil(t, overdraft.Sign(alice))
+
+	b := randomBlock(1, getPrevBlockHash(t, bc, 1))
+	b.AddTransaction(transfer)
+	b.AddTransaction(overdraft)
+	assert.Nil(t, b.Sign(crypto.GeneratePrivateKey()))
+	assert.Nil(t, bc.AddBlock(b))
+
+	assert.Equal(t, uint64(70), bc.GetBalance(alice.PublicKey().Address()))
+	assert.Equal(t, uint64(30), bc.GetBalance(bob))
+
+	// The failed overdraft still used up its nonce.
+	assert.Equal(t, uint64(2), bc.GetNonce(alice.PublicKey().Address()))
+}
+
+func TestSameTransferFromTwoSenders(t *testing.T) {
+	alice := crypto.GeneratePrivateKey()
+	carol := crypto.GeneratePrivateKey()
+	bob := crypto.GeneratePrivateKey().PublicKey().Address()
+
+	genesis := randomBlock(0, types.Hash{})
+	genesis.AddTransaction(&Transaction{To: alice.PublicKey().Address(), Value: 100})
+	genesis.AddTransaction(&Transaction{To: carol.PublicKey().Address(), Value: 100})
+	bc, err := NewBlockChain(genesis)
+	assert.Nil(t, err)
+
+	fromAlice := &Transaction{To: bob, Value: 10}
+	assert.Nil(t, fromAlice.Sign(alice))
+	fromCarol := &Transaction{To: bob, Value: 10}
+	assert.Nil(t, fromCarol.Sign(carol))
+	assert.NotEqual(t, fromAlice.Hash(TxHasher{}), fromCarol.Hash(TxHasher{}))
+
+	b := randomBlock(1, getPrevBlockHash(t, bc, 1))
+	b.AddTransaction(fromAlice)
+	b.AddTransaction(fromCarol)
+	assert.Nil(t, b.Sign(crypto.GeneratePrivateKey()))
+	assert.Nil(t, bc.AddBlock(b))
+
+	assert.Equal(t, uint64(20), bc.GetBalance(bob))
+	assert.Equal(t, uint64(90), bc.GetBalance(alice.PublicKey().Address()))
+	assert.Equal(t, uint64(90), bc.GetBalance(carol.PublicKey().Address()))
+
+	for _, tx := range []*Transaction{fromAlice, fromCarol} {
+		got, inBlock, err := bc.GetTransaction(tx.Hash(TxHasher{}))
+		assert.Nil(t, err)
+		assert.Equal(t, tx.From.Address(), got.From.Address())
+		assert.Equal(t, b, inBlock)
+	}
+}
+
+func TestRepeatedTransferNeedsNextNonce(t *testing.T) {
+	alice := crypto.GeneratePrivateKey()
+	bob := crypto.GeneratePrivateKey().PublicKey().Address()
+
+	genesis := randomBlock(0, types.Hash{})
+	genesis.AddTransaction(&Transaction{To: alice.PublicKey().Address(), Value: 100})
+	bc, err := NewBlockChain(genesis)
+	assert.Nil(t, err)
+
+	first := &Transaction{To: bob, Value: 10}
+	assert.Nil(t, first.Sign(alice))
+	second := &Transaction{To: bob, Value: 10, Nonce: 1}
+	assert.Nil(t, second.Sign(alice))
+	assert.NotEqual(t, first.Hash(TxHasher{}), second.Hash(TxHasher{}))
+
+	b := randomBlock(1, getPrevBlockHash(t, bc, 1))
+	b.AddTransaction(first)
+	b.AddTransaction(second)
+	// Replays of first: same nonce, so neither is applied again.
+	b.AddTransaction(first)
+	assert.Nil(t, b.Sign(crypto.GeneratePrivateKey()))
+	assert.Nil(t, bc.AddBlock(b))
+
+	assert.Equal(t, uint64(20), bc.GetBalance(bob))
+	assert.Equal(t, uint64(2), bc.GetNonce(alice.PublicKey().Address()))
+
+	replay := randomBlock(2, getPrevBlockHash(t, bc, 2))
+	replay.AddTransaction(first)
+	assert.Nil(t, replay.Sign(crypto.GeneratePrivateKey()))
+	assert.Nil(t, bc.AddBlock(replay))
+	assert.Equal(t, uint64(20), bc.GetBalance(bob))
+}
@@ -1,6 +1,9 @@
 package core
 
-import "io"
+import (
+	"encoding/gob"
+	"io"
+)
 
 // Encoder[T] is a generic interface for types that can encode values of
 // type T to an io.Writer. Implementations should write a deterministic
@@ -17,3 +20,18 @@ type Encoder[T any] interface {
 type Decoder[T any] interface {
 	Decode(io.Reader, T) error
 }
+
+// GobTxEncoder encodes transactions with encoding/gob. It is the wire format
+// used for raw transactions submitted to a node.
+type GobTxEncoder struct{}
+
+func (GobTxEncoder) Encode(w io.Writer, tx *Transaction) error {
+	return gob.NewEncoder(w).Encode(tx)
+}
+
+// GobTxDecoder decodes transactions written by GobTxEncoder.
+type GobTxDecoder struct{}
+
+func (GobTxDecoder) Decode(r io.Reader, tx *Transaction) error {
+	return gob.NewDecoder(r).Decode(tx)
+}
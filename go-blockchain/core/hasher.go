@@ -2,6 +2,7 @@ package core
 
 import (
 	"crypto/sha256"
+	"encoding/binary"
 
 	"github.com/thutasann/projectx/types"
 )
@@ -22,3 +23,21 @@ func (BlockHasher) Hash(b *Header) types.Hash {
 	h := sha256.Sum256(b.Bytes())
 	return types.Hash(h)
 }
+
+// TxHasher computes a transaction hash from its payload, recipient, value,
+// sender and nonce using SHA-256. The resulting digest is what gets signed by
+// the sender and what is used as the leaf value when building a block's
+// Merkle tree. Sender and nonce make two otherwise identical transfers, from
+// different accounts or repeated by the same one, hash differently.
+type TxHasher struct{}
+
+func (TxHasher) Hash(tx *Transaction) types.Hash {
+	h := sha256.New()
+	h.Write(tx.Data)
+	h.Write(tx.To.ToSlice())
+	binary.Write(h, binary.BigEndian, tx.Value)
+	h.Write(tx.From.ToSlice())
+	binary.Write(h, binary.BigEndian, tx.Nonce)
+
+	return types.HashFromBytes(h.Sum(nil))
+}
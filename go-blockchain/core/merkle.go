@@ -0,0 +1,157 @@
+package core
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/thutasann/projectx/types"
+)
+
+// Domain separation prefixes for Merkle tree hashing. Leaves and inner nodes
+// are hashed with different prefixes so an inner node can never be passed off
+// as a leaf (second preimage attack).
+const (
+	merkleLeafPrefix byte = 0x00
+	merkleNodePrefix byte = 0x01
+)
+
+// MerkleProofStep is a single sibling hash on the path from a leaf to the
+// Merkle root. Left reports whether the sibling sits on the left side, i.e.
+// whether it has to be hashed before the running value.
+type MerkleProofStep struct {
+	Hash types.Hash
+	Left bool
+}
+
+// MerkleProof proves that a transaction hash is a leaf of a Merkle tree.
+// Combined with a block header it lets a light client confirm that a
+// transaction is included in a block without downloading the block body.
+type MerkleProof struct {
+	TxHash types.Hash
+	Steps  []MerkleProofStep
+}
+
+// CalculateDataHash returns the Merkle root of the given transactions, which
+// is stored in Header.Datahash. An empty transaction list yields the zero hash.
+func CalculateDataHash(txx []Transaction) types.Hash {
+	return MerkleRoot(txHashes(txx))
+}
+
+// MerkleRoot builds a Merkle tree over the given leaf hashes and returns its
+// root. When a level has an odd number of nodes the last node is promoted to
+// the next level unchanged.
+func MerkleRoot(leaves []types.Hash) types.Hash {
+	if len(leaves) == 0 {
+		return types.Hash{}
+	}
+
+	level := make([]types.Hash, len(leaves))
+	for i, leaf := range leaves {
+		level[i] = hashMerkleLeaf(leaf)
+	}
+
+	for len(level) > 1 {
+		level = nextMerkleLevel(level)
+	}
+
+	return level[0]
+}
+
+// NewMerkleProof builds an inclusion proof for the transaction at index i.
+func NewMerkleProof(txx []Transaction, i int) (*MerkleProof, error) {
+	if i < 0 || i >= len(txx) {
+		return nil, fmt.Errorf("transaction index (%d) out of range [0, %d)", i, len(txx))
+	}
+
+	hashes := txHashes(txx)
+	proof := &MerkleProof{TxHash: hashes[i]}
+
+	level := make([]types.Hash, len(hashes))
+	for j, h := range hashes {
+		level[j] = hashMerkleLeaf(h)
+	}
+
+	for len(level) > 1 {
+		sibling := i ^ 1
+		if sibling < len(level) {
+			proof.Steps = append(proof.Steps, MerkleProofStep{
+				Hash: level[sibling],
+				Left: sibling < i,
+			})
+		}
+
+		level = nextMerkleLevel(level)
+		i /= 2
+	}
+
+	return proof, nil
+}
+
+// Verify reports whether the proof links its transaction hash to root.
+func (p *MerkleProof) Verify(root types.Hash) bool {
+	h := hashMerkleLeaf(p.TxHash)
+
+	for _, step := range p.Steps {
+		if step.Left {
+			h = hashMerkleNode(step.Hash, h)
+		} else {
+			h = hashMerkleNode(h, step.Hash)
+		}
+	}
+
+	return h == root
+}
+
+// VerifyTransactionInclusion checks that tx is part of the block described by
+// the header using only the header's Datahash and the given proof.
+func VerifyTransactionInclusion(h *Header, tx *Transaction, proof *MerkleProof) error {
+	if proof.TxHash != tx.Hash(TxHasher{}) {
+		return fmt.Errorf("merkle proof is for a different transaction")
+	}
+
+	if !proof.Verify(h.Datahash) {
+		return fmt.Errorf("transaction (%s) is not included in block (%d)", proof.TxHash, h.Height)
+	}
+
+	return nil
+}
+
+func txHashes(txx []Transaction) []types.Hash {
+	hashes := make([]types.Hash, len(txx))
+	for i := range txx {
+		hashes[i] = TxHasher{}.Hash(&txx[i])
+	}
+
+	return hashes
+}
+
+func nextMerkleLevel(level []types.Hash) []types.Hash {
+	next := make([]types.Hash, 0, (len(level)+1)/2)
+
+	for i := 0; i < len(level); i += 2 {
+		if i+1 == len(level) {
+			next = append(next, level[i])
+			continue
+		}
+		next = append(next, hashMerkleNode(level[i], level[i+1]))
+	}
+
+	return next
+}
+
+func hashMerkleLeaf(h types.Hash) types.Hash {
+	buf := make([]byte, 0, 33)
+	buf = append(buf, merkleLeafPrefix)
+	buf = append(buf, h[:]...)
+
+	return types.Hash(sha256.Sum256(buf))
+}
+
+func hashMerkleNode(left, right types.Hash) types.Hash {
+	buf := make([]byte, 0, 65)
+	buf = append(buf, merkleNodePrefix)
+	buf = append(buf, left[:]...)
+	buf = append(buf, right[:]...)
+
+	return types.Hash(sha256.Sum256(buf))
+}
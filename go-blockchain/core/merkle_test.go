@@ -0,0 +1,104 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thutasann/projectx/crypto"
+	"github.com/thutasann/projectx/types"
+)
+
+func TestMerkleRootEmpty(t *testing.T) {
+	assert.True(t, MerkleRoot(nil).IsZero())
+	assert.True(t, CalculateDataHash([]Transaction{}).IsZero())
+}
+
+func TestMerkleRootDeterministic(t *testing.T) {
+	txx := randomTxs(t, 5)
+
+	assert.Equal(t, CalculateDataHash(txx), CalculateDataHash(txx))
+
+	txx[0], txx[1] = txx[1], txx[0]
+	swapped := CalculateDataHash(txx)
+	txx[0], txx[1] = txx[1], txx[0]
+	assert.NotEqual(t, CalculateDataHash(txx), swapped)
+}
+
+func TestMerkleRootSingleLeafIsNotRawHash(t *testing.T) {
+	txx := randomTxs(t, 1)
+	root := CalculateDataHash(txx)
+
+	assert.NotEqual(t, txx[0].Hash(TxHasher{}), root)
+}
+
+func TestMerkleProof(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 4, 5, 7, 8, 13} {
+		t.Run(fmt.Sprintf("%d txs", n), func(t *testing.T) {
+			txx := randomTxs(t, n)
+			root := CalculateDataHash(txx)
+
+			for i := range txx {
+				proof, err := NewMerkleProof(txx, i)
+				assert.Nil(t, err)
+				assert.Equal(t, txx[i].Hash(TxHasher{}), proof.TxHash)
+				assert.True(t, proof.Verify(root))
+				assert.False(t, proof.Verify(types.RandomHash()))
+			}
+		})
+	}
+}
+
+func TestMerkleProofOutOfRange(t *testing.T) {
+	txx := randomTxs(t, 3)
+
+	_, err := NewMerkleProof(txx, 3)
+	assert.NotNil(t, err)
+
+	_, err = NewMerkleProof(txx, -1)
+	assert.NotNil(t, err)
+}
+
+func TestMerkleProofTampered(t *testing.T) {
+	txx := randomTxs(t, 6)
+	root := CalculateDataHash(txx)
+
+	proof, err := NewMerkleProof(txx, 2)
+	assert.Nil(t, err)
+
+	proof.Steps[0].Hash = types.RandomHash()
+	assert.False(t, proof.Verify(root))
+
+	proof, err = NewMerkleProof(txx, 2)
+	assert.Nil(t, err)
+
+	proof.Steps[1].Left = !proof.Steps[1].Left
+	assert.False(t, proof.Verify(root))
+}
+
+func TestVerifyTransactionInclusion(t *testing.T) {
+	b := randomBlock(0, types.Hash{})
+	for _, tx := range randomTxs(t, 4) {
+		b.AddTransaction(&tx)
+	}
+
+	proof, err := b.MerkleProof(1)
+	assert.Nil(t, err)
+	assert.Nil(t, VerifyTransactionInclusion(b.Header, &b.Transactions[1], proof))
+	assert.NotNil(t, VerifyTransactionInclusion(b.Header, &b.Transactions[2], proof))
+
+	other := randomBlock(0, types.Hash{})
+	other.AddTransaction(randomTxWithSignature(t))
+	assert.NotNil(t, VerifyTransactionInclusion(other.Header, &b.Transactions[1], proof))
+}
+
+func randomTxs(t *testing.T, n int) []Transaction {
+	txx := make([]Transaction, n)
+	for i := range txx {
+		tx := Transaction{Data: types.RandomBytes(32)}
+		assert.Nil(t, tx.Sign(crypto.GeneratePrivateKey()))
+		txx[i] = tx
+	}
+
+	return txx
+}
@@ -0,0 +1,222 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/thutasann/projectx/types"
+)
+
+// ContractStorage is the key-value store a single contract sees while its
+// bytecode is executed by the VM. Missing keys read as zero.
+type ContractStorage interface {
+	Get(key int64) int64
+	Set(key, value int64)
+}
+
+// stateLayer is implemented by every level of the state hierarchy so that a
+// StateBatch can read through to, and commit into, its parent.
+type stateLayer interface {
+	Get(contract types.Address, key int64) int64
+	Balance(addr types.Address) uint64
+	Nonce(addr types.Address) uint64
+	apply(writes *stateWrites)
+}
+
+// stateWrites holds contract storage, account balance and account nonce
+// changes.
+type stateWrites struct {
+	storage  map[types.Address]map[int64]int64
+	balances map[types.Address]uint64
+	nonces   map[types.Address]uint64
+}
+
+func newStateWrites() *stateWrites {
+	return &stateWrites{
+		storage:  make(map[types.Address]map[int64]int64),
+		balances: make(map[types.Address]uint64),
+		nonces:   make(map[types.Address]uint64),
+	}
+}
+
+func (w *stateWrites) merge(src *stateWrites) {
+	for contract, kv := range src.storage {
+		if w.storage[contract] == nil {
+			w.storage[contract] = make(map[int64]int64, len(kv))
+		}
+		for key, value := range kv {
+			w.storage[contract][key] = value
+		}
+	}
+
+	for addr, balance := range src.balances {
+		w.balances[addr] = balance
+	}
+
+	for addr, nonce := range src.nonces {
+		w.nonces[addr] = nonce
+	}
+}
+
+// State holds the committed per-contract key-value storage and the account
+// balances and nonces of the chain. It is only modified by committing a StateBatch.
+type State struct {
+	lock sync.RWMutex
+	data *stateWrites
+}
+
+func NewState() *State {
+	return &State{
+		data: newStateWrites(),
+	}
+}
+
+// Get returns the committed value stored under key for contract.
+func (s *State) Get(contract types.Address, key int64) int64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.data.storage[contract][key]
+}
+
+// Balance returns the committed balance of addr.
+func (s *State) Balance(addr types.Address) uint64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.data.balances[addr]
+}
+
+// Nonce returns the committed nonce of addr: the number of transactions it
+// has sent.
+func (s *State) Nonce(addr types.Address) uint64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.data.nonces[addr]
+}
+
+// Batch returns a new batch of pending writes on top of the committed state.
+func (s *State) Batch() *StateBatch {
+	return newStateBatch(s)
+}
+
+func (s *State) apply(writes *stateWrites) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.data.merge(writes)
+}
+
+// StateBatch buffers writes on top of a parent layer. Reads fall through to
+// the parent for keys the batch has not written. Nothing reaches the parent
+// until Commit is called, so a discarded batch leaves no trace.
+type StateBatch struct {
+	parent stateLayer
+	writes *stateWrites
+}
+
+func newStateBatch(parent stateLayer) *StateBatch {
+	return &StateBatch{
+		parent: parent,
+		writes: newStateWrites(),
+	}
+}
+
+// Get returns the value stored under key for contract as seen by this batch.
+func (b *StateBatch) Get(contract types.Address, key int64) int64 {
+	if value, ok := b.writes.storage[contract][key]; ok {
+		return value
+	}
+
+	return b.parent.Get(contract, key)
+}
+
+// Set buffers a write of value under key for contract.
+func (b *StateBatch) Set(contract types.Address, key, value int64) {
+	if b.writes.storage[contract] == nil {
+		b.writes.storage[contract] = make(map[int64]int64)
+	}
+
+	b.writes.storage[contract][key] = value
+}
+
+// Balance returns the balance of addr as seen by this batch.
+func (b *StateBatch) Balance(addr types.Address) uint64 {
+	if balance, ok := b.writes.balances[addr]; ok {
+		return balance
+	}
+
+	return b.parent.Balance(addr)
+}
+
+// Nonce returns the nonce of addr as seen by this batch.
+func (b *StateBatch) Nonce(addr types.Address) uint64 {
+	if nonce, ok := b.writes.nonces[addr]; ok {
+		return nonce
+	}
+
+	return b.parent.Nonce(addr)
+}
+
+// IncrementNonce records that addr sent one more transaction.
+func (b *StateBatch) IncrementNonce(addr types.Address) {
+	b.writes.nonces[addr] = b.Nonce(addr) + 1
+}
+
+// Mint credits amount to addr without debiting anyone. It is only used for
+// the allocations in the genesis block.
+func (b *StateBatch) Mint(addr types.Address, amount uint64) error {
+	balance := b.Balance(addr)
+	if balance+amount < balance {
+		return fmt.Errorf("balance of %s overflows", addr)
+	}
+
+	b.writes.balances[addr] = balance + amount
+	return nil
+}
+
+// Transfer moves amount from one account to another. It returns an error if
+// the sender's balance is insufficient.
+func (b *StateBatch) Transfer(from, to types.Address, amount uint64) error {
+	fromBalance := b.Balance(from)
+	if fromBalance < amount {
+		return fmt.Errorf("insufficient balance: %s has %d, needs %d", from, fromBalance, amount)
+	}
+
+	b.writes.balances[from] = fromBalance - amount
+	return b.Mint(to, amount)
+}
+
+// Batch returns a nested batch whose writes are committed into b.
+func (b *StateBatch) Batch() *StateBatch {
+	return newStateBatch(b)
+}
+
+// Storage returns a ContractStorage view of this batch scoped to contract.
+func (b *StateBatch) Storage(contract types.Address) ContractStorage {
+	return &contractStorage{batch: b, contract: contract}
+}
+
+// Commit applies all buffered writes to the parent layer at once.
+func (b *StateBatch) Commit() {
+	b.parent.apply(b.writes)
+	b.writes = newStateWrites()
+}
+
+func (b *StateBatch) apply(writes *stateWrites) {
+	b.writes.merge(writes)
+}
+
+type contractStorage struct {
+	batch    *StateBatch
+	contract types.Address
+}
+
+func (s *contractStorage) Get(key int64) int64 {
+	return s.batch.Get(s.contract, key)
+}
+
+func (s *contractStorage) Set(key, value int64) {
+	s.batch.Set(s.contract, key, value)
+}
@@ -1,15 +1,45 @@
 package core
 
+import (
+	"fmt"
+	"sync"
+
+	"github.com/thutasann/projectx/types"
+)
+
 type Storage interface {
 	Put(*Block) error
+	Get(types.Hash) (*Block, error)
 }
 
-type MemoryStore struct{}
+// MemoryStore keeps blocks in memory indexed by their header hash.
+type MemoryStore struct {
+	lock   sync.RWMutex
+	blocks map[types.Hash]*Block
+}
 
 func NewMemoryStore() *MemoryStore {
-	return &MemoryStore{}
+	return &MemoryStore{
+		blocks: make(map[types.Hash]*Block),
+	}
 }
 
 func (s *MemoryStore) Put(b *Block) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.blocks[b.Hash(BlockHasher{})] = b
 	return nil
 }
+
+func (s *MemoryStore) Get(hash types.Hash) (*Block, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	b, ok := s.blocks[hash]
+	if !ok {
+		return nil, fmt.Errorf("block with hash (%s) not found", hash)
+	}
+
+	return b, nil
+}
@@ -2,45 +2,80 @@ package core
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/thutasann/projectx/crypto"
+	"github.com/thutasann/projectx/types"
 )
 
 // Transaction represents a signed payload that can be verified by peers.
-// It contains the raw data, the public key of the signer, and the signature.
+// It contains the raw data, the recipient or contract it targets, the amount
+// transferred, the sender's nonce, the public key of the signer, and the
+// signature. When the transaction's block is applied, Value is moved from the
+// signer to To and Data is executed as VM bytecode against the storage of the
+// contract at To.
+//
+// Nonce must equal the number of transactions the sender already has on
+// chain (see BlockChain.GetNonce), so each signed transaction can be applied
+// once and only in order.
 type Transaction struct {
-	Data []byte
+	Data  []byte
+	To    types.Address
+	Value uint64
+	Nonce uint64
 
 	From      crypto.PublicKey
 	Signature *crypto.Signature
 }
 
-// Sign signs the transaction data with the provided private key.
-// It stores the signer's public key and the generated signature on the transaction.
+// Sign signs the transaction hash (see TxHasher) with the provided private key.
+// It stores the signer's public key, which is part of the hash, and the
+// generated signature on the transaction.
 func (tx *Transaction) Sign(privKey crypto.PrivateKey) error {
-	s, err := privKey.Sign(tx.Data)
+	tx.From = privKey.PublicKey()
+	hash := tx.Hash(TxHasher{})
+
+	s, err := privKey.Sign(hash.ToSlice())
 	if err != nil {
 		return err
 	}
 
-	tx.From = privKey.PublicKey()
 	tx.Signature = s
 
 	return nil
 }
 
 // Verify verifies the transaction's signature using the stored public key
-// and transaction data. It returns an error if the signature is missing or
-// does not match the provided data. The `Data` field must be the same value
-// that was signed (typically the hashed message used when signing).
+// and the transaction hash. It returns an error if the signature or sender is
+// missing, or if the signature does not match the hash of the current `Data`,
+// `To`, `Value`, `From` and `Nonce`.
 func (tx *Transaction) Verify() error {
 	if tx.Signature == nil {
 		return fmt.Errorf("transaction has no signature")
 	}
+	if tx.From.IsZero() {
+		return fmt.Errorf("transaction has no sender")
+	}
 
-	if !tx.Signature.Verify(tx.From, tx.Data) {
+	hash := tx.Hash(TxHasher{})
+	if !tx.Signature.Verify(tx.From, hash.ToSlice()) {
 		return fmt.Errorf("invalid transaction signature")
 	}
 
 	return nil
 }
+
+// Decode reads a Transaction from r using the supplied Decoder implementation.
+func (tx *Transaction) Decode(r io.Reader, dec Decoder[*Transaction]) error {
+	return dec.Decode(r, tx)
+}
+
+// Encode writes the Transaction to w using the supplied Encoder implementation.
+func (tx *Transaction) Encode(w io.Writer, enc Encoder[*Transaction]) error {
+	return enc.Encode(w, tx)
+}
+
+// Hash returns the transaction hash computed by the given hasher.
+func (tx *Transaction) Hash(hasher Hasher[*Transaction]) types.Hash {
+	return hasher.Hash(tx)
+}
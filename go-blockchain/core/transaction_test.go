@@ -1,10 +1,12 @@
 package core
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/thutasann/projectx/crypto"
+	"github.com/thutasann/projectx/types"
 )
 
 func TestSignTransaction(t *testing.T) {
@@ -32,6 +34,27 @@ func TestVerifyTransaction(t *testing.T) {
 	assert.NotNil(t, tx.Verify())
 }
 
+func TestVerifyTransactionTamperedData(t *testing.T) {
+	privKey := crypto.GeneratePrivateKey()
+	tx := &Transaction{
+		Data: []byte("foo"),
+	}
+
+	assert.Nil(t, tx.Sign(privKey))
+	assert.True(t, tx.Signature.Verify(tx.From, tx.Hash(TxHasher{}).ToSlice()))
+
+	tx.Data = []byte("bar")
+	assert.NotNil(t, tx.Verify())
+}
+
+func TestVerifyTransactionTamperedNonce(t *testing.T) {
+	tx := &Transaction{To: types.Address{1}, Value: 10}
+	assert.Nil(t, tx.Sign(crypto.GeneratePrivateKey()))
+
+	tx.Nonce = 1
+	assert.NotNil(t, tx.Verify())
+}
+
 func randomTxWithSignature(t *testing.T) *Transaction {
 	privKey := crypto.GeneratePrivateKey()
 	tx := &Transaction{
@@ -40,3 +63,35 @@ func randomTxWithSignature(t *testing.T) *Transaction {
 	assert.Nil(t, tx.Sign(privKey))
 	return tx
 }
+
+func TestEncodeDecodeTransaction(t *testing.T) {
+	tx := randomTxWithSignature(t)
+	tx.To = types.Address{1, 2, 3}
+	tx.Value = 10
+	assert.Nil(t, tx.Sign(crypto.GeneratePrivateKey()))
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, tx.Encode(buf, GobTxEncoder{}))
+
+	txDecoded := new(Transaction)
+	assert.Nil(t, txDecoded.Decode(buf, GobTxDecoder{}))
+	assert.Equal(t, tx.Hash(TxHasher{}), txDecoded.Hash(TxHasher{}))
+	assert.Equal(t, tx.From.Address(), txDecoded.From.Address())
+	assert.Nil(t, txDecoded.Verify())
+}
+
+func TestVerifyTransactionWithoutSender(t *testing.T) {
+	tx := &Transaction{To: types.Address{1}, Value: 10}
+	assert.Nil(t, tx.Sign(crypto.GeneratePrivateKey()))
+	tx.From = crypto.PublicKey{}
+	assert.NotNil(t, tx.Verify())
+
+	// An empty From survives encoding as a nil key.
+	buf := &bytes.Buffer{}
+	assert.Nil(t, tx.Encode(buf, GobTxEncoder{}))
+
+	txDecoded := new(Transaction)
+	assert.Nil(t, txDecoded.Decode(buf, GobTxDecoder{}))
+	assert.True(t, txDecoded.From.IsZero())
+	assert.NotNil(t, txDecoded.Verify())
+}
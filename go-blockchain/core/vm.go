@@ -0,0 +1,339 @@
+package core
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Instruction is a single VM opcode. Every opcode is one byte long except
+// InstrPush, which is followed by an 8-byte big-endian signed operand.
+type Instruction byte
+
+const (
+	InstrStop Instruction = 0x00 // halt execution successfully
+	InstrPush Instruction = 0x0a // push the following int64 operand
+	InstrPop  Instruction = 0x0b // discard the top of the stack
+	InstrDup  Instruction = 0x0c // duplicate the top of the stack
+	InstrSwap Instruction = 0x0d // swap the two topmost values
+
+	InstrAdd Instruction = 0x10 // a + b
+	InstrSub Instruction = 0x11 // a - b
+	InstrMul Instruction = 0x12 // a * b
+	InstrDiv Instruction = 0x13 // a / b, fails on division by zero
+	InstrMod Instruction = 0x14 // a % b, fails on division by zero
+
+	InstrEq  Instruction = 0x20 // 1 if a == b, else 0
+	InstrLt  Instruction = 0x21 // 1 if a < b, else 0
+	InstrGt  Instruction = 0x22 // 1 if a > b, else 0
+	InstrNot Instruction = 0x23 // 1 if a == 0, else 0
+
+	InstrJump   Instruction = 0x30 // jump to the popped offset
+	InstrJumpIf Instruction = 0x31 // pop offset, pop cond; jump if cond != 0
+
+	InstrLoad  Instruction = 0x40 // pop key, push storage[key]
+	InstrStore Instruction = 0x41 // pop value, pop key, storage[key] = value
+)
+
+// For binary operations a is the value pushed first and b the value pushed
+// last (the top of the stack).
+
+const (
+	// MaxStackSize is the maximum number of values on the VM stack.
+	MaxStackSize = 1024
+	// DefaultGasLimit is the gas available to a single transaction.
+	DefaultGasLimit uint64 = 100_000
+)
+
+// gasCosts holds the price of every opcode. Opcodes missing from the table
+// are invalid.
+var gasCosts = map[Instruction]uint64{
+	InstrStop:   0,
+	InstrPush:   1,
+	InstrPop:    1,
+	InstrDup:    1,
+	InstrSwap:   1,
+	InstrAdd:    2,
+	InstrSub:    2,
+	InstrMul:    3,
+	InstrDiv:    5,
+	InstrMod:    5,
+	InstrEq:     2,
+	InstrLt:     2,
+	InstrGt:     2,
+	InstrNot:    2,
+	InstrJump:   4,
+	InstrJumpIf: 5,
+	InstrLoad:   20,
+	InstrStore:  100,
+}
+
+var (
+	ErrOutOfGas           = errors.New("vm: out of gas")
+	ErrStackOverflow      = errors.New("vm: stack overflow")
+	ErrStackUnderflow     = errors.New("vm: stack underflow")
+	ErrDivisionByZero     = errors.New("vm: division by zero")
+	ErrInvalidJump        = errors.New("vm: invalid jump destination")
+	ErrInvalidInstruction = errors.New("vm: invalid instruction")
+	ErrTruncatedOperand   = errors.New("vm: truncated push operand")
+)
+
+// VM is a small stack-based virtual machine executing contract bytecode.
+// Execution is bounded by a gas limit and is fully deterministic: the same
+// code, storage and limit always produce the same result on every node.
+type VM struct {
+	code    []byte
+	ip      int
+	stack   []int64
+	storage ContractStorage
+	gas     uint64
+	gasUsed uint64
+	// jumpDests marks the offsets that start an instruction. Jumping into
+	// the middle of a push operand is rejected.
+	jumpDests []bool
+}
+
+func NewVM(code []byte, storage ContractStorage, gasLimit uint64) *VM {
+	return &VM{
+		code:      code,
+		stack:     make([]int64, 0, 16),
+		storage:   storage,
+		gas:       gasLimit,
+		jumpDests: analyzeJumpDests(code),
+	}
+}
+
+// Run executes the bytecode until it stops or an error occurs. Storage
+// writes made before an error are not rolled back by the VM; callers run it
+// against a StateBatch and discard the batch on failure.
+func (vm *VM) Run() error {
+	for vm.ip < len(vm.code) {
+		instr := Instruction(vm.code[vm.ip])
+
+		cost, ok := gasCosts[instr]
+		if !ok {
+			return fmt.Errorf("%w 0x%02x at offset %d", ErrInvalidInstruction, byte(instr), vm.ip)
+		}
+		if cost > vm.gas {
+			vm.gasUsed += vm.gas
+			vm.gas = 0
+			return ErrOutOfGas
+		}
+		vm.gas -= cost
+		vm.gasUsed += cost
+
+		if instr == InstrStop {
+			return nil
+		}
+
+		if err := vm.exec(instr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GasUsed returns the amount of gas consumed so far.
+func (vm *VM) GasUsed() uint64 {
+	return vm.gasUsed
+}
+
+// Stack returns a copy of the current stack, bottom first.
+func (vm *VM) Stack() []int64 {
+	out := make([]int64, len(vm.stack))
+	copy(out, vm.stack)
+	return out
+}
+
+func (vm *VM) exec(instr Instruction) error {
+	switch instr {
+	case InstrPush:
+		if vm.ip+9 > len(vm.code) {
+			return ErrTruncatedOperand
+		}
+		v := int64(binary.BigEndian.Uint64(vm.code[vm.ip+1 : vm.ip+9]))
+		vm.ip += 9
+		return vm.push(v)
+
+	case InstrPop:
+		_, err := vm.pop()
+		vm.ip++
+		return err
+
+	case InstrDup:
+		v, err := vm.peek()
+		if err != nil {
+			return err
+		}
+		vm.ip++
+		return vm.push(v)
+
+	case InstrSwap:
+		n := len(vm.stack)
+		if n < 2 {
+			return ErrStackUnderflow
+		}
+		vm.stack[n-1], vm.stack[n-2] = vm.stack[n-2], vm.stack[n-1]
+		vm.ip++
+		return nil
+
+	case InstrAdd, InstrSub, InstrMul, InstrDiv, InstrMod, InstrEq, InstrLt, InstrGt:
+		b, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		a, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		v, err := binaryOp(instr, a, b)
+		if err != nil {
+			return err
+		}
+		vm.ip++
+		return vm.push(v)
+
+	case InstrNot:
+		a, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		vm.ip++
+		return vm.push(boolToInt(a == 0))
+
+	case InstrJump:
+		dest, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		return vm.jump(dest)
+
+	case InstrJumpIf:
+		dest, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		cond, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		if cond == 0 {
+			vm.ip++
+			return nil
+		}
+		return vm.jump(dest)
+
+	case InstrLoad:
+		key, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		vm.ip++
+		return vm.push(vm.storage.Get(key))
+
+	case InstrStore:
+		value, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		key, err := vm.pop()
+		if err != nil {
+			return err
+		}
+		vm.storage.Set(key, value)
+		vm.ip++
+		return nil
+	}
+
+	return fmt.Errorf("%w 0x%02x at offset %d", ErrInvalidInstruction, byte(instr), vm.ip)
+}
+
+func (vm *VM) jump(dest int64) error {
+	if dest < 0 || dest >= int64(len(vm.code)) || !vm.jumpDests[dest] {
+		return fmt.Errorf("%w %d", ErrInvalidJump, dest)
+	}
+
+	vm.ip = int(dest)
+	return nil
+}
+
+func (vm *VM) push(v int64) error {
+	if len(vm.stack) >= MaxStackSize {
+		return ErrStackOverflow
+	}
+
+	vm.stack = append(vm.stack, v)
+	return nil
+}
+
+func (vm *VM) pop() (int64, error) {
+	v, err := vm.peek()
+	if err != nil {
+		return 0, err
+	}
+
+	vm.stack = vm.stack[:len(vm.stack)-1]
+	return v, nil
+}
+
+func (vm *VM) peek() (int64, error) {
+	if len(vm.stack) == 0 {
+		return 0, ErrStackUnderflow
+	}
+
+	return vm.stack[len(vm.stack)-1], nil
+}
+
+func binaryOp(instr Instruction, a, b int64) (int64, error) {
+	switch instr {
+	case InstrAdd:
+		return a + b, nil
+	case InstrSub:
+		return a - b, nil
+	case InstrMul:
+		return a * b, nil
+	case InstrDiv:
+		if b == 0 {
+			return 0, ErrDivisionByZero
+		}
+		return a / b, nil
+	case InstrMod:
+		if b == 0 {
+			return 0, ErrDivisionByZero
+		}
+		return a % b, nil
+	case InstrEq:
+		return boolToInt(a == b), nil
+	case InstrLt:
+		return boolToInt(a < b), nil
+	case InstrGt:
+		return boolToInt(a > b), nil
+	}
+
+	return 0, fmt.Errorf("%w 0x%02x", ErrInvalidInstruction, byte(instr))
+}
+
+// analyzeJumpDests marks every offset in code that starts an instruction,
+// skipping over push operands.
+func analyzeJumpDests(code []byte) []bool {
+	dests := make([]bool, len(code))
+
+	for i := 0; i < len(code); {
+		dests[i] = true
+		if Instruction(code[i]) == InstrPush {
+			i += 9
+			continue
+		}
+		i++
+	}
+
+	return dests
+}
+
+func boolToInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
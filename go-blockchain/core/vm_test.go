@@ -0,0 +1,175 @@
+package core
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thutasann/projectx/crypto"
+	"github.com/thutasann/projectx/types"
+)
+
+func TestVMArithmetic(t *testing.T) {
+	code := program(push(3), push(4), InstrAdd, push(5), InstrMul, push(2), InstrSub, push(4), InstrDiv, push(3), InstrMod)
+	vm := NewVM(code, NewState().Batch().Storage(types.Address{}), DefaultGasLimit)
+
+	assert.Nil(t, vm.Run())
+	assert.Equal(t, []int64{((3+4)*5 - 2) / 4 % 3}, vm.Stack())
+}
+
+func TestVMComparison(t *testing.T) {
+	code := program(push(1), push(2), InstrLt, push(1), push(2), InstrGt, push(7), push(7), InstrEq, push(0), InstrNot)
+	vm := NewVM(code, NewState().Batch().Storage(types.Address{}), DefaultGasLimit)
+
+	assert.Nil(t, vm.Run())
+	assert.Equal(t, []int64{1, 0, 1, 1}, vm.Stack())
+}
+
+func TestVMStorage(t *testing.T) {
+	contract := types.Address{1}
+	batch := NewState().Batch()
+
+	code := program(push(1), push(42), InstrStore, push(1), InstrLoad, push(2), InstrLoad)
+	vm := NewVM(code, batch.Storage(contract), DefaultGasLimit)
+
+	assert.Nil(t, vm.Run())
+	assert.Equal(t, []int64{42, 0}, vm.Stack())
+	assert.Equal(t, int64(42), batch.Get(contract, 1))
+	assert.Equal(t, int64(0), batch.Get(types.Address{2}, 1))
+}
+
+func TestVMLoop(t *testing.T) {
+	code := countTo(10)
+	batch := NewState().Batch()
+	vm := NewVM(code, batch.Storage(types.Address{}), DefaultGasLimit)
+
+	assert.Nil(t, vm.Run())
+	assert.Equal(t, int64(10), batch.Get(types.Address{}, 0))
+}
+
+func TestVMOutOfGas(t *testing.T) {
+	// An infinite loop has to be stopped by gas metering.
+	code := program(push(0), InstrJump)
+	vm := NewVM(code, NewState().Batch().Storage(types.Address{}), 1000)
+
+	assert.ErrorIs(t, vm.Run(), ErrOutOfGas)
+	assert.Equal(t, uint64(1000), vm.GasUsed())
+}
+
+func TestVMErrors(t *testing.T) {
+	cases := map[string]struct {
+		code []byte
+		err  error
+	}{
+		"invalid opcode":    {[]byte{0xff}, ErrInvalidInstruction},
+		"stack underflow":   {program(push(1), InstrAdd), ErrStackUnderflow},
+		"division by zero":  {program(push(1), push(0), InstrDiv), ErrDivisionByZero},
+		"truncated operand": {[]byte{byte(InstrPush), 0x01}, ErrTruncatedOperand},
+		"jump out of range": {program(push(100), InstrJump), ErrInvalidJump},
+		"jump into operand": {program(push(1), InstrJump), ErrInvalidJump},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			vm := NewVM(tc.code, NewState().Batch().Storage(types.Address{}), DefaultGasLimit)
+			assert.ErrorIs(t, vm.Run(), tc.err)
+		})
+	}
+}
+
+func TestVMStackOverflow(t *testing.T) {
+	code := program(push(1), InstrDup, push(9), InstrJump)
+	vm := NewVM(code, NewState().Batch().Storage(types.Address{}), DefaultGasLimit)
+
+	assert.ErrorIs(t, vm.Run(), ErrStackOverflow)
+}
+
+func TestVMStop(t *testing.T) {
+	code := program(push(1), InstrStop, push(2))
+	vm := NewVM(code, NewState().Batch().Storage(types.Address{}), DefaultGasLimit)
+
+	assert.Nil(t, vm.Run())
+	assert.Equal(t, []int64{1}, vm.Stack())
+}
+
+func TestStateBatchCommit(t *testing.T) {
+	contract := types.Address{1}
+	state := NewState()
+
+	blockBatch := state.Batch()
+	txBatch := blockBatch.Batch()
+	txBatch.Set(contract, 1, 10)
+	assert.Equal(t, int64(0), blockBatch.Get(contract, 1))
+
+	txBatch.Commit()
+	assert.Equal(t, int64(10), blockBatch.Get(contract, 1))
+	assert.Equal(t, int64(0), state.Get(contract, 1))
+
+	blockBatch.Commit()
+	assert.Equal(t, int64(10), state.Get(contract, 1))
+}
+
+func TestBlockChainExecutesTransactions(t *testing.T) {
+	bc := newBlockChainWithGenesis(t)
+	contract := types.Address{7}
+
+	good := &Transaction{Data: countTo(5), To: contract}
+	assert.Nil(t, good.Sign(crypto.GeneratePrivateKey()))
+
+	// Writes key 1 and then runs out of gas: none of its writes may persist.
+	bad := &Transaction{Data: program(push(1), push(99), InstrStore, push(19), InstrJump), To: contract}
+	assert.Nil(t, bad.Sign(crypto.GeneratePrivateKey()))
+
+	b := randomBlock(1, getPrevBlockHash(t, bc, 1))
+	b.AddTransaction(good)
+	b.AddTransaction(bad)
+	assert.Nil(t, b.Sign(crypto.GeneratePrivateKey()))
+	assert.Nil(t, bc.AddBlock(b))
+
+	assert.Equal(t, int64(5), bc.state.Get(contract, 0))
+	assert.Equal(t, int64(0), bc.state.Get(contract, 1))
+}
+
+func TestBlockChainRejectedBlockDoesNotChangeState(t *testing.T) {
+	bc := newBlockChainWithGenesis(t)
+	contract := types.Address{7}
+
+	tx := &Transaction{Data: countTo(3), To: contract}
+	assert.Nil(t, tx.Sign(crypto.GeneratePrivateKey()))
+
+	b := randomBlock(2, types.Hash{})
+	b.AddTransaction(tx)
+	assert.Nil(t, b.Sign(crypto.GeneratePrivateKey()))
+	assert.NotNil(t, bc.AddBlock(b))
+
+	assert.Equal(t, int64(0), bc.state.Get(contract, 0))
+}
+
+// countTo returns a program that increments storage key 0 until it reaches n.
+func countTo(n int64) []byte {
+	return program(
+		push(0), push(0), InstrLoad, push(1), InstrAdd, InstrStore,
+		push(0), InstrLoad, push(n), InstrLt,
+		push(0), InstrJumpIf,
+	)
+}
+
+func push(v int64) []byte {
+	b := make([]byte, 9)
+	b[0] = byte(InstrPush)
+	binary.BigEndian.PutUint64(b[1:], uint64(v))
+	return b
+}
+
+func program(parts ...any) []byte {
+	var code []byte
+	for _, p := range parts {
+		switch v := p.(type) {
+		case Instruction:
+			code = append(code, byte(v))
+		case []byte:
+			code = append(code, v...)
+		}
+	}
+	return code
+}
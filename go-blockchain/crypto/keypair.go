@@ -5,6 +5,7 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/sha256"
+	"fmt"
 	"math/big"
 
 	"github.com/thutasann/projectx/types"
@@ -47,15 +48,88 @@ func GeneratePrivateKey() PrivateKey {
 	}
 }
 
+// NewPrivateKeyFromBytes restores a private key from the 32-byte big-endian
+// scalar produced by Bytes.
+func NewPrivateKeyFromBytes(b []byte) (PrivateKey, error) {
+	key, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), b)
+	if err != nil {
+		return PrivateKey{}, err
+	}
+
+	return PrivateKey{
+		key: key,
+	}, nil
+}
+
+// Bytes serializes the private key as a 32-byte big-endian scalar.
+func (k PrivateKey) Bytes() []byte {
+	b, err := k.key.Bytes()
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
+
 type PublicKey struct {
 	key *ecdsa.PublicKey
 }
 
-// ToSlice serializes the public key in compressed elliptic curve form.
+// ToSlice serializes the public key in compressed elliptic curve form. The
+// zero PublicKey, e.g. the sender of an unsigned transaction, yields nil.
 func (k PublicKey) ToSlice() []byte {
+	if k.key == nil {
+		return nil
+	}
+
 	return elliptic.MarshalCompressed(k.key, k.key.X, k.key.Y)
 }
 
+// NewPublicKeyFromBytes restores a public key from its compressed form as
+// produced by ToSlice.
+func NewPublicKeyFromBytes(b []byte) (PublicKey, error) {
+	x, y := elliptic.UnmarshalCompressed(elliptic.P256(), b)
+	if x == nil {
+		return PublicKey{}, fmt.Errorf("invalid compressed public key")
+	}
+
+	return PublicKey{
+		key: &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y},
+	}, nil
+}
+
+// GobEncode encodes the public key in compressed form so that structures
+// embedding it (such as transactions) can be gob encoded.
+func (k PublicKey) GobEncode() ([]byte, error) {
+	if k.key == nil {
+		return []byte{}, nil
+	}
+
+	return k.ToSlice(), nil
+}
+
+// GobDecode restores a public key encoded by GobEncode.
+func (k *PublicKey) GobDecode(b []byte) error {
+	if len(b) == 0 {
+		k.key = nil
+		return nil
+	}
+
+	pubKey, err := NewPublicKeyFromBytes(b)
+	if err != nil {
+		return err
+	}
+
+	*k = pubKey
+	return nil
+}
+
+// IsZero reports whether the key is unset, such as the From of an unsigned
+// transaction or one decoded without a key.
+func (k PublicKey) IsZero() bool {
+	return k.key == nil
+}
+
 // Address derives a blockchain address from the public key by hashing the
 // compressed key and taking the last 20 bytes.
 func (k PublicKey) Address() types.Address {
@@ -67,10 +141,40 @@ type Signature struct {
 	r, s *big.Int
 }
 
+// GobEncode encodes the signature as the 32-byte r value followed by the
+// 32-byte s value.
+func (sig Signature) GobEncode() ([]byte, error) {
+	if sig.r == nil || sig.s == nil {
+		return nil, fmt.Errorf("cannot encode an empty signature")
+	}
+
+	b := make([]byte, 64)
+	sig.r.FillBytes(b[:32])
+	sig.s.FillBytes(b[32:])
+
+	return b, nil
+}
+
+// GobDecode restores a signature encoded by GobEncode.
+func (sig *Signature) GobDecode(b []byte) error {
+	if len(b) != 64 {
+		return fmt.Errorf("given signature with length %d should be 64", len(b))
+	}
+
+	sig.r = new(big.Int).SetBytes(b[:32])
+	sig.s = new(big.Int).SetBytes(b[32:])
+
+	return nil
+}
+
 // Verify checks whether the signature is valid for the given public key and data.
 // The `data` parameter must be the hashed message that was originally signed.
 // Callers are responsible for hashing (for example, SHA-256) before verification
-// when using higher-level protocols. Returns true if the signature is valid.
+// when using higher-level protocols. Returns true if the signature is valid;
+// a missing key or signature value never is.
 func (sig Signature) Verify(pubKey PublicKey, data []byte) bool {
+	if pubKey.key == nil || sig.r == nil || sig.s == nil {
+		return false
+	}
 	return ecdsa.Verify(pubKey.key, data, sig.r, sig.s)
 }
@@ -1,6 +1,8 @@
 package crypto
 
 import (
+	"bytes"
+	"encoding/gob"
 	"fmt"
 	"testing"
 
@@ -48,3 +50,61 @@ func TestKeyPair_Sign_Verify_Fail(t *testing.T) {
 	assert.False(t, sig.Verify(otherPublicKey, msg))
 	assert.False(t, sig.Verify(publicKey, []byte("this is wrong msg")))
 }
+
+func TestPrivateKeyBytesRoundTrip(t *testing.T) {
+	privKey := GeneratePrivateKey()
+
+	restored, err := NewPrivateKeyFromBytes(privKey.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, privKey.PublicKey().Address(), restored.PublicKey().Address())
+
+	msg := []byte("hello world")
+	sig, err := restored.Sign(msg)
+	assert.Nil(t, err)
+	assert.True(t, sig.Verify(privKey.PublicKey(), msg))
+
+	_, err = NewPrivateKeyFromBytes([]byte("too short"))
+	assert.NotNil(t, err)
+}
+
+func TestGobEncodeKeyAndSignature(t *testing.T) {
+	privKey := GeneratePrivateKey()
+	msg := []byte("hello world")
+	sig, err := privKey.Sign(msg)
+	assert.Nil(t, err)
+
+	type signed struct {
+		From      PublicKey
+		Signature *Signature
+	}
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, gob.NewEncoder(buf).Encode(signed{From: privKey.PublicKey(), Signature: sig}))
+
+	var decoded signed
+	assert.Nil(t, gob.NewDecoder(buf).Decode(&decoded))
+	assert.Equal(t, privKey.PublicKey().Address(), decoded.From.Address())
+	assert.True(t, decoded.Signature.Verify(decoded.From, msg))
+}
+
+func TestVerifyWithMissingKeyOrSignature(t *testing.T) {
+	privKey := GeneratePrivateKey()
+	msg := []byte("hello world")
+	sig, err := privKey.Sign(msg)
+	assert.Nil(t, err)
+
+	assert.True(t, PublicKey{}.IsZero())
+	assert.False(t, privKey.PublicKey().IsZero())
+
+	assert.False(t, sig.Verify(PublicKey{}, msg))
+	assert.False(t, Signature{}.Verify(privKey.PublicKey(), msg))
+	assert.False(t, Signature{}.Verify(PublicKey{}, msg))
+}
+
+func TestGobEncodeEmptySignature(t *testing.T) {
+	_, err := Signature{}.GobEncode()
+	assert.NotNil(t, err)
+
+	buf := &bytes.Buffer{}
+	assert.NotNil(t, gob.NewEncoder(buf).Encode(&Signature{}))
+}
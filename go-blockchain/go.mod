@@ -2,12 +2,14 @@ module github.com/thutasann/projectx
 
 go 1.25.0
 
-require github.com/stretchr/testify v1.11.1
+require (
+	github.com/sirupsen/logrus v1.9.4
+	github.com/stretchr/testify v1.11.1
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/sirupsen/logrus v1.9.4 // indirect
 	golang.org/x/sys v0.13.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -1,12 +1,54 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/sirupsen/logrus"
+	"github.com/thutasann/projectx/core"
 	"github.com/thutasann/projectx/network"
+	"github.com/thutasann/projectx/types"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "wallet" {
+		if err := runWallet(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fs := flag.NewFlagSet("projectx", flag.ExitOnError)
+	rpcAddr := fs.String("rpc", ":8545", "listen address of the JSON-RPC server")
+	alloc := fs.String("alloc", "", "genesis allocation as <address>=<amount>")
+	fs.Parse(os.Args[1:])
+
+	genesis, err := genesisBlock(*alloc)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	bc, err := core.NewBlockChain(genesis)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	rpcServer := network.NewJSONRPCServer(network.JSONRPCServerOpts{
+		ListenAddr: *rpcAddr,
+		Chain:      bc,
+		TxPool:     network.NewTxPool(),
+	})
+	go func() {
+		if err := rpcServer.Start(); err != nil {
+			logrus.Fatal(err)
+		}
+	}()
+
 	trlocal := network.NewLocalTransport("LOCAL")
 	trRemote := network.NewLocalTransport("REMOTE")
 
@@ -27,3 +69,35 @@ func main() {
 	s := network.NewServer(opts)
 	s.Start()
 }
+
+// genesisBlock builds the genesis block. If alloc is set, the block carries a
+// transaction minting the given amount to the given address.
+func genesisBlock(alloc string) (*core.Block, error) {
+	header := &core.Header{
+		Version:   1,
+		Height:    0,
+		Timestamp: time.Now().UnixNano(),
+	}
+
+	var txx []core.Transaction
+	if alloc != "" {
+		addrHex, amountStr, ok := strings.Cut(alloc, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid -alloc %q, expected <address>=<amount>", alloc)
+		}
+
+		addr, err := types.AddressFromHex(addrHex)
+		if err != nil {
+			return nil, err
+		}
+
+		amount, err := strconv.ParseUint(amountStr, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		txx = append(txx, core.Transaction{To: addr, Value: amount})
+	}
+
+	return core.NewBlock(header, txx), nil
+}
@@ -0,0 +1,517 @@
+package network
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"github.com/thutasann/projectx/core"
+	"github.com/thutasann/projectx/types"
+)
+
+// Standard JSON-RPC 2.0 error codes, plus a generic server error used when a
+// requested block or transaction cannot be found or a submission is rejected.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcServerError    = -32000
+)
+
+// maxRPCBodySize bounds the size of a JSON-RPC request body.
+const maxRPCBodySize = 1 << 20
+
+type JSONRPCServerOpts struct {
+	// ListenAddr is the HTTP address the server listens on, e.g. ":8545".
+	ListenAddr string
+	Chain      *core.BlockChain
+	TxPool     *TxPool
+}
+
+// JSONRPCServer exposes a node's chain and mempool over HTTP using JSON-RPC
+// 2.0. Requests are POSTed to "/" with positional params. Hashes and
+// addresses are hex encoded as produced by types.Hash and types.Address.
+type JSONRPCServer struct {
+	JSONRPCServerOpts
+	handlers map[string]rpcHandler
+}
+
+type rpcHandler func(params []json.RawMessage) (any, *RPCError)
+
+// RPCError is the error object of a JSON-RPC response.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+type rpcRequest struct {
+	JSONRPC string            `json:"jsonrpc"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+	ID      json.RawMessage   `json:"id"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// HeaderJSON is the JSON representation of a core.Header.
+type HeaderJSON struct {
+	Hash          string `json:"hash"`
+	Version       uint32 `json:"version"`
+	DataHash      string `json:"dataHash"`
+	PrevBlockHash string `json:"prevBlockHash"`
+	Timestamp     int64  `json:"timestamp"`
+	Height        uint32 `json:"height"`
+}
+
+// BlockJSON is the JSON representation of a core.Block.
+type BlockJSON struct {
+	HeaderJSON
+	Validator    string            `json:"validator"`
+	Transactions []TransactionJSON `json:"transactions"`
+}
+
+// TransactionJSON is the JSON representation of a core.Transaction. Block
+// fields are empty while the transaction is still pending in the mempool.
+type TransactionJSON struct {
+	Hash        string  `json:"hash"`
+	From        string  `json:"from"`
+	To          string  `json:"to"`
+	Value       uint64  `json:"value"`
+	Nonce       uint64  `json:"nonce"`
+	Data        string  `json:"data"`
+	Pending     bool    `json:"pending"`
+	BlockHash   string  `json:"blockHash,omitempty"`
+	BlockHeight *uint32 `json:"blockHeight,omitempty"`
+}
+
+func NewJSONRPCServer(opts JSONRPCServerOpts) *JSONRPCServer {
+	s := &JSONRPCServer{
+		JSONRPCServerOpts: opts,
+	}
+
+	s.handlers = map[string]rpcHandler{
+		"chainHeight":        s.chainHeight,
+		"getBlockByHeight":   s.getBlockByHeight,
+		"getBlockByHash":     s.getBlockByHash,
+		"getHeader":          s.getHeader,
+		"getTransaction":     s.getTransaction,
+		"getBalance":         s.getBalance,
+		"getNonce":           s.getNonce,
+		"sendRawTransaction": s.sendRawTransaction,
+	}
+
+	return s
+}
+
+// Start listens on ListenAddr and serves JSON-RPC requests until the
+// listener fails.
+func (s *JSONRPCServer) Start() error {
+	logrus.WithField("addr", s.ListenAddr).Info("starting JSON-RPC server")
+	return http.ListenAndServe(s.ListenAddr, s)
+}
+
+func (s *JSONRPCServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req rpcRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRPCBodySize)).Decode(&req); err != nil {
+		writeRPCResponse(w, rpcResponse{Error: &RPCError{rpcParseError, err.Error()}})
+		return
+	}
+
+	resp := rpcResponse{ID: req.ID}
+
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		resp.Error = &RPCError{rpcInvalidRequest, "invalid JSON-RPC 2.0 request"}
+		writeRPCResponse(w, resp)
+		return
+	}
+
+	handler, ok := s.handlers[req.Method]
+	if !ok {
+		resp.Error = &RPCError{rpcMethodNotFound, fmt.Sprintf("method %q not found", req.Method)}
+		writeRPCResponse(w, resp)
+		return
+	}
+
+	result, rpcErr := handler(req.Params)
+	if rpcErr != nil {
+		resp.Error = rpcErr
+		writeRPCResponse(w, resp)
+		return
+	}
+
+	// Marshal the result up front so that zero values such as a height of
+	// 0 are kept while the field is still omitted from error responses.
+	b, err := json.Marshal(result)
+	if err != nil {
+		resp.Error = &RPCError{rpcServerError, err.Error()}
+		writeRPCResponse(w, resp)
+		return
+	}
+
+	resp.Result = b
+	writeRPCResponse(w, resp)
+}
+
+func (s *JSONRPCServer) chainHeight(params []json.RawMessage) (any, *RPCError) {
+	if err := decodeParams(params); err != nil {
+		return nil, err
+	}
+
+	return s.Chain.Height(), nil
+}
+
+func (s *JSONRPCServer) getBlockByHeight(params []json.RawMessage) (any, *RPCError) {
+	var height uint32
+	if err := decodeParams(params, &height); err != nil {
+		return nil, err
+	}
+
+	b, err := s.Chain.GetBlock(height)
+	if err != nil {
+		return nil, &RPCError{rpcServerError, err.Error()}
+	}
+
+	return newBlockJSON(b), nil
+}
+
+func (s *JSONRPCServer) getBlockByHash(params []json.RawMessage) (any, *RPCError) {
+	hash, rpcErr := decodeHashParam(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	b, err := s.Chain.GetBlockByHash(hash)
+	if err != nil {
+		return nil, &RPCError{rpcServerError, err.Error()}
+	}
+
+	return newBlockJSON(b), nil
+}
+
+func (s *JSONRPCServer) getHeader(params []json.RawMessage) (any, *RPCError) {
+	var height uint32
+	if err := decodeParams(params, &height); err != nil {
+		return nil, err
+	}
+
+	h, err := s.Chain.GetHeader(height)
+	if err != nil {
+		return nil, &RPCError{rpcServerError, err.Error()}
+	}
+
+	return newHeaderJSON(h), nil
+}
+
+func (s *JSONRPCServer) getTransaction(params []json.RawMessage) (any, *RPCError) {
+	hash, rpcErr := decodeHashParam(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	if tx, b, err := s.Chain.GetTransaction(hash); err == nil {
+		txJSON := newTransactionJSON(tx)
+		txJSON.BlockHash = b.Hash(core.BlockHasher{}).String()
+		txJSON.BlockHeight = &b.Height
+		return txJSON, nil
+	}
+
+	if tx, ok := s.TxPool.Get(hash); ok {
+		txJSON := newTransactionJSON(tx)
+		txJSON.Pending = true
+		return txJSON, nil
+	}
+
+	return nil, &RPCError{rpcServerError, fmt.Sprintf("transaction with hash (%s) not found", hash)}
+}
+
+func (s *JSONRPCServer) getBalance(params []json.RawMessage) (any, *RPCError) {
+	var addrHex string
+	if err := decodeParams(params, &addrHex); err != nil {
+		return nil, err
+	}
+
+	addr, err := types.AddressFromHex(addrHex)
+	if err != nil {
+		return nil, &RPCError{rpcInvalidParams, err.Error()}
+	}
+
+	return s.Chain.GetBalance(addr), nil
+}
+
+// getNonce returns the nonce the next transaction from an address must carry:
+// its committed nonce, moved past any of its transactions already pending in
+// the mempool.
+func (s *JSONRPCServer) getNonce(params []json.RawMessage) (any, *RPCError) {
+	var addrHex string
+	if err := decodeParams(params, &addrHex); err != nil {
+		return nil, err
+	}
+
+	addr, err := types.AddressFromHex(addrHex)
+	if err != nil {
+		return nil, &RPCError{rpcInvalidParams, err.Error()}
+	}
+
+	pending := make(map[uint64]bool)
+	for _, tx := range s.TxPool.Transactions() {
+		if tx.Signature != nil && tx.From.Address() == addr {
+			pending[tx.Nonce] = true
+		}
+	}
+
+	nonce := s.Chain.GetNonce(addr)
+	for pending[nonce] {
+		nonce++
+	}
+
+	return nonce, nil
+}
+
+// sendRawTransaction accepts a hex encoded, gob encoded (core.GobTxEncoder)
+// signed transaction, verifies it and adds it to the mempool. It returns the
+// transaction hash.
+func (s *JSONRPCServer) sendRawTransaction(params []json.RawMessage) (any, *RPCError) {
+	var rawHex string
+	if err := decodeParams(params, &rawHex); err != nil {
+		return nil, err
+	}
+
+	raw, err := hex.DecodeString(rawHex)
+	if err != nil {
+		return nil, &RPCError{rpcInvalidParams, err.Error()}
+	}
+
+	tx := new(core.Transaction)
+	if err := tx.Decode(bytes.NewReader(raw), core.GobTxDecoder{}); err != nil {
+		return nil, &RPCError{rpcInvalidParams, fmt.Sprintf("could not decode transaction: %s", err)}
+	}
+
+	if err := tx.Verify(); err != nil {
+		return nil, &RPCError{rpcServerError, err.Error()}
+	}
+
+	hash := tx.Hash(core.TxHasher{})
+	if _, _, err := s.Chain.GetTransaction(hash); err == nil {
+		return nil, &RPCError{rpcServerError, fmt.Sprintf("transaction (%s) already included in chain", hash)}
+	}
+
+	if nonce := s.Chain.GetNonce(tx.From.Address()); tx.Nonce < nonce {
+		return nil, &RPCError{rpcServerError, fmt.Sprintf("nonce too low: %s is at %d, got %d", tx.From.Address(), nonce, tx.Nonce)}
+	}
+
+	// Transfers already waiting in the mempool spend the same balance.
+	balance := s.Chain.GetBalance(tx.From.Address())
+	pending := s.pendingSpend(tx.From.Address(), hash)
+	if balance < pending || balance-pending < tx.Value {
+		return nil, &RPCError{rpcServerError, fmt.Sprintf("insufficient balance: %s has %d with %d pending, needs %d", tx.From.Address(), balance, pending, tx.Value)}
+	}
+
+	if s.TxPool.Add(tx) {
+		logrus.WithFields(logrus.Fields{
+			"hash":    hash,
+			"mempool": s.TxPool.Len(),
+		}).Info("adding new tx to mempool")
+	}
+
+	return hash.String(), nil
+}
+
+// pendingSpend sums the value of mempool transactions from addr, other than
+// the one with hash exclude. Transactions whose nonce is already used on
+// chain can no longer be mined and are not counted.
+func (s *JSONRPCServer) pendingSpend(addr types.Address, exclude types.Hash) uint64 {
+	nonce := s.Chain.GetNonce(addr)
+
+	var total uint64
+	for _, tx := range s.TxPool.Transactions() {
+		if tx.Signature == nil || tx.From.IsZero() || tx.From.Address() != addr || tx.Nonce < nonce {
+			continue
+		}
+		if tx.Hash(core.TxHasher{}) == exclude {
+			continue
+		}
+		total += tx.Value
+	}
+
+	return total
+}
+
+// decodeParams unmarshals positional params into dst, requiring the exact
+// number of params.
+func decodeParams(params []json.RawMessage, dst ...any) *RPCError {
+	if len(params) != len(dst) {
+		return &RPCError{rpcInvalidParams, fmt.Sprintf("expected %d params, got %d", len(dst), len(params))}
+	}
+
+	for i := range dst {
+		if err := json.Unmarshal(params[i], dst[i]); err != nil {
+			return &RPCError{rpcInvalidParams, fmt.Sprintf("param %d: %s", i, err)}
+		}
+	}
+
+	return nil
+}
+
+func decodeHashParam(params []json.RawMessage) (types.Hash, *RPCError) {
+	var hashHex string
+	if err := decodeParams(params, &hashHex); err != nil {
+		return types.Hash{}, err
+	}
+
+	hash, err := types.HashFromHex(hashHex)
+	if err != nil {
+		return types.Hash{}, &RPCError{rpcInvalidParams, err.Error()}
+	}
+
+	return hash, nil
+}
+
+func writeRPCResponse(w http.ResponseWriter, resp rpcResponse) {
+	resp.JSONRPC = "2.0"
+	if resp.ID == nil {
+		resp.ID = json.RawMessage("null")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logrus.WithError(err).Error("could not write JSON-RPC response")
+	}
+}
+
+func newHeaderJSON(h *core.Header) HeaderJSON {
+	return HeaderJSON{
+		Hash:          core.BlockHasher{}.Hash(h).String(),
+		Version:       h.Version,
+		DataHash:      h.Datahash.String(),
+		PrevBlockHash: h.PrevBlockHash.String(),
+		Timestamp:     h.Timestamp,
+		Height:        h.Height,
+	}
+}
+
+func newBlockJSON(b *core.Block) BlockJSON {
+	txx := make([]TransactionJSON, len(b.Transactions))
+	for i := range b.Transactions {
+		txx[i] = newTransactionJSON(&b.Transactions[i])
+	}
+
+	var validator string
+	if b.Signature != nil {
+		validator = b.Validator.Address().String()
+	}
+
+	return BlockJSON{
+		HeaderJSON:   newHeaderJSON(b.Header),
+		Validator:    validator,
+		Transactions: txx,
+	}
+}
+
+func newTransactionJSON(tx *core.Transaction) TransactionJSON {
+	var from string
+	if tx.Signature != nil {
+		from = tx.From.Address().String()
+	}
+
+	return TransactionJSON{
+		Hash:  tx.Hash(core.TxHasher{}).String(),
+		From:  from,
+		To:    tx.To.String(),
+		Value: tx.Value,
+		Nonce: tx.Nonce,
+		Data:  hex.EncodeToString(tx.Data),
+	}
+}
+
+// JSONRPCClient is a minimal client for JSONRPCServer.
+type JSONRPCClient struct {
+	// URL is the HTTP endpoint of the node, e.g. "http://localhost:8545".
+	URL        string
+	HTTPClient *http.Client
+}
+
+func NewJSONRPCClient(url string) *JSONRPCClient {
+	return &JSONRPCClient{
+		URL:        url,
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// Call invokes method with the given positional params and decodes the
+// result into result. JSON-RPC errors are returned as *RPCError.
+func (c *JSONRPCClient) Call(method string, result any, params ...any) error {
+	if params == nil {
+		params = []any{}
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+		"id":      1,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.HTTPClient.Post(c.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var rpcResp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *RPCError       `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		return fmt.Errorf("could not decode JSON-RPC response (status %d): %w", resp.StatusCode, err)
+	}
+
+	if rpcResp.Error != nil {
+		return rpcResp.Error
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	if len(rpcResp.Result) == 0 {
+		return errors.New("JSON-RPC response has no result")
+	}
+
+	return json.Unmarshal(rpcResp.Result, result)
+}
+
+// SendTransaction encodes and submits a signed transaction and returns the
+// hash reported by the node.
+func (c *JSONRPCClient) SendTransaction(tx *core.Transaction) (string, error) {
+	buf := &bytes.Buffer{}
+	if err := tx.Encode(buf, core.GobTxEncoder{}); err != nil {
+		return "", err
+	}
+
+	var hash string
+	err := c.Call("sendRawTransaction", &hash, hex.EncodeToString(buf.Bytes()))
+	return hash, err
+}
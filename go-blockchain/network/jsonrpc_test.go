@@ -0,0 +1,256 @@
+package network
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thutasann/projectx/core"
+	"github.com/thutasann/projectx/crypto"
+	"github.com/thutasann/projectx/types"
+)
+
+type rpcTestNode struct {
+	chain  *core.BlockChain
+	txPool *TxPool
+	client *JSONRPCClient
+	key    crypto.PrivateKey
+	block  *core.Block
+}
+
+// newRPCTestNode starts a JSON-RPC server over a chain whose genesis block
+// allocates 100 to a fresh key and which has one more block transferring 10
+// from that key.
+func newRPCTestNode(t *testing.T) *rpcTestNode {
+	key := crypto.GeneratePrivateKey()
+
+	genesis := core.NewBlock(&core.Header{Version: 1, Timestamp: time.Now().UnixNano()}, []core.Transaction{
+		{To: key.PublicKey().Address(), Value: 100},
+	})
+	bc, err := core.NewBlockChain(genesis)
+	assert.Nil(t, err)
+
+	tx := &core.Transaction{To: types.Address{9}, Value: 10}
+	assert.Nil(t, tx.Sign(key))
+
+	block := core.NewBlock(&core.Header{
+		Version:       1,
+		PrevBlockHash: core.BlockHasher{}.Hash(genesis.Header),
+		Height:        1,
+		Timestamp:     time.Now().UnixNano(),
+	}, []core.Transaction{*tx})
+	assert.Nil(t, block.Sign(crypto.GeneratePrivateKey()))
+	assert.Nil(t, bc.AddBlock(block))
+
+	txPool := NewTxPool()
+	server := httptest.NewServer(NewJSONRPCServer(JSONRPCServerOpts{
+		Chain:  bc,
+		TxPool: txPool,
+	}))
+	t.Cleanup(server.Close)
+
+	return &rpcTestNode{
+		chain:  bc,
+		txPool: txPool,
+		client: NewJSONRPCClient(server.URL),
+		key:    key,
+		block:  block,
+	}
+}
+
+func TestJSONRPCChainHeight(t *testing.T) {
+	node := newRPCTestNode(t)
+
+	var height uint32
+	assert.Nil(t, node.client.Call("chainHeight", &height))
+	assert.Equal(t, uint32(1), height)
+}
+
+func TestJSONRPCGetBlock(t *testing.T) {
+	node := newRPCTestNode(t)
+	blockHash := node.block.Hash(core.BlockHasher{})
+
+	var byHeight BlockJSON
+	assert.Nil(t, node.client.Call("getBlockByHeight", &byHeight, 1))
+	assert.Equal(t, blockHash.String(), byHeight.Hash)
+	assert.Equal(t, node.block.Datahash.String(), byHeight.DataHash)
+	assert.Equal(t, node.block.Validator.Address().String(), byHeight.Validator)
+	assert.Len(t, byHeight.Transactions, 1)
+
+	var byHash BlockJSON
+	assert.Nil(t, node.client.Call("getBlockByHash", &byHash, blockHash.String()))
+	assert.Equal(t, byHeight, byHash)
+
+	var header HeaderJSON
+	assert.Nil(t, node.client.Call("getHeader", &header, 1))
+	assert.Equal(t, byHeight.HeaderJSON, header)
+
+	assertRPCError(t, node.client.Call("getBlockByHeight", &byHeight, 5), rpcServerError)
+	assertRPCError(t, node.client.Call("getBlockByHash", &byHash, "zz"), rpcInvalidParams)
+	assertRPCError(t, node.client.Call("getHeader", &header), rpcInvalidParams)
+}
+
+func TestJSONRPCGetTransactionAndBalance(t *testing.T) {
+	node := newRPCTestNode(t)
+	tx := node.block.Transactions[0]
+
+	var txJSON TransactionJSON
+	assert.Nil(t, node.client.Call("getTransaction", &txJSON, tx.Hash(core.TxHasher{}).String()))
+	assert.Equal(t, node.key.PublicKey().Address().String(), txJSON.From)
+	assert.Equal(t, types.Address{9}.String(), txJSON.To)
+	assert.Equal(t, uint64(10), txJSON.Value)
+	assert.False(t, txJSON.Pending)
+	assert.Equal(t, uint32(1), *txJSON.BlockHeight)
+
+	var balance uint64
+	assert.Nil(t, node.client.Call("getBalance", &balance, node.key.PublicKey().Address().String()))
+	assert.Equal(t, uint64(90), balance)
+
+	assert.Nil(t, node.client.Call("getBalance", &balance, types.Address{1}.String()))
+	assert.Equal(t, uint64(0), balance)
+
+	assertRPCError(t, node.client.Call("getTransaction", &txJSON, types.RandomHash().String()), rpcServerError)
+}
+
+func TestJSONRPCSendRawTransaction(t *testing.T) {
+	node := newRPCTestNode(t)
+
+	tx := &core.Transaction{To: types.Address{7}, Value: 5, Nonce: 1}
+	assert.Nil(t, tx.Sign(node.key))
+
+	hash, err := node.client.SendTransaction(tx)
+	assert.Nil(t, err)
+	assert.Equal(t, tx.Hash(core.TxHasher{}).String(), hash)
+	assert.Equal(t, 1, node.txPool.Len())
+
+	var txJSON TransactionJSON
+	assert.Nil(t, node.client.Call("getTransaction", &txJSON, hash))
+	assert.True(t, txJSON.Pending)
+	assert.Nil(t, txJSON.BlockHeight)
+
+	// Resubmitting is idempotent.
+	_, err = node.client.SendTransaction(tx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, node.txPool.Len())
+
+	// Already mined.
+	_, err = node.client.SendTransaction(&node.block.Transactions[0])
+	assertRPCError(t, err, rpcServerError)
+
+	// Tampered after signing.
+	tx.Value = 50
+	_, err = node.client.SendTransaction(tx)
+	assertRPCError(t, err, rpcServerError)
+
+	// Nonce 0 was used by the mined transfer.
+	stale := &core.Transaction{To: types.Address{8}, Value: 5}
+	assert.Nil(t, stale.Sign(node.key))
+	_, err = node.client.SendTransaction(stale)
+	assertRPCError(t, err, rpcServerError)
+
+	// Insufficient balance.
+	rich := &core.Transaction{To: types.Address{7}, Value: 1000, Nonce: 2}
+	assert.Nil(t, rich.Sign(node.key))
+	_, err = node.client.SendTransaction(rich)
+	assertRPCError(t, err, rpcServerError)
+
+	var ignored string
+	assertRPCError(t, node.client.Call("sendRawTransaction", &ignored, hex.EncodeToString([]byte("garbage"))), rpcInvalidParams)
+	assert.Equal(t, 1, node.txPool.Len())
+}
+
+func TestJSONRPCSendRawTransactionCountsPending(t *testing.T) {
+	node := newRPCTestNode(t)
+
+	// 90 left after the mined transfer of 10.
+	first := &core.Transaction{To: types.Address{7}, Value: 60, Nonce: 1}
+	assert.Nil(t, first.Sign(node.key))
+	_, err := node.client.SendTransaction(first)
+	assert.Nil(t, err)
+
+	// Affordable on its own, but not on top of the pending 60.
+	second := &core.Transaction{To: types.Address{7}, Value: 40, Nonce: 2}
+	assert.Nil(t, second.Sign(node.key))
+	_, err = node.client.SendTransaction(second)
+	assertRPCError(t, err, rpcServerError)
+	assert.Equal(t, 1, node.txPool.Len())
+
+	// Resubmitting the pending one doesn't count it twice.
+	_, err = node.client.SendTransaction(first)
+	assert.Nil(t, err)
+
+	third := &core.Transaction{To: types.Address{7}, Value: 30, Nonce: 2}
+	assert.Nil(t, third.Sign(node.key))
+	_, err = node.client.SendTransaction(third)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, node.txPool.Len())
+}
+
+func TestJSONRPCSendRawTransactionWithoutSender(t *testing.T) {
+	node := newRPCTestNode(t)
+
+	// Signed, then stripped of its sender: decodes with a nil key.
+	tx := &core.Transaction{To: types.Address{7}, Value: 5, Nonce: 1}
+	assert.Nil(t, tx.Sign(node.key))
+	tx.From = crypto.PublicKey{}
+
+	_, err := node.client.SendTransaction(tx)
+	assertRPCError(t, err, rpcServerError)
+	assert.Equal(t, 0, node.txPool.Len())
+
+	// The node is still serving.
+	var height uint32
+	assert.Nil(t, node.client.Call("chainHeight", &height))
+	assert.Equal(t, uint32(1), height)
+}
+
+func TestJSONRPCGetNonce(t *testing.T) {
+	node := newRPCTestNode(t)
+	addr := node.key.PublicKey().Address().String()
+
+	var nonce uint64
+	assert.Nil(t, node.client.Call("getNonce", &nonce, addr))
+	assert.Equal(t, uint64(1), nonce)
+
+	// A pending transaction moves the next nonce past it.
+	tx := &core.Transaction{To: types.Address{7}, Value: 5, Nonce: 1}
+	assert.Nil(t, tx.Sign(node.key))
+	_, err := node.client.SendTransaction(tx)
+	assert.Nil(t, err)
+
+	assert.Nil(t, node.client.Call("getNonce", &nonce, addr))
+	assert.Equal(t, uint64(2), nonce)
+
+	assert.Nil(t, node.client.Call("getNonce", &nonce, types.Address{1}.String()))
+	assert.Equal(t, uint64(0), nonce)
+}
+
+func TestJSONRPCInvalidRequests(t *testing.T) {
+	node := newRPCTestNode(t)
+
+	assertRPCError(t, node.client.Call("noSuchMethod", nil), rpcMethodNotFound)
+
+	resp, err := http.Post(node.client.URL, "application/json", bytes.NewReader([]byte("{")))
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Get(node.client.URL)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func assertRPCError(t *testing.T, err error, code int) {
+	t.Helper()
+
+	var rpcErr *RPCError
+	if assert.True(t, errors.As(err, &rpcErr), "expected *RPCError, got %v", err) {
+		assert.Equal(t, code, rpcErr.Code)
+	}
+}
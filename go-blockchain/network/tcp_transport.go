@@ -0,0 +1,325 @@
+package network
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// maxFrameSize bounds the payload length accepted from a peer so a corrupt
+// or malicious length prefix cannot force a huge allocation.
+const maxFrameSize = 16 << 20
+
+// dialTimeout is the maximum time spent establishing an outbound connection.
+const dialTimeout = 5 * time.Second
+
+// tcpPeer wraps an outbound connection. Writes are serialized so frames from
+// concurrent SendMessage calls never interleave on the wire.
+type tcpPeer struct {
+	lock sync.Mutex
+	conn net.Conn
+}
+
+// TCPTransport implements the Transport interface over TCP so that nodes can
+// run as separate processes. Every message is sent as a length-prefixed frame.
+// Outbound connections are dialed by NetAddr and used only for writing; the
+// first frame on each connection announces the dialer's listen address so the
+// receiving side can report it as RPC.From.
+type TCPTransport struct {
+	// addr is the address the listener is bound to.
+	addr NetAddr
+	// listener accepts inbound peer connections.
+	listener net.Listener
+	// consumeCh is a buffered channel through which incoming RPC messages are delivered.
+	consumeCh chan RPC
+	// lock protects concurrent access to the peers and inbound maps.
+	lock sync.RWMutex
+	// peers is a map of outbound connections indexed by the remote listen address.
+	peers map[NetAddr]*tcpPeer
+	// inbound tracks accepted connections so they can be closed on shutdown.
+	inbound map[net.Conn]struct{}
+	// quitCh is closed when the transport shuts down.
+	quitCh chan struct{}
+	// wg waits for the accept loop and connection readers to exit.
+	wg sync.WaitGroup
+	// closeOnce guards Close against repeated calls.
+	closeOnce sync.Once
+}
+
+// NewTCPTransport creates a TCPTransport listening on the given address and
+// starts accepting inbound connections. Passing a port of 0 binds a random
+// free port; Addr reports the address that was actually bound.
+func NewTCPTransport(addr NetAddr) (*TCPTransport, error) {
+	ln, err := net.Listen("tcp", string(addr))
+	if err != nil {
+		return nil, err
+	}
+
+	t := &TCPTransport{
+		addr:      NetAddr(ln.Addr().String()),
+		listener:  ln,
+		consumeCh: make(chan RPC, 1024),
+		peers:     make(map[NetAddr]*tcpPeer),
+		inbound:   make(map[net.Conn]struct{}),
+		quitCh:    make(chan struct{}),
+	}
+
+	t.wg.Add(1)
+	go t.acceptLoop()
+
+	return t, nil
+}
+
+// Consume returns a read-only channel for receiving incoming RPC messages.
+// The channel is closed once the transport has been closed.
+func (t *TCPTransport) Consume() <-chan RPC {
+	return t.consumeCh
+}
+
+// Connect dials the address of the given transport and keeps the connection
+// for subsequent SendMessage calls.
+func (t *TCPTransport) Connect(tr Transport) error {
+	return t.Dial(tr.Addr())
+}
+
+// Dial establishes an outbound connection to the peer listening at addr.
+// It is a no-op if a connection to that peer is already open.
+func (t *TCPTransport) Dial(addr NetAddr) error {
+	_, err := t.dial(addr)
+	return err
+}
+
+// dial returns the open connection to addr, dialing and performing the
+// address handshake if there is none yet.
+func (t *TCPTransport) dial(addr NetAddr) (*tcpPeer, error) {
+	t.lock.RLock()
+	peer, ok := t.peers[addr]
+	t.lock.RUnlock()
+	if ok {
+		return peer, nil
+	}
+
+	conn, err := net.DialTimeout("tcp", string(addr), dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("%s: could not dial %s: %w", t.addr, addr, err)
+	}
+
+	if err := writeFrame(conn, []byte(t.addr)); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("%s: handshake with %s failed: %w", t.addr, addr, err)
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	select {
+	case <-t.quitCh:
+		conn.Close()
+		return nil, fmt.Errorf("%s: transport is closed", t.addr)
+	default:
+	}
+
+	// Another goroutine may have dialed the same peer in the meantime.
+	if existing, ok := t.peers[addr]; ok {
+		conn.Close()
+		return existing, nil
+	}
+
+	peer = &tcpPeer{conn: conn}
+	t.peers[addr] = peer
+
+	t.wg.Add(1)
+	go t.watchPeer(addr, peer)
+
+	return peer, nil
+}
+
+// Addr returns the network address this transport is listening on.
+func (t *TCPTransport) Addr() NetAddr {
+	return t.addr
+}
+
+// SendMessage sends a message payload to the peer at the specified address.
+// A connection is dialed on demand. If writing fails the broken connection is
+// dropped and the peer is redialed once before the error is returned.
+func (t *TCPTransport) SendMessage(to NetAddr, payload []byte) error {
+	peer, err := t.dial(to)
+	if err != nil {
+		return err
+	}
+
+	if err := peer.send(payload); err == nil {
+		return nil
+	}
+
+	t.dropPeer(to, peer)
+
+	peer, err = t.dial(to)
+	if err != nil {
+		return err
+	}
+
+	if err := peer.send(payload); err != nil {
+		t.dropPeer(to, peer)
+		return fmt.Errorf("%s: could not send message to %s: %w", t.addr, to, err)
+	}
+
+	return nil
+}
+
+// Close stops accepting connections, closes every open connection and waits
+// for the reader goroutines to exit before closing the consume channel.
+func (t *TCPTransport) Close() error {
+	var err error
+
+	t.closeOnce.Do(func() {
+		close(t.quitCh)
+		err = t.listener.Close()
+
+		t.lock.Lock()
+		for addr, peer := range t.peers {
+			peer.conn.Close()
+			delete(t.peers, addr)
+		}
+		for conn := range t.inbound {
+			conn.Close()
+		}
+		t.lock.Unlock()
+
+		t.wg.Wait()
+		close(t.consumeCh)
+	})
+
+	return err
+}
+
+// dropPeer closes and forgets a broken outbound connection, unless it has
+// already been replaced by a newer one.
+func (t *TCPTransport) dropPeer(addr NetAddr, peer *tcpPeer) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.peers[addr] == peer {
+		delete(t.peers, addr)
+	}
+	peer.conn.Close()
+}
+
+// watchPeer blocks until the remote side closes an outbound connection and
+// then drops it, so the next SendMessage to that peer redials.
+func (t *TCPTransport) watchPeer(addr NetAddr, peer *tcpPeer) {
+	defer t.wg.Done()
+
+	io.Copy(io.Discard, peer.conn)
+	t.dropPeer(addr, peer)
+}
+
+func (t *TCPTransport) acceptLoop() {
+	defer t.wg.Done()
+
+	for {
+		conn, err := t.listener.Accept()
+		if err != nil {
+			select {
+			case <-t.quitCh:
+				return
+			default:
+			}
+
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+
+		t.lock.Lock()
+		select {
+		case <-t.quitCh:
+			t.lock.Unlock()
+			conn.Close()
+			return
+		default:
+		}
+		t.inbound[conn] = struct{}{}
+		t.lock.Unlock()
+
+		t.wg.Add(1)
+		go t.handleConn(conn)
+	}
+}
+
+// handleConn reads the handshake frame carrying the remote listen address and
+// then delivers every following frame as an RPC until the connection closes.
+func (t *TCPTransport) handleConn(conn net.Conn) {
+	defer func() {
+		t.lock.Lock()
+		delete(t.inbound, conn)
+		t.lock.Unlock()
+
+		conn.Close()
+		t.wg.Done()
+	}()
+
+	from, err := readFrame(conn)
+	if err != nil {
+		return
+	}
+
+	for {
+		payload, err := readFrame(conn)
+		if err != nil {
+			return
+		}
+
+		select {
+		case t.consumeCh <- RPC{From: NetAddr(from), Payload: payload}:
+		case <-t.quitCh:
+			return
+		}
+	}
+}
+
+func (p *tcpPeer) send(payload []byte) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return writeFrame(p.conn, payload)
+}
+
+// writeFrame writes payload prefixed with its length as a big-endian uint32.
+func writeFrame(w io.Writer, payload []byte) error {
+	if len(payload) > maxFrameSize {
+		return fmt.Errorf("frame of %d bytes exceeds max size %d", len(payload), maxFrameSize)
+	}
+
+	buf := make([]byte, 4+len(payload))
+	binary.BigEndian.PutUint32(buf, uint32(len(payload)))
+	copy(buf[4:], payload)
+
+	_, err := w.Write(buf)
+	return err
+}
+
+// readFrame reads a single length-prefixed frame written by writeFrame.
+func readFrame(r io.Reader) ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+
+	size := binary.BigEndian.Uint32(header[:])
+	if size > maxFrameSize {
+		return nil, fmt.Errorf("frame of %d bytes exceeds max size %d", size, maxFrameSize)
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
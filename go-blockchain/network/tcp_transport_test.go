@@ -0,0 +1,160 @@
+package network
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	assert.NoError(t, writeFrame(buf, []byte("foo")))
+	assert.NoError(t, writeFrame(buf, []byte{}))
+	assert.NoError(t, writeFrame(buf, []byte("hello world")))
+
+	for _, want := range [][]byte{[]byte("foo"), {}, []byte("hello world")} {
+		got, err := readFrame(buf)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err := readFrame(buf)
+	assert.Error(t, err)
+}
+
+func TestReadFrameTooLarge(t *testing.T) {
+	buf := bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff})
+
+	_, err := readFrame(buf)
+	assert.Error(t, err)
+}
+
+func TestTCPTransportDialOnSend(t *testing.T) {
+	tra := newTCPTransport(t, "127.0.0.1:0")
+	trb := newTCPTransport(t, "127.0.0.1:0")
+
+	for i := range 10 {
+		msg := fmt.Appendf(nil, "msg %d", i)
+		assert.NoError(t, tra.SendMessage(trb.Addr(), msg))
+
+		rpc := receive(t, trb)
+		assert.Equal(t, msg, rpc.Payload)
+		assert.Equal(t, tra.Addr(), rpc.From)
+	}
+}
+
+func TestTCPTransportReconnect(t *testing.T) {
+	tra := newTCPTransport(t, "127.0.0.1:0")
+	trb := newTCPTransport(t, "127.0.0.1:0")
+	addr := trb.Addr()
+
+	assert.NoError(t, tra.Connect(trb))
+	assert.NoError(t, tra.SendMessage(addr, []byte("first")))
+	assert.Equal(t, []byte("first"), receive(t, trb).Payload)
+
+	assert.NoError(t, trb.Close())
+	assert.Eventually(t, func() bool {
+		tra.lock.RLock()
+		defer tra.lock.RUnlock()
+		_, ok := tra.peers[addr]
+		return !ok
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Error(t, tra.SendMessage(addr, []byte("lost")))
+
+	trb = newTCPTransport(t, addr)
+	assert.NoError(t, tra.SendMessage(addr, []byte("second")))
+
+	rpc := receive(t, trb)
+	assert.Equal(t, []byte("second"), rpc.Payload)
+	assert.Equal(t, tra.Addr(), rpc.From)
+}
+
+func TestTCPTransportClose(t *testing.T) {
+	tr := newTCPTransport(t, "127.0.0.1:0")
+
+	assert.NoError(t, tr.Close())
+	assert.NoError(t, tr.Close())
+
+	_, ok := <-tr.Consume()
+	assert.False(t, ok)
+}
+
+// TestTCPTransportMultiProcess starts a second node in a child process and
+// exchanges messages with it over localhost.
+func TestTCPTransportMultiProcess(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping multi-process test in short mode")
+	}
+
+	tr := newTCPTransport(t, "127.0.0.1:0")
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestTCPTransportHelperProcess$")
+	cmd.Env = append(os.Environ(), "PROJECTX_PEER_ADDR="+string(tr.Addr()))
+	cmd.Stderr = os.Stderr
+	assert.NoError(t, cmd.Start())
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	hello := receive(t, tr)
+	assert.Equal(t, []byte("hello"), hello.Payload)
+
+	assert.NoError(t, tr.SendMessage(hello.From, []byte("ping")))
+
+	reply := receive(t, tr)
+	assert.Equal(t, []byte("pong: ping"), reply.Payload)
+	assert.Equal(t, hello.From, reply.From)
+}
+
+// TestTCPTransportHelperProcess is not a real test. It is run as a child
+// process by TestTCPTransportMultiProcess and acts as the remote peer.
+func TestTCPTransportHelperProcess(t *testing.T) {
+	peerAddr := NetAddr(os.Getenv("PROJECTX_PEER_ADDR"))
+	if peerAddr == "" {
+		return
+	}
+
+	tr, err := NewTCPTransport("127.0.0.1:0")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer tr.Close()
+
+	if err := tr.SendMessage(peerAddr, []byte("hello")); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	select {
+	case rpc := <-tr.Consume():
+		reply := append([]byte("pong: "), rpc.Payload...)
+		if err := tr.SendMessage(rpc.From, reply); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	case <-time.After(10 * time.Second):
+		fmt.Fprintln(os.Stderr, "helper: timed out waiting for ping")
+		os.Exit(1)
+	}
+}
+
+func newTCPTransport(t *testing.T, addr NetAddr) *TCPTransport {
+	t.Helper()
+
+	tr, err := NewTCPTransport(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tr.Close() })
+
+	return tr
+}
@@ -0,0 +1,82 @@
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// transportFactory creates a fresh transport for a test. Any cleanup it
+// needs is registered on t.
+type transportFactory func(t *testing.T, name NetAddr) Transport
+
+var transportFactories = map[string]transportFactory{
+	"local": func(t *testing.T, name NetAddr) Transport {
+		return NewLocalTransport(name)
+	},
+	"tcp": func(t *testing.T, name NetAddr) Transport {
+		return newTCPTransport(t, "127.0.0.1:0")
+	},
+}
+
+func TestConnect(t *testing.T) {
+	for name, newTransport := range transportFactories {
+		t.Run(name, func(t *testing.T) {
+			tra := newTransport(t, "A")
+			trb := newTransport(t, "B")
+
+			assert.NoError(t, tra.Connect(trb))
+			assert.NoError(t, trb.Connect(tra))
+
+			message := []byte("ping")
+			assert.NoError(t, tra.SendMessage(trb.Addr(), message))
+
+			rpc := receive(t, trb)
+			assert.Equal(t, message, rpc.Payload)
+			assert.Equal(t, tra.Addr(), rpc.From)
+		})
+	}
+}
+
+func TestSendMessage(t *testing.T) {
+	for name, newTransport := range transportFactories {
+		t.Run(name, func(t *testing.T) {
+			tra := newTransport(t, "A")
+			trb := newTransport(t, "B")
+
+			assert.NoError(t, tra.Connect(trb))
+			assert.NoError(t, trb.Connect(tra))
+
+			msg := []byte("hello world")
+			assert.NoError(t, tra.SendMessage(trb.Addr(), msg))
+
+			rpc := receive(t, trb)
+			assert.Equal(t, msg, rpc.Payload)
+			assert.Equal(t, tra.Addr(), rpc.From)
+		})
+	}
+}
+
+func TestSendMessageUnknownPeer(t *testing.T) {
+	for name, newTransport := range transportFactories {
+		t.Run(name, func(t *testing.T) {
+			tra := newTransport(t, "A")
+
+			assert.Error(t, tra.SendMessage("127.0.0.1:1", []byte("ping")))
+		})
+	}
+}
+
+func receive(t *testing.T, tr Transport) RPC {
+	t.Helper()
+
+	select {
+	case rpc := <-tr.Consume():
+		return rpc
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s: timed out waiting for message", tr.Addr())
+	}
+
+	return RPC{}
+}
@@ -0,0 +1,88 @@
+package network
+
+import (
+	"sync"
+
+	"github.com/thutasann/projectx/core"
+	"github.com/thutasann/projectx/types"
+)
+
+// TxPool holds verified transactions that are waiting to be included in a
+// block. Transactions are kept in insertion order and deduplicated by hash.
+type TxPool struct {
+	lock         sync.RWMutex
+	transactions map[types.Hash]*core.Transaction
+	order        []types.Hash
+}
+
+func NewTxPool() *TxPool {
+	return &TxPool{
+		transactions: make(map[types.Hash]*core.Transaction),
+	}
+}
+
+// Add inserts tx into the pool. It reports false if a transaction with the
+// same hash is already pending.
+func (p *TxPool) Add(tx *core.Transaction) bool {
+	hash := tx.Hash(core.TxHasher{})
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if _, ok := p.transactions[hash]; ok {
+		return false
+	}
+
+	p.transactions[hash] = tx
+	p.order = append(p.order, hash)
+
+	return true
+}
+
+// Has reports whether a transaction with the given hash is pending.
+func (p *TxPool) Has(hash types.Hash) bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	_, ok := p.transactions[hash]
+	return ok
+}
+
+// Get returns the pending transaction with the given hash, if any.
+func (p *TxPool) Get(hash types.Hash) (*core.Transaction, bool) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	tx, ok := p.transactions[hash]
+	return tx, ok
+}
+
+// Len returns the number of pending transactions.
+func (p *TxPool) Len() int {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return len(p.order)
+}
+
+// Transactions returns the pending transactions in insertion order.
+func (p *TxPool) Transactions() []*core.Transaction {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	txx := make([]*core.Transaction, len(p.order))
+	for i, hash := range p.order {
+		txx[i] = p.transactions[hash]
+	}
+
+	return txx
+}
+
+// Flush removes every pending transaction.
+func (p *TxPool) Flush() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.transactions = make(map[types.Hash]*core.Transaction)
+	p.order = nil
+}
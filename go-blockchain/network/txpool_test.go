@@ -0,0 +1,31 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thutasann/projectx/core"
+)
+
+func TestTxPool(t *testing.T) {
+	p := NewTxPool()
+	assert.Equal(t, 0, p.Len())
+
+	first := &core.Transaction{Data: []byte("foo")}
+	second := &core.Transaction{Data: []byte("bar")}
+
+	assert.True(t, p.Add(first))
+	assert.True(t, p.Add(second))
+	assert.False(t, p.Add(&core.Transaction{Data: []byte("foo")}))
+	assert.Equal(t, 2, p.Len())
+	assert.True(t, p.Has(first.Hash(core.TxHasher{})))
+	assert.Equal(t, []*core.Transaction{first, second}, p.Transactions())
+
+	tx, ok := p.Get(second.Hash(core.TxHasher{}))
+	assert.True(t, ok)
+	assert.Equal(t, second, tx)
+
+	p.Flush()
+	assert.Equal(t, 0, p.Len())
+	assert.False(t, p.Has(first.Hash(core.TxHasher{})))
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"encoding/hex"
 	"fmt"
+	"strings"
 )
 
 type Address [20]uint8
@@ -38,3 +39,18 @@ func NewAddressFromBytes(b []byte) Address {
 func (a Address) String() string {
 	return hex.EncodeToString(a.ToSlice())
 }
+
+// AddressFromHex parses a 40-character hexadecimal string into an Address.
+// An optional "0x" prefix is accepted.
+func AddressFromHex(s string) (Address, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return Address{}, err
+	}
+
+	if len(b) != 20 {
+		return Address{}, fmt.Errorf("given hex address with length %d should be 20 bytes", len(b))
+	}
+
+	return NewAddressFromBytes(b), nil
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"strings"
 )
 
 // Hash represents a 32-byte hash value.
@@ -60,3 +61,18 @@ func RandomBytes(size int) []byte {
 func RandomHash() Hash {
 	return HashFromBytes(RandomBytes(32))
 }
+
+// HashFromHex parses a 64-character hexadecimal string into a Hash.
+// An optional "0x" prefix is accepted.
+func HashFromHex(s string) (Hash, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return Hash{}, err
+	}
+
+	if len(b) != 32 {
+		return Hash{}, fmt.Errorf("given hex hash with length %d should be 32 bytes", len(b))
+	}
+
+	return HashFromBytes(b), nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+
+	"github.com/thutasann/projectx/core"
+	"github.com/thutasann/projectx/crypto"
+	"github.com/thutasann/projectx/network"
+	"github.com/thutasann/projectx/types"
+)
+
+const walletUsage = `usage: projectx wallet <command> [flags]
+
+commands:
+  new        generate a new private key and print it with its address
+  address    print the address of a private key
+  balance    query the balance of an address
+  transfer   sign a transfer and submit it to a node`
+
+// runWallet implements the "wallet" subcommand.
+func runWallet(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", walletUsage)
+	}
+
+	switch args[0] {
+	case "new":
+		privKey := crypto.GeneratePrivateKey()
+		fmt.Printf("private key: %s\n", hex.EncodeToString(privKey.Bytes()))
+		fmt.Printf("address:     %s\n", privKey.PublicKey().Address())
+		return nil
+
+	case "address":
+		fs := flag.NewFlagSet("wallet address", flag.ExitOnError)
+		keyHex := fs.String("key", "", "hex encoded private key")
+		fs.Parse(args[1:])
+
+		privKey, err := parsePrivateKey(*keyHex)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(privKey.PublicKey().Address())
+		return nil
+
+	case "balance":
+		fs := flag.NewFlagSet("wallet balance", flag.ExitOnError)
+		addrHex := fs.String("address", "", "hex encoded address")
+		rpcURL := fs.String("rpc", "http://localhost:8545", "JSON-RPC endpoint of the node")
+		fs.Parse(args[1:])
+
+		var balance uint64
+		if err := network.NewJSONRPCClient(*rpcURL).Call("getBalance", &balance, *addrHex); err != nil {
+			return err
+		}
+
+		fmt.Println(balance)
+		return nil
+
+	case "transfer":
+		fs := flag.NewFlagSet("wallet transfer", flag.ExitOnError)
+		keyHex := fs.String("key", "", "hex encoded private key of the sender")
+		toHex := fs.String("to", "", "hex encoded address of the recipient")
+		value := fs.Uint64("value", 0, "amount to transfer")
+		dataHex := fs.String("data", "", "optional hex encoded contract bytecode")
+		nonce := fs.Int64("nonce", -1, "sender nonce (default: next nonce reported by the node)")
+		rpcURL := fs.String("rpc", "http://localhost:8545", "JSON-RPC endpoint of the node")
+		fs.Parse(args[1:])
+
+		client := network.NewJSONRPCClient(*rpcURL)
+
+		privKey, err := parsePrivateKey(*keyHex)
+		if err != nil {
+			return err
+		}
+
+		to, err := types.AddressFromHex(*toHex)
+		if err != nil {
+			return fmt.Errorf("invalid -to: %w", err)
+		}
+
+		data, err := hex.DecodeString(*dataHex)
+		if err != nil {
+			return fmt.Errorf("invalid -data: %w", err)
+		}
+
+		if *nonce < 0 {
+			var next uint64
+			if err := client.Call("getNonce", &next, privKey.PublicKey().Address().String()); err != nil {
+				return fmt.Errorf("could not get nonce: %w", err)
+			}
+			*nonce = int64(next)
+		}
+
+		tx := &core.Transaction{
+			Data:  data,
+			To:    to,
+			Value: *value,
+			Nonce: uint64(*nonce),
+		}
+		if err := tx.Sign(privKey); err != nil {
+			return err
+		}
+
+		hash, err := client.SendTransaction(tx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(hash)
+		return nil
+	}
+
+	return fmt.Errorf("unknown wallet command %q\n%s", args[0], walletUsage)
+}
+
+func parsePrivateKey(keyHex string) (crypto.PrivateKey, error) {
+	if keyHex == "" {
+		return crypto.PrivateKey{}, fmt.Errorf("missing -key")
+	}
+
+	b, err := hex.DecodeString(keyHex)
+	if err != nil {
+		return crypto.PrivateKey{}, fmt.Errorf("invalid -key: %w", err)
+	}
+
+	return crypto.NewPrivateKeyFromBytes(b)
+}
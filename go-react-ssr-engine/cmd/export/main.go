@@ -0,0 +1,372 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/thutasann/go-react-ssr-engine/internal/bundler"
+	"github.com/thutasann/go-react-ssr-engine/internal/config"
+	"github.com/thutasann/go-react-ssr-engine/internal/engine"
+	"github.com/thutasann/go-react-ssr-engine/internal/hydration"
+	"github.com/thutasann/go-react-ssr-engine/internal/props"
+	"github.com/thutasann/go-react-ssr-engine/internal/router"
+	"github.com/thutasann/go-react-ssr-engine/pkg/html"
+)
+
+// export prerenders every route to plain HTML for static hosting.
+//
+// Output layout (served as-is by any CDN):
+//
+//	out/index.html            <- "/"
+//	out/posts/42/index.html   <- "/posts/:id" expanded via getStaticPaths
+//	out/_reactgo/...          <- client bundles referenced by the manifest
+//	out/...                   <- public/ assets
+func main() {
+	outDir := flag.String("out", "out", "output directory")
+	flag.Parse()
+
+	cfg, err := config.Load("reactgo.config.json")
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	// Exported pages are deployed — always ship production bundles.
+	cfg.Dev = false
+
+	start := time.Now()
+
+	// --- 1. Bundle ---
+	b := bundler.New(cfg)
+	buildResult, err := b.Build()
+	if err != nil {
+		log.Fatalf("bundler: %v", err)
+	}
+
+	// --- 2. V8 Engine ---
+	eng, err := engine.New(cfg)
+	if err != nil {
+		log.Fatalf("engine: %v", err)
+	}
+	defer eng.Shutdown()
+	eng.LoadBundle(buildResult.ServerBundle)
+
+	// --- 3. Router ---
+	rt, err := router.New(cfg)
+	if err != nil {
+		log.Fatalf("router: %v", err)
+	}
+
+	// --- 4. Hydration ---
+	manifest := hydration.NewManifest()
+	clientDir := filepath.Join(cfg.BuildDir, "client")
+	if err := manifest.Build(clientDir, buildResult.ClientEntries, buildResult.ClientCSS); err != nil {
+		log.Fatalf("manifest: %v", err)
+	}
+	hydrator := hydration.NewHydrator(manifest)
+
+	// --- 5. Pages ---
+	routes := rt.Routes()
+	sort.Strings(routes)
+
+	pages := 0
+	for _, route := range routes {
+		paths, err := routePaths(eng, rt, route)
+		if err != nil {
+			log.Fatalf("export %s: %v", route, err)
+		}
+		if paths == nil {
+			fmt.Printf("  skip  %s (dynamic route without getStaticPaths)\n", route)
+			continue
+		}
+
+		for _, p := range paths {
+			written, err := exportPage(eng, hydrator, *outDir, route, p.path, p.params)
+			if err != nil {
+				log.Fatalf("export %s: %v", p.path, err)
+			}
+			if written {
+				pages++
+			}
+		}
+	}
+
+	// --- 6. Error page ---
+	// Static hosts (Netlify, GitHub Pages, S3 website) serve 404.html for
+	// unknown paths.
+	if route, ok := rt.ErrorPage(404); ok {
+		file := filepath.Join(*outDir, "404.html")
+		if err := exportErrorPage(eng, hydrator, route.Pattern, file); err != nil {
+			log.Fatalf("export 404: %v", err)
+		}
+		fmt.Printf("  page  %s -> %s\n", route.Pattern, file)
+	}
+
+	// --- 7. Client bundles ---
+	assets := manifest.Assets()
+	for _, url := range assets {
+		rel := strings.TrimPrefix(url, "/_reactgo/")
+		if err := copyFile(filepath.Join(clientDir, rel), filepath.Join(*outDir, "_reactgo", rel)); err != nil {
+			log.Fatalf("copy %s: %v", url, err)
+		}
+	}
+
+	// --- 8. Public assets ---
+	if err := copyDir(cfg.PublicDir, *outDir); err != nil {
+		log.Fatalf("copy public: %v", err)
+	}
+
+	fmt.Printf("\nexported %d pages and %d client assets to %s in %s\n",
+		pages, len(assets), *outDir, time.Since(start).Round(time.Millisecond))
+}
+
+// staticPath is one concrete URL to prerender for a route pattern.
+type staticPath struct {
+	path   string
+	params map[string]string
+}
+
+// routePaths lists the concrete paths for a route. Static routes have exactly
+// one. Dynamic routes come from the page's getStaticPaths; nil means the page
+// doesn't export it and can't be prerendered.
+func routePaths(eng *engine.Engine, rt *router.Router, route string) ([]staticPath, error) {
+	if !strings.ContainsAny(route, ":*") {
+		return []staticPath{{path: route}}, nil
+	}
+
+	result, err := eng.StaticPaths(route)
+	if err != nil {
+		return nil, err
+	}
+
+	if result == "null" {
+		return nil, nil
+	}
+
+	var sp struct {
+		Paths []json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal([]byte(result), &sp); err != nil {
+		return nil, fmt.Errorf("getStaticPaths: %w", err)
+	}
+
+	paths := make([]staticPath, 0, len(sp.Paths))
+	for _, raw := range sp.Paths {
+		// Entries are either "/posts/1" or { params: { id: "1" } }
+		var literal string
+		if err := json.Unmarshal(raw, &literal); err == nil {
+			matched, params, ok := rt.Match(literal)
+			if !ok || matched.Pattern != route {
+				return nil, fmt.Errorf("getStaticPaths: %s does not match %s", literal, route)
+			}
+			paths = append(paths, staticPath{path: literal, params: params})
+			continue
+		}
+
+		var entry struct {
+			Params map[string]json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return nil, fmt.Errorf("getStaticPaths: invalid entry %s", raw)
+		}
+		params, err := flattenParams(entry.Params)
+		if err != nil {
+			return nil, err
+		}
+		path, err := expandRoute(route, params)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, staticPath{path: path, params: params})
+	}
+
+	return paths, nil
+}
+
+// exportPage runs props + render for one path and writes its HTML.
+// Pages whose props redirect or return notFound are skipped.
+func exportPage(eng *engine.Engine, hydrator *hydration.Hydrator, outDir, route, path string, params map[string]string) (bool, error) {
+	// No request at build time: an anonymous GET with no headers or cookies.
+	if params == nil {
+		params = make(map[string]string)
+	}
+	pageCtx := &props.PageContext{
+		Route:   route,
+		Params:  params,
+		Query:   make(map[string]string),
+		Path:    path,
+		Method:  "GET",
+		Headers: make(map[string]string),
+		Cookies: make(map[string]string),
+	}
+
+	// --- Props ---
+	propsJSON := "{}"
+	propsResult, err := eng.RenderProps(route, pageCtx)
+	if err != nil {
+		return false, err
+	}
+	var pageProps props.PageProps
+	if err := json.Unmarshal([]byte(propsResult), &pageProps); err == nil {
+		if pageProps.Error != "" {
+			return false, fmt.Errorf("getServerSideProps: %s", pageProps.Error)
+		}
+		if pageProps.Redirect != nil || pageProps.NotFound {
+			fmt.Printf("  skip  %s (redirect/notFound)\n", path)
+			return false, nil
+		}
+	}
+	var wrapper struct {
+		Props json.RawMessage `json:"props"`
+	}
+	if err := json.Unmarshal([]byte(propsResult), &wrapper); err == nil && wrapper.Props != nil {
+		propsJSON = string(wrapper.Props)
+	}
+
+	// --- SSR ---
+	result, err := eng.Render(route, propsJSON)
+	if err != nil {
+		return false, err
+	}
+
+	// --- Document ---
+	doc := html.NewDocument()
+	doc.BodyHTML = result.HTML
+	doc.SetPageHead(pageProps.Head)
+	result.ApplyHead(doc.Head)
+	hydrator.Prepare(doc, route, propsJSON)
+
+	file := outputFile(outDir, path)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return false, err
+	}
+	if err := os.WriteFile(file, []byte(doc.Render()), 0644); err != nil {
+		return false, err
+	}
+
+	fmt.Printf("  page  %s -> %s\n", path, file)
+	return true, nil
+}
+
+// flattenParams converts getStaticPaths params to router form. Catch-all
+// values are arrays of segments ({slug: ["a", "b"]}) and become "a/b".
+func flattenParams(raw map[string]json.RawMessage) (map[string]string, error) {
+	params := make(map[string]string, len(raw))
+	for name, value := range raw {
+		var single string
+		if err := json.Unmarshal(value, &single); err == nil {
+			params[name] = single
+			continue
+		}
+		var segments []string
+		if err := json.Unmarshal(value, &segments); err != nil {
+			return nil, fmt.Errorf("getStaticPaths: param %q must be a string or string array", name)
+		}
+		params[name] = strings.Join(segments, "/")
+	}
+	return params, nil
+}
+
+// exportErrorPage renders pages/404.tsx to a standalone file.
+func exportErrorPage(eng *engine.Engine, hydrator *hydration.Hydrator, route, file string) error {
+	propsJSON := `{"statusCode":404}`
+	result, err := eng.Render(route, propsJSON)
+	if err != nil {
+		return err
+	}
+
+	doc := html.NewDocument()
+	doc.BodyHTML = result.HTML
+	result.ApplyHead(doc.Head)
+	hydrator.Prepare(doc, route, propsJSON)
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, []byte(doc.Render()), 0644)
+}
+
+// expandRoute fills a pattern's :param and *catch-all segments, e.g.
+// ("/posts/:id", {id: "42"}) -> "/posts/42",
+// ("/docs/*slug", {slug: "a/b"}) -> "/docs/a/b".
+func expandRoute(pattern string, params map[string]string) (string, error) {
+	segments := strings.Split(pattern, "/")
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, "*") {
+			name := strings.TrimSuffix(seg[1:], "?")
+			value := params[name]
+			if value == "" && !strings.HasSuffix(seg, "?") {
+				return "", fmt.Errorf("getStaticPaths: missing param %q for %s", name, pattern)
+			}
+			segments[i] = value
+			continue
+		}
+		if !strings.HasPrefix(seg, ":") {
+			continue
+		}
+		value, ok := params[seg[1:]]
+		if !ok || value == "" {
+			return "", fmt.Errorf("getStaticPaths: missing param %q for %s", seg[1:], pattern)
+		}
+		if strings.Contains(value, "/") {
+			return "", fmt.Errorf("getStaticPaths: param %q=%q contains '/'", seg[1:], value)
+		}
+		segments[i] = value
+	}
+	path := strings.TrimSuffix(strings.Join(segments, "/"), "/")
+	if path == "" {
+		path = "/"
+	}
+	return path, nil
+}
+
+// outputFile maps a URL path to its HTML file. Every page becomes
+// <path>/index.html so CDNs serve it at both /about and /about/.
+func outputFile(outDir, path string) string {
+	return filepath.Join(outDir, filepath.FromSlash(strings.Trim(path, "/")), "index.html")
+}
+
+// copyDir copies every file under src into dst, keeping relative paths.
+// A missing src is not an error — public/ is optional.
+func copyDir(src, dst string) error {
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		return copyFile(path, filepath.Join(dst, rel))
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
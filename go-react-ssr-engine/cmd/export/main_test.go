@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandRoute(t *testing.T) {
+	tests := []struct {
+		pattern string
+		params  map[string]string
+		want    string
+		wantErr bool
+	}{
+		{"/posts/:id", map[string]string{"id": "42"}, "/posts/42", false},
+		{"/users/:uid/posts/:pid", map[string]string{"uid": "alice", "pid": "7"}, "/users/alice/posts/7", false},
+		{"/posts/:id", map[string]string{}, "", true},
+		{"/posts/:id", map[string]string{"id": "a/b"}, "", true},
+		{"/docs/*slug", map[string]string{"slug": "a/b"}, "/docs/a/b", false},
+		{"/docs/*slug", map[string]string{}, "", true},
+		{"/docs/*slug?", map[string]string{}, "/docs", false},
+		{"/*all?", map[string]string{"all": ""}, "/", false},
+	}
+
+	for _, tt := range tests {
+		got, err := expandRoute(tt.pattern, tt.params)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s %v: expected error, got %s", tt.pattern, tt.params, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %v: unexpected error %v", tt.pattern, tt.params, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s %v: expected %s, got %s", tt.pattern, tt.params, tt.want, got)
+		}
+	}
+}
+
+func TestFlattenParams(t *testing.T) {
+	raw := map[string]json.RawMessage{
+		"id":   json.RawMessage(`"42"`),
+		"slug": json.RawMessage(`["a","b"]`),
+	}
+	params, err := flattenParams(raw)
+	if err != nil {
+		t.Fatalf("flatten: %v", err)
+	}
+	if params["id"] != "42" || params["slug"] != "a/b" {
+		t.Errorf("unexpected params %v", params)
+	}
+
+	if _, err := flattenParams(map[string]json.RawMessage{"id": json.RawMessage(`42`)}); err == nil {
+		t.Errorf("expected error for non-string param")
+	}
+}
+
+func TestOutputFile(t *testing.T) {
+	tests := map[string]string{
+		"/":         filepath.Join("out", "index.html"),
+		"/about":    filepath.Join("out", "about", "index.html"),
+		"/posts/42": filepath.Join("out", "posts", "42", "index.html"),
+	}
+	for path, want := range tests {
+		if got := outputFile("out", path); got != want {
+			t.Errorf("%s: expected %s, got %s", path, want, got)
+		}
+	}
+}
+
+func TestCopyDir(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+
+	os.MkdirAll(filepath.Join(src, "img"), 0755)
+	os.WriteFile(filepath.Join(src, "robots.txt"), []byte("User-agent: *"), 0644)
+	os.WriteFile(filepath.Join(src, "img", "logo.svg"), []byte("<svg/>"), 0644)
+
+	if err := copyDir(src, dst); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dst, "img", "logo.svg"))
+	if err != nil || string(data) != "<svg/>" {
+		t.Errorf("expected nested file copied, got %q (%v)", data, err)
+	}
+
+	// Missing source dir is not an error
+	if err := copyDir(filepath.Join(src, "missing"), dst); err != nil {
+		t.Errorf("expected nil for missing dir, got %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"time"
+
+	"github.com/thutasann/go-react-ssr-engine/internal/props"
+)
+
+// pageCache is the part of the page LRU an ISR run writes to.
+type pageCache interface {
+	SetWithRevalidate(key string, value string, revalidate time.Duration)
+	Delete(key string)
+}
+
+// regenerate is one background ISR run for the stale page at key: load its
+// props, render it and swap the new HTML in. If the page now redirects or
+// 404s the entry is dropped. On any error — props or render — the stale
+// copy is left untouched and keeps being served; it is still stale, so the
+// next request retries.
+func regenerate(
+	c pageCache,
+	key string,
+	load func() (*props.PageProps, string, error),
+	render func(pageProps *props.PageProps, propsJSON string) (string, error),
+) error {
+	pageProps, propsJSON, err := load()
+	if err != nil {
+		return err
+	}
+	if pageProps.Redirect != nil || pageProps.NotFound {
+		c.Delete(key)
+		return nil
+	}
+
+	fullHTML, err := render(pageProps, propsJSON)
+	if err != nil {
+		return err
+	}
+	c.SetWithRevalidate(key, fullHTML, time.Duration(pageProps.Revalidate)*time.Second)
+	return nil
+}
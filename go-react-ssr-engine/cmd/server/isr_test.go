@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/thutasann/go-react-ssr-engine/internal/cache"
+	"github.com/thutasann/go-react-ssr-engine/internal/props"
+)
+
+// stalePage returns an LRU holding "old" under key, already stale.
+func stalePage(t *testing.T, key string) *cache.LRU {
+	t.Helper()
+	c := cache.NewLRU(10)
+	c.SetWithRevalidate(key, "old", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if _, stale, ok := c.Lookup(key); !ok || !stale {
+		t.Fatalf("expected a stale entry, got ok=%v stale=%v", ok, stale)
+	}
+	return c
+}
+
+func TestRegenerateKeepsStaleOnPropsError(t *testing.T) {
+	c := stalePage(t, "/posts/1")
+
+	rendered := false
+	err := regenerate(c, "/posts/1",
+		func() (*props.PageProps, string, error) {
+			return &props.PageProps{}, "{}", errors.New("getServerSideProps: backend down")
+		},
+		func(*props.PageProps, string) (string, error) {
+			rendered = true
+			return "empty", nil
+		},
+	)
+	if err == nil {
+		t.Fatal("expected the props error to be returned")
+	}
+	if rendered {
+		t.Error("a page whose props failed must not be rendered for the cache")
+	}
+
+	// Still the old HTML, and still stale so the next request retries
+	value, stale, ok := c.Lookup("/posts/1")
+	if !ok || value != "old" || !stale {
+		t.Errorf("expected stale old page to survive, got ok=%v stale=%v value=%q", ok, stale, value)
+	}
+}
+
+func TestRegenerateKeepsStaleOnRenderError(t *testing.T) {
+	c := stalePage(t, "/posts/1")
+
+	err := regenerate(c, "/posts/1",
+		func() (*props.PageProps, string, error) {
+			return &props.PageProps{Revalidate: 60}, `{"id":1}`, nil
+		},
+		func(*props.PageProps, string) (string, error) {
+			return "", errors.New("render failed")
+		},
+	)
+	if err == nil {
+		t.Fatal("expected the render error to be returned")
+	}
+	if value, _, ok := c.Lookup("/posts/1"); !ok || value != "old" {
+		t.Errorf("expected old page to survive, got ok=%v value=%q", ok, value)
+	}
+}
+
+func TestRegenerateReplacesPage(t *testing.T) {
+	c := stalePage(t, "/posts/1")
+
+	err := regenerate(c, "/posts/1",
+		func() (*props.PageProps, string, error) {
+			return &props.PageProps{Revalidate: 60}, `{"id":1}`, nil
+		},
+		func(_ *props.PageProps, propsJSON string) (string, error) {
+			return "new " + propsJSON, nil
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value, stale, ok := c.Lookup("/posts/1")
+	if !ok || stale || value != `new {"id":1}` {
+		t.Errorf("expected fresh new page, got ok=%v stale=%v value=%q", ok, stale, value)
+	}
+}
+
+func TestRegenerateDropsNotFound(t *testing.T) {
+	for name, pageProps := range map[string]*props.PageProps{
+		"notFound": {NotFound: true},
+		"redirect": {Redirect: &props.Redirect{Destination: "/"}},
+	} {
+		c := stalePage(t, "/posts/1")
+		err := regenerate(c, "/posts/1",
+			func() (*props.PageProps, string, error) { return pageProps, "{}", nil },
+			func(*props.PageProps, string) (string, error) { return "unused", nil },
+		)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if _, _, ok := c.Lookup("/posts/1"); ok {
+			t.Errorf("%s: expected the entry to be dropped", name)
+		}
+	}
+}
@@ -1,10 +1,15 @@
 package main
 
 import (
+	"bufio"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net/textproto"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -18,6 +23,8 @@ import (
 	"github.com/thutasann/go-react-ssr-engine/internal/engine"
 	"github.com/thutasann/go-react-ssr-engine/internal/health"
 	"github.com/thutasann/go-react-ssr-engine/internal/hydration"
+	"github.com/thutasann/go-react-ssr-engine/internal/livereload"
+	"github.com/thutasann/go-react-ssr-engine/internal/metrics"
 	"github.com/thutasann/go-react-ssr-engine/internal/props"
 	"github.com/thutasann/go-react-ssr-engine/internal/router"
 	"github.com/thutasann/go-react-ssr-engine/pkg/html"
@@ -44,12 +51,21 @@ func main() {
 	checker := health.NewChecker()
 	drainer := health.NewDrainer(checker, 30*time.Second)
 
+	// --- Metrics ---
+	// Builds run before the handler exists, so the build histogram is
+	// registered here; the rest is registered alongside the handler.
+	reg := metrics.NewRegistry()
+	buildDuration := reg.Histogram("reactgo_bundle_build_duration_seconds",
+		"Time to build the server and client bundles.", "",
+		[]float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30})
+
 	// --- 1. Bundle ---
 	b := bundler.New(cfg)
 	buildResult, err := b.Build()
 	if err != nil {
 		log.Fatalf("bundler: %v", err)
 	}
+	buildDuration.Observe("", buildResult.Duration)
 	fmt.Printf("bundler: server bundle %d bytes, %d client entries\n",
 		len(buildResult.ServerBundle), len(buildResult.ClientEntries))
 
@@ -68,6 +84,9 @@ func main() {
 	for _, route := range rt.Routes() {
 		fmt.Printf("  route: %s\n", route)
 	}
+	for _, route := range rt.APIRoutes() {
+		fmt.Printf("  api:   %s\n", route)
+	}
 
 	// --- 4. Cache ---
 	lru := cache.NewLRU(cfg.CacheMaxEntries)
@@ -78,22 +97,31 @@ func main() {
 	// --- 5. Hydration ---
 	manifest := hydration.NewManifest()
 	clientDir := filepath.Join(cfg.BuildDir, "client")
-	manifest.Build(clientDir, buildResult.ClientEntries)
+	manifest.Build(clientDir, buildResult.ClientEntries, buildResult.ClientCSS)
 	hydrator := hydration.NewHydrator(manifest)
 
+	// Live reload: the watcher reports rebuilds, open tabs reload themselves.
+	reload := livereload.NewHub()
+	if cfg.Dev {
+		hydrator.EnableLiveReload(livereload.ScriptPath)
+	}
+
 	// --- 6. Handler ---
-	handler := buildHandler(cfg, eng, rt, lru, hydrator, checker, drainer)
+	handler := buildHandler(cfg, eng, rt, lru, hydrator, checker, drainer, reload, reg)
 
 	// --- 7. Watcher ---
 	if cfg.Dev {
 		inv := cache.NewInvalidator(lru, cfg.PagesDir)
 		w := bundler.NewWatcher(cfg, b, func(result *bundler.BuildResult) {
+			buildDuration.Observe("", result.Duration)
 			eng.LoadBundle(result.ServerBundle)
 			rt.Rebuild()
-			manifest.Build(clientDir, result.ClientEntries)
+			manifest.Build(clientDir, result.ClientEntries, result.ClientCSS)
 			inv.OnRebuild()
+			reload.Reload()
 			fmt.Println("hot reload complete")
 		})
+		w.OnError(reload.BuildError)
 		if err := w.Start(); err != nil {
 			log.Printf("watcher: %v", err)
 		}
@@ -117,7 +145,8 @@ func main() {
 	go func() {
 		addr := fmt.Sprintf(":%d", cfg.Port)
 		fmt.Printf("\n  reactgo ready at http://localhost%s\n", addr)
-		fmt.Printf("  health: http://localhost%s/_health\n\n", addr)
+		fmt.Printf("  health: http://localhost%s/_health\n", addr)
+		fmt.Printf("  metrics: http://localhost%s/_metrics\n\n", addr)
 		if err := server.ListenAndServe(addr); err != nil {
 			log.Fatalf("server: %v", err)
 		}
@@ -130,7 +159,9 @@ func main() {
 
 	fmt.Println("\nshutting down...")
 
-	// Stop accepting new connections
+	// Stop accepting new connections. Open live reload streams never end
+	// on their own, so close them first.
+	reload.Close()
 	server.Shutdown()
 
 	// Wait for in-flight requests to finish
@@ -151,6 +182,8 @@ func buildHandler(
 	hydrator *hydration.Hydrator,
 	checker *health.Checker,
 	drainer *health.Drainer,
+	reload *livereload.Hub,
+	reg *metrics.Registry,
 ) fasthttp.RequestHandler {
 
 	// Static file handlers
@@ -173,12 +206,20 @@ func buildHandler(
 		// Client bundles are content-hashed — safe to cache forever.
 		// Browser will fetch new URL when hash changes after rebuild.
 		CacheDuration: 365 * 24 * time.Hour,
+		// Page entries keep their names across rebuilds; in dev a live
+		// reload must get the new file, not the cached handle.
+		SkipCache: cfg.Dev,
 	}
 	clientHandler := clientFS.NewRequestHandler()
 
-	// Rate limiter: 1000 requests per path per second.
-	// Protects V8 pool from being monopolized by a single hot route.
-	limiter := router.NewRateLimiter(1000, 1*time.Second)
+	// Rate limiter: a token bucket per path by default (1000 req/s), which
+	// keeps a single hot route from monopolizing the V8 pool. Key it by
+	// client IP in the config to limit clients individually.
+	keyFn, err := router.KeyFuncByName(cfg.RateLimit.Key)
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	limiter := router.NewTokenBucket(cfg.RateLimit.Rate, cfg.RateLimit.Burst, keyFn)
 
 	// Middleware chain — order matters:
 	// Recovery (outermost, catches everything)
@@ -187,81 +228,346 @@ func buildHandler(
 	// -> Logger (log after we know the status)
 	// -> ETag (add caching headers)
 	// -> Gzip (compress last, after ETag is computed)
-	chain := router.Chain(
+	// Metrics: latencies are observed here in the handler; cache, pool and
+	// limiter counters are read from their owners at scrape time.
+	renderDuration := reg.Histogram("reactgo_render_duration_seconds",
+		"Time to render a page in V8, by route.", "route", metrics.DefBuckets)
+	propsDuration := reg.Histogram("reactgo_props_duration_seconds",
+		"Time to run getServerSideProps, by route.", "route", metrics.DefBuckets)
+	queueWait := reg.Histogram("reactgo_pool_queue_wait_seconds",
+		"Time spent waiting for a free V8 worker.", "", metrics.DefBuckets)
+	eng.ObserveQueueWait(func(d time.Duration) { queueWait.Observe("", d) })
+
+	reg.CounterFunc("reactgo_cache_hits_total", "Page cache hits.", func() float64 {
+		hits, _ := lru.Stats()
+		return float64(hits)
+	})
+	reg.CounterFunc("reactgo_cache_misses_total", "Page cache misses.", func() float64 {
+		_, misses := lru.Stats()
+		return float64(misses)
+	})
+	reg.GaugeFunc("reactgo_cache_entries", "Pages currently cached.", func() float64 {
+		return float64(lru.Len())
+	})
+	reg.GaugeFunc("reactgo_pool_workers", "V8 workers in the pool.", func() float64 {
+		return float64(eng.PoolStats().Size)
+	})
+	reg.GaugeFunc("reactgo_pool_workers_busy", "V8 workers currently checked out.", func() float64 {
+		return float64(eng.PoolStats().Busy)
+	})
+	reg.GaugeFunc("reactgo_pool_waiting", "Requests waiting for a V8 worker.", func() float64 {
+		return float64(eng.PoolStats().Waiting)
+	})
+	reg.CounterFunc("reactgo_pool_workers_recycled_total", "V8 workers replaced with a fresh isolate.", func() float64 {
+		return float64(eng.PoolStats().Recycled)
+	})
+	reg.CounterFunc("reactgo_rate_limited_total", "Requests rejected with 429 by the rate limiter.", func() float64 {
+		return float64(limiter.Rejected())
+	})
+	reg.GaugeFunc("reactgo_rate_limit_buckets", "Rate limit buckets currently tracked.", func() float64 {
+		return float64(limiter.Len())
+	})
+
+	middlewares := []router.Middleware{
 		router.Recovery(),
 		router.Timing(),
-		limiter.RateLimit(),
+	}
+	if !cfg.RateLimit.Disabled {
+		middlewares = append(middlewares, router.RateLimit(limiter))
+	}
+	middlewares = append(middlewares,
 		router.Logger(),
 		router.ETag(),
 		router.Gzip(),
 	)
 
-	renderFn := func(rctx *router.RequestContext) (string, error) {
-		// --- Cache ---
-		pageCtx := &props.PageContext{
-			Route:  rctx.Route.Pattern,
-			Params: rctx.Params,
-			Path:   rctx.Path,
+	chain := router.Chain(middlewares...)
+
+	// loadProps runs getServerSideProps and splits its result into the parsed
+	// envelope (redirect, notFound, revalidate) and the props JSON for React.
+	// A failing loader — a Go error, or an error reported by the bridge —
+	// returns empty props along with the error, so callers can still render
+	// but know not to cache the result.
+	loadProps := func(pageCtx *props.PageContext) (*props.PageProps, string, error) {
+		pageProps := &props.PageProps{}
+		propsJSON := "{}"
+
+		start := time.Now()
+		propsResult, err := eng.RenderProps(pageCtx.Route, pageCtx)
+		propsDuration.Observe(pageCtx.Route, time.Since(start))
+		if err != nil {
+			return pageProps, propsJSON, err
 		}
-		cacheKey := props.CacheKey(pageCtx)
 
-		if !rctx.SkipCache {
-			if cached, ok := lru.Get(cacheKey); ok {
-				return cached, nil
-			}
+		json.Unmarshal([]byte(propsResult), pageProps)
+		if pageProps.Error != "" {
+			return &props.PageProps{}, propsJSON, fmt.Errorf("getServerSideProps: %s", pageProps.Error)
 		}
 
-		// --- Props ---
-		propsJSON := "{}"
-		propsResult, err := eng.RenderProps(rctx.Route.Pattern, pageCtx)
+		propsJSON = propsResult
+		var wrapper struct {
+			Props json.RawMessage `json:"props"`
+		}
+		if err := json.Unmarshal([]byte(propsResult), &wrapper); err == nil && wrapper.Props != nil {
+			propsJSON = string(wrapper.Props)
+		}
+		return pageProps, propsJSON, nil
+	}
+
+	// renderPage renders a complete buffered document for a route.
+	// head comes from getServerSideProps; <Head> components override it.
+	renderPage := func(route, propsJSON string, head *html.HeadData) (string, error) {
+		start := time.Now()
+		result, err := eng.Render(route, propsJSON)
+		renderDuration.Observe(route, time.Since(start))
 		if err != nil {
-			log.Printf("[%s] props error: %v", rctx.RequestID, err)
-		} else {
-			var pageProps props.PageProps
-			if err := json.Unmarshal([]byte(propsResult), &pageProps); err == nil {
-				if pageProps.Redirect != nil {
-					rctx.StatusCode = 302
-					if pageProps.Redirect.Permanent {
-						rctx.StatusCode = 301
+			return "", err
+		}
+
+		doc := html.NewDocument()
+		doc.BodyHTML = result.HTML
+		doc.SetPageHead(head)
+		result.ApplyHead(doc.Head)
+		hydrator.Prepare(doc, route, propsJSON)
+
+		return doc.Render(), nil
+	}
+
+	// renderShell builds a client-only document: no server HTML, just props
+	// and the client bundle. Served with 200 when the server render timed
+	// out, so a runaway page degrades to client rendering instead of a 500.
+	renderShell := func(route, propsJSON string, head *html.HeadData) string {
+		doc := html.NewDocument()
+		doc.ClientOnly = true
+		doc.SetPageHead(head)
+		hydrator.Prepare(doc, route, propsJSON)
+		return doc.Render()
+	}
+
+	// renderErrorPage renders the app's pages/404.tsx or pages/500.tsx.
+	// Falls back to a bare heading when there is no custom page or it fails —
+	// an error page must never turn into another error.
+	renderErrorPage := func(code int, requestID string) string {
+		fallback := fmt.Sprintf("<h1>%d - %s</h1>", code, errorTitles[code])
+
+		route, ok := rt.ErrorPage(code)
+		if !ok {
+			return fallback
+		}
+		fullHTML, err := renderPage(route.Pattern, fmt.Sprintf(`{"statusCode":%d}`, code), nil)
+		if err != nil {
+			log.Printf("[%s] %d page render error: %v", requestID, code, err)
+			return fallback
+		}
+		return fullHTML
+	}
+
+	// revalidate regenerates a stale page in the background (ISR).
+	// See regenerate: the stale copy survives a failed run.
+	revalidator := cache.NewRevalidator()
+	revalidate := func(cacheKey string, pageCtx *props.PageContext) {
+		revalidator.Trigger(cacheKey, func() {
+			err := regenerate(lru, cacheKey,
+				func() (*props.PageProps, string, error) { return loadProps(pageCtx) },
+				func(pageProps *props.PageProps, propsJSON string) (string, error) {
+					return renderPage(pageCtx.Route, propsJSON, pageProps.Head)
+				},
+			)
+			if err != nil {
+				log.Printf("[isr] revalidate %s failed, serving stale: %v", cacheKey, err)
+			}
+		})
+	}
+
+	// vary adds the configured cookies and headers to page cache keys.
+	vary := &props.Vary{Cookies: cfg.CacheKeyCookies, Headers: cfg.CacheKeyHeaders}
+
+	// renderFn renders one page request. Built per request around its
+	// PageContext, which carries what the middleware chain doesn't know about.
+	renderFn := func(pageCtx *props.PageContext) router.HandlerFunc {
+		return func(rctx *router.RequestContext) (string, error) {
+			// --- Cache ---
+			cacheKey := vary.CacheKey(pageCtx)
+
+			if !rctx.SkipCache {
+				if cached, stale, ok := lru.Lookup(cacheKey); ok {
+					if stale {
+						revalidate(cacheKey, pageCtx)
 					}
-					rctx.Headers["Location"] = pageProps.Redirect.Destination
-					return "", nil
+					return cached, nil
 				}
-				if pageProps.NotFound {
-					rctx.StatusCode = 404
-					return "<h1>404 - Not Found</h1>", nil
+			}
+
+			// --- Props ---
+			// Cookies and headers apply to redirects and 404s too —
+			// e.g. a login page that sets a session and redirects.
+			pageProps, propsJSON, propsErr := loadProps(pageCtx)
+			if propsErr != nil {
+				log.Printf("[%s] props error: %v", rctx.RequestID, propsErr)
+			}
+			applyPropsHeaders(rctx, pageProps)
+			if pageProps.Redirect != nil {
+				rctx.StatusCode = 302
+				if pageProps.Redirect.Permanent {
+					rctx.StatusCode = 301
 				}
+				rctx.Headers["Location"] = pageProps.Redirect.Destination
+				return "", nil
 			}
-			propsJSON = propsResult
-			var wrapper struct {
-				Props json.RawMessage `json:"props"`
+			if pageProps.NotFound {
+				rctx.StatusCode = 404
+				return renderErrorPage(404, rctx.RequestID), nil
 			}
-			if err := json.Unmarshal([]byte(propsResult), &wrapper); err == nil && wrapper.Props != nil {
-				propsJSON = string(wrapper.Props)
+			revalidateAfter := time.Duration(pageProps.Revalidate) * time.Second
+
+			// The cache holds HTML only; a cached hit couldn't replay cookies
+			// or headers, so personalized responses are never stored. Nor is
+			// a page rendered with empty props because its loader failed.
+			cacheable := !rctx.SkipCache && !pageProps.Personalized() && propsErr == nil
+
+			// --- Streaming SSR ---
+			// Headers and <head> go out before React starts; the body follows
+			// chunk by chunk. The full page is cached once the stream completes,
+			// so repeat requests are served whole from the cache, with an ETag.
+			if cfg.Streaming {
+				// <Head> components can't reach a <head> that is already sent;
+				// the client runtime applies them once the page hydrates.
+				doc := html.NewDocument()
+				doc.SetPageHead(pageProps.Head)
+				hydrator.Prepare(doc, rctx.Route.Pattern, propsJSON)
+
+				route := rctx.Route.Pattern
+				rctx.Stream = func(w router.BodyWriter) error {
+					out := &teeWriter{w: w}
+					io.WriteString(out, doc.RenderHead())
+					if err := out.Flush(); err != nil {
+						return err
+					}
+
+					start := time.Now()
+					err := eng.RenderStream(route, propsJSON, out)
+					renderDuration.Observe(route, time.Since(start))
+
+					// A terminated render leaves partial HTML in the root;
+					// the client discards it and renders from scratch.
+					if errors.Is(err, engine.ErrRenderTimeout) {
+						log.Printf("[%s] render timed out, client takes over: %v", rctx.RequestID, err)
+						doc.ClientOnly = true
+						cacheable = false
+						err = nil
+					}
+
+					// Always close the document — even after an error the client
+					// bundle can take over and render the page in the browser.
+					io.WriteString(out, doc.RenderTail())
+					if flushErr := out.Flush(); err == nil {
+						err = flushErr
+					}
+
+					if err == nil && cacheable {
+						lru.SetWithRevalidate(cacheKey, out.buf.String(), revalidateAfter)
+					}
+					return err
+				}
+				return "", nil
 			}
+
+			// --- SSR ---
+			fullHTML, err := renderPage(rctx.Route.Pattern, propsJSON, pageProps.Head)
+			if errors.Is(err, engine.ErrRenderTimeout) {
+				log.Printf("[%s] render timed out, serving client-only shell: %v", rctx.RequestID, err)
+				return renderShell(rctx.Route.Pattern, propsJSON, pageProps.Head), nil
+			}
+			if err != nil {
+				return "", err
+			}
+
+			if cacheable {
+				lru.SetWithRevalidate(cacheKey, fullHTML, revalidateAfter)
+			}
+
+			return fullHTML, nil
 		}
+	}
 
-		// --- SSR ---
-		bodyHTML, err := eng.Render(rctx.Route.Pattern, propsJSON)
-		if err != nil {
-			return "", err
+	// apiFn runs a pages/api handler in V8 and maps its response onto the
+	// request context. Goes through the same chain as pages, so API routes
+	// get rate limiting, logging, ETags and gzip for free.
+	apiFn := func(req *engine.APIRequest) router.HandlerFunc {
+		return func(rctx *router.RequestContext) (string, error) {
+			resp, err := eng.HandleAPI(rctx.Route.Pattern, req)
+			if err != nil {
+				return "", err
+			}
+
+			rctx.StatusCode = resp.Status
+			for k, v := range resp.Headers {
+				rctx.Headers[textproto.CanonicalMIMEHeaderKey(k)] = v
+			}
+			if _, ok := rctx.Headers["Content-Type"]; !ok {
+				rctx.Headers["Content-Type"] = "text/plain; charset=utf-8"
+			}
+			return resp.Body, nil
 		}
+	}
 
-		// --- Document ---
-		doc := html.NewDocument()
-		doc.BodyHTML = bodyHTML
-		hydrator.Prepare(doc, rctx.Route.Pattern, propsJSON)
+	// respond writes a successful chain result to the client.
+	// Returns true if the body is streamed and finishes after the handler returns.
+	respond := func(ctx *fasthttp.RequestCtx, rctx *router.RequestContext, body string) bool {
+		// --- Redirects ---
+		if location, ok := rctx.Headers["Location"]; ok && rctx.StatusCode >= 300 && rctx.StatusCode < 400 {
+			ctx.Redirect(location, rctx.StatusCode)
+			return false
+		}
 
-		fullHTML := doc.Render()
+		// --- ETag 304 check ---
+		if etag, ok := rctx.Headers["ETag"]; ok {
+			clientETag := string(ctx.Request.Header.Peek("If-None-Match"))
+			if clientETag == etag {
+				ctx.SetStatusCode(304)
+				return false
+			}
+		}
 
-		if !rctx.SkipCache {
-			lru.Set(cacheKey, fullHTML)
+		// --- Write response ---
+		ctx.SetStatusCode(rctx.StatusCode)
+		ctx.SetContentType("text/html; charset=utf-8")
+
+		// Set all headers from middleware chain
+		for k, v := range rctx.Headers {
+			ctx.Response.Header.Set(k, v)
 		}
+		setCookies(ctx, rctx.SetCookies)
 
-		return fullHTML, nil
-	}
+		// Add security headers
+		ctx.Response.Header.Set("X-Content-Type-Options", "nosniff")
+		ctx.Response.Header.Set("X-Frame-Options", "SAMEORIGIN")
+		ctx.Response.Header.Set("X-Request-ID", rctx.RequestID)
 
-	handlerWithMiddleware := chain(renderFn)
+		if rctx.Stream != nil {
+			stream := rctx.Stream
+			ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+				defer checker.RecordComplete()
+				defer func() {
+					if r := recover(); r != nil {
+						checker.RecordError()
+						log.Printf("[%s] PANIC streaming %s: %v", rctx.RequestID, rctx.Path, r)
+					}
+				}()
+
+				if err := stream(w); err != nil && !errors.Is(err, engine.ErrRenderAborted) {
+					checker.RecordError()
+					log.Printf("[%s] stream error: %v", rctx.RequestID, err)
+				} else if err != nil {
+					log.Printf("[%s] stream timed out, pending boundaries left to client", rctx.RequestID)
+				}
+			})
+			return true
+		}
+
+		ctx.WriteString(body)
+		return false
+	}
 
 	return func(ctx *fasthttp.RequestCtx) {
 		path := string(ctx.Path())
@@ -275,6 +581,13 @@ func buildHandler(
 			return
 		}
 
+		// --- Metrics endpoint ---
+		if path == "/_metrics" {
+			ctx.SetContentType(metrics.ContentType)
+			reg.WriteTo(ctx)
+			return
+		}
+
 		// --- SPA data endpoint ---
 		// Client-side navigation fetches props JSON instead of full HTML.
 		// Skips SSR entirely — component is already loaded on client.
@@ -290,35 +603,127 @@ func buildHandler(
 				return
 			}
 
-			route, params, found := rt.Match(queryPath)
+			pagePath, rawQuery, _ := strings.Cut(queryPath, "?")
+			route, params, found := rt.Match(pagePath)
 			if !found {
 				ctx.SetStatusCode(404)
 				ctx.WriteString(`{"error":"route not found"}`)
 				return
 			}
 
-			pageCtx := &props.PageContext{
-				Route:  route.Pattern,
-				Params: params,
-				Path:   queryPath,
-			}
+			var query fasthttp.Args
+			query.Parse(rawQuery)
+			pageCtx := newPageContext(cfg, ctx, route, params, pagePath, &query)
 
+			start := time.Now()
 			propsResult, err := eng.RenderProps(route.Pattern, pageCtx)
+			propsDuration.Observe(route.Pattern, time.Since(start))
 			if err != nil {
 				ctx.SetStatusCode(500)
 				fmt.Fprintf(ctx, `{"error":"%s"}`, err.Error())
 				return
 			}
 
-			// Extract just the props field
-			var wrapper struct {
+			// Cookies from getServerSideProps must stick on client-side
+			// navigation too, not just on full page loads.
+			var pageProps props.PageProps
+			if err := json.Unmarshal([]byte(propsResult), &pageProps); err == nil {
+				setCookies(ctx, propsCookies(&pageProps))
+			}
+
+			// Props for the component, the head the client runtime swaps
+			// in — the same head a full page load would render — and the
+			// stylesheets to load before the page is shown.
+			var data struct {
 				Props json.RawMessage `json:"props"`
+				Head  *html.HeadData  `json:"head,omitempty"`
+				CSS   []string        `json:"css,omitempty"`
 			}
-			if err := json.Unmarshal([]byte(propsResult), &wrapper); err == nil && wrapper.Props != nil {
-				ctx.Write(wrapper.Props)
-			} else {
-				ctx.WriteString("{}")
+			if err := json.Unmarshal([]byte(propsResult), &data); err != nil || data.Props == nil {
+				data.Props = json.RawMessage("{}")
+			}
+			data.CSS = hydrator.Stylesheets(route.Pattern)
+			out, _ := json.Marshal(data)
+			ctx.Write(out)
+			return
+		}
+
+		// --- On-demand revalidation ---
+		// POST /__revalidate?path=/posts/1[&path=...] purges the cached page
+		// for each path so the next request renders it fresh. Requires
+		// Authorization: Bearer <revalidateSecret>.
+		if path == "/__revalidate" {
+			ctx.SetContentType("application/json")
+
+			if cfg.RevalidateSecret == "" {
+				ctx.SetStatusCode(404)
+				ctx.WriteString(`{"error":"revalidation disabled"}`)
+				return
+			}
+			if !ctx.IsPost() {
+				ctx.SetStatusCode(405)
+				ctx.Response.Header.Set("Allow", "POST")
+				ctx.WriteString(`{"error":"method not allowed"}`)
+				return
+			}
+			token := strings.TrimPrefix(string(ctx.Request.Header.Peek("Authorization")), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(cfg.RevalidateSecret)) != 1 {
+				ctx.SetStatusCode(401)
+				ctx.WriteString(`{"error":"invalid token"}`)
+				return
+			}
+
+			paths := ctx.QueryArgs().PeekMulti("path")
+			if len(paths) == 0 {
+				ctx.SetStatusCode(400)
+				ctx.WriteString(`{"error":"missing path param"}`)
+				return
 			}
+
+			purged := make([]string, 0, len(paths))
+			for _, p := range paths {
+				pagePath, rawQuery, _ := strings.Cut(string(p), "?")
+				route, params, found := rt.Match(pagePath)
+				if !found {
+					continue
+				}
+
+				var query fasthttp.Args
+				query.Parse(rawQuery)
+				cacheKey := props.CacheKey(&props.PageContext{
+					Route:  route.Pattern,
+					Params: params,
+					Query:  queryMap(&query),
+					Path:   pagePath,
+				})
+				// Personalized variants (cacheKeyCookies/Headers) share the
+				// base key as prefix and go with it.
+				lru.Delete(cacheKey)
+				lru.DeletePrefix(cacheKey + props.VarySeparator)
+				purged = append(purged, cacheKey)
+			}
+
+			data, _ := json.Marshal(map[string]interface{}{
+				"revalidated": len(purged) > 0,
+				"purged":      purged,
+			})
+			ctx.Write(data)
+			return
+		}
+
+		// --- Dev live reload ---
+		// Long-lived event streams: not tracked as requests, or draining
+		// would wait on them.
+		if cfg.Dev && path == livereload.EventsPath {
+			ctx.SetContentType("text/event-stream")
+			ctx.Response.Header.Set("Cache-Control", "no-cache")
+			ctx.SetBodyStreamWriter(reload.Stream)
+			return
+		}
+		if cfg.Dev && path == livereload.ScriptPath {
+			ctx.SetContentType("application/javascript; charset=utf-8")
+			ctx.Response.Header.Set("Cache-Control", "no-cache")
+			ctx.WriteString(livereload.ClientScript)
 			return
 		}
 
@@ -331,8 +736,15 @@ func buildHandler(
 		}
 
 		// --- Track request ---
+		// Streamed responses finish after this handler returns, so they
+		// record completion themselves from the body writer.
 		checker.RecordRequest()
-		defer checker.RecordComplete()
+		streaming := false
+		defer func() {
+			if !streaming {
+				checker.RecordComplete()
+			}
+		}()
 
 		// --- Client bundles ---
 		if strings.HasPrefix(path, "/_reactgo/") {
@@ -347,17 +759,51 @@ func buildHandler(
 			return
 		}
 
+		// --- API routes ---
+		if apiRoute, params, found := rt.MatchAPI(path); found {
+			if params == nil {
+				params = make(map[string]string)
+			}
+
+			rctx := router.NewRequestContext(path)
+			rctx.ClientIP = clientIP(cfg, ctx)
+			rctx.Route = apiRoute
+			rctx.Params = params
+			rctx.AcceptGzip = strings.Contains(
+				string(ctx.Request.Header.Peek("Accept-Encoding")), "gzip",
+			)
+
+			body, err := chain(apiFn(newAPIRequest(ctx, params)))(rctx)
+			if err != nil {
+				checker.RecordError()
+				ctx.SetStatusCode(500)
+				ctx.SetContentType("application/json")
+				msg := "internal server error"
+				if cfg.Dev {
+					msg = err.Error()
+				}
+				data, _ := json.Marshal(map[string]string{"error": msg})
+				ctx.Write(data)
+				log.Printf("[%s] api error: %v", rctx.RequestID, err)
+				return
+			}
+
+			respond(ctx, rctx, body)
+			return
+		}
+
 		// --- Route matching ---
 		route, params, found := rt.Match(path)
 		if !found {
 			ctx.SetStatusCode(404)
 			ctx.SetContentType("text/html; charset=utf-8")
-			fmt.Fprintf(ctx, "<h1>404 - Not Found</h1>")
+			ctx.WriteString(renderErrorPage(404, ""))
 			return
 		}
 
 		// --- Build request context ---
 		rctx := router.NewRequestContext(path)
+		rctx.ClientIP = clientIP(cfg, ctx)
 		rctx.Route = route
 		rctx.Params = params
 		rctx.AcceptGzip = strings.Contains(
@@ -366,61 +812,165 @@ func buildHandler(
 		rctx.SkipCache = len(ctx.QueryArgs().Peek("nocache")) > 0 ||
 			strings.Contains(string(ctx.Request.Header.Peek("Cache-Control")), "no-cache")
 
-		// Parse query params
-		ctx.QueryArgs().VisitAll(func(key, value []byte) {
-			if rctx.Params == nil {
-				rctx.Params = make(map[string]string)
-			}
-			rctx.Params["query_"+string(key)] = string(value)
-		})
+		pageCtx := newPageContext(cfg, ctx, route, params, path, ctx.QueryArgs())
 
 		// --- Render ---
-		htmlResult, err := handlerWithMiddleware(rctx)
+		htmlResult, err := chain(renderFn(pageCtx))(rctx)
 		if err != nil {
 			checker.RecordError()
 			ctx.SetStatusCode(500)
 			ctx.SetContentType("text/html; charset=utf-8")
-			fmt.Fprintf(ctx, "<h1>500 - Server Error</h1>")
+			// Dev keeps the raw error visible instead of the polished page
 			if cfg.Dev {
-				fmt.Fprintf(ctx, "<pre>%v</pre>", err)
+				fmt.Fprintf(ctx, "<h1>500 - Server Error</h1><pre>%v</pre>", err)
+			} else {
+				ctx.WriteString(renderErrorPage(500, rctx.RequestID))
 			}
 			log.Printf("[%s] render error: %v", rctx.RequestID, err)
 			return
 		}
 
-		// --- Redirects ---
-		if location, ok := rctx.Headers["Location"]; ok {
-			ctx.Redirect(location, rctx.StatusCode)
-			return
-		}
+		streaming = respond(ctx, rctx, htmlResult)
+	}
+}
 
-		// --- ETag 304 check ---
-		if etag, ok := rctx.Headers["ETag"]; ok {
-			clientETag := string(ctx.Request.Header.Peek("If-None-Match"))
-			if clientETag == etag {
-				ctx.SetStatusCode(304)
-				return
+// newAPIRequest copies what an API handler needs out of the fasthttp request.
+// Header names are lower-cased so handlers can read req.headers['content-type'].
+func newAPIRequest(ctx *fasthttp.RequestCtx, params map[string]string) *engine.APIRequest {
+	req := &engine.APIRequest{
+		Method:  string(ctx.Method()),
+		Path:    string(ctx.Path()),
+		Headers: make(map[string]string),
+		Query:   make(map[string]string),
+		Params:  params,
+		Body:    string(ctx.PostBody()),
+	}
+	ctx.Request.Header.VisitAll(func(key, value []byte) {
+		req.Headers[strings.ToLower(string(key))] = string(value)
+	})
+	ctx.QueryArgs().VisitAll(func(key, value []byte) {
+		req.Query[string(key)] = string(value)
+	})
+	return req
+}
+
+// errorTitles label the built-in error pages used when the app has no
+// pages/404.tsx or pages/500.tsx.
+var errorTitles = map[int]string{
+	404: "Not Found",
+	500: "Server Error",
+}
+
+// newPageContext builds the getServerSideProps context for a request.
+// path and query are passed in because /__data loads props for the page the
+// client is navigating to, not for its own URL.
+func newPageContext(cfg *config.Config, ctx *fasthttp.RequestCtx, route *router.Route, params map[string]string, path string, query *fasthttp.Args) *props.PageContext {
+	if params == nil {
+		params = make(map[string]string)
+	}
+	pageCtx := &props.PageContext{
+		Route:   route.Pattern,
+		Params:  params,
+		Query:   queryMap(query),
+		Path:    path,
+		Method:  string(ctx.Method()),
+		Headers: make(map[string]string),
+		Cookies: make(map[string]string),
+	}
+
+	// Header names aren't normalized by the server, so lower-case them here
+	// for predictable lookups in JS and in the cache key.
+	ctx.Request.Header.VisitAll(func(key, value []byte) {
+		pageCtx.Headers[strings.ToLower(string(key))] = string(value)
+	})
+	ctx.Request.Header.VisitAllCookie(func(key, value []byte) {
+		pageCtx.Cookies[string(key)] = string(value)
+	})
+
+	pageCtx.IP = clientIP(cfg, ctx)
+	pageCtx.Locale = props.NegotiateLocale(pageCtx.Headers["accept-language"], cfg.Locales)
+	return pageCtx
+}
+
+// clientIP is the request's client address. X-Forwarded-For is only
+// believed behind a trusted proxy; anyone can send it otherwise. Proxies
+// append the address they saw, so only the last entry comes from the
+// trusted proxy: everything before it was sent by the client.
+func clientIP(cfg *config.Config, ctx *fasthttp.RequestCtx) string {
+	if cfg.TrustProxy {
+		if values := ctx.Request.Header.PeekAll("X-Forwarded-For"); len(values) > 0 {
+			last := string(values[len(values)-1])
+			if i := strings.LastIndexByte(last, ','); i >= 0 {
+				last = last[i+1:]
+			}
+			if ip := strings.TrimSpace(last); ip != "" {
+				return ip
 			}
 		}
+	}
+	return ctx.RemoteIP().String()
+}
 
-		// --- Write response ---
-		ctx.SetStatusCode(rctx.StatusCode)
-		ctx.SetContentType("text/html; charset=utf-8")
+// queryMap copies query args into a map. Repeated keys keep the last value.
+// Shared by page rendering and /__revalidate so both derive the same cache key.
+func queryMap(query *fasthttp.Args) map[string]string {
+	values := make(map[string]string, query.Len())
+	query.VisitAll(func(key, value []byte) {
+		values[string(key)] = string(value)
+	})
+	return values
+}
 
-		// Set all headers from middleware chain
-		for k, v := range rctx.Headers {
-			ctx.Response.Header.Set(k, v)
-		}
+// applyPropsHeaders copies cookies and extra headers returned by
+// getServerSideProps onto the response.
+func applyPropsHeaders(rctx *router.RequestContext, pageProps *props.PageProps) {
+	for k, v := range pageProps.Headers {
+		rctx.Headers[textproto.CanonicalMIMEHeaderKey(k)] = v
+	}
+	rctx.SetCookies = append(rctx.SetCookies, propsCookies(pageProps)...)
+}
 
-		// Add security headers
-		ctx.Response.Header.Set("X-Content-Type-Options", "nosniff")
-		ctx.Response.Header.Set("X-Frame-Options", "SAMEORIGIN")
-		ctx.Response.Header.Set("X-Request-ID", rctx.RequestID)
+// propsCookies formats the cookies from getServerSideProps as Set-Cookie
+// values, dropping any with an invalid name.
+func propsCookies(pageProps *props.PageProps) []string {
+	var cookies []string
+	for _, c := range pageProps.SetCookie {
+		if v := c.String(); v != "" {
+			cookies = append(cookies, v)
+		} else {
+			log.Printf("props: skipping invalid cookie %q", c.Name)
+		}
+	}
+	return cookies
+}
 
-		ctx.WriteString(htmlResult)
+// setCookies writes Set-Cookie headers, one per cookie.
+func setCookies(ctx *fasthttp.RequestCtx, cookies []string) {
+	for _, v := range cookies {
+		c := fasthttp.AcquireCookie()
+		if err := c.Parse(v); err == nil {
+			ctx.Response.Header.SetCookie(c)
+		}
+		fasthttp.ReleaseCookie(c)
 	}
 }
 
+// teeWriter forwards streamed HTML to the client while keeping a copy,
+// so a completed stream can be stored in the page cache.
+type teeWriter struct {
+	w   router.BodyWriter
+	buf strings.Builder
+}
+
+func (t *teeWriter) Write(p []byte) (int, error) {
+	t.buf.Write(p)
+	return t.w.Write(p)
+}
+
+func (t *teeWriter) Flush() error {
+	return t.w.Flush()
+}
+
 func fileExists(path string) bool {
 	info, err := os.Stat(path)
 	return err == nil && !info.IsDir()
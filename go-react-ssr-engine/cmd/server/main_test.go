@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net"
+	"testing"
+
+	"github.com/thutasann/go-react-ssr-engine/internal/config"
+	"github.com/valyala/fasthttp"
+)
+
+func TestClientIP(t *testing.T) {
+	cases := []struct {
+		name       string
+		trustProxy bool
+		forwarded  []string
+		want       string
+	}{
+		{"no proxy header", true, nil, "10.0.0.2"},
+		{"untrusted header ignored", false, []string{"203.0.113.7"}, "10.0.0.2"},
+		{"single entry", true, []string{"203.0.113.7"}, "203.0.113.7"},
+		{"spoofed leading entry", true, []string{"1.2.3.4, 203.0.113.7"}, "203.0.113.7"},
+		{"spaces", true, []string{" 1.2.3.4 ,  203.0.113.7 "}, "203.0.113.7"},
+		{"repeated headers", true, []string{"1.2.3.4", "5.6.7.8, 203.0.113.7"}, "203.0.113.7"},
+		{"empty last entry", true, []string{"1.2.3.4, "}, "10.0.0.2"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var req fasthttp.Request
+			for _, v := range c.forwarded {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+			var ctx fasthttp.RequestCtx
+			ctx.Init(&req, &net.TCPAddr{IP: net.ParseIP("10.0.0.2"), Port: 5000}, nil)
+
+			if got := clientIP(&config.Config{TrustProxy: c.trustProxy}, &ctx); got != c.want {
+				t.Errorf("clientIP() = %q, want %q", got, c.want)
+			}
+		})
+	}
+}
@@ -1,5 +1,6 @@
 import React from 'react';
+import styles from './button.module.css';
 
 export default function Button() {
-  return <button>Click me</button>;
+  return <button className={styles.button}>Click me</button>;
 }
@@ -0,0 +1,329 @@
+package bundler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// PublicPath is the URL prefix the client build dir is served under.
+// Asset URLs baked into both bundles start with it.
+const PublicPath = "/_reactgo/"
+
+// assetNames places imported images and fonts under client/assets with a
+// content hash, so the server and client builds agree on every URL.
+const assetNames = "assets/[name]-[hash]"
+
+// assetExts are imported as files: the import evaluates to the asset's URL.
+var assetExts = []string{
+	".png", ".jpg", ".jpeg", ".gif", ".webp", ".avif", ".svg", ".ico",
+	".woff", ".woff2", ".ttf", ".otf", ".eot",
+}
+
+// loaders returns the esbuild loaders for non-JS imports. The server never
+// emits CSS — only the client build extracts it — but both builds load
+// assets the same way so their URLs match.
+func loaders(server bool) map[string]api.Loader {
+	m := make(map[string]api.Loader, len(assetExts)+1)
+	for _, ext := range assetExts {
+		m[ext] = api.LoaderFile
+	}
+	m[".css"] = api.LoaderCSS
+	if server {
+		m[".css"] = api.LoaderEmpty
+	}
+	return m
+}
+
+// cssModuleSuffix marks the stylesheet half of a CSS module import.
+const cssModuleSuffix = "?reactgo-css"
+
+// cssModulesPlugin compiles *.module.css imports into a JS object of
+// scoped class names. Names derive from the file path, not from bundle
+// contents, so the server bundle and every client bundle produce the same
+// names and hydration matches. In the client build the module also imports
+// the scoped stylesheet, which esbuild extracts with the page's CSS.
+func cssModulesPlugin(server bool) api.Plugin {
+	return api.Plugin{
+		Name: "reactgo-css-modules",
+		Setup: func(build api.PluginBuild) {
+			build.OnResolve(api.OnResolveOptions{Filter: `\.module\.css$`},
+				func(args api.OnResolveArgs) (api.OnResolveResult, error) {
+					return api.OnResolveResult{
+						Path:      filepath.Join(args.ResolveDir, args.Path),
+						Namespace: "css-module",
+					}, nil
+				})
+
+			build.OnResolve(api.OnResolveOptions{Filter: `\.module\.css\` + cssModuleSuffix + `$`, Namespace: "css-module"},
+				func(args api.OnResolveArgs) (api.OnResolveResult, error) {
+					return api.OnResolveResult{
+						Path:      strings.TrimSuffix(args.Path, cssModuleSuffix),
+						Namespace: "css-module-css",
+					}, nil
+				})
+
+			build.OnLoad(api.OnLoadOptions{Filter: `.*`, Namespace: "css-module"},
+				func(args api.OnLoadArgs) (api.OnLoadResult, error) {
+					_, names, err := loadCSSModule(args.Path)
+					if err != nil {
+						return api.OnLoadResult{}, err
+					}
+
+					mapJSON, _ := json.Marshal(names)
+					var js strings.Builder
+					if !server {
+						pathJSON, _ := json.Marshal(args.Path + cssModuleSuffix)
+						fmt.Fprintf(&js, "import %s;\n", pathJSON)
+					}
+					fmt.Fprintf(&js, "export default %s;\n", mapJSON)
+
+					contents := js.String()
+					return api.OnLoadResult{
+						Contents:   &contents,
+						Loader:     api.LoaderJS,
+						ResolveDir: filepath.Dir(args.Path),
+					}, nil
+				})
+
+			build.OnLoad(api.OnLoadOptions{Filter: `.*`, Namespace: "css-module-css"},
+				func(args api.OnLoadArgs) (api.OnLoadResult, error) {
+					css, _, err := loadCSSModule(args.Path)
+					if err != nil {
+						return api.OnLoadResult{}, err
+					}
+					// url() references resolve next to the original file.
+					return api.OnLoadResult{
+						Contents:   &css,
+						Loader:     api.LoaderCSS,
+						ResolveDir: filepath.Dir(args.Path),
+					}, nil
+				})
+		},
+	}
+}
+
+// loadCSSModule reads a CSS module and scopes its class names.
+func loadCSSModule(path string) (string, map[string]string, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+	css, names := scopeCSS(string(src), cssModulePrefix(path), cssModuleHash(path))
+	return css, names, nil
+}
+
+// cssModulePrefix is the file name without .module.css: button.module.css -> button.
+func cssModulePrefix(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), ".module.css")
+}
+
+// cssModuleHash keeps equal class names in different files apart. It hashes
+// the path relative to the project root, so it is the same on every machine.
+func cssModuleHash(path string) string {
+	rel := path
+	if wd, err := os.Getwd(); err == nil {
+		if r, err := filepath.Rel(wd, path); err == nil {
+			rel = r
+		}
+	}
+	sum := sha256.Sum256([]byte(filepath.ToSlash(rel)))
+	return hex.EncodeToString(sum[:])[:6]
+}
+
+// scopeCSS renames every class selector .name to .prefix_name__hash and
+// returns the rewritten CSS with the original -> scoped name map.
+// :global(.name) is left unscoped. Class-like text inside declarations,
+// strings, comments and url() is untouched.
+func scopeCSS(src, prefix, hash string) (string, map[string]string) {
+	names := make(map[string]string)
+	var out strings.Builder
+	out.Grow(len(src) + len(src)/4)
+
+	// blocks tracks whether each open { holds rules (top level, @media,
+	// @supports...) or declarations. Only rule preludes contain selectors.
+	var blocks []bool
+	inRules := func() bool { return len(blocks) == 0 || blocks[len(blocks)-1] }
+	preludeStart := 0
+	globalDepth := 0 // paren depth inside :global(...), 0 when outside
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				end = len(src) - i - 2
+			} else {
+				end += 2
+			}
+			out.WriteString(src[i : i+2+end])
+			i += 2 + end
+			continue
+
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(src) && src[j] != c {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(src))
+			out.WriteString(src[i:j])
+			i = j
+			continue
+
+		case hasPrefixFold(src[i:], "url("):
+			end := strings.IndexByte(src[i:], ')')
+			if end < 0 {
+				end = len(src) - i - 1
+			}
+			out.WriteString(src[i : i+end+1])
+			i += end + 1
+			continue
+
+		case inRules() && strings.HasPrefix(src[i:], ":global("):
+			// Drop the wrapper, keep what's inside as written.
+			globalDepth = 1
+			i += len(":global(")
+			continue
+
+		case globalDepth > 0 && c == '(':
+			globalDepth++
+		case globalDepth > 0 && c == ')':
+			globalDepth--
+			if globalDepth == 0 {
+				i++
+				continue
+			}
+
+		case c == '{':
+			prelude := strings.TrimSpace(src[preludeStart:i])
+			blocks = append(blocks, isGroupingRule(prelude))
+			preludeStart = i + 1
+		case c == '}':
+			if len(blocks) > 0 {
+				blocks = blocks[:len(blocks)-1]
+			}
+			preludeStart = i + 1
+		case c == ';':
+			preludeStart = i + 1
+
+		case c == '.' && inRules() && globalDepth == 0 && (i == 0 || !isIdentChar(src[i-1])):
+			j := i + 1
+			if j < len(src) && src[j] == '-' {
+				j++
+			}
+			if j < len(src) && isIdentStart(src[j]) {
+				for j < len(src) && isIdentChar(src[j]) {
+					j++
+				}
+				name := src[i+1 : j]
+				scoped, ok := names[name]
+				if !ok {
+					scoped = prefix + "_" + name + "__" + hash
+					names[name] = scoped
+				}
+				out.WriteByte('.')
+				out.WriteString(scoped)
+				i = j
+				continue
+			}
+		}
+
+		out.WriteByte(c)
+		i++
+	}
+
+	return out.String(), names
+}
+
+// isGroupingRule reports whether a block prelude opens a block of rules
+// rather than declarations.
+func isGroupingRule(prelude string) bool {
+	for _, at := range []string{"@media", "@supports", "@layer", "@container", "@document", "@scope"} {
+		if hasPrefixFold(prelude, at) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || c == '-' || c >= '0' && c <= '9'
+}
+
+// clientMetafile is the part of esbuild's metafile that maps entries to
+// their extracted CSS.
+type clientMetafile struct {
+	Outputs map[string]struct {
+		EntryPoint string `json:"entryPoint"`
+		CSSBundle  string `json:"cssBundle"`
+	} `json:"outputs"`
+}
+
+// hashCSSBundles renames each entry's CSS bundle to include a content hash
+// (_hydrate_index.css -> _hydrate_index-1a2b3c4d.css) so it can be cached
+// forever, and returns hydrate entry name -> hashed file name. Previous
+// hashed copies of the same entry are removed.
+func hashCSSBundles(metafile string) (map[string]string, error) {
+	// Metafile paths are relative to the working directory.
+	var meta clientMetafile
+	if err := json.Unmarshal([]byte(metafile), &meta); err != nil {
+		return nil, fmt.Errorf("metafile: %w", err)
+	}
+
+	outputs := make([]string, 0, len(meta.Outputs))
+	for out := range meta.Outputs {
+		outputs = append(outputs, out)
+	}
+	sort.Strings(outputs)
+
+	bundles := make(map[string]string)
+	for _, out := range outputs {
+		o := meta.Outputs[out]
+		if o.EntryPoint == "" || o.CSSBundle == "" {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(o.EntryPoint), filepath.Ext(o.EntryPoint))
+		if !strings.HasPrefix(name, "_hydrate_") {
+			continue // dynamically imported page chunks
+		}
+
+		css, err := os.ReadFile(o.CSSBundle)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(css)
+		dir := filepath.Dir(o.CSSBundle)
+		hashed := filepath.Join(dir, name+"-"+hex.EncodeToString(sum[:])[:8]+".css")
+
+		stale, _ := filepath.Glob(filepath.Join(dir, name+"-????????.css"))
+		for _, f := range stale {
+			if f != hashed {
+				os.Remove(f)
+			}
+		}
+		if err := os.Rename(o.CSSBundle, hashed); err != nil {
+			return nil, err
+		}
+		bundles[name] = filepath.Base(hashed)
+	}
+	return bundles, nil
+}
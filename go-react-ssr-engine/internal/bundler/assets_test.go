@@ -0,0 +1,133 @@
+package bundler
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+func TestScopeCSS(t *testing.T) {
+	src := `/* .comment */
+.button, .button:hover > .icon { color: red; margin: .5em; }
+:global(.app) .title { background: url(./bg.png); font-family: "a.b"; }
+@media (max-width: 600px) { .title { transition: opacity .2s; } }
+@font-face { font-family: x; src: url(x.woff2); }
+`
+	css, names := scopeCSS(src, "card", "abc123")
+
+	want := map[string]string{
+		"button": "card_button__abc123",
+		"icon":   "card_icon__abc123",
+		"title":  "card_title__abc123",
+	}
+	if len(names) != len(want) {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+	for k, v := range want {
+		if names[k] != v {
+			t.Errorf("names[%q] = %q, want %q", k, names[k], v)
+		}
+	}
+
+	for _, s := range []string{
+		"/* .comment */",
+		".card_button__abc123, .card_button__abc123:hover > .card_icon__abc123 {",
+		"margin: .5em;",
+		".app .card_title__abc123 {",
+		"url(./bg.png)",
+		`"a.b"`,
+		"@media (max-width: 600px) { .card_title__abc123 { transition: opacity .2s; } }",
+	} {
+		if !strings.Contains(css, s) {
+			t.Errorf("scoped CSS missing %q:\n%s", s, css)
+		}
+	}
+}
+
+// TestCSSModulesMatchAcrossBuilds builds the same page the way the server
+// and client builds do; class names and asset URLs must come out equal or
+// hydration would mismatch.
+func TestCSSModulesMatchAcrossBuilds(t *testing.T) {
+	// esbuild and the metafile work relative to the project root.
+	t.Chdir(t.TempDir())
+	write := func(name, content string) {
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("card.module.css", ".card { background: url(./logo.png); }\n")
+	write("global.css", "body { margin: 0; }\n")
+	write("logo.png", "\x89PNG fake")
+	write("_hydrate_card.js", `import styles from './card.module.css';
+import './global.css';
+import logo from './logo.png';
+globalThis.out = styles.card + ' ' + logo;
+`)
+
+	build := func(server bool) api.BuildResult {
+		result := api.Build(api.BuildOptions{
+			EntryPoints: []string{"_hydrate_card.js"},
+			Bundle:      true,
+			Write:       !server,
+			Outdir:      "out",
+			Loader:      loaders(server),
+			AssetNames:  assetNames,
+			PublicPath:  PublicPath,
+			Plugins:     []api.Plugin{cssModulesPlugin(server)},
+			Metafile:    !server,
+		})
+		if len(result.Errors) > 0 {
+			t.Fatalf("build (server=%v): %s", server, result.Errors[0].Text)
+		}
+		return result
+	}
+
+	outputJS := func(result api.BuildResult) string {
+		for _, f := range result.OutputFiles {
+			if strings.HasSuffix(f.Path, ".js") {
+				return string(f.Contents)
+			}
+		}
+		t.Fatal("no JS output")
+		return ""
+	}
+
+	server, client := outputJS(build(true)), build(false)
+	clientJS := outputJS(client)
+
+	className := regexp.MustCompile(`card_card__[0-9a-f]{6}`)
+	assetURL := regexp.MustCompile(`/_reactgo/assets/logo-[A-Z0-9]+\.png`)
+	for _, re := range []*regexp.Regexp{className, assetURL} {
+		s, c := re.FindString(server), re.FindString(clientJS)
+		if s == "" || s != c {
+			t.Errorf("server %q != client %q for %s", s, c, re)
+		}
+	}
+	if strings.Contains(server, "margin") {
+		t.Error("server bundle should not contain CSS")
+	}
+
+	// The client's CSS is extracted, hashed and references the same class.
+	bundles, err := hashCSSBundles(client.Metafile)
+	if err != nil {
+		t.Fatalf("hashCSSBundles: %v", err)
+	}
+	name := bundles["_hydrate_card"]
+	if !regexp.MustCompile(`^_hydrate_card-[0-9a-f]{8}\.css$`).MatchString(name) {
+		t.Fatalf("hashed CSS name = %q", name)
+	}
+	css, err := os.ReadFile(filepath.Join("out", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !className.Match(css) || !strings.Contains(string(css), "margin: 0") {
+		t.Errorf("extracted CSS missing module or global styles:\n%s", css)
+	}
+	if _, err := os.Stat(filepath.Join("out", "_hydrate_card.css")); !os.IsNotExist(err) {
+		t.Error("unhashed CSS file should be renamed")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/evanw/esbuild/pkg/api"
 	"github.com/thutasann/go-react-ssr-engine/internal/config"
@@ -21,9 +22,18 @@ func New(cfg *config.Config) *Bundler {
 type BuildResult struct {
 	ServerBundle  string
 	ClientEntries map[string]string
+
+	// ClientCSS maps route -> content-hashed CSS file extracted from the
+	// page's imports. Routes without CSS are absent.
+	ClientCSS map[string]string
+
+	// Duration is how long the build took, server and client bundles together.
+	Duration time.Duration
 }
 
 func (b *Bundler) Build() (*BuildResult, error) {
+	start := time.Now()
+
 	entries, err := b.discoverPages()
 	if err != nil {
 		return nil, fmt.Errorf("bundler: page discovery failed: %w", err)
@@ -33,12 +43,17 @@ func (b *Bundler) Build() (*BuildResult, error) {
 		return nil, fmt.Errorf("bundler: no pages found in %s", b.cfg.PagesDir)
 	}
 
-	serverJS, err := b.buildServer(entries)
+	apiEntries, err := b.discoverAPIRoutes()
+	if err != nil {
+		return nil, fmt.Errorf("bundler: api route discovery failed: %w", err)
+	}
+
+	serverJS, err := b.buildServer(entries, apiEntries)
 	if err != nil {
 		return nil, fmt.Errorf("bundler: server build failed: %w", err)
 	}
 
-	clientEntries, err := b.buildClient(entries)
+	clientEntries, clientCSS, err := b.buildClient(entries)
 	if err != nil {
 		return nil, fmt.Errorf("bundler: client build failed: %w", err)
 	}
@@ -46,6 +61,8 @@ func (b *Bundler) Build() (*BuildResult, error) {
 	return &BuildResult{
 		ServerBundle:  serverJS,
 		ClientEntries: clientEntries,
+		ClientCSS:     clientCSS,
+		Duration:      time.Since(start),
 	}, nil
 }
 
@@ -57,6 +74,10 @@ func (b *Bundler) discoverPages() ([]string, error) {
 			return err
 		}
 		if info.IsDir() {
+			// pages/api holds request handlers, not React pages
+			if path == b.apiDir() {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
@@ -77,8 +98,145 @@ func (b *Bundler) discoverPages() ([]string, error) {
 	return entries, err
 }
 
-func (b *Bundler) buildServer(entries []string) (string, error) {
-	virtualEntry := b.generateServerEntry(entries)
+// discoverAPIRoutes finds request handlers under pages/api.
+// Any JS/TS module works — handlers don't need JSX.
+// Missing api dir is not an error.
+func (b *Bundler) discoverAPIRoutes() ([]string, error) {
+	apiDir := b.apiDir()
+	if _, err := os.Stat(apiDir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	var entries []string
+
+	err := filepath.Walk(apiDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		switch filepath.Ext(path) {
+		case ".ts", ".js", ".tsx", ".jsx":
+		default:
+			return nil
+		}
+
+		if strings.HasPrefix(filepath.Base(path), "_") {
+			return nil
+		}
+
+		entries = append(entries, path)
+		return nil
+	})
+
+	return entries, err
+}
+
+func (b *Bundler) apiDir() string {
+	return filepath.Join(b.cfg.PagesDir, "api")
+}
+
+// appFile returns pages/_app.tsx (or .jsx), or "" if the app has none.
+func (b *Bundler) appFile() string {
+	return findComponent(b.cfg.PagesDir, "_app")
+}
+
+// layoutsFor lists the _layout files wrapping a page, outermost first.
+// pages/docs/guide/intro.tsx gets pages/_layout.tsx, then
+// pages/docs/_layout.tsx, then pages/docs/guide/_layout.tsx — whichever exist.
+func (b *Bundler) layoutsFor(entry string) []string {
+	rel, err := filepath.Rel(b.cfg.PagesDir, filepath.Dir(entry))
+	if err != nil {
+		return nil
+	}
+
+	dirs := []string{b.cfg.PagesDir}
+	if rel != "." {
+		dir := b.cfg.PagesDir
+		for _, part := range strings.Split(rel, string(filepath.Separator)) {
+			dir = filepath.Join(dir, part)
+			dirs = append(dirs, dir)
+		}
+	}
+
+	var layouts []string
+	for _, dir := range dirs {
+		if layout := findComponent(dir, "_layout"); layout != "" {
+			layouts = append(layouts, layout)
+		}
+	}
+	return layouts
+}
+
+// findComponent looks for name.tsx or name.jsx in dir.
+func findComponent(dir, name string) string {
+	for _, ext := range []string{".tsx", ".jsx"} {
+		path := filepath.Join(dir, name+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// layoutRuntime composes a page with its layouts. Shared by the server and
+// client entries so both render the same tree; expects createElement in scope.
+// Layouts receive the page props plus children. Each route is composed once
+// so the wrapper keeps its identity and React doesn't remount it per render.
+const layoutRuntime = `
+function withLayouts(Page, layouts) {
+  if (!layouts || layouts.length === 0) return Page;
+  function WithLayouts(props) {
+    var el = createElement(Page, props);
+    for (var i = layouts.length - 1; i >= 0; i--) {
+      el = createElement(layouts[i], props, el);
+    }
+    return el;
+  }
+  WithLayouts.displayName = 'WithLayouts(' + (Page.displayName || Page.name || 'Page') + ')';
+  return WithLayouts;
+}
+
+// _app wraps every page, Next.js style: it receives { Component, pageProps }.
+function renderRoot(App, Component, props) {
+  if (App) return createElement(App, { Component: Component, pageProps: props });
+  return createElement(Component, props);
+}
+`
+
+// moduleRefs assigns one variable per distinct layout file so a layout
+// shared by many pages is imported once.
+type moduleRefs struct {
+	names map[string]string
+	order []string
+}
+
+func (m *moduleRefs) ref(path string) string {
+	if m.names == nil {
+		m.names = make(map[string]string)
+	}
+	if name, ok := m.names[path]; ok {
+		return name
+	}
+	name := fmt.Sprintf("Layout%d", len(m.order))
+	m.names[path] = name
+	m.order = append(m.order, path)
+	return name
+}
+
+// layoutList renders a page's layouts as a JS array of variable names.
+func (m *moduleRefs) layoutList(layouts []string) string {
+	names := make([]string, len(layouts))
+	for i, layout := range layouts {
+		names[i] = m.ref(layout)
+	}
+	return "[" + strings.Join(names, ", ") + "]"
+}
+
+func (b *Bundler) buildServer(entries, apiEntries []string) (string, error) {
+	virtualEntry := b.generateServerEntry(entries, apiEntries)
 
 	serverDir := filepath.Join(b.cfg.BuildDir, "server")
 	os.MkdirAll(serverDir, 0755)
@@ -100,19 +258,10 @@ func (b *Bundler) buildServer(entries []string) (string, error) {
 
 	shims := map[string]string{
 		"util": `
-var TextEncoder = function() {};
-TextEncoder.prototype.encode = function(s) {
-  var arr = [];
-  for (var i = 0; i < s.length; i++) arr.push(s.charCodeAt(i));
-  return new Uint8Array(arr);
-};
-var TextDecoder = function() {};
-TextDecoder.prototype.decode = function(arr) {
-  if (typeof arr === 'string') return arr;
-  var s = '';
-  for (var i = 0; i < arr.length; i++) s += String.fromCharCode(arr[i]);
-  return s;
-};
+// The V8 bootstrap defines a UTF-8 TextEncoder with encodeInto, which
+// React's streaming renderer relies on. Re-export it instead of shadowing it.
+var TextEncoder = globalThis.TextEncoder;
+var TextDecoder = globalThis.TextDecoder;
 module.exports = {
   TextEncoder: TextEncoder,
   TextDecoder: TextDecoder,
@@ -294,16 +443,25 @@ module.exports.EventEmitter = EventEmitter;`,
 	}
 
 	// Build alias map: "util" -> "/path/to/_shims/util.js"
-	aliases := make(map[string]string, len(shims))
+	aliases := make(map[string]string, len(shims)+1)
 	for mod := range shims {
 		absShim, _ := filepath.Abs(filepath.Join(shimDir, mod+".js"))
 		aliases[mod] = absShim
 	}
 
+	headModule, err := b.writeHeadModule()
+	if err != nil {
+		return "", err
+	}
+	aliases[HeadModule] = headModule
+
+	// Outdir is never written to; esbuild needs one to name imported assets.
+	// Their files come from the client build, which hashes them identically.
 	result := api.Build(api.BuildOptions{
 		EntryPoints:      []string{entryPath},
 		Bundle:           true,
 		Write:            false,
+		Outdir:           filepath.Join(b.cfg.BuildDir, "server"),
 		Platform:         api.PlatformNeutral,
 		Format:           api.FormatIIFE,
 		Target:           api.ES2020,
@@ -313,6 +471,10 @@ module.exports.EventEmitter = EventEmitter;`,
 		MinifyWhitespace: !b.cfg.Dev,
 		NodePaths:        []string{absNodeModules},
 		Alias:            aliases,
+		Loader:           loaders(true),
+		AssetNames:       assetNames,
+		PublicPath:       PublicPath,
+		Plugins:          []api.Plugin{cssModulesPlugin(true)},
 		Define: map[string]string{
 			"process.env.NODE_ENV": fmt.Sprintf(`"%s"`, b.envMode()),
 		},
@@ -322,10 +484,15 @@ module.exports.EventEmitter = EventEmitter;`,
 		return "", fmt.Errorf("esbuild server: %s", result.Errors[0].Text)
 	}
 
-	return string(result.OutputFiles[0].Contents), nil
+	for _, out := range result.OutputFiles {
+		if strings.HasSuffix(out.Path, ".js") {
+			return string(out.Contents), nil
+		}
+	}
+	return "", fmt.Errorf("esbuild server: no JS output")
 }
 
-func (b *Bundler) buildClient(entries []string) (map[string]string, error) {
+func (b *Bundler) buildClient(entries []string) (map[string]string, map[string]string, error) {
 	clientDir := filepath.Join(b.cfg.BuildDir, "client")
 	os.MkdirAll(clientDir, 0755)
 
@@ -334,11 +501,16 @@ func (b *Bundler) buildClient(entries []string) (map[string]string, error) {
 	// and calls hydrateRoot. esbuild bundles each one separately.
 	hydrateEntries, err := b.generateClientEntries(entries, clientDir)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	absNodeModules, _ := filepath.Abs("node_modules")
 
+	headModule, err := b.writeHeadModule()
+	if err != nil {
+		return nil, nil, err
+	}
+
 	result := api.Build(api.BuildOptions{
 		EntryPoints:      hydrateEntries,
 		Bundle:           true,
@@ -354,17 +526,31 @@ func (b *Bundler) buildClient(entries []string) (map[string]string, error) {
 		MinifySyntax:     !b.cfg.Dev,
 		MinifyWhitespace: !b.cfg.Dev,
 		NodePaths:        []string{absNodeModules},
+		Alias:            map[string]string{HeadModule: headModule},
+		Loader:           loaders(false),
+		AssetNames:       assetNames,
+		PublicPath:       PublicPath,
+		Plugins:          []api.Plugin{cssModulesPlugin(false)},
+		Metafile:         true,
 		Define: map[string]string{
 			"process.env.NODE_ENV": fmt.Sprintf(`"%s"`, b.envMode()),
 		},
 	})
 
 	if len(result.Errors) > 0 {
-		return nil, fmt.Errorf("esbuild client: %s", result.Errors[0].Text)
+		return nil, nil, fmt.Errorf("esbuild client: %s", result.Errors[0].Text)
 	}
 
-	// Map route -> output JS URL path
+	// Entry CSS is named after the entry; give it a content hash so it
+	// can be cached as long as the chunks.
+	cssBundles, err := hashCSSBundles(result.Metafile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("client css: %w", err)
+	}
+
+	// Map route -> output JS URL path, and route -> CSS file
 	clientMap := make(map[string]string)
+	cssMap := make(map[string]string)
 	for _, entry := range entries {
 		route := b.filePathToRoute(entry)
 		// Hydrate entry mirrors page structure: pages/index.tsx -> _hydrate_index.js
@@ -373,9 +559,12 @@ func (b *Bundler) buildClient(entries []string) (map[string]string, error) {
 		if _, err := os.Stat(outFile); err == nil {
 			clientMap[route] = outFile
 		}
+		if css, ok := cssBundles[name]; ok {
+			cssMap[route] = filepath.Join(clientDir, css)
+		}
 	}
 
-	return clientMap, nil
+	return clientMap, cssMap, nil
 }
 
 // generateClientEntries creates per-page hydration scripts with SPA router.
@@ -386,76 +575,141 @@ func (b *Bundler) buildClient(entries []string) (map[string]string, error) {
 func (b *Bundler) generateClientEntries(entries []string, clientDir string) ([]string, error) {
 	var hydrateFiles []string
 
-	// Route map for client-side navigation
+	// Route map for client-side navigation. Layouts are imported statically —
+	// they're shared by many pages and esbuild splits them into common chunks.
+	var layouts moduleRefs
 	var routeImports strings.Builder
 	for _, entry := range entries {
 		absPage, _ := filepath.Abs(entry)
 		route := b.filePathToRoute(entry)
-		routeImports.WriteString(fmt.Sprintf("  '%s': () => import('%s'),\n", route, absPage))
+		routeImports.WriteString(fmt.Sprintf("  '%s': { load: () => import('%s'), layouts: %s },\n",
+			route, absPage, layouts.layoutList(b.layoutsFor(entry))))
+	}
+
+	var moduleImports strings.Builder
+	for _, layout := range layouts.order {
+		absLayout, _ := filepath.Abs(layout)
+		moduleImports.WriteString(fmt.Sprintf("import %s from '%s';\n", layouts.ref(layout), absLayout))
+	}
+	if app := b.appFile(); app != "" {
+		absApp, _ := filepath.Abs(app)
+		moduleImports.WriteString(fmt.Sprintf("import App from '%s';\n", absApp))
+	} else {
+		moduleImports.WriteString("const App = null;\n")
 	}
 
 	for _, entry := range entries {
 		absPage, _ := filepath.Abs(entry)
 		name := b.hydrateEntryName(entry)
+		route := b.filePathToRoute(entry)
 
 		// SPA hydration script.
 		// Key insight: initial hydration renders ONLY the page component
 		// (matching server HTML exactly). SPA navigation activates after
 		// hydration completes via useEffect (which only runs on client).
-		script := fmt.Sprintf(`import { hydrateRoot } from 'react-dom/client';
+		script := fmt.Sprintf(`import { hydrateRoot, createRoot } from 'react-dom/client';
 import { createElement, useState, useEffect, useCallback, useRef } from 'react';
+import { setPageHead } from 'reactgo/head';
 import InitialPage from '%s';
-
+%s
 const routes = {
 %s};
+%s
+// Composed pages by route pattern — see withLayouts.
+const composed = {};
+function composePage(pattern, Page) {
+  if (!composed[pattern]) composed[pattern] = withLayouts(Page, routes[pattern].layouts);
+  return composed[pattern];
+}
 
+// Same precedence as the Go router: static > :param > *catch-all.
 function routeToRegex(pattern) {
   const paramNames = [];
-  const regexStr = pattern
-    .split('/')
-    .map(seg => {
-      if (seg.startsWith(':')) {
-        paramNames.push(seg.slice(1));
-        return '([^/]+)';
-      }
-      return seg;
-    })
-    .join('/');
-  return { regex: new RegExp('^' + regexStr + '$'), paramNames };
+  const catchAll = [];
+  // rank holds one entry per segment: 0 static, 1 param, 2 catch-all
+  const rank = [];
+  let regexStr = '';
+  for (const seg of pattern.split('/').filter(Boolean)) {
+    if (seg.startsWith('*')) {
+      const optional = seg.endsWith('?');
+      paramNames.push(optional ? seg.slice(1, -1) : seg.slice(1));
+      catchAll.push(true);
+      regexStr += optional ? '(?:/(.*))?' : '/(.+)';
+      rank.push(2);
+    } else if (seg.startsWith(':')) {
+      paramNames.push(seg.slice(1));
+      catchAll.push(false);
+      regexStr += '/([^/]+)';
+      rank.push(1);
+    } else {
+      regexStr += '/' + seg;
+      rank.push(0);
+    }
+  }
+  return { regex: new RegExp('^' + (regexStr || '/') + '$'), paramNames, catchAll, rank };
 }
 
 const compiledRoutes = Object.keys(routes).map(pattern => ({
   pattern,
   ...routeToRegex(pattern),
-  load: routes[pattern]
-}));
+  load: routes[pattern].load
+})).sort((a, b) => {
+  // More specific segment wins at the first position the patterns differ
+  for (let i = 0; i < Math.min(a.rank.length, b.rank.length); i++) {
+    if (a.rank[i] !== b.rank[i]) return a.rank[i] - b.rank[i];
+  }
+  // "/docs" before "/docs/*slug?", which also matches "/docs"
+  return a.rank.length - b.rank.length;
+});
 
 function matchRoute(path) {
   for (const route of compiledRoutes) {
     const match = path.match(route.regex);
     if (match) {
       const params = {};
-      route.paramNames.forEach((name, i) => { params[name] = match[i + 1]; });
+      route.paramNames.forEach((name, i) => {
+        const value = match[i + 1];
+        // Catch-alls are arrays of segments, like on the server
+        params[name] = route.catchAll[i] ? (value ? value.split('/') : []) : value;
+      });
       return { pattern: route.pattern, params, load: route.load };
     }
   }
   return null;
 }
 
+// Adds <link rel="stylesheet"> for hrefs not already on the page and
+// resolves once they have loaded, so the next page never renders unstyled.
+// Stylesheets stay once added; CSS is scoped or global by the app's choice.
+function loadStyles(hrefs) {
+  if (!hrefs) return Promise.resolve();
+  const present = new Set(Array.from(document.querySelectorAll('link[rel="stylesheet"]'), l => l.getAttribute('href')));
+  return Promise.all(hrefs.filter(href => !present.has(href)).map(href => new Promise(resolve => {
+    const link = document.createElement('link');
+    link.rel = 'stylesheet';
+    link.href = href;
+    link.onload = link.onerror = resolve;
+    document.head.appendChild(link);
+  })));
+}
+
+// Resolves to { props, head, css }, or null when props can't be fetched — e.g. a
+// static export served from a CDN has no /__data endpoint — so navigation
+// falls back to a full load.
 async function fetchProps(path) {
   try {
     const res = await fetch('/__data?path=' + encodeURIComponent(path));
-    if (!res.ok) return {};
+    if (!res.ok) return null;
     return await res.json();
   } catch (e) {
     console.error('props fetch failed:', e);
-    return {};
+    return null;
   }
 }
 
 function SPAShell({ initialProps }) {
   const [currentPath, setPath] = useState(window.location.pathname);
-  const [PageComponent, setPage] = useState(() => InitialPage);
+  const [PageComponent, setPage] = useState(() => composePage(initialRoute, InitialPage));
   const [pageProps, setPageProps] = useState(initialProps);
   const [loading, setLoading] = useState(false);
   const hydrated = useRef(false);
@@ -471,13 +725,19 @@ function SPAShell({ initialProps }) {
 
     setLoading(true);
     try {
-      const [mod, props] = await Promise.all([
+      const [mod, data] = await Promise.all([
         matched.load(),
         fetchProps(path)
       ]);
-      const Component = mod.default || mod;
+      if (data === null) {
+        window.location.href = path;
+        return;
+      }
+      await loadStyles(data.css);
+      const Component = composePage(matched.pattern, mod.default || mod);
       setPage(() => Component);
-      setPageProps({ ...props, ...matched.params });
+      setPageProps({ ...data.props, ...matched.params });
+      setPageHead(data.head);
       setPath(path);
       if (pushState) window.history.pushState({ path }, '', path);
       window.scrollTo(0, 0);
@@ -489,6 +749,12 @@ function SPAShell({ initialProps }) {
     }
   }, [currentPath]);
 
+  // The server already rendered this head; registering it in the same
+  // commit as the page's <Head> effects keeps the first sync a no-op.
+  useEffect(() => {
+    setPageHead(window.__REACTGO_HEAD__);
+  }, []);
+
   // Activate SPA link interception AFTER hydration.
   // useEffect never runs on server, so this doesn't affect SSR match.
   useEffect(() => {
@@ -529,7 +795,7 @@ function SPAShell({ initialProps }) {
           }
         })
       : null,
-    createElement(PageComponent, pageProps)
+    renderRoot(App, PageComponent, pageProps)
   );
 }
 
@@ -550,10 +816,16 @@ document.head.appendChild(style);
 // These DON'T match because server doesn't have the outer <div>.
 // Fix: server wraps in a plain <div> too.
 
+const initialRoute = '%s';
 const container = document.getElementById('__reactgo');
 const initialProps = window.__REACTGO_DATA__ || {};
-hydrateRoot(container, createElement(SPAShell, { initialProps }));
-`, absPage, routeImports.String())
+// A client-only shell (server render timed out) has nothing to hydrate.
+if (window.__REACTGO_CSR__) {
+  createRoot(container).render(createElement(SPAShell, { initialProps }));
+} else {
+  hydrateRoot(container, createElement(SPAShell, { initialProps }));
+}
+`, absPage, moduleImports.String(), routeImports.String(), layoutRuntime, route)
 
 		hydratePath := filepath.Join(clientDir, name+".jsx")
 		if err := os.WriteFile(hydratePath, []byte(script), 0644); err != nil {
@@ -566,29 +838,64 @@ hydrateRoot(container, createElement(SPAShell, { initialProps }));
 }
 
 // generateServerEntry creates the V8 entry that registers all pages
-// and exposes __renderToString and __getServerSideProps globals.
-func (b *Bundler) generateServerEntry(entries []string) string {
+// and API handlers, and exposes __renderToString, __renderToStream,
+// __getServerSideProps, __getStaticPaths and __handleAPI globals.
+func (b *Bundler) generateServerEntry(entries, apiEntries []string) string {
 	var sb strings.Builder
 
 	sb.WriteString(`var React = require('react');
 var ReactDOMServer = require('react-dom/server');
 
+var createElement = React.createElement;
 var routes = {};
 var propsLoaders = {};
-
+var staticPathsLoaders = {};
+var apiHandlers = {};
 `)
+	sb.WriteString(layoutRuntime)
+	sb.WriteString("\n")
+
+	if app := b.appFile(); app != "" {
+		absApp, _ := filepath.Abs(app)
+		sb.WriteString(fmt.Sprintf("var AppModule = require('%s');\nvar App = AppModule.default || AppModule;\n\n", absApp))
+	} else {
+		sb.WriteString("var App = null;\n\n")
+	}
 
+	var layouts moduleRefs
+	var pages strings.Builder
 	for i, entry := range entries {
 		absPath, _ := filepath.Abs(entry)
 		route := b.filePathToRoute(entry)
 
-		sb.WriteString(fmt.Sprintf("var Page%d = require('%s');\n", i, absPath))
+		pages.WriteString(fmt.Sprintf("var Page%d = require('%s');\n", i, absPath))
 		// Support both default and named exports
-		sb.WriteString(fmt.Sprintf("var Comp%d = Page%d.default || Page%d;\n", i, i, i))
-		sb.WriteString(fmt.Sprintf("routes['%s'] = Comp%d;\n", route, i))
+		pages.WriteString(fmt.Sprintf("var Comp%d = Page%d.default || Page%d;\n", i, i, i))
+		// Wrap in the directory's _layout chain, outermost first
+		pages.WriteString(fmt.Sprintf("routes['%s'] = withLayouts(Comp%d, %s);\n", route, i, layouts.layoutList(b.layoutsFor(entry))))
 
 		// Register getServerSideProps if exported
-		sb.WriteString(fmt.Sprintf("if (Page%d.getServerSideProps) { propsLoaders['%s'] = Page%d.getServerSideProps; }\n\n", i, route, i))
+		pages.WriteString(fmt.Sprintf("if (Page%d.getServerSideProps) { propsLoaders['%s'] = Page%d.getServerSideProps; }\n", i, route, i))
+
+		// Register getStaticPaths if exported — used by static export only
+		pages.WriteString(fmt.Sprintf("if (Page%d.getStaticPaths) { staticPathsLoaders['%s'] = Page%d.getStaticPaths; }\n\n", i, route, i))
+	}
+
+	// Layouts first — pages reference them
+	for _, layout := range layouts.order {
+		absLayout, _ := filepath.Abs(layout)
+		name := layouts.ref(layout)
+		sb.WriteString(fmt.Sprintf("var %sModule = require('%s');\nvar %s = %sModule.default || %sModule;\n", name, absLayout, name, name, name))
+	}
+	sb.WriteString("\n")
+	sb.WriteString(pages.String())
+
+	for i, entry := range apiEntries {
+		absPath, _ := filepath.Abs(entry)
+		route := b.filePathToRoute(entry)
+
+		sb.WriteString(fmt.Sprintf("var Api%d = require('%s');\n", i, absPath))
+		sb.WriteString(fmt.Sprintf("apiHandlers['%s'] = Api%d.default || Api%d.handler || Api%d;\n\n", route, i, i, i))
 	}
 
 	// Global render bridge — called by Worker.Execute()
@@ -599,22 +906,187 @@ globalThis.__renderToString = function(route, props) {
     return '<div>404 - Page not found</div>';
   }
   try {
-    return ReactDOMServer.renderToString(React.createElement(Component, props));
+    return ReactDOMServer.renderToString(renderRoot(App, Component, props));
   } catch(e) {
     return '<div>Render Error: ' + e.message + '</div>';
   }
 };
 
+// Streaming render bridge — called by Worker.ExecuteStream().
+// Output goes to the Go callbacks __reactgoWrite/__reactgoFlush/__reactgoDone.
+// The page is wrapped in a <div> exactly like __renderToString's caller does,
+// so SPAShell hydrates against the same markup in both modes.
+globalThis.__renderToStream = function(route, props) {
+  var finished = false;
+  function finish(err) {
+    if (finished) return;
+    finished = true;
+    __reactgoDone(err ? String((err && err.message) || err) : '');
+  }
+
+  globalThis.__reactgoAbortStream = function() { finish(new Error('aborted before shell')); };
+
+  var Component = routes[route];
+  if (!Component) {
+    __reactgoWrite('<div><div>404 - Page not found</div></div>');
+    finish();
+    return;
+  }
+
+  var decoder = new TextDecoder();
+  var destination = {
+    write: function(chunk) {
+      __reactgoWrite(typeof chunk === 'string' ? chunk : decoder.decode(chunk, { stream: true }));
+      return true;
+    },
+    flush: function() { __reactgoFlush(); },
+    end: function() { __reactgoFlush(); finish(); },
+    destroy: function(err) { finish(err); },
+    on: function() { return this; },
+    once: function() { return this; },
+    emit: function() { return this; },
+    removeListener: function() { return this; }
+  };
+
+  // onShellReady may fire synchronously inside renderToPipeableStream
+  // (setImmediate runs inline here), before stream is assigned.
+  var stream = null;
+  var shellReady = false;
+  try {
+    stream = ReactDOMServer.renderToPipeableStream(
+      React.createElement('div', null, null, renderRoot(App, Component, props)),
+      {
+        onShellReady: function() {
+          shellReady = true;
+          if (stream) stream.pipe(destination);
+        },
+        onShellError: function(e) { finish(e || new Error('shell render failed')); },
+        onError: function(e) { console.error(e); }
+      }
+    );
+  } catch(e) {
+    finish(e);
+    return;
+  }
+  if (shellReady) stream.pipe(destination);
+
+  globalThis.__reactgoAbortStream = function() { stream.abort(); };
+};
+
+// Catch-all params arrive from Go as "a/b/c"; pages and handlers get
+// ['a', 'b', 'c'] (or [] for an empty optional catch-all), like Next.js.
+function expandParams(route, params) {
+  if (!params) return params;
+  route.split('/').forEach(function(seg) {
+    if (seg.charAt(0) !== '*') return;
+    var name = seg.replace(/^\*|\?$/g, '');
+    params[name] = params[name] ? params[name].split('/') : [];
+  });
+  return params;
+}
+
 globalThis.__getServerSideProps = function(route, context) {
+  var sent = false;
+  function finish(result, err) {
+    if (sent) return;
+    sent = true;
+    if (err) {
+      __reactgoDone(err);
+      return;
+    }
+    __reactgoWrite(JSON.stringify(result));
+    __reactgoDone('');
+  }
+
+  // Called by the worker on timeout, or once nothing is left that could settle.
+  globalThis.__reactgoAbortStream = function() { finish(null, 'getServerSideProps timed out'); };
+
   var loader = propsLoaders[route];
   if (!loader) {
-    return JSON.stringify({ props: {} });
+    finish({ props: {} });
+    return;
   }
+  context.params = expandParams(route, context.params);
+
+  // Loaders may be async (await fetch(...)); a plain object resolves at once.
+  function fail(e) { finish({ props: {}, error: (e && e.message) || String(e) }); }
   try {
-    var result = loader(context);
-    return JSON.stringify(result);
+    Promise.resolve(loader(context)).then(function(result) { finish(result); }, fail);
+  } catch(e) {
+    fail(e);
+  }
+};
+
+// Static export bridge — called by Worker.ExecuteStaticPaths().
+// Returns JSON of { paths: [{ params: {...} } | '/path', ...] } or null.
+globalThis.__getStaticPaths = function(route) {
+  var loader = staticPathsLoaders[route];
+  if (!loader) {
+    return 'null';
+  }
+  return JSON.stringify(loader());
+};
+
+// API bridge — called by Worker.ExecuteAPI().
+// Handlers use a Next.js-style (req, res) signature and may be async.
+// The response is reported once, as JSON, through __reactgoWrite.
+globalThis.__handleAPI = function(route, req) {
+  var sent = false;
+  var response = { status: 200, headers: {}, body: '' };
+  function finish(err) {
+    if (sent) return;
+    sent = true;
+    if (err) {
+      __reactgoDone(String((err && err.message) || err));
+      return;
+    }
+    __reactgoWrite(JSON.stringify(response));
+    __reactgoDone('');
+  }
+
+  // Called by the worker on timeout, or once nothing is left that could respond.
+  globalThis.__reactgoAbortStream = function() { finish(new Error('api handler did not send a response')); };
+
+  var handler = apiHandlers[route];
+  if (typeof handler !== 'function') {
+    finish(new Error('no handler exported for ' + route));
+    return;
+  }
+
+  req.params = expandParams(route, req.params);
+
+  var ct = req.headers['content-type'] || '';
+  if (ct.indexOf('application/json') === 0 && req.body) {
+    try { req.body = JSON.parse(req.body); } catch(e) { /* leave as text */ }
+  }
+
+  var res = {
+    status: function(code) { response.status = code; return res; },
+    setHeader: function(k, v) { response.headers[String(k).toLowerCase()] = String(v); return res; },
+    getHeader: function(k) { return response.headers[String(k).toLowerCase()]; },
+    json: function(data) {
+      if (!response.headers['content-type']) response.headers['content-type'] = 'application/json';
+      response.body = JSON.stringify(data);
+      finish();
+    },
+    send: function(body) {
+      if (typeof body === 'object' && body !== null) return res.json(body);
+      if (!response.headers['content-type']) response.headers['content-type'] = 'text/plain; charset=utf-8';
+      response.body = body === undefined ? '' : String(body);
+      finish();
+    },
+    end: function(body) { response.body = body === undefined ? response.body : String(body); finish(); }
+  };
+
+  try {
+    var result = handler(req, res);
+    // Sync handlers must call res.json/send/end (possibly from a timer).
+    // Async handlers may also just resolve, which ends the response as-is.
+    if (result && typeof result.then === 'function') {
+      result.then(function() { finish(); }, function(e) { finish(e || new Error('api handler rejected')); });
+    }
   } catch(e) {
-    return JSON.stringify({ props: {}, error: e.message });
+    finish(e);
   }
 };
 
@@ -630,6 +1102,10 @@ func (b *Bundler) filePathToRoute(filePath string) string {
 	route := strings.TrimPrefix(filePath, b.cfg.PagesDir)
 	route = strings.TrimSuffix(route, filepath.Ext(route))
 	route = filepath.ToSlash(route)
+	// [[...slug]] -> *slug?, [...slug] -> *slug, [id] -> :id
+	route = strings.ReplaceAll(route, "[[...", "*")
+	route = strings.ReplaceAll(route, "]]", "?")
+	route = strings.ReplaceAll(route, "[...", "*")
 	route = strings.ReplaceAll(route, "[", ":")
 	route = strings.ReplaceAll(route, "]", "")
 
@@ -652,6 +1128,7 @@ func (b *Bundler) hydrateEntryName(filePath string) string {
 	name = strings.ReplaceAll(name, "/", "_")
 	name = strings.ReplaceAll(name, "[", "")
 	name = strings.ReplaceAll(name, "]", "")
+	name = strings.ReplaceAll(name, "...", "")
 	return "_hydrate_" + name
 }
 
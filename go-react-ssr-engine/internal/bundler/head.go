@@ -0,0 +1,150 @@
+package bundler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/thutasann/go-react-ssr-engine/pkg/html"
+)
+
+// HeadModule is the import path pages use for the <Head> component:
+//
+//	import Head from 'reactgo/head';
+//
+// It resolves to headRuntime in both the server and the client build.
+const HeadModule = "reactgo/head"
+
+// headRuntime is one module for both sides of <Head>:
+//
+//   - Server: each <Head> pushes its tags to __reactgoHeadTags while
+//     renderToString runs; Worker.Execute hands them to html.Head.
+//   - Client: mounted <Head>s and the page's props head (setPageHead) are
+//     merged in order and reconciled against the managed tags in <head>.
+//
+// Tags are deduplicated by the same keys html.Head uses, so a full page load
+// and a client-side navigation to the same page end up with the same <head>.
+const headRuntime = `import { useEffect, Children, isValidElement } from 'react';
+
+const DEFAULT_TITLE = %q;
+const MANAGED = %q;
+
+function metaKey(m) {
+  return m.key || (m.property ? 'property:' + m.property : 'name:' + m.name);
+}
+
+function linkKey(l) {
+  return l.key || 'link:' + l.rel + ':' + l.href;
+}
+
+function toHead(children) {
+  const head = { meta: [], link: [] };
+  Children.forEach(children, (child) => {
+    if (!isValidElement(child)) return;
+    const p = child.props;
+    const key = child.key != null ? String(child.key) : undefined;
+    if (child.type === 'title') {
+      head.title = Children.toArray(p.children).join('');
+    } else if (child.type === 'meta') {
+      head.meta.push({ name: p.name, property: p.property, content: p.content == null ? '' : String(p.content), key });
+    } else if (child.type === 'link') {
+      head.link.push({ rel: p.rel, href: p.href, as: p.as, key });
+    }
+  });
+  return head;
+}
+
+let pageHead = null;
+const mounted = new Map();
+let seq = 0;
+let scheduled = false;
+
+function sync() {
+  if (scheduled) return;
+  scheduled = true;
+  queueMicrotask(() => {
+    scheduled = false;
+    apply();
+  });
+}
+
+function apply() {
+  let title;
+  const tags = new Map();
+  for (const head of [pageHead, ...mounted.values()]) {
+    if (!head) continue;
+    if (head.title) title = head.title;
+    for (const m of head.meta || []) {
+      const attrs = m.property ? { property: m.property } : { name: m.name };
+      attrs.content = m.content;
+      tags.set(metaKey(m), { tag: 'meta', attrs });
+    }
+    for (const l of head.link || []) {
+      tags.set(linkKey(l), { tag: 'link', attrs: { rel: l.rel, href: l.href, as: l.as } });
+    }
+  }
+
+  document.title = title || DEFAULT_TITLE;
+
+  const existing = new Map();
+  document.head.querySelectorAll('[' + MANAGED + ']').forEach((el) => existing.set(el.getAttribute(MANAGED), el));
+  tags.forEach(({ tag, attrs }, key) => {
+    let el = existing.get(key);
+    existing.delete(key);
+    if (!el || el.tagName.toLowerCase() !== tag) {
+      if (el) el.remove();
+      el = document.createElement(tag);
+      el.setAttribute(MANAGED, key);
+      document.head.appendChild(el);
+    }
+    for (const name in attrs) {
+      if (attrs[name] == null) el.removeAttribute(name);
+      else el.setAttribute(name, attrs[name]);
+    }
+  });
+  existing.forEach((el) => el.remove());
+}
+
+// setPageHead replaces the head returned by getServerSideProps — called by
+// the client router on every navigation.
+export function setPageHead(head) {
+  pageHead = head || null;
+  sync();
+}
+
+export default function Head({ children }) {
+  const head = toHead(children);
+  if (Array.isArray(globalThis.__reactgoHeadTags)) {
+    globalThis.__reactgoHeadTags.push(head);
+  }
+
+  const json = JSON.stringify(head);
+  useEffect(() => {
+    const id = ++seq;
+    mounted.set(id, JSON.parse(json));
+    sync();
+    return () => {
+      mounted.delete(id);
+      sync();
+    };
+  }, [json]);
+
+  return null;
+}
+`
+
+// writeHeadModule writes the <Head> runtime into the build dir and returns
+// its absolute path, for use as the HeadModule alias.
+func (b *Bundler) writeHeadModule() (string, error) {
+	dir := filepath.Join(b.cfg.BuildDir, "runtime")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, "head.js")
+	source := fmt.Sprintf(headRuntime, html.DefaultTitle, html.ManagedAttr)
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		return "", err
+	}
+	return filepath.Abs(path)
+}
@@ -20,6 +20,7 @@ type Watcher struct {
 	cfg      *config.Config
 	bundler  *Bundler
 	onChange func(*BuildResult) // callback when rebuild completes
+	onError  func(error)        // callback when rebuild fails, optional
 
 	mu    sync.Mutex
 	timer *time.Timer
@@ -35,6 +36,12 @@ func NewWatcher(cfg *config.Config, bundler *Bundler, onChange func(*BuildResult
 	}
 }
 
+// OnError registers a callback for failed rebuilds, e.g. to show the
+// bundler error in the browser. Must be called before Start.
+func (w *Watcher) OnError(fn func(error)) {
+	w.onError = fn
+}
+
 // Start begins watching in a background goroutine.
 // Returns immediately. Errors are logged, not returned -
 // a watcher failer shouldn't crash the server.
@@ -102,6 +109,9 @@ func (w *Watcher) debounceRebuild() {
 		result, err := w.bundler.Build()
 		if err != nil {
 			log.Printf("watcher: rebuild failed: %v", err)
+			if w.onError != nil {
+				w.onError(err)
+			}
 			return
 		}
 
@@ -2,7 +2,10 @@ package cache
 
 import (
 	"container/list"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // LRU is a concurrent-safe Least Recently Used cache for rendered HTML.
@@ -18,11 +21,19 @@ type LRU struct {
 	maxSize int
 	items   map[string]*list.Element
 	order   *list.List // front = most recent, back = least recent
+
+	// hits and misses count Get/Lookup results, for metrics.
+	hits   atomic.Uint64
+	misses atomic.Uint64
 }
 
 type entry struct {
 	key   string
 	value string // rendered HTML
+
+	// staleAt is when the entry needs revalidation. Zero means never.
+	// Stale entries are still served — see Lookup.
+	staleAt time.Time
 }
 
 // NewLRU creates a cache with a max entry count.
@@ -40,6 +51,7 @@ func NewLRU(maxSize int) *LRU {
 // Promotes the entry to front (most recent) on hit.
 func (c *LRU) Get(key string) (string, bool) {
 	if c.maxSize == 0 {
+		c.misses.Add(1)
 		return "", false
 	}
 
@@ -48,28 +60,68 @@ func (c *LRU) Get(key string) (string, bool) {
 
 	elem, exists := c.items[key]
 	if !exists {
+		c.misses.Add(1)
 		return "", false
 	}
+	c.hits.Add(1)
 
 	// Move to front — this page is hot, keep it in cache
 	c.order.MoveToFront(elem)
 	return elem.Value.(*entry).value, true
 }
 
+// Lookup is Get for stale-while-revalidate callers. It also reports whether
+// the entry's revalidate window has passed. A stale hit is still a hit — the
+// caller serves it and refreshes the entry in the background.
+func (c *LRU) Lookup(key string) (value string, stale bool, ok bool) {
+	if c.maxSize == 0 {
+		c.misses.Add(1)
+		return "", false, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, exists := c.items[key]
+	if !exists {
+		c.misses.Add(1)
+		return "", false, false
+	}
+	c.hits.Add(1)
+
+	c.order.MoveToFront(elem)
+	e := elem.Value.(*entry)
+	stale = !e.staleAt.IsZero() && !time.Now().Before(e.staleAt)
+	return e.value, stale, true
+}
+
 // Set stores rendered HTML. Evicts the least recently used entry
 // if cache is full. Overwrites if key already exists.
 func (c *LRU) Set(key string, value string) {
+	c.SetWithRevalidate(key, value, 0)
+}
+
+// SetWithRevalidate stores rendered HTML that goes stale after revalidate.
+// Zero revalidate never goes stale, same as Set.
+func (c *LRU) SetWithRevalidate(key string, value string, revalidate time.Duration) {
 	if c.maxSize == 0 {
 		return
 	}
 
+	var staleAt time.Time
+	if revalidate > 0 {
+		staleAt = time.Now().Add(revalidate)
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	// Update existing entry
 	if elem, exists := c.items[key]; exists {
 		c.order.MoveToFront(elem)
-		elem.Value.(*entry).value = value
+		e := elem.Value.(*entry)
+		e.value = value
+		e.staleAt = staleAt
 		return
 	}
 
@@ -83,7 +135,7 @@ func (c *LRU) Set(key string, value string) {
 	}
 
 	// Insert new entry at front
-	e := &entry{key: key, value: value}
+	e := &entry{key: key, value: value, staleAt: staleAt}
 	elem := c.order.PushFront(e)
 	c.items[key] = elem
 }
@@ -103,6 +155,27 @@ func (c *LRU) Delete(key string) {
 	}
 }
 
+// DeletePrefix removes every key starting with prefix and returns how many
+// were removed. O(n) over the cache — meant for purges, not the request path.
+func (c *LRU) DeletePrefix(prefix string) int {
+	if c.maxSize == 0 {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for key, elem := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			c.order.Remove(elem)
+			delete(c.items, key)
+			removed++
+		}
+	}
+	return removed
+}
+
 // Flush clears the entire cache. Used on full rebuild in dev mode.
 func (c *LRU) Flush() {
 	c.mu.Lock()
@@ -112,6 +185,11 @@ func (c *LRU) Flush() {
 	c.order.Init()
 }
 
+// Stats returns how many lookups hit and missed since the cache was created.
+func (c *LRU) Stats() (hits, misses uint64) {
+	return c.hits.Load(), c.misses.Load()
+}
+
 // Len returns current number of cached entries. For metrics/debug.
 func (c *LRU) Len() int {
 	c.mu.Lock()
@@ -3,7 +3,9 @@ package cache
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestLRUBasic(t *testing.T) {
@@ -62,6 +64,24 @@ func TestLRUDelete(t *testing.T) {
 	}
 }
 
+func TestLRUDeletePrefix(t *testing.T) {
+	c := NewLRU(10)
+	c.Set("/about", "base")
+	c.Set("/about|vary|cookie.theme=dark&", "dark")
+	c.Set("/about|vary|cookie.theme=light&", "light")
+	c.Set("/contact", "other")
+
+	if n := c.DeletePrefix("/about|vary|"); n != 2 {
+		t.Errorf("expected 2 removed, got %d", n)
+	}
+	if _, ok := c.Get("/about"); !ok {
+		t.Error("base key should survive a vary purge")
+	}
+	if c.Len() != 2 {
+		t.Errorf("expected 2 entries left, got %d", c.Len())
+	}
+}
+
 func TestLRUFlush(t *testing.T) {
 	c := NewLRU(10)
 	c.Set("a", "1")
@@ -83,6 +103,22 @@ func TestLRUZeroSize(t *testing.T) {
 	}
 }
 
+func TestLRUStats(t *testing.T) {
+	c := NewLRU(2)
+	c.Set("a", "1")
+
+	c.Get("a")
+	c.Get("b")
+	c.Lookup("a")
+	c.Lookup("c")
+	c.Get("a")
+
+	hits, misses := c.Stats()
+	if hits != 3 || misses != 2 {
+		t.Errorf("Stats() = %d hits, %d misses, want 3, 2", hits, misses)
+	}
+}
+
 func TestLRUConcurrent(t *testing.T) {
 	// Hammer the cache from 100 goroutines to detect races.
 	// Run with -race flag: go test -race ./internal/cache/
@@ -103,6 +139,76 @@ func TestLRUConcurrent(t *testing.T) {
 	wg.Wait()
 }
 
+func TestLRULookupStale(t *testing.T) {
+	c := NewLRU(10)
+	c.Set("forever", "a")
+	c.SetWithRevalidate("isr", "b", 20*time.Millisecond)
+
+	if _, stale, ok := c.Lookup("isr"); !ok || stale {
+		t.Fatalf("expected fresh hit, got ok=%v stale=%v", ok, stale)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	// Stale entries are still served
+	value, stale, ok := c.Lookup("isr")
+	if !ok || !stale || value != "b" {
+		t.Errorf("expected stale hit with value b, got ok=%v stale=%v value=%q", ok, stale, value)
+	}
+	if _, stale, _ := c.Lookup("forever"); stale {
+		t.Error("entry without revalidate should never go stale")
+	}
+
+	// Overwriting resets the window
+	c.SetWithRevalidate("isr", "c", time.Hour)
+	if _, stale, _ := c.Lookup("isr"); stale {
+		t.Error("expected fresh entry after overwrite")
+	}
+}
+
+func TestRevalidatorSingleFlight(t *testing.T) {
+	r := NewRevalidator()
+	release := make(chan struct{})
+	var runs atomic.Int32
+
+	fn := func() {
+		runs.Add(1)
+		<-release
+	}
+
+	if !r.Trigger("/posts/:id|id=1&", fn) {
+		t.Fatal("expected first trigger to start")
+	}
+	if r.Trigger("/posts/:id|id=1&", fn) {
+		t.Error("expected second trigger for same key to be skipped")
+	}
+	if !r.InFlight("/posts/:id|id=1&") {
+		t.Error("expected key to be in flight")
+	}
+
+	close(release)
+	for r.InFlight("/posts/:id|id=1&") {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Once finished, the key can be revalidated again
+	if !r.Trigger("/posts/:id|id=1&", func() {}) {
+		t.Error("expected trigger after completion to start")
+	}
+	if runs.Load() != 1 {
+		t.Errorf("expected 1 run of fn, got %d", runs.Load())
+	}
+}
+
+func TestRevalidatorRecoversPanic(t *testing.T) {
+	r := NewRevalidator()
+	r.Trigger("boom", func() { panic("render exploded") })
+
+	for r.InFlight("boom") {
+		time.Sleep(time.Millisecond)
+	}
+}
+
 func BenchmarkLRUGet(b *testing.B) {
 	c := NewLRU(10000)
 	// Pre-fill
@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"log"
+	"sync"
+)
+
+// Revalidator runs background re-renders for stale cache entries.
+// At most one re-render per key is in flight: while a page regenerates,
+// every other request for it keeps getting the stale copy instead of
+// piling more renders onto the V8 pool.
+type Revalidator struct {
+	mu       sync.Mutex
+	inflight map[string]struct{}
+}
+
+func NewRevalidator() *Revalidator {
+	return &Revalidator{
+		inflight: make(map[string]struct{}),
+	}
+}
+
+// Trigger starts fn in a new goroutine unless a revalidation for key is
+// already running. Returns true if fn was started.
+func (r *Revalidator) Trigger(key string, fn func()) bool {
+	r.mu.Lock()
+	if _, busy := r.inflight[key]; busy {
+		r.mu.Unlock()
+		return false
+	}
+	r.inflight[key] = struct{}{}
+	r.mu.Unlock()
+
+	go func() {
+		defer func() {
+			r.mu.Lock()
+			delete(r.inflight, key)
+			r.mu.Unlock()
+		}()
+		defer func() {
+			// A failed re-render must not take the server down; the stale
+			// entry keeps being served and the next request retries.
+			if rec := recover(); rec != nil {
+				log.Printf("cache: revalidate %s panicked: %v", key, rec)
+			}
+		}()
+		fn()
+	}()
+
+	return true
+}
+
+// InFlight reports whether key is currently being revalidated.
+func (r *Revalidator) InFlight(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, busy := r.inflight[key]
+	return busy
+}
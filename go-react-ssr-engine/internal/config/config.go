@@ -16,17 +16,101 @@ type Config struct {
 	WorkerPoolSize  int    `json:"workerPoolSize"`
 	Dev             bool   `json:"dev"`
 	CacheMaxEntries int    `json:"cacheMaxEntries"`
+
+	// Streaming renders pages with renderToPipeableStream: the <head> is
+	// flushed immediately and Suspense boundaries stream in as they resolve.
+	Streaming bool `json:"streaming"`
+
+	// StreamTimeoutMs caps how long a streaming render may wait on Suspense
+	// boundaries before the rest is left for the client to render.
+	StreamTimeoutMs int `json:"streamTimeoutMs"`
+
+	// APITimeoutMs caps how long a pages/api handler may run, including
+	// time spent waiting on promises and timers.
+	APITimeoutMs int `json:"apiTimeoutMs"`
+
+	// PropsTimeoutMs caps how long getServerSideProps may run, including
+	// time spent waiting on fetch() and timers.
+	PropsTimeoutMs int `json:"propsTimeoutMs"`
+
+	// FetchTimeoutMs caps each fetch() request made from page code.
+	FetchTimeoutMs int `json:"fetchTimeoutMs"`
+
+	// FetchAllowedHosts lists the hosts fetch() may call: exact names,
+	// host:port, or "*.example.com" for subdomains. Empty allows any host.
+	FetchAllowedHosts []string `json:"fetchAllowedHosts"`
+
+	// RenderTimeoutMs caps how long page JavaScript may run without
+	// yielding. A render that exceeds it is terminated and the page is
+	// served as a client-only shell.
+	RenderTimeoutMs int `json:"renderTimeoutMs"`
+
+	// MaxHeapMB is V8's hard heap limit per isolate. Exceeding it crashes
+	// the process, so it is off by default; 0 keeps V8's own limit.
+	MaxHeapMB int `json:"maxHeapMB"`
+
+	// WorkerMaxRenders and WorkerHeapLimitMB recycle a V8 worker after that
+	// many uses, or once its heap grows past the threshold. 0 disables.
+	WorkerMaxRenders  int `json:"workerMaxRenders"`
+	WorkerHeapLimitMB int `json:"workerHeapLimitMB"`
+
+	// RevalidateSecret authenticates on-demand purges via /__revalidate.
+	// Empty disables the endpoint.
+	RevalidateSecret string `json:"revalidateSecret"`
+
+	// CacheKeyCookies and CacheKeyHeaders name the request cookies and
+	// headers a page's HTML depends on. Each distinct combination of their
+	// values is cached separately.
+	CacheKeyCookies []string `json:"cacheKeyCookies"`
+	CacheKeyHeaders []string `json:"cacheKeyHeaders"`
+
+	// Locales the app supports, e.g. ["en-US", "de"]. The first is the
+	// default; PageContext.Locale is negotiated from Accept-Language.
+	Locales []string `json:"locales"`
+
+	// TrustProxy takes the client IP from the last X-Forwarded-For entry,
+	// the one the proxy in front of the server appended.
+	// Only enable behind a proxy that sets the header.
+	TrustProxy bool `json:"trustProxy"`
+
+	// RateLimit configures the token-bucket limiter in front of pages
+	// and API routes.
+	RateLimit RateLimitConfig `json:"rateLimit"`
+}
+
+// RateLimitConfig is a token bucket per key: up to Burst requests at once,
+// refilled at Rate requests per second.
+type RateLimitConfig struct {
+	Disabled bool    `json:"disabled"`
+	Rate     float64 `json:"rate"`
+	Burst    int     `json:"burst"`
+
+	// Key picks what shares a bucket: "path" (all clients of a path),
+	// "ip" (each client across paths) or "ip+path".
+	Key string `json:"key"`
 }
 
 func DefaultConfig() *Config {
 	return &Config{
-		Port:            3000,
-		PagesDir:        "pages",
-		PublicDir:       "public",
-		BuildDir:        ".build",
-		WorkerPoolSize:  runtime.NumCPU(), // one V8 isolate per core - no oversubscription
-		Dev:             false,
-		CacheMaxEntries: 10000,
+		Port:              3000,
+		PagesDir:          "pages",
+		PublicDir:         "public",
+		BuildDir:          ".build",
+		WorkerPoolSize:    runtime.NumCPU(), // one V8 isolate per core - no oversubscription
+		Dev:               false,
+		CacheMaxEntries:   10000,
+		Streaming:         false,
+		StreamTimeoutMs:   10000,
+		APITimeoutMs:      10000,
+		PropsTimeoutMs:    10000,
+		FetchTimeoutMs:    5000,
+		RenderTimeoutMs:   5000,
+		WorkerHeapLimitMB: 256,
+		RateLimit: RateLimitConfig{
+			Rate:  1000,
+			Burst: 1000,
+			Key:   "path",
+		},
 	}
 }
 
@@ -51,6 +135,28 @@ func Load(path string) (*Config, error) {
 	if cfg.WorkerPoolSize == 0 {
 		cfg.WorkerPoolSize = runtime.NumCPU()
 	}
+	if cfg.StreamTimeoutMs <= 0 {
+		cfg.StreamTimeoutMs = DefaultConfig().StreamTimeoutMs
+	}
+	if cfg.APITimeoutMs <= 0 {
+		cfg.APITimeoutMs = DefaultConfig().APITimeoutMs
+	}
+	if cfg.PropsTimeoutMs <= 0 {
+		cfg.PropsTimeoutMs = DefaultConfig().PropsTimeoutMs
+	}
+	if cfg.FetchTimeoutMs <= 0 {
+		cfg.FetchTimeoutMs = DefaultConfig().FetchTimeoutMs
+	}
+	if cfg.RenderTimeoutMs <= 0 {
+		cfg.RenderTimeoutMs = DefaultConfig().RenderTimeoutMs
+	}
+	if cfg.RateLimit.Rate <= 0 {
+		cfg.RateLimit.Rate = DefaultConfig().RateLimit.Rate
+	}
+	// A burst below one request would reject everything.
+	if cfg.RateLimit.Burst <= 0 {
+		cfg.RateLimit.Burst = max(1, int(cfg.RateLimit.Rate))
+	}
 
 	return cfg, nil
 }
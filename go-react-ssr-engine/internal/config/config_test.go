@@ -70,3 +70,21 @@ func TestLoadZeroWorkers(t *testing.T) {
 		t.Errorf("expected %d workers for zero value, got %d", runtime.NumCPU(), cfg.WorkerPoolSize)
 	}
 }
+
+func TestLoadRateLimit(t *testing.T) {
+	// Setting only the key keeps the default rate; burst follows the rate
+	content := []byte(`{"rateLimit": {"key": "ip", "rate": 5}}`)
+	tmpFile := "test_rate_limit.json"
+	os.WriteFile(tmpFile, content, 0644)
+	defer os.Remove(tmpFile)
+
+	cfg, err := Load(tmpFile)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	rl := cfg.RateLimit
+	if rl.Key != "ip" || rl.Rate != 5 || rl.Burst != 1000 || rl.Disabled {
+		t.Errorf("unexpected rate limit config %+v", rl)
+	}
+}
@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// APIRequest is what a pages/api handler receives as req.
+// Header names are lower-cased, like Node's IncomingMessage.
+type APIRequest struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers"`
+	Query   map[string]string `json:"query"`
+	Params  map[string]string `json:"params"`
+	Body    string            `json:"body"`
+}
+
+// APIResponse is what the handler sent through res.
+// Header names are lower-cased; setHeader is case-insensitive.
+type APIResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
+// ExecuteAPI calls __handleAPI in V8 and waits for the handler to respond.
+// Handlers may be async; the worker event loop runs until res is ended or
+// timeout elapses.
+func (w *Worker) ExecuteAPI(bundle, route, reqJSON string, timeout time.Duration) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.loadBundle(bundle); err != nil {
+		return "", err
+	}
+
+	var out bufferWriter
+	call := fmt.Sprintf(`__handleAPI(%q, %s)`, route, reqJSON)
+	if err := w.runAsync(call, timeout, &out); err != nil {
+		return "", fmt.Errorf("worker %d: api %s: %w", w.id, route, err)
+	}
+
+	return out.String(), nil
+}
+
+// HandleAPI runs an API route handler on a pooled worker.
+func (e *Engine) HandleAPI(route string, req *APIRequest) (*APIResponse, error) {
+	e.mu.RLock()
+	bundle := e.serverBundle
+	e.mu.RUnlock()
+
+	if bundle == "" {
+		return nil, fmt.Errorf("engine: no bundle loaded")
+	}
+
+	reqJSON, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("engine: api request marshal: %w", err)
+	}
+
+	worker := e.pool.Acquire()
+	defer e.pool.Release(worker)
+
+	timeout := time.Duration(e.cfg.APITimeoutMs) * time.Millisecond
+	result, err := worker.ExecuteAPI(bundle, route, string(reqJSON), timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp APIResponse
+	if err := json.Unmarshal([]byte(result), &resp); err != nil {
+		return nil, fmt.Errorf("engine: api %s: invalid response: %w", route, err)
+	}
+	if resp.Status == 0 {
+		resp.Status = 200
+	}
+	return &resp, nil
+}
+
+// bufferWriter collects __reactgoWrite output when nothing is streamed.
+type bufferWriter struct {
+	strings.Builder
+}
+
+func (b *bufferWriter) Flush() error { return nil }
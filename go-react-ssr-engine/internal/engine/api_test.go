@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// apiBundle mimics the generated __handleAPI bridge with two handlers:
+// "/api/echo" answers synchronously, "/api/slow" after a timer.
+const apiBundle = `
+globalThis.__handleAPI = function(route, req) {
+	globalThis.__reactgoAbortStream = function() { __reactgoDone('api handler did not send a response'); };
+	function send(status, body) {
+		__reactgoWrite(JSON.stringify({ status: status, headers: { 'content-type': 'application/json' }, body: JSON.stringify(body) }));
+		__reactgoDone('');
+	}
+	if (route === '/api/echo') {
+		send(201, { method: req.method, id: req.params.id, q: req.query.q, ua: req.headers['user-agent'], body: req.body });
+		return;
+	}
+	if (route === '/api/slow') {
+		setTimeout(function() { send(200, { ok: true }); }, Number(req.query.ms));
+		return;
+	}
+	__reactgoDone('no handler exported for ' + route);
+};
+`
+
+func TestExecuteAPI(t *testing.T) {
+	w := newStreamWorker(t)
+
+	req := `{"method":"POST","path":"/api/echo","headers":{"user-agent":"test"},"query":{"q":"x"},"params":{"id":"7"},"body":"hi"}`
+	result, err := w.ExecuteAPI(apiBundle, "/api/echo", req, time.Second)
+	if err != nil {
+		t.Fatalf("api: %v", err)
+	}
+
+	var resp APIResponse
+	if err := json.Unmarshal([]byte(result), &resp); err != nil {
+		t.Fatalf("invalid response %q: %v", result, err)
+	}
+	if resp.Status != 201 {
+		t.Errorf("expected status 201, got %d", resp.Status)
+	}
+	if resp.Headers["content-type"] != "application/json" {
+		t.Errorf("expected json content-type, got %v", resp.Headers)
+	}
+	want := `{"method":"POST","id":"7","q":"x","ua":"test","body":"hi"}`
+	if resp.Body != want {
+		t.Errorf("expected body %s, got %s", want, resp.Body)
+	}
+}
+
+func TestExecuteAPIAsync(t *testing.T) {
+	w := newStreamWorker(t)
+
+	req := `{"method":"GET","headers":{},"query":{"ms":"20"},"params":{}}`
+	result, err := w.ExecuteAPI(apiBundle, "/api/slow", req, time.Second)
+	if err != nil {
+		t.Fatalf("api: %v", err)
+	}
+	if !strings.Contains(result, `"status":200`) {
+		t.Errorf("unexpected response %s", result)
+	}
+}
+
+func TestExecuteAPITimeout(t *testing.T) {
+	w := newStreamWorker(t)
+
+	req := `{"method":"GET","headers":{},"query":{"ms":"5000"},"params":{}}`
+	_, err := w.ExecuteAPI(apiBundle, "/api/slow", req, 50*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "did not send a response") {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+}
+
+func TestExecuteAPIMissingHandler(t *testing.T) {
+	w := newStreamWorker(t)
+
+	_, err := w.ExecuteAPI(apiBundle, "/api/missing", `{"headers":{},"query":{},"params":{}}`, time.Second)
+	if err == nil || !strings.Contains(err.Error(), "no handler exported") {
+		t.Fatalf("expected missing handler error, got %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/thutasann/go-react-ssr-engine/internal/config"
 )
@@ -21,12 +22,23 @@ type Engine struct {
 func New(cfg *config.Config) (*Engine, error) {
 	e := &Engine{cfg: cfg}
 
+	// Must precede the first isolate.
+	SetHeapLimit(cfg.MaxHeapMB)
+
 	pool, err := NewPool(cfg.WorkerPoolSize)
 	if err != nil {
 		return nil, fmt.Errorf("engine: pool init: %w", err)
 	}
 	e.pool = pool
 
+	fetchTimeout := time.Duration(cfg.FetchTimeoutMs) * time.Millisecond
+	pool.setFetcher(NewFetcher(fetchTimeout, cfg.FetchAllowedHosts))
+	pool.setLimits(Limits{
+		RenderTimeout: time.Duration(cfg.RenderTimeoutMs) * time.Millisecond,
+		MaxRenders:    cfg.WorkerMaxRenders,
+		MaxHeapBytes:  uint64(cfg.WorkerHeapLimitMB) << 20,
+	})
+
 	return e, nil
 }
 
@@ -40,13 +52,13 @@ func (e *Engine) LoadBundle(js string) {
 
 // Render executes React SSR for a route with given props JSON.
 // Acquires a worker, renders, releases. Blocks if pool exhausted.
-func (e *Engine) Render(route string, propsJSON string) (string, error) {
+func (e *Engine) Render(route string, propsJSON string) (*RenderResult, error) {
 	e.mu.RLock()
 	bundle := e.serverBundle
 	e.mu.RUnlock()
 
 	if bundle == "" {
-		return "", fmt.Errorf("engine: no bundle loaded")
+		return nil, fmt.Errorf("engine: no bundle loaded")
 	}
 
 	worker := e.pool.Acquire()
@@ -55,6 +67,25 @@ func (e *Engine) Render(route string, propsJSON string) (string, error) {
 	return worker.Execute(bundle, route, propsJSON)
 }
 
+// RenderStream executes streaming React SSR for a route, writing HTML chunks
+// to w as Suspense boundaries resolve. Holds one worker for the whole stream.
+// Returns ErrRenderAborted (wrapped) if StreamTimeoutMs elapsed first.
+func (e *Engine) RenderStream(route string, propsJSON string, w StreamWriter) error {
+	e.mu.RLock()
+	bundle := e.serverBundle
+	e.mu.RUnlock()
+
+	if bundle == "" {
+		return fmt.Errorf("engine: no bundle loaded")
+	}
+
+	worker := e.pool.Acquire()
+	defer e.pool.Release(worker)
+
+	timeout := time.Duration(e.cfg.StreamTimeoutMs) * time.Millisecond
+	return worker.ExecuteStream(bundle, route, propsJSON, timeout, w)
+}
+
 // RenderProps executes getServerSideProps for a route.
 // context is serialized PageContext JSON.
 // Returns raw JSON string from V8.
@@ -75,7 +106,37 @@ func (e *Engine) RenderProps(route string, context interface{}) (string, error)
 	worker := e.pool.Acquire()
 	defer e.pool.Release(worker)
 
-	return worker.ExecuteProps(bundle, route, string(ctxJSON))
+	timeout := time.Duration(e.cfg.PropsTimeoutMs) * time.Millisecond
+	return worker.ExecuteProps(bundle, route, string(ctxJSON), timeout)
+}
+
+// StaticPaths executes getStaticPaths for a dynamic route.
+// Used by the static exporter to enumerate the pages to prerender.
+// Returns raw JSON string from V8, "null" if the page has no getStaticPaths.
+func (e *Engine) StaticPaths(route string) (string, error) {
+	e.mu.RLock()
+	bundle := e.serverBundle
+	e.mu.RUnlock()
+
+	if bundle == "" {
+		return "", fmt.Errorf("engine: no bundle loaded")
+	}
+
+	worker := e.pool.Acquire()
+	defer e.pool.Release(worker)
+
+	return worker.ExecuteStaticPaths(bundle, route)
+}
+
+// PoolStats returns the worker pool utilization, for metrics.
+func (e *Engine) PoolStats() PoolStats {
+	return e.pool.Stats()
+}
+
+// ObserveQueueWait registers fn to receive how long each render waited for
+// a free worker. Must be called before serving requests.
+func (e *Engine) ObserveQueueWait(fn func(time.Duration)) {
+	e.pool.ObserveWait(fn)
 }
 
 func (e *Engine) Shutdown() {
@@ -0,0 +1,342 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	v8 "rogchap.com/v8go"
+)
+
+// maxFetchBody caps a response body read into V8. Pages fetch JSON from
+// backend APIs; anything larger is almost certainly a mistake.
+const maxFetchBody = 10 << 20 // 10MB
+
+// Fetcher performs the HTTP requests behind fetch() in V8.
+// Shared by all workers; http.Client is safe for concurrent use.
+type Fetcher struct {
+	client  *http.Client
+	timeout time.Duration
+
+	// allowed lists the hosts pages may call. Entries are exact host names
+	// ("api.internal"), host:port ("localhost:8080") or subdomain wildcards
+	// ("*.example.com"). Empty allows any host.
+	allowed []string
+}
+
+// NewFetcher creates a fetcher with a per-request timeout and host allow-list.
+func NewFetcher(timeout time.Duration, allowedHosts []string) *Fetcher {
+	f := &Fetcher{timeout: timeout, allowed: allowedHosts}
+	f.client = &http.Client{
+		// Redirects must not escape the allow-list.
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("too many redirects")
+			}
+			return f.check(req.URL)
+		},
+	}
+	return f
+}
+
+// fetchRequest is what fetch(url, init) sends to Go.
+type fetchRequest struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
+// fetchResponse is handed back to JS to build a Response.
+// Error set means the request failed and the promise rejects.
+type fetchResponse struct {
+	Status     int               `json:"status"`
+	StatusText string            `json:"statusText"`
+	URL        string            `json:"url"`
+	Headers    map[string]string `json:"headers"`
+	Body       string            `json:"body"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// check rejects URLs fetch may not call.
+func (f *Fetcher) check(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported URL %q: absolute http(s) URL required", u.String())
+	}
+	if !hostAllowed(u, f.allowed) {
+		return fmt.Errorf("host %q is not in fetchAllowedHosts", u.Host)
+	}
+	return nil
+}
+
+func hostAllowed(u *url.URL, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, entry := range allowed {
+		entry = strings.ToLower(entry)
+		switch {
+		case strings.HasPrefix(entry, "*."):
+			if strings.HasSuffix(host, entry[1:]) {
+				return true
+			}
+		case strings.Contains(entry, ":"):
+			if strings.ToLower(u.Host) == entry {
+				return true
+			}
+		case host == entry:
+			return true
+		}
+	}
+	return false
+}
+
+// do performs one request. Failures are reported in the response's Error
+// so they surface as a rejected promise, like a network error in a browser.
+func (f *Fetcher) do(ctx context.Context, req *fetchRequest) *fetchResponse {
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+
+	var body io.Reader
+	if req.Body != "" {
+		body = strings.NewReader(req.Body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, req.URL, body)
+	if err != nil {
+		return &fetchResponse{Error: err.Error()}
+	}
+	for k, v := range req.Headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	resp, err := f.client.Do(httpReq)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return &fetchResponse{Error: fmt.Sprintf("%s %s: timed out after %s", req.Method, req.URL, f.timeout)}
+		}
+		return &fetchResponse{Error: err.Error()}
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchBody+1))
+	if err != nil {
+		return &fetchResponse{Error: err.Error()}
+	}
+	if len(data) > maxFetchBody {
+		return &fetchResponse{Error: fmt.Sprintf("%s %s: response body exceeds %d bytes", req.Method, req.URL, maxFetchBody)}
+	}
+
+	headers := make(map[string]string, len(resp.Header))
+	for k, v := range resp.Header {
+		headers[strings.ToLower(k)] = strings.Join(v, ", ")
+	}
+
+	return &fetchResponse{
+		Status:     resp.StatusCode,
+		StatusText: http.StatusText(resp.StatusCode),
+		URL:        resp.Request.URL.String(),
+		Headers:    headers,
+		Body:       string(data),
+	}
+}
+
+// fetchState tracks the fetch() calls of one async run. Requests execute on
+// their own goroutines; everything else runs on the event loop goroutine,
+// since only it may touch the isolate.
+type fetchState struct {
+	fetcher *Fetcher
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	// results receives finished requests from their goroutines.
+	results chan fetchResult
+
+	// inflight counts requests whose goroutine hasn't reported yet.
+	inflight int
+
+	// ready holds responses waiting to be handed to JS.
+	ready []fetchDelivery
+
+	// calls dedupes identical GET/HEAD requests within one run: later
+	// callers join the request in flight or reuse its response.
+	calls map[string]*fetchCall
+}
+
+type fetchCall struct {
+	ids  []int
+	resp *fetchResponse
+}
+
+type fetchResult struct {
+	key  string
+	resp *fetchResponse
+}
+
+type fetchDelivery struct {
+	id   int
+	resp *fetchResponse
+}
+
+func newFetchState(fetcher *Fetcher) *fetchState {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &fetchState{
+		fetcher: fetcher,
+		ctx:     ctx,
+		cancel:  cancel,
+		results: make(chan fetchResult),
+		calls:   make(map[string]*fetchCall),
+	}
+}
+
+// start begins the request for fetch call id.
+func (f *fetchState) start(id int, req *fetchRequest) {
+	if f.fetcher == nil {
+		f.fail(id, "fetch is not configured for this worker")
+		return
+	}
+
+	req.Method = strings.ToUpper(req.Method)
+	if req.Method == "" {
+		req.Method = http.MethodGet
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		f.fail(id, err.Error())
+		return
+	}
+	if err := f.fetcher.check(u); err != nil {
+		f.fail(id, err.Error())
+		return
+	}
+
+	key := dedupeKey(id, req)
+	if call, ok := f.calls[key]; ok {
+		if call.resp != nil {
+			f.ready = append(f.ready, fetchDelivery{id: id, resp: call.resp})
+		} else {
+			call.ids = append(call.ids, id)
+		}
+		return
+	}
+
+	f.calls[key] = &fetchCall{ids: []int{id}}
+	f.inflight++
+	go func() {
+		resp := f.fetcher.do(f.ctx, req)
+		select {
+		case f.results <- fetchResult{key: key, resp: resp}:
+		case <-f.ctx.Done():
+		}
+	}()
+}
+
+// complete records a finished request and queues it for every caller.
+func (f *fetchState) complete(res fetchResult) {
+	f.inflight--
+	call := f.calls[res.key]
+	call.resp = res.resp
+	for _, id := range call.ids {
+		f.ready = append(f.ready, fetchDelivery{id: id, resp: res.resp})
+	}
+	call.ids = nil
+}
+
+func (f *fetchState) fail(id int, msg string) {
+	f.ready = append(f.ready, fetchDelivery{id: id, resp: &fetchResponse{Error: msg}})
+}
+
+// waiting reports whether any request is still in flight.
+func (f *fetchState) waiting() bool {
+	return f.inflight > 0
+}
+
+// hasReady reports whether responses are waiting to be delivered.
+func (f *fetchState) hasReady() bool {
+	return len(f.ready) > 0
+}
+
+// close cancels in-flight requests once the run is over.
+func (f *fetchState) close() {
+	f.cancel()
+}
+
+// dedupeKey identifies requests that can share a response. Only safe
+// methods without a body are shared; anything else gets a unique key.
+func dedupeKey(id int, req *fetchRequest) string {
+	if (req.Method != http.MethodGet && req.Method != http.MethodHead) || req.Body != "" {
+		return fmt.Sprintf("#%d", id)
+	}
+
+	names := make([]string, 0, len(req.Headers))
+	for k := range req.Headers {
+		names = append(names, strings.ToLower(k))
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	sb.WriteString(req.Method + " " + req.URL)
+	for _, k := range names {
+		sb.WriteString("\n" + k + ": " + req.Headers[k])
+	}
+	return sb.String()
+}
+
+// installFetchBridge exposes __reactgoFetch(id, requestJSON) to the fetch()
+// polyfill. Responses come back through __reactgoResolveFetch, called by the
+// event loop. fetch() outside runAsync (e.g. in a renderToString pass) has no
+// event loop to resolve it and rejects right away.
+func (w *Worker) installFetchBridge(global *v8.ObjectTemplate) {
+	global.Set("__reactgoFetch", v8.NewFunctionTemplate(w.iso, func(info *v8.FunctionCallbackInfo) *v8.Value {
+		s := w.stream
+		if s == nil {
+			msg, _ := v8.NewValue(w.iso, "fetch is only available in getServerSideProps, API routes and streaming renders")
+			return msg
+		}
+
+		args := info.Args()
+		if len(args) < 2 {
+			return nil
+		}
+		id := int(args[0].Integer())
+
+		var req fetchRequest
+		if err := json.Unmarshal([]byte(args[1].String()), &req); err != nil {
+			s.fetches.fail(id, "invalid request: "+err.Error())
+			return nil
+		}
+		s.fetches.start(id, &req)
+		return nil
+	}))
+}
+
+// deliverFetches resolves the JS promises of every ready response.
+func (w *Worker) deliverFetches(s *streamState) error {
+	for len(s.fetches.ready) > 0 {
+		ready := s.fetches.ready
+		s.fetches.ready = nil
+
+		for _, d := range ready {
+			data, err := json.Marshal(d.resp)
+			if err != nil {
+				return fmt.Errorf("fetch: %w", err)
+			}
+			call := fmt.Sprintf(`__reactgoResolveFetch(%d, %s)`, d.id, data)
+			if _, err := w.run(call, "fetch.js"); err != nil {
+				return fmt.Errorf("fetch: %w", err)
+			}
+		}
+		// Callers may fetch again straight away, or fail validation.
+		if err := w.microtasks(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package engine
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fetchBundle mimics the generated __getServerSideProps bridge with async
+// loaders that call fetch(). context.query.url is the URL to fetch.
+const fetchBundle = `
+var loaders = {
+	'/one': async function(ctx) {
+		var res = await fetch(ctx.query.url, { headers: { 'X-Test': 'yes' } });
+		return { props: { status: res.status, ok: res.ok, type: res.headers.get('content-type'), data: await res.json() } };
+	},
+	'/twice': async function(ctx) {
+		var all = await Promise.all([fetch(ctx.query.url), fetch(ctx.query.url)]);
+		return { props: { a: await all[0].text(), b: await all[1].text() } };
+	},
+	'/error': async function(ctx) {
+		try {
+			await fetch(ctx.query.url);
+			return { props: { caught: '' } };
+		} catch (e) {
+			return { props: { caught: e.message } };
+		}
+	},
+	'/sync': function() { return { props: { sync: true } }; }
+};
+globalThis.__getServerSideProps = function(route, context) {
+	var sent = false;
+	function finish(result, err) {
+		if (sent) return;
+		sent = true;
+		if (err) { __reactgoDone(err); return; }
+		__reactgoWrite(JSON.stringify(result));
+		__reactgoDone('');
+	}
+	globalThis.__reactgoAbortStream = function() { finish(null, 'getServerSideProps timed out'); };
+	Promise.resolve(loaders[route](context)).then(function(r) { finish(r); }, function(e) {
+		finish({ props: {}, error: e.message });
+	});
+};
+`
+
+func newFetchWorker(t *testing.T, timeout time.Duration, allowed []string) *Worker {
+	t.Helper()
+	w := newStreamWorker(t)
+	w.fetcher = NewFetcher(timeout, allowed)
+	return w
+}
+
+func propsContext(rawURL string) string {
+	data, _ := json.Marshal(map[string]interface{}{
+		"query": map[string]string{"url": rawURL},
+	})
+	return string(data)
+}
+
+func decodeProps(t *testing.T, result string) map[string]interface{} {
+	t.Helper()
+	var out struct {
+		Props map[string]interface{} `json:"props"`
+	}
+	if err := json.Unmarshal([]byte(result), &out); err != nil {
+		t.Fatalf("invalid props %q: %v", result, err)
+	}
+	return out.Props
+}
+
+func TestFetchResolvesInProps(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Test") != "yes" {
+			t.Errorf("request header not forwarded: %v", r.Header)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name":"gopher"}`))
+	}))
+	defer srv.Close()
+
+	w := newFetchWorker(t, time.Second, nil)
+	result, err := w.ExecuteProps(fetchBundle, "/one", propsContext(srv.URL), time.Second)
+	if err != nil {
+		t.Fatalf("props: %v", err)
+	}
+
+	props := decodeProps(t, result)
+	if props["status"] != float64(200) || props["ok"] != true {
+		t.Errorf("unexpected status in %v", props)
+	}
+	if props["type"] != "application/json" {
+		t.Errorf("expected content-type header, got %v", props["type"])
+	}
+	if data, _ := props["data"].(map[string]interface{}); data["name"] != "gopher" {
+		t.Errorf("expected decoded body, got %v", props["data"])
+	}
+}
+
+func TestFetchDedupesWithinRun(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("shared"))
+	}))
+	defer srv.Close()
+
+	w := newFetchWorker(t, time.Second, nil)
+	result, err := w.ExecuteProps(fetchBundle, "/twice", propsContext(srv.URL), time.Second)
+	if err != nil {
+		t.Fatalf("props: %v", err)
+	}
+
+	props := decodeProps(t, result)
+	if props["a"] != "shared" || props["b"] != "shared" {
+		t.Errorf("expected both callers to get the body, got %v", props)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("expected 1 upstream request, got %d", n)
+	}
+
+	// A new run fetches again.
+	if _, err := w.ExecuteProps(fetchBundle, "/twice", propsContext(srv.URL), time.Second); err != nil {
+		t.Fatalf("props: %v", err)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("expected dedupe to be per run, got %d requests", n)
+	}
+}
+
+func TestFetchRejectsDisallowedHost(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	w := newFetchWorker(t, time.Second, []string{"api.example.com"})
+	result, err := w.ExecuteProps(fetchBundle, "/error", propsContext(srv.URL), time.Second)
+	if err != nil {
+		t.Fatalf("props: %v", err)
+	}
+
+	caught, _ := decodeProps(t, result)["caught"].(string)
+	if !strings.Contains(caught, "not in fetchAllowedHosts") {
+		t.Errorf("expected allow-list rejection, got %q", caught)
+	}
+	if hits.Load() != 0 {
+		t.Error("disallowed host must not be contacted")
+	}
+}
+
+func TestFetchTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	w := newFetchWorker(t, 50*time.Millisecond, nil)
+	result, err := w.ExecuteProps(fetchBundle, "/error", propsContext(srv.URL), time.Second)
+	if err != nil {
+		t.Fatalf("props: %v", err)
+	}
+
+	caught, _ := decodeProps(t, result)["caught"].(string)
+	if !strings.Contains(caught, "timed out") {
+		t.Errorf("expected fetch timeout, got %q", caught)
+	}
+}
+
+func TestPropsTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	w := newFetchWorker(t, 5*time.Second, nil)
+	_, err := w.ExecuteProps(fetchBundle, "/one", propsContext(srv.URL), 50*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected props timeout, got %v", err)
+	}
+
+	// The worker is usable afterwards.
+	result, err := w.ExecuteProps(fetchBundle, "/sync", "{}", time.Second)
+	if err != nil || !strings.Contains(result, `"sync":true`) {
+		t.Fatalf("expected sync props, got %s, %v", result, err)
+	}
+}
+
+func TestHostAllowed(t *testing.T) {
+	allowed := []string{"api.example.com", "*.internal.io", "localhost:8080"}
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://api.example.com/x", true},
+		{"https://API.example.com/x", true},
+		{"https://example.com/x", false},
+		{"http://svc.internal.io/x", true},
+		{"http://a.b.internal.io/x", true},
+		{"http://internal.io/x", false},
+		{"http://localhost:8080/x", true},
+		{"http://localhost:9090/x", false},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		if got := hostAllowed(u, allowed); got != tt.want {
+			t.Errorf("hostAllowed(%s) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+
+	u, _ := url.Parse("https://anything.dev")
+	if !hostAllowed(u, nil) {
+		t.Error("empty allow-list should allow any host")
+	}
+}
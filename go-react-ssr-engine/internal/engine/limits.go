@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	v8 "rogchap.com/v8go"
+)
+
+// ErrRenderTimeout is returned when JavaScript ran longer than
+// Limits.RenderTimeout without yielding — typically an infinite loop in page
+// code. The isolate was terminated and the worker is replaced on release.
+var ErrRenderTimeout = errors.New("engine: script exceeded render timeout")
+
+// Limits bounds what a single worker may consume.
+type Limits struct {
+	// RenderTimeout caps each uninterrupted run of JavaScript: a whole
+	// renderToString, or one step (timer, fetch callback) of an async render.
+	// Time spent waiting on timers and fetches doesn't count. Zero disables.
+	RenderTimeout time.Duration
+
+	// MaxRenders recycles a worker after this many uses. Bounds slow leaks
+	// in page code that a heap threshold would only catch late. Zero disables.
+	MaxRenders int
+
+	// MaxHeapBytes recycles a worker once its used heap reaches this size.
+	// Checked between renders. Zero disables.
+	MaxHeapBytes uint64
+}
+
+// SetHeapLimit caps the V8 old-generation heap of every isolate at mb
+// megabytes. The flag is process-wide and must be set before the pool is
+// created. V8 aborts the process when an isolate runs out of heap, so this
+// is a last resort — keep Limits.MaxHeapBytes well below it so workers are
+// recycled first.
+func SetHeapLimit(mb int) {
+	if mb > 0 {
+		v8.SetFlags(fmt.Sprintf("--max-old-space-size=%d", mb))
+	}
+}
+
+// run executes src with the render timeout armed.
+func (w *Worker) run(src, origin string) (*v8.Value, error) {
+	var val *v8.Value
+	err := w.guard(func() error {
+		var err error
+		val, err = w.ctx.RunScript(src, origin)
+		return err
+	})
+	return val, err
+}
+
+// microtasks drains the microtask queue with the render timeout armed.
+// Promise continuations are page code too and can loop forever.
+func (w *Worker) microtasks() error {
+	return w.guard(func() error {
+		w.ctx.PerformMicrotaskCheckpoint()
+		return nil
+	})
+}
+
+// guard runs fn and terminates the isolate if it outlives the render timeout.
+// TerminateExecution is the only V8 call that is safe from another goroutine.
+func (w *Worker) guard(fn func() error) error {
+	if w.limits.RenderTimeout <= 0 {
+		return fn()
+	}
+
+	var fired atomic.Bool
+	done := make(chan struct{})
+	timer := time.AfterFunc(w.limits.RenderTimeout, func() {
+		fired.Store(true)
+		w.iso.TerminateExecution()
+		close(done)
+	})
+
+	err := fn()
+	if !timer.Stop() {
+		// The watchdog already fired; wait so it can't terminate a later script.
+		<-done
+	}
+
+	if fired.Load() {
+		// Globals may be half-updated. Never reuse this context.
+		w.broken = true
+		w.bundleLoaded = false
+		return fmt.Errorf("%w (%s)", ErrRenderTimeout, w.limits.RenderTimeout)
+	}
+	return err
+}
+
+// needsRecycle reports whether the worker should be replaced instead of
+// going back to the pool. Called on release, when nothing else holds it.
+func (w *Worker) needsRecycle() bool {
+	if w.broken {
+		return true
+	}
+	if w.limits.MaxRenders > 0 && w.uses >= w.limits.MaxRenders {
+		return true
+	}
+	if w.limits.MaxHeapBytes > 0 && w.iso.GetHeapStatistics().UsedHeapSize >= w.limits.MaxHeapBytes {
+		return true
+	}
+	return false
+}
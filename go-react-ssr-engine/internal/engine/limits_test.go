@@ -0,0 +1,133 @@
+package engine
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// loopBundle renders normally unless the route asks it to spin forever,
+// either synchronously or in a promise continuation.
+const loopBundle = `
+globalThis.__renderToString = function(route, props) {
+	if (route === '/spin') { for (;;) {} }
+	return '<p>' + route + '</p>';
+};
+globalThis.__handleAPI = function(route, req) {
+	globalThis.__reactgoAbortStream = function() { __reactgoDone('aborted'); };
+	Promise.resolve().then(function() { for (;;) {} });
+};
+`
+
+func newLimitedWorker(t *testing.T, l Limits) *Worker {
+	t.Helper()
+	w := newStreamWorker(t)
+	w.limits = l
+	return w
+}
+
+func TestRenderTimeoutTerminatesLoop(t *testing.T) {
+	w := newLimitedWorker(t, Limits{RenderTimeout: 50 * time.Millisecond})
+
+	start := time.Now()
+	_, err := w.Execute(loopBundle, "/spin", "{}")
+	if !errors.Is(err, ErrRenderTimeout) {
+		t.Fatalf("expected ErrRenderTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("termination took %s", elapsed)
+	}
+	if !w.needsRecycle() {
+		t.Error("terminated worker must be recycled")
+	}
+
+	// The isolate itself is still usable once the context is rebuilt.
+	result, err := w.Execute(loopBundle, "/ok", "{}")
+	if err != nil {
+		t.Fatalf("render after timeout: %v", err)
+	}
+	if !strings.Contains(result.HTML, "<p>/ok</p>") {
+		t.Errorf("unexpected html %q", result.HTML)
+	}
+}
+
+func TestRenderTimeoutInMicrotask(t *testing.T) {
+	w := newLimitedWorker(t, Limits{RenderTimeout: 50 * time.Millisecond})
+
+	_, err := w.ExecuteAPI(loopBundle, "/api/spin", `{"params":{}}`, 5*time.Second)
+	if !errors.Is(err, ErrRenderTimeout) {
+		t.Fatalf("expected ErrRenderTimeout, got %v", err)
+	}
+}
+
+func TestPoolRecyclesAfterMaxRenders(t *testing.T) {
+	pool, err := NewPool(1)
+	if err != nil {
+		t.Fatalf("pool init: %v", err)
+	}
+	defer pool.Shutdown()
+	pool.setLimits(Limits{MaxRenders: 2})
+
+	first := pool.Acquire()
+	pool.Release(first)
+	if w := pool.Acquire(); w != first {
+		t.Fatal("worker replaced before reaching MaxRenders")
+	} else {
+		pool.Release(w)
+	}
+
+	w := pool.Acquire()
+	defer pool.Release(w)
+	if w == first {
+		t.Fatal("worker not replaced after MaxRenders")
+	}
+	if w.limits.MaxRenders != 2 {
+		t.Error("replacement did not inherit limits")
+	}
+	if pool.Recycled() != 1 {
+		t.Errorf("expected 1 recycled worker, got %d", pool.Recycled())
+	}
+}
+
+func TestPoolRecyclesOverHeapLimit(t *testing.T) {
+	pool, err := NewPool(1)
+	if err != nil {
+		t.Fatalf("pool init: %v", err)
+	}
+	defer pool.Shutdown()
+	pool.setLimits(Limits{MaxHeapBytes: 1}) // any heap is over
+
+	first := pool.Acquire()
+	pool.Release(first)
+
+	w := pool.Acquire()
+	defer pool.Release(w)
+	if w == first {
+		t.Fatal("worker over heap limit was not replaced")
+	}
+}
+
+func TestPoolRecyclesTerminatedWorker(t *testing.T) {
+	pool, err := NewPool(1)
+	if err != nil {
+		t.Fatalf("pool init: %v", err)
+	}
+	defer pool.Shutdown()
+	pool.setLimits(Limits{RenderTimeout: 50 * time.Millisecond})
+
+	first := pool.Acquire()
+	if _, err := first.Execute(loopBundle, "/spin", "{}"); !errors.Is(err, ErrRenderTimeout) {
+		t.Fatalf("expected ErrRenderTimeout, got %v", err)
+	}
+	pool.Release(first)
+
+	w := pool.Acquire()
+	defer pool.Release(w)
+	if w == first {
+		t.Fatal("terminated worker went back into the pool")
+	}
+	if _, err := w.Execute(loopBundle, "/ok", "{}"); err != nil {
+		t.Fatalf("replacement render: %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+)
+
+// runAsync evaluates call — which must eventually invoke __reactgoDone —
+// and drives the event loop until it does. Output written through
+// __reactgoWrite goes to out. Caller must hold w.mu with the bundle loaded.
+func (w *Worker) runAsync(call string, timeout time.Duration, out StreamWriter) error {
+	s := &streamState{out: out, fetches: newFetchState(w.fetcher)}
+	w.stream = s
+	defer func() {
+		// Cancels in-flight requests; their results are dropped.
+		s.fetches.close()
+		w.stream = nil
+	}()
+
+	if _, err := w.run("__reactgoResetTimers(); __reactgoResetFetches(); "+call, "async.js"); err != nil {
+		return err
+	}
+
+	if err := w.runLoop(s, time.Now().Add(timeout)); err != nil {
+		return err
+	}
+	if s.doneErr != nil {
+		return s.doneErr
+	}
+	return s.writeErr
+}
+
+// runLoop is a minimal event loop: it runs microtasks, due timers and
+// completed fetches until the render reports done. If the deadline passes,
+// the client disconnects, or nothing is scheduled that could unblock the
+// render, it is aborted.
+func (w *Worker) runLoop(s *streamState, deadline time.Time) error {
+	for {
+		if err := w.microtasks(); err != nil {
+			return err
+		}
+		if s.done {
+			return nil
+		}
+
+		if s.writeErr != nil || !time.Now().Before(deadline) {
+			return w.abortStream(s)
+		}
+
+		// Responses that are already known (errors, deduplicated requests)
+		// are handed over without waiting.
+		if err := w.deliverFetches(s); err != nil {
+			return err
+		}
+		if s.done {
+			return nil
+		}
+
+		next, err := w.run(`__reactgoRunTimers()`, "timers.js")
+		if err != nil {
+			return fmt.Errorf("timers: %w", err)
+		}
+		if err := w.microtasks(); err != nil {
+			return err
+		}
+		if s.done {
+			return nil
+		}
+
+		// A timer callback may have queued a response of its own.
+		if s.fetches.hasReady() {
+			continue
+		}
+
+		// No timers and no requests in flight means no pending promise
+		// can ever settle.
+		wait := next.Integer()
+		if wait < 0 && !s.fetches.waiting() {
+			return w.abortStream(s)
+		}
+
+		delay := time.Until(deadline)
+		if wait >= 0 && time.Duration(wait)*time.Millisecond < delay {
+			delay = time.Duration(wait) * time.Millisecond
+		}
+
+		// Sleep until the next timer is due, but wake early for a response.
+		timer := time.NewTimer(delay)
+		select {
+		case res := <-s.fetches.results:
+			s.fetches.complete(res)
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// abortStream asks the running script to give up and finish.
+func (w *Worker) abortStream(s *streamState) error {
+	if _, err := w.run(`__reactgoAbortStream()`, "abort.js"); err != nil {
+		return fmt.Errorf("abort: %w", err)
+	}
+	if err := w.microtasks(); err != nil {
+		return err
+	}
+
+	if !s.done {
+		return fmt.Errorf("render did not finish after abort")
+	}
+	if s.doneErr != nil || s.writeErr != nil {
+		return nil
+	}
+	return ErrRenderAborted
+}
@@ -0,0 +1,381 @@
+package engine
+
+// polyfills is evaluated in every fresh V8 context before the server bundle.
+// V8 ships only the ECMAScript built-ins, so everything React and typical
+// page code expect from a browser or Node.js runtime is stubbed here.
+//
+// Timers and fetch are real: setTimeout queues callbacks that the worker
+// event loop (see loop.go) runs once they are due, and fetch resolves when
+// its Go request completes (see fetch.go). Everything else is synchronous.
+const polyfills = `
+// --- Console ---
+var console = {
+	log: function(){}, warn: function(){}, error: function(){},
+	info: function(){}, debug: function(){}, trace: function(){},
+	dir: function(){}, table: function(){}, time: function(){},
+	timeEnd: function(){}, timeLog: function(){}, assert: function(){},
+	count: function(){}, countReset: function(){}, group: function(){},
+	groupEnd: function(){}, groupCollapsed: function(){}, clear: function(){}
+};
+
+// --- Process ---
+var process = {
+	env: { NODE_ENV: 'production' },
+	nextTick: function(cb) { cb(); },
+	version: 'v18.0.0',
+	versions: { node: '18.0.0' },
+	platform: 'linux',
+	argv: [], pid: 1,
+	cwd: function() { return '/'; },
+	exit: function() {},
+	on: function() { return this; },
+	once: function() { return this; },
+	off: function() { return this; },
+	removeListener: function() { return this; },
+	emit: function() { return this; },
+	stderr: { write: function(){} },
+	stdout: { write: function(){} },
+	hrtime: function() { return [0,0]; },
+	binding: function() { return {}; }
+};
+
+// --- Timers ---
+// setTimeout callbacks are queued and run by the Go event loop via
+// __reactgoRunTimers, so promises that wait on a timer (e.g. a Suspense
+// data source) resolve after a real delay instead of re-entering the caller.
+var queueMicrotask = function(cb) { Promise.resolve().then(cb); };
+var __reactgoTimers = { seq: 0, queue: {} };
+var setTimeout = function(cb, ms) {
+	var id = ++__reactgoTimers.seq;
+	var args = Array.prototype.slice.call(arguments, 2);
+	__reactgoTimers.queue[id] = { cb: cb, args: args, at: Date.now() + (ms > 0 ? ms : 0) };
+	return id;
+};
+var clearTimeout = function(id) { delete __reactgoTimers.queue[id]; };
+var setInterval = function() { return 0; };
+var clearInterval = function() {};
+var setImmediate = function(cb) { cb(); return 0; };
+var clearImmediate = function() {};
+
+// __reactgoRunTimers runs every due timer in scheduling order and returns
+// the milliseconds until the next one, or -1 when none are pending.
+globalThis.__reactgoRunTimers = function() {
+	var q = __reactgoTimers.queue;
+	var now = Date.now();
+	var due = Object.keys(q).map(Number).filter(function(id) { return q[id].at <= now; });
+	due.sort(function(a, b) { return q[a].at - q[b].at || a - b; });
+	for (var i = 0; i < due.length; i++) {
+		var t = q[due[i]];
+		if (!t) continue;
+		delete q[due[i]];
+		t.cb.apply(null, t.args);
+	}
+	var next = -1;
+	for (var id in q) {
+		var wait = Math.max(0, q[id].at - Date.now());
+		if (next < 0 || wait < next) next = wait;
+	}
+	return next;
+};
+
+// __reactgoResetTimers drops timers left behind by a previous render.
+globalThis.__reactgoResetTimers = function() { __reactgoTimers.queue = {}; };
+
+// --- Encoding ---
+// Real UTF-8 — React's streaming renderer encodes chunks with encodeInto
+// and the Go side decodes them back, so non-ASCII text must round-trip.
+var TextEncoder = function() {};
+TextEncoder.prototype.encoding = 'utf-8';
+TextEncoder.prototype.encodeInto = function(s, dest) {
+	var read = 0, written = 0;
+	for (var i = 0; i < s.length; i++) {
+		var c = s.charCodeAt(i), units = 1;
+		if (c >= 0xd800 && c <= 0xdbff && i + 1 < s.length) {
+			var lo = s.charCodeAt(i + 1);
+			if (lo >= 0xdc00 && lo <= 0xdfff) { c = 0x10000 + ((c - 0xd800) << 10) + (lo - 0xdc00); units = 2; }
+		}
+		if (c >= 0xd800 && c <= 0xdfff) c = 0xfffd; // lone surrogate
+		var n = c < 0x80 ? 1 : c < 0x800 ? 2 : c < 0x10000 ? 3 : 4;
+		if (written + n > dest.length) break;
+		if (n === 1) dest[written++] = c;
+		else if (n === 2) { dest[written++] = 0xc0 | (c >> 6); dest[written++] = 0x80 | (c & 63); }
+		else if (n === 3) { dest[written++] = 0xe0 | (c >> 12); dest[written++] = 0x80 | ((c >> 6) & 63); dest[written++] = 0x80 | (c & 63); }
+		else { dest[written++] = 0xf0 | (c >> 18); dest[written++] = 0x80 | ((c >> 12) & 63); dest[written++] = 0x80 | ((c >> 6) & 63); dest[written++] = 0x80 | (c & 63); }
+		read += units;
+		i += units - 1;
+	}
+	return { read: read, written: written };
+};
+TextEncoder.prototype.encode = function(s) {
+	s = s === undefined ? '' : String(s);
+	var buf = new Uint8Array(s.length * 3);
+	return buf.slice(0, this.encodeInto(s, buf).written);
+};
+
+var TextDecoder = function(enc) { this.encoding = enc || 'utf-8'; this._pending = []; };
+TextDecoder.prototype.decode = function(buf, opts) {
+	if (typeof buf === 'string') return buf;
+	var bytes = this._pending;
+	this._pending = [];
+	if (buf) {
+		var view = buf instanceof Uint8Array ? buf : new Uint8Array(buf.buffer || buf);
+		for (var k = 0; k < view.length; k++) bytes.push(view[k]);
+	}
+	var out = '', i = 0;
+	while (i < bytes.length) {
+		var b = bytes[i], n = b < 0x80 ? 1 : b >= 0xf0 ? 4 : b >= 0xe0 ? 3 : b >= 0xc0 ? 2 : 0;
+		if (n === 0) { out += '\ufffd'; i++; continue; }
+		if (i + n > bytes.length) {
+			if (opts && opts.stream) { this._pending = bytes.slice(i); break; }
+			out += '\ufffd'; break;
+		}
+		var c = n === 1 ? b : b & (0xff >> (n + 1));
+		for (var j = 1; j < n; j++) c = (c << 6) | (bytes[i + j] & 63);
+		out += String.fromCodePoint(c);
+		i += n;
+	}
+	return out;
+};
+
+// --- URL ---
+if (typeof URL === 'undefined') {
+	var URL = function(url, base) {
+		this.href = url;
+		this.pathname = url.split('?')[0];
+		this.search = url.indexOf('?') >= 0 ? url.slice(url.indexOf('?')) : '';
+		this.hash = '';
+		this.hostname = '';
+		this.host = '';
+		this.origin = '';
+		this.protocol = 'https:';
+		this.port = '';
+		this.searchParams = {
+			get: function(k) { return null; },
+			has: function(k) { return false; },
+			forEach: function() {},
+			entries: function() { return []; }
+		};
+	};
+}
+if (typeof URLSearchParams === 'undefined') {
+	var URLSearchParams = function(init) {
+		this._params = {};
+		if (typeof init === 'string') {
+			init.replace(/^\?/, '').split('&').forEach(function(pair) {
+				var kv = pair.split('=');
+				if (kv[0]) this._params[decodeURIComponent(kv[0])] = decodeURIComponent(kv[1] || '');
+			}.bind(this));
+		}
+	};
+	URLSearchParams.prototype.get = function(k) { return this._params[k] || null; };
+	URLSearchParams.prototype.has = function(k) { return k in this._params; };
+	URLSearchParams.prototype.forEach = function(cb) {
+		for (var k in this._params) cb(this._params[k], k);
+	};
+}
+
+// --- Performance ---
+var performance = {
+	now: function() { return Date.now(); },
+	mark: function() {},
+	measure: function() {},
+	getEntriesByName: function() { return []; },
+	getEntriesByType: function() { return []; },
+	clearMarks: function() {},
+	clearMeasures: function() {}
+};
+
+// --- Buffer ---
+if (typeof Buffer === 'undefined') {
+	var Buffer = {
+		from: function(data) {
+			if (typeof data === 'string') {
+				var arr = [];
+				for (var i = 0; i < data.length; i++) arr.push(data.charCodeAt(i));
+				return new Uint8Array(arr);
+			}
+			return new Uint8Array(data || 0);
+		},
+		alloc: function(n) { return new Uint8Array(n); },
+		allocUnsafe: function(n) { return new Uint8Array(n); },
+		isBuffer: function() { return false; },
+		concat: function(list) {
+			var total = 0;
+			for (var i = 0; i < list.length; i++) total += list[i].length;
+			var result = new Uint8Array(total);
+			var offset = 0;
+			for (var i = 0; i < list.length; i++) { result.set(list[i], offset); offset += list[i].length; }
+			return result;
+		},
+		byteLength: function(s) { return typeof s === 'string' ? s.length : (s.byteLength || 0); }
+	};
+}
+
+// --- Misc globals ---
+if (typeof global === 'undefined') var global = globalThis;
+if (typeof self === 'undefined') var self = globalThis;
+if (typeof window === 'undefined') var window = globalThis;
+
+// --- MessageChannel (React scheduler uses this) ---
+var MessageChannel = function() {
+	var self = this;
+	this.port1 = {
+		onmessage: null,
+		postMessage: function() {
+			if (self.port2.onmessage) self.port2.onmessage({ data: null });
+		}
+	};
+	this.port2 = {
+		onmessage: null,
+		postMessage: function() {
+			if (self.port1.onmessage) self.port1.onmessage({ data: null });
+		}
+	};
+};
+var MessagePort = function() {};
+var MessageEvent = function() {};
+
+// --- AbortController ---
+if (typeof AbortController === 'undefined') {
+	var AbortSignal = function() { this.aborted = false; this.reason = undefined; };
+	AbortSignal.prototype.addEventListener = function() {};
+	AbortSignal.prototype.removeEventListener = function() {};
+	var AbortController = function() { this.signal = new AbortSignal(); };
+	AbortController.prototype.abort = function(reason) {
+		this.signal.aborted = true;
+		this.signal.reason = reason;
+	};
+}
+
+// --- Headers/Request/Response/fetch ---
+// fetch() is backed by Go's HTTP client through __reactgoFetch. The request
+// runs on a Go goroutine; the event loop resolves the promise through
+// __reactgoResolveFetch once the response is in. Bodies are text.
+var Headers = function(init) {
+	this._h = {};
+	if (!init) return;
+	if (init instanceof Headers) init = init._h;
+	if (Array.isArray(init)) {
+		for (var i = 0; i < init.length; i++) this.set(init[i][0], init[i][1]);
+		return;
+	}
+	for (var k in init) this.set(k, init[k]);
+};
+Headers.prototype.get = function(k) {
+	var v = this._h[String(k).toLowerCase()];
+	return v === undefined ? null : v;
+};
+Headers.prototype.set = function(k, v) { this._h[String(k).toLowerCase()] = String(v); };
+Headers.prototype.append = function(k, v) {
+	var key = String(k).toLowerCase();
+	this._h[key] = key in this._h ? this._h[key] + ', ' + v : String(v);
+};
+Headers.prototype.has = function(k) { return String(k).toLowerCase() in this._h; };
+Headers.prototype['delete'] = function(k) { delete this._h[String(k).toLowerCase()]; };
+Headers.prototype.forEach = function(cb, thisArg) {
+	for (var k in this._h) cb.call(thisArg, this._h[k], k, this);
+};
+Headers.prototype.entries = function() {
+	var h = this._h;
+	return Object.keys(h).map(function(k) { return [k, h[k]]; })[Symbol.iterator]();
+};
+Headers.prototype[Symbol.iterator] = Headers.prototype.entries;
+
+var Request = function(input, init) {
+	init = init || {};
+	if (input instanceof Request) {
+		this.url = input.url;
+		this.method = init.method || input.method;
+		this.headers = new Headers(init.headers || input.headers);
+		this.body = init.body !== undefined ? init.body : input.body;
+	} else {
+		this.url = String(input);
+		this.method = init.method || 'GET';
+		this.headers = new Headers(init.headers);
+		this.body = init.body;
+	}
+	this.method = this.method.toUpperCase();
+};
+
+var Response = function(body, init) {
+	init = init || {};
+	this._body = body == null ? '' : String(body);
+	this.status = init.status === undefined ? 200 : init.status;
+	this.statusText = init.statusText || '';
+	this.headers = new Headers(init.headers);
+	this.ok = this.status >= 200 && this.status < 300;
+	this.url = init.url || '';
+	this.redirected = false;
+	this.bodyUsed = false;
+};
+Response.prototype._consume = function() {
+	if (this.bodyUsed) return Promise.reject(new TypeError('body already used'));
+	this.bodyUsed = true;
+	return Promise.resolve(this._body);
+};
+Response.prototype.text = function() { return this._consume(); };
+Response.prototype.json = function() { return this._consume().then(JSON.parse); };
+Response.prototype.arrayBuffer = function() {
+	return this._consume().then(function(s) { return new TextEncoder().encode(s).buffer; });
+};
+Response.prototype.clone = function() {
+	return new Response(this._body, { status: this.status, statusText: this.statusText, headers: this.headers, url: this.url });
+};
+
+var __reactgoFetches = { seq: 0, pending: {} };
+var fetch = function(input, init) {
+	var req = new Request(input, init);
+	var body = req.body;
+	if (body != null && typeof body !== 'string') body = String(body);
+	var id = ++__reactgoFetches.seq;
+	return new Promise(function(resolve, reject) {
+		__reactgoFetches.pending[id] = { resolve: resolve, reject: reject };
+		var err = __reactgoFetch(id, JSON.stringify({ url: req.url, method: req.method, headers: req.headers._h, body: body || '' }));
+		if (err) {
+			delete __reactgoFetches.pending[id];
+			reject(new TypeError('fetch failed: ' + err));
+		}
+	});
+};
+
+// __reactgoResolveFetch settles fetch call id with the Go response.
+var __reactgoResolveFetch = function(id, r) {
+	var p = __reactgoFetches.pending[id];
+	if (!p) return;
+	delete __reactgoFetches.pending[id];
+	if (r.error) {
+		p.reject(new TypeError('fetch failed: ' + r.error));
+		return;
+	}
+	p.resolve(new Response(r.body, { status: r.status, statusText: r.statusText, headers: r.headers, url: r.url }));
+};
+
+// Pending fetches belong to the previous run; their promises never settle.
+var __reactgoResetFetches = function() { __reactgoFetches.pending = {}; };
+
+// --- ReadableStream stub ---
+if (typeof ReadableStream === 'undefined') {
+	var ReadableStream = function() {};
+	ReadableStream.prototype.getReader = function() {
+		return { read: function() { return Promise.resolve({ done: true, value: undefined }); }, releaseLock: function() {} };
+	};
+}
+
+// --- WeakRef (React may use) ---
+if (typeof WeakRef === 'undefined') {
+	var WeakRef = function(target) { this._target = target; };
+	WeakRef.prototype.deref = function() { return this._target; };
+}
+
+// --- FinalizationRegistry ---
+if (typeof FinalizationRegistry === 'undefined') {
+	var FinalizationRegistry = function() {};
+	FinalizationRegistry.prototype.register = function() {};
+	FinalizationRegistry.prototype.unregister = function() {};
+}
+
+// --- structuredClone ---
+if (typeof structuredClone === 'undefined') {
+	var structuredClone = function(obj) { return JSON.parse(JSON.stringify(obj)); };
+}
+`
@@ -3,6 +3,8 @@ package engine
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Pool manages a fixed set of V8 workers via a buffered channel.
@@ -15,14 +17,55 @@ import (
 // - Fixed pool = predictable memory: workerCount * 10MB.
 //
 // - Backpressure is free: when pool is empty, callers block on channel receive.
+//
+// Workers that hit their Limits are disposed on Release and replaced by a
+// fresh isolate, so a leaking or runaway page can't degrade the pool for good.
 type Pool struct {
 	workers chan *Worker
 	size    int
 
 	// all keeps references for shutdown cleanup.
-	// Only touched at init and shutdown - no contention
+	// Touched at init, shutdown and when a worker is recycled.
+	mu   sync.Mutex
 	all  []*Worker
 	once sync.Once
+
+	// fetcher and limits are handed to every worker, including replacements.
+	fetcher *Fetcher
+	limits  Limits
+
+	recycled atomic.Uint64
+
+	// busy counts checked-out workers, waiting the callers blocked in Acquire.
+	busy    atomic.Int64
+	waiting atomic.Int64
+
+	// observeWait, if set, receives how long each Acquire blocked.
+	observeWait func(time.Duration)
+}
+
+// PoolStats is a snapshot of the pool, for metrics.
+type PoolStats struct {
+	Size     int
+	Busy     int
+	Waiting  int
+	Recycled uint64
+}
+
+// Stats returns the current pool utilization.
+func (p *Pool) Stats() PoolStats {
+	return PoolStats{
+		Size:     p.size,
+		Busy:     int(p.busy.Load()),
+		Waiting:  int(p.waiting.Load()),
+		Recycled: p.recycled.Load(),
+	}
+}
+
+// ObserveWait registers fn to receive the queue wait of every Acquire.
+// Must be called before the pool is used.
+func (p *Pool) ObserveWait(fn func(time.Duration)) {
+	p.observeWait = fn
 }
 
 // NewPool creates `size` V8 workers and puts them in the channel.
@@ -51,19 +94,83 @@ func NewPool(size int) (*Pool, error) {
 	return p, nil
 }
 
+// setFetcher gives every worker the client behind fetch().
+// Called once at engine init, before any worker is acquired.
+func (p *Pool) setFetcher(f *Fetcher) {
+	p.fetcher = f
+	for _, w := range p.all {
+		w.fetcher = f
+	}
+}
+
+// setLimits applies resource limits to every worker.
+// Called once at engine init, before any worker is acquired.
+func (p *Pool) setLimits(l Limits) {
+	p.limits = l
+	for _, w := range p.all {
+		w.limits = l
+	}
+}
+
+// Recycled returns how many workers have been replaced so far.
+func (p *Pool) Recycled() uint64 {
+	return p.recycled.Load()
+}
+
 // Acquire blocks until a worker is available.
 // Under normal load this returns instantly (buffered channel).
 // Under heavy load this is the backpressure point — goroutines park here.
 func (p *Pool) Acquire() *Worker {
-	return <-p.workers
+	start := time.Now()
+	p.waiting.Add(1)
+	w := <-p.workers
+	p.waiting.Add(-1)
+	p.busy.Add(1)
+
+	if p.observeWait != nil {
+		p.observeWait(time.Since(start))
+	}
+	return w
 }
 
 // Release returns a worker to the pool for reuse.
 // Always call this in a defer after Acquire.
+// A worker over its limits is swapped for a fresh one first.
 func (p *Pool) Release(w *Worker) {
+	p.busy.Add(-1)
+	w.uses++
+	if w.needsRecycle() {
+		w = p.replace(w)
+	}
 	p.workers <- w
 }
 
+// replace disposes w and returns a fresh worker with the same id.
+// If a new isolate can't be created, w is kept with a clean context.
+func (p *Pool) replace(w *Worker) *Worker {
+	fresh, err := NewWorker(w.id)
+	if err != nil {
+		w.broken = false
+		w.bundleLoaded = false
+		w.uses = 0
+		return w
+	}
+	fresh.fetcher = p.fetcher
+	fresh.limits = p.limits
+
+	p.mu.Lock()
+	for i, old := range p.all {
+		if old == w {
+			p.all[i] = fresh
+		}
+	}
+	p.mu.Unlock()
+
+	w.Dispose()
+	p.recycled.Add(1)
+	return fresh
+}
+
 // Shutdown disposes all V8 isolates. Called once via sync.Once.
 // Drains the channel first so no goroutine is stuck holding a dead worker.
 func (p *Pool) Shutdown() {
@@ -76,9 +183,11 @@ func (p *Pool) Shutdown() {
 			// Just drain
 		}
 
-		// Dispose every isolate we ever created
+		// Dispose every live isolate; recycled ones are already gone
+		p.mu.Lock()
 		for _, w := range p.all {
 			w.Dispose()
 		}
+		p.mu.Unlock()
 	})
 }
@@ -4,6 +4,7 @@ import (
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestPoolAcquireRelease(t *testing.T) {
@@ -28,6 +29,41 @@ func TestPoolAcquireRelease(t *testing.T) {
 	}
 }
 
+func TestPoolStats(t *testing.T) {
+	pool, err := NewPool(2)
+	if err != nil {
+		t.Fatalf("pool init: %v", err)
+	}
+	defer pool.Shutdown()
+
+	var waits atomic.Int64
+	pool.ObserveWait(func(time.Duration) { waits.Add(1) })
+
+	a := pool.Acquire()
+	b := pool.Acquire()
+	if s := pool.Stats(); s.Size != 2 || s.Busy != 2 || s.Waiting != 0 {
+		t.Errorf("Stats() = %+v, want size 2, busy 2, waiting 0", s)
+	}
+
+	// A third caller blocks until a worker comes back.
+	done := make(chan *Worker)
+	go func() { done <- pool.Acquire() }()
+	for pool.Stats().Waiting != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	pool.Release(a)
+	c := <-done
+
+	pool.Release(b)
+	pool.Release(c)
+	if s := pool.Stats(); s.Busy != 0 || s.Waiting != 0 {
+		t.Errorf("Stats() after release = %+v, want busy 0, waiting 0", s)
+	}
+	if n := waits.Load(); n != 3 {
+		t.Errorf("observed %d waits, want 3", n)
+	}
+}
+
 func TestPoolConcurrency(t *testing.T) {
 	// Verifies the pool handles high concurrency without deadlocks.
 	// 4 workers, 100 goroutines — each goroutine acquires, does work, releases.
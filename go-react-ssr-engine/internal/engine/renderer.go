@@ -1,9 +1,12 @@
 package engine
 
 import (
+	"encoding/json"
 	"fmt"
 	"sync"
+	"time"
 
+	"github.com/thutasann/go-react-ssr-engine/pkg/html"
 	v8 "rogchap.com/v8go"
 )
 
@@ -22,6 +25,23 @@ type Worker struct {
 	bundleLoaded bool
 	bundleHash   string
 
+	// stream is the sink of the streaming render in progress, if any.
+	// Read by the __reactgoWrite/__reactgoDone callbacks installed on the context.
+	stream *streamState
+
+	// fetcher performs fetch() requests. Nil disables fetch.
+	fetcher *Fetcher
+
+	// limits bounds CPU time per script and when the worker is recycled.
+	limits Limits
+
+	// uses counts pool checkouts, for Limits.MaxRenders.
+	uses int
+
+	// broken is set when a script was terminated. The worker must not be
+	// reused; the pool replaces it on release.
+	broken bool
+
 	mu sync.Mutex // protects isolate — V8 is not thread safe per isolate
 }
 
@@ -48,255 +68,30 @@ func NewWorker(id int) (*Worker, error) {
 	}, nil
 }
 
+// RenderResult is the output of a renderToString pass.
+type RenderResult struct {
+	HTML string `json:"html"`
+
+	// Head holds what each <Head> component rendered, in render order.
+	Head []html.HeadData `json:"head"`
+}
+
+// ApplyHead merges the <Head> tags into h. Applied after the page's props
+// head, so components override it.
+func (r *RenderResult) ApplyHead(h *html.Head) {
+	for i := range r.Head {
+		h.Apply(&r.Head[i])
+	}
+}
+
 // Execute runs the bundle and calls __renderToString.
 // If the bundle hasn't changed since last call, skips re-parsing.
-func (w *Worker) Execute(bundle, route, propsJSON string) (string, error) {
+func (w *Worker) Execute(bundle, route, propsJSON string) (*RenderResult, error) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	// Only load bundle if it changed — massive speedup on repeated renders.
-	// First render: ~5ms (parse + compile). Subsequent: ~0.1ms (cached bytecode).
-	bundleHash := hashBundle(bundle)
-	if !w.bundleLoaded || w.bundleHash != bundleHash {
-		// Fresh context to avoid stale state from previous bundle
-		if w.ctx != nil {
-			w.ctx.Close()
-		}
-		global := v8.NewObjectTemplate(w.iso)
-		w.ctx = v8.NewContext(w.iso, global)
-
-		// Re-inject polyfills
-		w.ctx.RunScript(`
-			// --- Console ---
-			var console = {
-				log: function(){}, warn: function(){}, error: function(){},
-				info: function(){}, debug: function(){}, trace: function(){},
-				dir: function(){}, table: function(){}, time: function(){},
-				timeEnd: function(){}, timeLog: function(){}, assert: function(){},
-				count: function(){}, countReset: function(){}, group: function(){},
-				groupEnd: function(){}, groupCollapsed: function(){}, clear: function(){}
-			};
-
-			// --- Process ---
-			var process = {
-				env: { NODE_ENV: 'production' },
-				nextTick: function(cb) { cb(); },
-				version: 'v18.0.0',
-				versions: { node: '18.0.0' },
-				platform: 'linux',
-				argv: [], pid: 1,
-				cwd: function() { return '/'; },
-				exit: function() {},
-				on: function() { return this; },
-				once: function() { return this; },
-				off: function() { return this; },
-				removeListener: function() { return this; },
-				emit: function() { return this; },
-				stderr: { write: function(){} },
-				stdout: { write: function(){} },
-				hrtime: function() { return [0,0]; },
-				binding: function() { return {}; }
-			};
-
-			// --- Timers ---
-			var queueMicrotask = function(cb) { cb(); };
-			if (typeof setTimeout === 'undefined') {
-				var setTimeout = function(cb, ms) { cb(); return 0; };
-			}
-			var clearTimeout = clearTimeout || function() {};
-			var setInterval = setInterval || function() { return 0; };
-			var clearInterval = clearInterval || function() {};
-			var setImmediate = function(cb) { cb(); return 0; };
-			var clearImmediate = function() {};
-
-			// --- Encoding ---
-			var TextEncoder = function() {};
-			TextEncoder.prototype.encode = function(s) {
-				var arr = [];
-				for (var i = 0; i < s.length; i++) {
-					var c = s.charCodeAt(i);
-					if (c < 128) arr.push(c);
-					else if (c < 2048) { arr.push(192 | (c >> 6)); arr.push(128 | (c & 63)); }
-					else { arr.push(224 | (c >> 12)); arr.push(128 | ((c >> 6) & 63)); arr.push(128 | (c & 63)); }
-				}
-				return new Uint8Array(arr);
-			};
-			TextEncoder.prototype.encoding = 'utf-8';
-
-			var TextDecoder = function(enc) { this.encoding = enc || 'utf-8'; };
-			TextDecoder.prototype.decode = function(buf) {
-				if (typeof buf === 'string') return buf;
-				if (!buf || !buf.length) return '';
-				var s = '';
-				for (var i = 0; i < buf.length; i++) s += String.fromCharCode(buf[i]);
-				return s;
-			};
-
-			// --- URL ---
-			if (typeof URL === 'undefined') {
-				var URL = function(url, base) {
-					this.href = url;
-					this.pathname = url.split('?')[0];
-					this.search = url.indexOf('?') >= 0 ? url.slice(url.indexOf('?')) : '';
-					this.hash = '';
-					this.hostname = '';
-					this.host = '';
-					this.origin = '';
-					this.protocol = 'https:';
-					this.port = '';
-					this.searchParams = {
-						get: function(k) { return null; },
-						has: function(k) { return false; },
-						forEach: function() {},
-						entries: function() { return []; }
-					};
-				};
-			}
-			if (typeof URLSearchParams === 'undefined') {
-				var URLSearchParams = function(init) {
-					this._params = {};
-					if (typeof init === 'string') {
-						init.replace(/^\?/, '').split('&').forEach(function(pair) {
-							var kv = pair.split('=');
-							if (kv[0]) this._params[decodeURIComponent(kv[0])] = decodeURIComponent(kv[1] || '');
-						}.bind(this));
-					}
-				};
-				URLSearchParams.prototype.get = function(k) { return this._params[k] || null; };
-				URLSearchParams.prototype.has = function(k) { return k in this._params; };
-				URLSearchParams.prototype.forEach = function(cb) {
-					for (var k in this._params) cb(this._params[k], k);
-				};
-			}
-
-			// --- Performance ---
-			var performance = {
-				now: function() { return Date.now(); },
-				mark: function() {},
-				measure: function() {},
-				getEntriesByName: function() { return []; },
-				getEntriesByType: function() { return []; },
-				clearMarks: function() {},
-				clearMeasures: function() {}
-			};
-
-			// --- Buffer ---
-			if (typeof Buffer === 'undefined') {
-				var Buffer = {
-					from: function(data) {
-						if (typeof data === 'string') {
-							var arr = [];
-							for (var i = 0; i < data.length; i++) arr.push(data.charCodeAt(i));
-							return new Uint8Array(arr);
-						}
-						return new Uint8Array(data || 0);
-					},
-					alloc: function(n) { return new Uint8Array(n); },
-					allocUnsafe: function(n) { return new Uint8Array(n); },
-					isBuffer: function() { return false; },
-					concat: function(list) {
-						var total = 0;
-						for (var i = 0; i < list.length; i++) total += list[i].length;
-						var result = new Uint8Array(total);
-						var offset = 0;
-						for (var i = 0; i < list.length; i++) { result.set(list[i], offset); offset += list[i].length; }
-						return result;
-					},
-					byteLength: function(s) { return typeof s === 'string' ? s.length : (s.byteLength || 0); }
-				};
-			}
-
-			// --- Misc globals ---
-			if (typeof global === 'undefined') var global = globalThis;
-			if (typeof self === 'undefined') var self = globalThis;
-			if (typeof window === 'undefined') var window = globalThis;
-
-			// --- MessageChannel (React scheduler uses this) ---
-			var MessageChannel = function() {
-				var self = this;
-				this.port1 = {
-					onmessage: null,
-					postMessage: function() {
-						if (self.port2.onmessage) self.port2.onmessage({ data: null });
-					}
-				};
-				this.port2 = {
-					onmessage: null,
-					postMessage: function() {
-						if (self.port1.onmessage) self.port1.onmessage({ data: null });
-					}
-				};
-			};
-			var MessagePort = function() {};
-			var MessageEvent = function() {};
-
-			// --- AbortController ---
-			if (typeof AbortController === 'undefined') {
-				var AbortSignal = function() { this.aborted = false; this.reason = undefined; };
-				AbortSignal.prototype.addEventListener = function() {};
-				AbortSignal.prototype.removeEventListener = function() {};
-				var AbortController = function() { this.signal = new AbortSignal(); };
-				AbortController.prototype.abort = function(reason) {
-					this.signal.aborted = true;
-					this.signal.reason = reason;
-				};
-			}
-
-			// --- Headers/Request/Response (fetch API stubs) ---
-			if (typeof Headers === 'undefined') {
-				var Headers = function() { this._h = {}; };
-				Headers.prototype.get = function(k) { return this._h[k.toLowerCase()] || null; };
-				Headers.prototype.set = function(k, v) { this._h[k.toLowerCase()] = v; };
-				Headers.prototype.has = function(k) { return k.toLowerCase() in this._h; };
-			}
-
-			if (typeof Request === 'undefined') {
-				var Request = function(url, opts) { this.url = url; this.method = (opts && opts.method) || 'GET'; };
-			}
-
-			if (typeof Response === 'undefined') {
-				var Response = function(body, opts) { this.body = body; this.status = (opts && opts.status) || 200; };
-				Response.prototype.text = function() { return Promise.resolve(this.body || ''); };
-				Response.prototype.json = function() { return Promise.resolve(JSON.parse(this.body || '{}')); };
-			}
-
-			if (typeof fetch === 'undefined') {
-				var fetch = function() { return Promise.resolve(new Response('{}')); };
-			}
-
-			// --- ReadableStream stub ---
-			if (typeof ReadableStream === 'undefined') {
-				var ReadableStream = function() {};
-				ReadableStream.prototype.getReader = function() {
-					return { read: function() { return Promise.resolve({ done: true, value: undefined }); }, releaseLock: function() {} };
-				};
-			}
-
-			// --- WeakRef (React may use) ---
-			if (typeof WeakRef === 'undefined') {
-				var WeakRef = function(target) { this._target = target; };
-				WeakRef.prototype.deref = function() { return this._target; };
-			}
-
-			// --- FinalizationRegistry ---
-			if (typeof FinalizationRegistry === 'undefined') {
-				var FinalizationRegistry = function() {};
-				FinalizationRegistry.prototype.register = function() {};
-				FinalizationRegistry.prototype.unregister = function() {};
-			}
-
-			// --- structuredClone ---
-			if (typeof structuredClone === 'undefined') {
-				var structuredClone = function(obj) { return JSON.parse(JSON.stringify(obj)); };
-			}
-		`, "bootstrap.js")
-
-		_, err := w.ctx.RunScript(bundle, "server_bundle.js")
-		if err != nil {
-			return "", fmt.Errorf("worker %d: bundle exec: %w", w.id, err)
-		}
-		w.bundleLoaded = true
-		w.bundleHash = bundleHash
+	if err := w.loadBundle(bundle); err != nil {
+		return nil, err
 	}
 
 	// Wrap in the same container div that SPAShell renders on client.
@@ -305,272 +100,102 @@ func (w *Worker) Execute(bundle, route, propsJSON string) (string, error) {
 	// SPAShell renders: createElement('div', null, null, createElement(Page, props))
 	// Which produces: <div><Page HTML></div>
 	// Server must match this structure exactly for hydration.
+	//
+	// <Head> components push their tags to __reactgoHeadTags while rendering.
 	renderCall := fmt.Sprintf(
 		`(function(){
-			return '<div>' + __renderToString(%q, %s) + '</div>';
+			globalThis.__reactgoHeadTags = [];
+			try {
+				var html = '<div>' + __renderToString(%q, %s) + '</div>';
+				return JSON.stringify({ html: html, head: globalThis.__reactgoHeadTags });
+			} finally {
+				globalThis.__reactgoHeadTags = null;
+			}
 		})()`, route, propsJSON)
-	val, err := w.ctx.RunScript(renderCall, "render.js")
+	val, err := w.run(renderCall, "render.js")
 	if err != nil {
-		return "", fmt.Errorf("worker %d: render %s: %w", w.id, route, err)
+		return nil, fmt.Errorf("worker %d: render %s: %w", w.id, route, err)
 	}
 
-	return val.String(), nil
+	var result RenderResult
+	if err := json.Unmarshal([]byte(val.String()), &result); err != nil {
+		return nil, fmt.Errorf("worker %d: render %s: invalid result: %w", w.id, route, err)
+	}
+
+	return &result, nil
 }
 
 // ExecuteProps calls __getServerSideProps in V8.
 // Returns JSON string of { props, redirect, notFound }.
-func (w *Worker) ExecuteProps(bundle, route, contextJSON string) (string, error) {
+// getServerSideProps may be async (e.g. await fetch()); the worker event loop
+// runs until it settles or timeout elapses.
+func (w *Worker) ExecuteProps(bundle, route, contextJSON string, timeout time.Duration) (string, error) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	bundleHash := hashBundle(bundle)
-	if !w.bundleLoaded || w.bundleHash != bundleHash {
-		if w.ctx != nil {
-			w.ctx.Close()
-		}
-		global := v8.NewObjectTemplate(w.iso)
-		w.ctx = v8.NewContext(w.iso, global)
-
-		w.ctx.RunScript(`
-			// --- Console ---
-			var console = {
-				log: function(){}, warn: function(){}, error: function(){},
-				info: function(){}, debug: function(){}, trace: function(){},
-				dir: function(){}, table: function(){}, time: function(){},
-				timeEnd: function(){}, timeLog: function(){}, assert: function(){},
-				count: function(){}, countReset: function(){}, group: function(){},
-				groupEnd: function(){}, groupCollapsed: function(){}, clear: function(){}
-			};
-
-			// --- Process ---
-			var process = {
-				env: { NODE_ENV: 'production' },
-				nextTick: function(cb) { cb(); },
-				version: 'v18.0.0',
-				versions: { node: '18.0.0' },
-				platform: 'linux',
-				argv: [], pid: 1,
-				cwd: function() { return '/'; },
-				exit: function() {},
-				on: function() { return this; },
-				once: function() { return this; },
-				off: function() { return this; },
-				removeListener: function() { return this; },
-				emit: function() { return this; },
-				stderr: { write: function(){} },
-				stdout: { write: function(){} },
-				hrtime: function() { return [0,0]; },
-				binding: function() { return {}; }
-			};
-
-			// --- Timers ---
-			var queueMicrotask = function(cb) { cb(); };
-			if (typeof setTimeout === 'undefined') {
-				var setTimeout = function(cb, ms) { cb(); return 0; };
-			}
-			var clearTimeout = clearTimeout || function() {};
-			var setInterval = setInterval || function() { return 0; };
-			var clearInterval = clearInterval || function() {};
-			var setImmediate = function(cb) { cb(); return 0; };
-			var clearImmediate = function() {};
-
-			// --- Encoding ---
-			var TextEncoder = function() {};
-			TextEncoder.prototype.encode = function(s) {
-				var arr = [];
-				for (var i = 0; i < s.length; i++) {
-					var c = s.charCodeAt(i);
-					if (c < 128) arr.push(c);
-					else if (c < 2048) { arr.push(192 | (c >> 6)); arr.push(128 | (c & 63)); }
-					else { arr.push(224 | (c >> 12)); arr.push(128 | ((c >> 6) & 63)); arr.push(128 | (c & 63)); }
-				}
-				return new Uint8Array(arr);
-			};
-			TextEncoder.prototype.encoding = 'utf-8';
-
-			var TextDecoder = function(enc) { this.encoding = enc || 'utf-8'; };
-			TextDecoder.prototype.decode = function(buf) {
-				if (typeof buf === 'string') return buf;
-				if (!buf || !buf.length) return '';
-				var s = '';
-				for (var i = 0; i < buf.length; i++) s += String.fromCharCode(buf[i]);
-				return s;
-			};
-
-			// --- URL ---
-			if (typeof URL === 'undefined') {
-				var URL = function(url, base) {
-					this.href = url;
-					this.pathname = url.split('?')[0];
-					this.search = url.indexOf('?') >= 0 ? url.slice(url.indexOf('?')) : '';
-					this.hash = '';
-					this.hostname = '';
-					this.host = '';
-					this.origin = '';
-					this.protocol = 'https:';
-					this.port = '';
-					this.searchParams = {
-						get: function(k) { return null; },
-						has: function(k) { return false; },
-						forEach: function() {},
-						entries: function() { return []; }
-					};
-				};
-			}
-			if (typeof URLSearchParams === 'undefined') {
-				var URLSearchParams = function(init) {
-					this._params = {};
-					if (typeof init === 'string') {
-						init.replace(/^\?/, '').split('&').forEach(function(pair) {
-							var kv = pair.split('=');
-							if (kv[0]) this._params[decodeURIComponent(kv[0])] = decodeURIComponent(kv[1] || '');
-						}.bind(this));
-					}
-				};
-				URLSearchParams.prototype.get = function(k) { return this._params[k] || null; };
-				URLSearchParams.prototype.has = function(k) { return k in this._params; };
-				URLSearchParams.prototype.forEach = function(cb) {
-					for (var k in this._params) cb(this._params[k], k);
-				};
-			}
-
-			// --- Performance ---
-			var performance = {
-				now: function() { return Date.now(); },
-				mark: function() {},
-				measure: function() {},
-				getEntriesByName: function() { return []; },
-				getEntriesByType: function() { return []; },
-				clearMarks: function() {},
-				clearMeasures: function() {}
-			};
-
-			// --- Buffer ---
-			if (typeof Buffer === 'undefined') {
-				var Buffer = {
-					from: function(data) {
-						if (typeof data === 'string') {
-							var arr = [];
-							for (var i = 0; i < data.length; i++) arr.push(data.charCodeAt(i));
-							return new Uint8Array(arr);
-						}
-						return new Uint8Array(data || 0);
-					},
-					alloc: function(n) { return new Uint8Array(n); },
-					allocUnsafe: function(n) { return new Uint8Array(n); },
-					isBuffer: function() { return false; },
-					concat: function(list) {
-						var total = 0;
-						for (var i = 0; i < list.length; i++) total += list[i].length;
-						var result = new Uint8Array(total);
-						var offset = 0;
-						for (var i = 0; i < list.length; i++) { result.set(list[i], offset); offset += list[i].length; }
-						return result;
-					},
-					byteLength: function(s) { return typeof s === 'string' ? s.length : (s.byteLength || 0); }
-				};
-			}
+	if err := w.loadBundle(bundle); err != nil {
+		return "", err
+	}
 
-			// --- Misc globals ---
-			if (typeof global === 'undefined') var global = globalThis;
-			if (typeof self === 'undefined') var self = globalThis;
-			if (typeof window === 'undefined') var window = globalThis;
-
-			// --- MessageChannel (React scheduler uses this) ---
-			var MessageChannel = function() {
-				var self = this;
-				this.port1 = {
-					onmessage: null,
-					postMessage: function() {
-						if (self.port2.onmessage) self.port2.onmessage({ data: null });
-					}
-				};
-				this.port2 = {
-					onmessage: null,
-					postMessage: function() {
-						if (self.port1.onmessage) self.port1.onmessage({ data: null });
-					}
-				};
-			};
-			var MessagePort = function() {};
-			var MessageEvent = function() {};
-
-			// --- AbortController ---
-			if (typeof AbortController === 'undefined') {
-				var AbortSignal = function() { this.aborted = false; this.reason = undefined; };
-				AbortSignal.prototype.addEventListener = function() {};
-				AbortSignal.prototype.removeEventListener = function() {};
-				var AbortController = function() { this.signal = new AbortSignal(); };
-				AbortController.prototype.abort = function(reason) {
-					this.signal.aborted = true;
-					this.signal.reason = reason;
-				};
-			}
+	var out bufferWriter
+	propsCall := fmt.Sprintf(`__getServerSideProps(%q, %s)`, route, contextJSON)
+	if err := w.runAsync(propsCall, timeout, &out); err != nil {
+		return "", fmt.Errorf("worker %d: props %s: %w", w.id, route, err)
+	}
 
-			// --- Headers/Request/Response (fetch API stubs) ---
-			if (typeof Headers === 'undefined') {
-				var Headers = function() { this._h = {}; };
-				Headers.prototype.get = function(k) { return this._h[k.toLowerCase()] || null; };
-				Headers.prototype.set = function(k, v) { this._h[k.toLowerCase()] = v; };
-				Headers.prototype.has = function(k) { return k.toLowerCase() in this._h; };
-			}
+	return out.String(), nil
+}
 
-			if (typeof Request === 'undefined') {
-				var Request = function(url, opts) { this.url = url; this.method = (opts && opts.method) || 'GET'; };
-			}
+// ExecuteStaticPaths calls __getStaticPaths in V8.
+// Returns JSON string of { paths: [...] }, or "null" if the page
+// doesn't export getStaticPaths.
+func (w *Worker) ExecuteStaticPaths(bundle, route string) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
 
-			if (typeof Response === 'undefined') {
-				var Response = function(body, opts) { this.body = body; this.status = (opts && opts.status) || 200; };
-				Response.prototype.text = function() { return Promise.resolve(this.body || ''); };
-				Response.prototype.json = function() { return Promise.resolve(JSON.parse(this.body || '{}')); };
-			}
+	if err := w.loadBundle(bundle); err != nil {
+		return "", err
+	}
 
-			if (typeof fetch === 'undefined') {
-				var fetch = function() { return Promise.resolve(new Response('{}')); };
-			}
+	call := fmt.Sprintf(`__getStaticPaths(%q)`, route)
+	val, err := w.run(call, "static_paths.js")
+	if err != nil {
+		return "", fmt.Errorf("worker %d: static paths %s: %w", w.id, route, err)
+	}
 
-			// --- ReadableStream stub ---
-			if (typeof ReadableStream === 'undefined') {
-				var ReadableStream = function() {};
-				ReadableStream.prototype.getReader = function() {
-					return { read: function() { return Promise.resolve({ done: true, value: undefined }); }, releaseLock: function() {} };
-				};
-			}
+	return val.String(), nil
+}
 
-			// --- WeakRef (React may use) ---
-			if (typeof WeakRef === 'undefined') {
-				var WeakRef = function(target) { this._target = target; };
-				WeakRef.prototype.deref = function() { return this._target; };
-			}
+// loadBundle compiles bundle into a fresh context unless it is already loaded.
+// Only load bundle if it changed — massive speedup on repeated renders.
+// First render: ~5ms (parse + compile). Subsequent: ~0.1ms (cached bytecode).
+// Caller must hold w.mu.
+func (w *Worker) loadBundle(bundle string) error {
+	bundleHash := hashBundle(bundle)
+	if w.bundleLoaded && w.bundleHash == bundleHash {
+		return nil
+	}
 
-			// --- FinalizationRegistry ---
-			if (typeof FinalizationRegistry === 'undefined') {
-				var FinalizationRegistry = function() {};
-				FinalizationRegistry.prototype.register = function() {};
-				FinalizationRegistry.prototype.unregister = function() {};
-			}
+	// Fresh context to avoid stale state from previous bundle
+	if w.ctx != nil {
+		w.ctx.Close()
+	}
+	global := v8.NewObjectTemplate(w.iso)
+	w.installStreamBridge(global)
+	w.installFetchBridge(global)
+	w.ctx = v8.NewContext(w.iso, global)
 
-			// --- structuredClone ---
-			if (typeof structuredClone === 'undefined') {
-				var structuredClone = function(obj) { return JSON.parse(JSON.stringify(obj)); };
-			}
-		`, "bootstrap.js")
-
-		_, err := w.ctx.RunScript(bundle, "server_bundle.js")
-		if err != nil {
-			return "", fmt.Errorf("worker %d: bundle exec: %w", w.id, err)
-		}
-		w.bundleLoaded = true
-		w.bundleHash = bundleHash
+	if _, err := w.ctx.RunScript(polyfills, "bootstrap.js"); err != nil {
+		return fmt.Errorf("worker %d: bootstrap: %w", w.id, err)
 	}
 
-	propsCall := fmt.Sprintf(`__getServerSideProps(%q, %s)`, route, contextJSON)
-	val, err := w.ctx.RunScript(propsCall, "props.js")
-	if err != nil {
-		return "", fmt.Errorf("worker %d: props %s: %w", w.id, route, err)
+	if _, err := w.run(bundle, "server_bundle.js"); err != nil {
+		return fmt.Errorf("worker %d: bundle exec: %w", w.id, err)
 	}
+	w.bundleLoaded = true
+	w.bundleHash = bundleHash
 
-	return val.String(), nil
+	return nil
 }
 
 func (w *Worker) Dispose() {
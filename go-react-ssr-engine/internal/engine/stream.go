@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	v8 "rogchap.com/v8go"
+)
+
+// ErrRenderAborted is returned by a streaming render that hit its deadline
+// (or lost its client) before every Suspense boundary resolved. The output is
+// still a complete document — React swaps pending boundaries for client-render
+// instructions — but it must not be cached as if it were the final page.
+var ErrRenderAborted = errors.New("engine: stream aborted before all boundaries resolved")
+
+// StreamWriter receives HTML chunks from a streaming render.
+// Flush is called whenever React completes a flush, so bytes reach the client
+// without waiting for the rest of the page. *bufio.Writer satisfies it.
+type StreamWriter interface {
+	io.Writer
+	Flush() error
+}
+
+// streamState tracks one streaming render while the worker drives its event loop.
+type streamState struct {
+	out StreamWriter
+
+	// done is set by __reactgoDone once React has written its last chunk.
+	done bool
+
+	// doneErr is the error passed to __reactgoDone, e.g. React's
+	// onShellError (nothing was rendered) or a throwing API handler.
+	doneErr error
+
+	// writeErr is the first failed write or flush. Usually the client went away;
+	// the render is aborted and its remaining output discarded.
+	writeErr error
+
+	// fetches tracks fetch() calls made during this run.
+	fetches *fetchState
+}
+
+// installStreamBridge exposes the Go side of streaming to JS:
+//
+//	__reactgoWrite(chunk)  append HTML to the response
+//	__reactgoFlush()       push buffered bytes to the client
+//	__reactgoDone(error)   render finished; error is "" on success
+//
+// The callbacks write to w.stream, which is only set during runAsync.
+// fetch() has its own bridge, see installFetchBridge.
+func (w *Worker) installStreamBridge(global *v8.ObjectTemplate) {
+	global.Set("__reactgoWrite", v8.NewFunctionTemplate(w.iso, func(info *v8.FunctionCallbackInfo) *v8.Value {
+		s := w.stream
+		if s == nil || s.writeErr != nil || len(info.Args()) == 0 {
+			return nil
+		}
+		_, s.writeErr = io.WriteString(s.out, info.Args()[0].String())
+		return nil
+	}))
+
+	global.Set("__reactgoFlush", v8.NewFunctionTemplate(w.iso, func(info *v8.FunctionCallbackInfo) *v8.Value {
+		s := w.stream
+		if s == nil || s.writeErr != nil {
+			return nil
+		}
+		s.writeErr = s.out.Flush()
+		return nil
+	}))
+
+	global.Set("__reactgoDone", v8.NewFunctionTemplate(w.iso, func(info *v8.FunctionCallbackInfo) *v8.Value {
+		s := w.stream
+		if s == nil {
+			return nil
+		}
+		s.done = true
+		if args := info.Args(); len(args) > 0 && args[0].String() != "" {
+			s.doneErr = errors.New(args[0].String())
+		}
+		return nil
+	}))
+}
+
+// ExecuteStream renders a route with React's streaming renderer and writes
+// HTML to out as it is produced. The shell arrives first; content behind
+// Suspense boundaries follows as the promises it waits on resolve.
+//
+// Once timeout elapses the render is aborted: React flushes client-render
+// fallbacks for anything still pending and ExecuteStream returns
+// ErrRenderAborted. The bytes written so far always form valid HTML.
+func (w *Worker) ExecuteStream(bundle, route, propsJSON string, timeout time.Duration, out StreamWriter) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.loadBundle(bundle); err != nil {
+		return err
+	}
+
+	call := fmt.Sprintf(`__renderToStream(%q, %s)`, route, propsJSON)
+	if err := w.runAsync(call, timeout, out); err != nil {
+		return fmt.Errorf("worker %d: stream %s: %w", w.id, route, err)
+	}
+	return nil
+}
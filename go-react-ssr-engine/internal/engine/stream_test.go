@@ -0,0 +1,167 @@
+package engine
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// streamBundle stands in for the React server bundle. It mimics what
+// renderToPipeableStream does: the shell is written and flushed right away,
+// then each "boundary" is written when its timer fires.
+const streamBundle = `
+globalThis.__renderToStream = function(route, props) {
+	var pending = props.delays.length;
+	var aborted = false;
+	__reactgoWrite('<div>shell:' + route + '</div>');
+	__reactgoFlush();
+	if (pending === 0) { __reactgoDone(''); return; }
+
+	props.delays.forEach(function(ms, i) {
+		new Promise(function(resolve) { setTimeout(resolve, ms); }).then(function() {
+			if (aborted) return;
+			__reactgoWrite('<div id="S:' + i + '">' + new TextDecoder().decode(new TextEncoder().encode(props.text)) + '</div>');
+			__reactgoFlush();
+			if (--pending === 0) __reactgoDone('');
+		});
+	});
+
+	globalThis.__reactgoAbortStream = function() {
+		aborted = true;
+		__reactgoWrite('<template data-abort></template>');
+		__reactgoDone('');
+	};
+};
+`
+
+type recordingWriter struct {
+	sb      strings.Builder
+	flushes []string
+}
+
+func (r *recordingWriter) Write(p []byte) (int, error) {
+	return r.sb.Write(p)
+}
+
+func (r *recordingWriter) Flush() error {
+	r.flushes = append(r.flushes, r.sb.String())
+	return nil
+}
+
+func newStreamWorker(t *testing.T) *Worker {
+	t.Helper()
+	w, err := NewWorker(0)
+	if err != nil {
+		t.Fatalf("worker init: %v", err)
+	}
+	t.Cleanup(w.Dispose)
+	return w
+}
+
+func TestExecuteStreamFlushesShellFirst(t *testing.T) {
+	w := newStreamWorker(t)
+	out := &recordingWriter{}
+
+	err := w.ExecuteStream(streamBundle, "/", `{"delays":[30,10],"text":"héllo ✓"}`, time.Second, out)
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+
+	if len(out.flushes) != 3 {
+		t.Fatalf("expected 3 flushes, got %d: %q", len(out.flushes), out.flushes)
+	}
+	if out.flushes[0] != "<div>shell:/</div>" {
+		t.Errorf("first flush should be the shell alone, got %q", out.flushes[0])
+	}
+
+	// Boundaries arrive in timer order, not declaration order.
+	want := `<div>shell:/</div><div id="S:1">héllo ✓</div><div id="S:0">héllo ✓</div>`
+	if out.sb.String() != want {
+		t.Errorf("expected %q, got %q", want, out.sb.String())
+	}
+}
+
+func TestExecuteStreamAbortsAtDeadline(t *testing.T) {
+	w := newStreamWorker(t)
+	out := &recordingWriter{}
+
+	start := time.Now()
+	err := w.ExecuteStream(streamBundle, "/slow", `{"delays":[5000],"text":"x"}`, 50*time.Millisecond, out)
+	if !errors.Is(err, ErrRenderAborted) {
+		t.Fatalf("expected ErrRenderAborted, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("abort took %s, expected ~50ms", elapsed)
+	}
+	if !strings.HasSuffix(out.sb.String(), "<template data-abort></template>") {
+		t.Errorf("expected abort fallback at end of output, got %q", out.sb.String())
+	}
+
+	// The worker stays usable and stale timers don't leak into the next render.
+	out = &recordingWriter{}
+	if err := w.ExecuteStream(streamBundle, "/", `{"delays":[],"text":""}`, time.Second, out); err != nil {
+		t.Fatalf("second stream: %v", err)
+	}
+	if out.sb.String() != "<div>shell:/</div>" {
+		t.Errorf("unexpected second render output %q", out.sb.String())
+	}
+}
+
+func TestExecuteStreamShellError(t *testing.T) {
+	w := newStreamWorker(t)
+	bundle := `globalThis.__renderToStream = function() { __reactgoDone('boom'); };`
+
+	err := w.ExecuteStream(bundle, "/", `{}`, time.Second, &recordingWriter{})
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected shell error, got %v", err)
+	}
+}
+
+func TestExecuteStreamSharesBundleWithExecute(t *testing.T) {
+	w := newStreamWorker(t)
+	bundle := streamBundle + `globalThis.__renderToString = function(route) { return 'str:' + route; };`
+
+	result, err := w.Execute(bundle, "/a", `{}`)
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if result.HTML != "<div>str:/a</div>" {
+		t.Errorf("unexpected render output %q", result.HTML)
+	}
+
+	out := &recordingWriter{}
+	if err := w.ExecuteStream(bundle, "/b", `{"delays":[],"text":""}`, time.Second, out); err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	if out.sb.String() != "<div>shell:/b</div>" {
+		t.Errorf("unexpected stream output %q", out.sb.String())
+	}
+}
+
+func TestExecuteCollectsHead(t *testing.T) {
+	w := newStreamWorker(t)
+	// Stands in for pages rendering <Head> from the reactgo/head runtime.
+	bundle := `globalThis.__renderToString = function(route) {
+		__reactgoHeadTags.push({ title: 'Layout' });
+		__reactgoHeadTags.push({ title: 'Post', meta: [{ name: 'description', content: 'hi' }] });
+		return 'page';
+	};`
+
+	result, err := w.Execute(bundle, "/post", `{}`)
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if len(result.Head) != 2 || result.Head[1].Title != "Post" || result.Head[1].Meta[0].Content != "hi" {
+		t.Errorf("unexpected head %+v", result.Head)
+	}
+
+	// Tags never leak into the next render.
+	result, err = w.Execute(streamBundle+`globalThis.__renderToString = function() { return ''; };`, "/", `{}`)
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if len(result.Head) != 0 {
+		t.Errorf("expected no head, got %+v", result.Head)
+	}
+}
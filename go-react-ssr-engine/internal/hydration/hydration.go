@@ -19,17 +19,33 @@ import (
 // Result: instant content + fast interactivity. Best of both worlds.
 type Hydrator struct {
 	manifest *Manifest
+
+	// liveReloadScript, when set, is added to every page (dev mode).
+	liveReloadScript string
 }
 
 func NewHydrator(manifest *Manifest) *Hydrator {
 	return &Hydrator{manifest: manifest}
 }
 
+// EnableLiveReload adds the dev live reload client at scriptPath to every
+// page, including pages without a client bundle.
+func (h *Hydrator) EnableLiveReload(scriptPath string) {
+	h.liveReloadScript = scriptPath
+}
+
 // Prepare configures the HTML document with client scripts and preload hints.
 // Called after SSR render, before final HTML serialization.
 func (h *Hydrator) Prepare(doc *html.Document, route string, propsJSON string) {
 	doc.PropsJSON = propsJSON
 
+	if h.liveReloadScript != "" {
+		doc.Head.Scripts = append(doc.Head.Scripts, html.Script{
+			Src:   h.liveReloadScript,
+			Defer: true,
+		})
+	}
+
 	entry, ok := h.manifest.Get(route)
 	if !ok {
 		// No client bundle for this route — SSR only, no interactivity.
@@ -66,6 +82,16 @@ func (h *Hydrator) Prepare(doc *html.Document, route string, propsJSON string) {
 
 }
 
+// Stylesheets returns the CSS URLs a route's page needs. Client-side
+// navigation loads them before swapping in the page.
+func (h *Hydrator) Stylesheets(route string) []string {
+	entry, ok := h.manifest.Get(route)
+	if !ok || entry.CSSPath == "" {
+		return nil
+	}
+	return []string{entry.CSSPath}
+}
+
 // GenerateClientEntry produces the inline hydration bootstrap script.
 // This is the tiny JS snippet that calls hydrateRoot() with the right
 // component and props. It's the bridge between SSR HTML and live React.
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 )
@@ -16,6 +17,10 @@ import (
 type Manifest struct {
 	mu      sync.RWMutex
 	entries map[string]ManifestEntry
+
+	// files are imported images and fonts under assets/. Pages reference
+	// them by URL from inside their bundles, so they aren't per route.
+	files []string
 }
 
 // ManifestEntry holds the client bundle info for one route.
@@ -23,7 +28,8 @@ type ManifestEntry struct {
 	// JSPath is the URL path to the client JS file, e.g. "/_reactgo/pages/index.js"
 	JSPath string `json:"js"`
 
-	// CSSPath is optional — populated if the page imports CSS
+	// CSSPath is optional — populated if the page imports CSS.
+	// Content-hashed, e.g. "/_reactgo/_hydrate_index-1a2b3c4d.css"
 	CSSPath string `json:"css,omitempty"`
 
 	// Deps lists shared chunk paths this page depends on (React, etc.)
@@ -38,8 +44,9 @@ func NewManifest() *Manifest {
 }
 
 // Build scans esbuild client output dir and maps routes to bundle paths.
+// cssMap holds each route's extracted stylesheet, if it has one.
 // Called after every successful build.
-func (m *Manifest) Build(clientDir string, routeMap map[string]string) error {
+func (m *Manifest) Build(clientDir string, routeMap, cssMap map[string]string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -52,14 +59,26 @@ func (m *Manifest) Build(clientDir string, routeMap map[string]string) error {
 		return err
 	}
 
-	for route, filePath := range routeMap {
-		// Convert filesystem path to URL path served by static handler
-		urlPath := "/_reactgo/" + strings.TrimPrefix(filePath, clientDir+"/")
+	files, err := m.findAssets(clientDir)
+	if err != nil {
+		return err
+	}
+	m.files = files
+
+	// Convert filesystem path to URL path served by static handler
+	toURL := func(filePath string) string {
+		return "/_reactgo/" + filepath.ToSlash(strings.TrimPrefix(filePath, clientDir+string(filepath.Separator)))
+	}
 
-		m.entries[route] = ManifestEntry{
-			JSPath: urlPath,
+	for route, filePath := range routeMap {
+		entry := ManifestEntry{
+			JSPath: toURL(filePath),
 			Deps:   chunks,
 		}
+		if css, ok := cssMap[route]; ok {
+			entry.CSSPath = toURL(css)
+		}
+		m.entries[route] = entry
 	}
 
 	return nil
@@ -74,6 +93,37 @@ func (m *Manifest) Get(route string) (ManifestEntry, bool) {
 	return entry, ok
 }
 
+// Assets returns every client URL referenced by the manifest — page bundles,
+// shared chunks, stylesheets and imported assets — deduplicated and sorted.
+// Used by the static exporter to copy only what pages actually load.
+func (m *Manifest) Assets() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var assets []string
+	add := func(url string) {
+		if url != "" && !seen[url] {
+			seen[url] = true
+			assets = append(assets, url)
+		}
+	}
+
+	for _, file := range m.files {
+		add(file)
+	}
+	for _, entry := range m.entries {
+		add(entry.JSPath)
+		add(entry.CSSPath)
+		for _, dep := range entry.Deps {
+			add(dep)
+		}
+	}
+
+	sort.Strings(assets)
+	return assets
+}
+
 // findChunks locates shared JS chunks in the chunks/ subdirectory.
 // These are common dependencies esbuild extracted (React, ReactDOM, etc).
 func (m *Manifest) findChunks(clientDir string) ([]string, error) {
@@ -97,6 +147,27 @@ func (m *Manifest) findChunks(clientDir string) ([]string, error) {
 	return chunks, nil
 }
 
+// findAssets lists the images and fonts pages import, emitted by esbuild
+// under assets/ with content-hashed names.
+func (m *Manifest) findAssets(clientDir string) ([]string, error) {
+	assetsDir := filepath.Join(clientDir, "assets")
+	entries, err := os.ReadDir(assetsDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			files = append(files, "/_reactgo/assets/"+e.Name())
+		}
+	}
+	return files, nil
+}
+
 // WriteJSON saves manifest to disk for debugging and external tooling.
 func (m *Manifest) WriteJSON(path string) error {
 	m.mu.RLock()
@@ -0,0 +1,60 @@
+package livereload
+
+// ScriptPath is where the server serves ClientScript in dev mode.
+const ScriptPath = "/__reload.js"
+
+// EventsPath is the Server-Sent Events endpoint ClientScript connects to.
+const EventsPath = "/__reload"
+
+// ClientScript is the browser side of live reload. It reloads the page after
+// a rebuild — SSR HTML, client bundles and props all change together, so a
+// fresh load is the only state that is guaranteed consistent — and shows
+// bundler errors in an overlay until the next successful build.
+const ClientScript = `(function () {
+  if (!window.EventSource) return;
+
+  var buildId = null;
+  var overlay = null;
+
+  function showError(message) {
+    if (!overlay) {
+      overlay = document.createElement('div');
+      overlay.id = '__reactgo_overlay';
+      overlay.style.cssText = 'position:fixed;inset:0;z-index:2147483647;overflow:auto;' +
+        'background:rgba(20,20,20,.92);color:#ff6b6b;font:14px/1.5 ui-monospace,Menlo,monospace;padding:2rem';
+      overlay.addEventListener('click', function () { overlay.remove(); overlay = null; });
+      document.body.appendChild(overlay);
+    }
+    overlay.innerHTML = '';
+    var title = document.createElement('div');
+    title.style.cssText = 'color:#fff;font-weight:bold;margin-bottom:1rem';
+    title.textContent = 'Build failed — fix the error and save to reload. Click to dismiss.';
+    var pre = document.createElement('pre');
+    pre.style.cssText = 'white-space:pre-wrap;margin:0';
+    pre.textContent = message;
+    overlay.appendChild(title);
+    overlay.appendChild(pre);
+  }
+
+  var source = new EventSource('` + EventsPath + `');
+
+  // Sent on every (re)connect. A changed build id means a rebuild happened
+  // while we were disconnected.
+  source.addEventListener('hello', function (e) {
+    var data = JSON.parse(e.data);
+    if (buildId !== null && data.buildId !== buildId) {
+      location.reload();
+      return;
+    }
+    buildId = data.buildId;
+  });
+
+  source.addEventListener('reload', function () {
+    location.reload();
+  });
+
+  source.addEventListener('build-error', function (e) {
+    showError(JSON.parse(e.data).message);
+  });
+})();
+`
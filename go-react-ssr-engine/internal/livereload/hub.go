@@ -0,0 +1,169 @@
+package livereload
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Hub fans build events out to every browser tab connected to /__reload.
+// Dev mode only: the watcher reports each rebuild, the hub pushes it over
+// Server-Sent Events, and the client runtime (ClientScript) reloads the page
+// or shows the build error in an overlay.
+type Hub struct {
+	mu      sync.Mutex
+	clients map[chan Event]struct{}
+	closed  bool
+
+	// buildID increments on every successful rebuild. Sent on connect so a
+	// tab that reconnects after missing a rebuild knows it is stale.
+	buildID int64
+
+	// lastErr is the error of the latest build, "" once a build succeeds.
+	// Replayed on connect so reloading a broken page keeps the overlay.
+	lastErr string
+
+	heartbeat time.Duration
+}
+
+// Event is one message on the /__reload stream.
+type Event struct {
+	// Type is the SSE event name: "hello", "reload" or "build-error".
+	// Not "error" — EventSource fires that for connection failures.
+	Type    string `json:"type"`
+	BuildID int64  `json:"buildId"`
+	Message string `json:"message,omitempty"`
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		clients:   make(map[chan Event]struct{}),
+		heartbeat: 15 * time.Second,
+	}
+}
+
+// Reload tells every client a rebuild completed.
+func (h *Hub) Reload() {
+	h.mu.Lock()
+	h.buildID++
+	h.lastErr = ""
+	h.broadcast(Event{Type: "reload", BuildID: h.buildID})
+	h.mu.Unlock()
+}
+
+// BuildError tells every client the latest rebuild failed.
+func (h *Hub) BuildError(err error) {
+	h.mu.Lock()
+	h.lastErr = err.Error()
+	h.broadcast(Event{Type: "build-error", BuildID: h.buildID, Message: h.lastErr})
+	h.mu.Unlock()
+}
+
+// broadcast never blocks: a tab too slow to take an event has a full
+// buffer of events that each trigger a reload anyway. Caller holds h.mu.
+func (h *Hub) broadcast(ev Event) {
+	for ch := range h.clients {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// subscribe registers a client and returns the events to replay on connect.
+func (h *Hub) subscribe() (chan Event, []Event, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, nil, false
+	}
+
+	ch := make(chan Event, 8)
+	h.clients[ch] = struct{}{}
+
+	initial := []Event{{Type: "hello", BuildID: h.buildID}}
+	if h.lastErr != "" {
+		initial = append(initial, Event{Type: "build-error", BuildID: h.buildID, Message: h.lastErr})
+	}
+	return ch, initial, true
+}
+
+func (h *Hub) unsubscribe(ch chan Event) {
+	h.mu.Lock()
+	if _, ok := h.clients[ch]; ok {
+		delete(h.clients, ch)
+		close(ch)
+	}
+	h.mu.Unlock()
+}
+
+// Close ends every open stream. Called on shutdown — open event streams
+// would otherwise hold their connections until the client goes away.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for ch := range h.clients {
+		delete(h.clients, ch)
+		close(ch)
+	}
+}
+
+// Stream writes events to one client as text/event-stream until the client
+// disconnects or the hub closes. Heartbeat comments surface a dead
+// connection as a write error instead of leaking the subscription.
+func (h *Hub) Stream(w *bufio.Writer) {
+	ch, initial, ok := h.subscribe()
+	if !ok {
+		return
+	}
+	defer h.unsubscribe(ch)
+
+	// Reconnect quickly after the dev server restarts.
+	if _, err := w.WriteString("retry: 1000\n\n"); err != nil {
+		return
+	}
+	for _, ev := range initial {
+		if writeEvent(w, ev) != nil {
+			return
+		}
+	}
+	if w.Flush() != nil {
+		return
+	}
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			if writeEvent(w, ev) != nil {
+				return
+			}
+		case <-ticker.C:
+			if _, err := w.WriteString(": ping\n\n"); err != nil {
+				return
+			}
+		}
+		if w.Flush() != nil {
+			return
+		}
+	}
+}
+
+func writeEvent(w *bufio.Writer, ev Event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+	return err
+}
@@ -0,0 +1,95 @@
+package livereload
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startClient connects one client and returns a reader over its SSE stream.
+func startClient(t *testing.T, h *Hub) (*bufio.Reader, chan struct{}) {
+	t.Helper()
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		h.Stream(bufio.NewWriter(pw))
+		pw.Close()
+		close(done)
+	}()
+	t.Cleanup(func() { pr.Close() })
+	return bufio.NewReader(pr), done
+}
+
+// nextEvent reads up to the next blank line and returns the event name and data.
+func nextEvent(t *testing.T, r *bufio.Reader) (string, string) {
+	t.Helper()
+	var name, data string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && name != "":
+			return name, data
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestHubPushesReloadAndErrors(t *testing.T) {
+	h := NewHub()
+	r, _ := startClient(t, h)
+
+	if name, data := nextEvent(t, r); name != "hello" || !strings.Contains(data, `"buildId":0`) {
+		t.Fatalf("expected hello for build 0, got %s %s", name, data)
+	}
+
+	h.BuildError(errors.New("pages/index.tsx: unexpected token"))
+	if name, data := nextEvent(t, r); name != "build-error" || !strings.Contains(data, "unexpected token") {
+		t.Fatalf("expected build error, got %s %s", name, data)
+	}
+
+	h.Reload()
+	if name, data := nextEvent(t, r); name != "reload" || !strings.Contains(data, `"buildId":1`) {
+		t.Fatalf("expected reload for build 1, got %s %s", name, data)
+	}
+}
+
+func TestHubReplaysStateOnConnect(t *testing.T) {
+	h := NewHub()
+	h.Reload()
+	h.BuildError(errors.New("broken"))
+
+	r, _ := startClient(t, h)
+	if name, data := nextEvent(t, r); name != "hello" || !strings.Contains(data, `"buildId":1`) {
+		t.Fatalf("expected hello for build 1, got %s %s", name, data)
+	}
+	if name, data := nextEvent(t, r); name != "build-error" || !strings.Contains(data, "broken") {
+		t.Fatalf("expected replayed build error, got %s %s", name, data)
+	}
+}
+
+func TestHubCloseEndsStreams(t *testing.T) {
+	h := NewHub()
+	r, done := startClient(t, h)
+	nextEvent(t, r)
+
+	h.Close()
+	go io.Copy(io.Discard, r)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("stream still open after Close")
+	}
+
+	// Late clients return immediately.
+	h.Stream(bufio.NewWriter(io.Discard))
+}
@@ -0,0 +1,202 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContentType is the Prometheus text exposition format served at /_metrics.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefBuckets suit request-path latencies: 1ms to 10s.
+var DefBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Registry holds the server's metrics and writes them in the Prometheus
+// text format. Histograms are updated on the request path; counters and
+// gauges are read from the components that own them (cache, pool, rate
+// limiter) only when scraped, so those stay free of metrics code.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+func (r *Registry) add(m metric) {
+	r.mu.Lock()
+	r.metrics = append(r.metrics, m)
+	r.mu.Unlock()
+}
+
+// WriteTo writes every metric in registration order.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	metrics := append([]metric(nil), r.metrics...)
+	r.mu.Unlock()
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, m := range metrics {
+		m.write(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// Histogram is a latency histogram, optionally split by one label
+// (e.g. route). An empty label name makes it a single series.
+type Histogram struct {
+	name, help, label string
+	buckets           []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	counts []uint64 // per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// Histogram registers a histogram. buckets are upper bounds in seconds, ascending.
+func (r *Registry) Histogram(name, help, label string, buckets []float64) *Histogram {
+	h := &Histogram{
+		name:    name,
+		help:    help,
+		label:   label,
+		buckets: buckets,
+		series:  make(map[string]*series),
+	}
+	r.add(h)
+	return h
+}
+
+// Observe records a duration for a label value. The value is ignored
+// when the histogram has no label.
+func (h *Histogram) Observe(labelValue string, d time.Duration) {
+	if h.label == "" {
+		labelValue = ""
+	}
+	v := d.Seconds()
+	i := sort.SearchFloat64s(h.buckets, v)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[labelValue]
+	if !ok {
+		s = &series{counts: make([]uint64, len(h.buckets))}
+		h.series[labelValue] = s
+	}
+	if i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.sum += v
+	s.count++
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	writeHeader(w, h.name, h.help, "histogram")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	keys := make([]string, 0, len(h.series))
+	for k := range h.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		s := h.series[k]
+		labels := ""
+		if h.label != "" {
+			labels = h.label + `="` + escapeLabel(k) + `",`
+		}
+
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket{%sle=%q} %d\n", h.name, labels, formatFloat(upper), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket{%sle=\"+Inf\"} %d\n", h.name, labels, s.count)
+
+		labels = strings.TrimSuffix(labels, ",")
+		if labels != "" {
+			labels = "{" + labels + "}"
+		}
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, labels, formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, labels, s.count)
+	}
+}
+
+// funcMetric is a counter or gauge whose value is read at scrape time.
+type funcMetric struct {
+	name, help, kind string
+	fn               func() float64
+}
+
+// CounterFunc registers a counter read from fn. fn must never decrease.
+func (r *Registry) CounterFunc(name, help string, fn func() float64) {
+	r.add(&funcMetric{name: name, help: help, kind: "counter", fn: fn})
+}
+
+// GaugeFunc registers a gauge read from fn.
+func (r *Registry) GaugeFunc(name, help string, fn func() float64) {
+	r.add(&funcMetric{name: name, help: help, kind: "gauge", fn: fn})
+}
+
+func (m *funcMetric) write(w *bufio.Writer) {
+	writeHeader(w, m.name, m.help, m.kind)
+	fmt.Fprintf(w, "%s %s\n", m.name, formatFloat(m.fn()))
+}
+
+func writeHeader(w *bufio.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, escapeHelp(help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
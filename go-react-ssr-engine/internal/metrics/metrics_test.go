@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistogramByLabel(t *testing.T) {
+	r := NewRegistry()
+	h := r.Histogram("render_seconds", "Render time.", "route", []float64{0.01, 0.1})
+
+	h.Observe("/b", 5*time.Millisecond)
+	h.Observe("/b", 50*time.Millisecond)
+	h.Observe("/a", 2*time.Second)
+
+	var sb strings.Builder
+	r.WriteTo(&sb)
+	out := sb.String()
+
+	for _, want := range []string{
+		"# HELP render_seconds Render time.\n# TYPE render_seconds histogram\n",
+		`render_seconds_bucket{route="/a",le="0.01"} 0`,
+		`render_seconds_bucket{route="/a",le="+Inf"} 1`,
+		`render_seconds_bucket{route="/b",le="0.01"} 1`,
+		`render_seconds_bucket{route="/b",le="0.1"} 2`,
+		`render_seconds_bucket{route="/b",le="+Inf"} 2`,
+		`render_seconds_sum{route="/b"} 0.055`,
+		`render_seconds_count{route="/b"} 2`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	// Series are sorted by label value.
+	if strings.Index(out, `route="/a"`) > strings.Index(out, `route="/b"`) {
+		t.Errorf("series not sorted:\n%s", out)
+	}
+}
+
+func TestHistogramWithoutLabel(t *testing.T) {
+	r := NewRegistry()
+	h := r.Histogram("wait_seconds", "Wait.", "", []float64{1})
+	h.Observe("ignored", 100*time.Millisecond)
+
+	var sb strings.Builder
+	r.WriteTo(&sb)
+	out := sb.String()
+
+	for _, want := range []string{
+		`wait_seconds_bucket{le="1"} 1`,
+		"wait_seconds_sum 0.1\n",
+		"wait_seconds_count 1\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestFuncMetrics(t *testing.T) {
+	r := NewRegistry()
+	n := 0.0
+	r.CounterFunc("hits_total", "Hits.", func() float64 { return n })
+	r.GaugeFunc("busy", "Busy.", func() float64 { return 3 })
+
+	n = 42
+	var sb strings.Builder
+	r.WriteTo(&sb)
+	out := sb.String()
+
+	for _, want := range []string{
+		"# TYPE hits_total counter\nhits_total 42\n",
+		"# TYPE busy gauge\nbusy 3\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestLabelEscaping(t *testing.T) {
+	r := NewRegistry()
+	h := r.Histogram("x_seconds", "X.", "route", []float64{1})
+	h.Observe("a\"b\\c\nd", time.Millisecond)
+
+	var sb strings.Builder
+	r.WriteTo(&sb)
+	if want := `route="a\"b\\c\nd"`; !strings.Contains(sb.String(), want) {
+		t.Errorf("output missing %q:\n%s", want, sb.String())
+	}
+}
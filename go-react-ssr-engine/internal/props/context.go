@@ -1,22 +1,46 @@
 package props
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/thutasann/go-react-ssr-engine/pkg/html"
+)
 
 // PageContext carries request-scoped data to the props loader
 // Created per-request from the router match result.
 // This ist he Go equivalent of Next.js getServerSideProps context.
 type PageContext struct {
 	// Route pattern e.g. "/posts/:id"
-	Route string
+	Route string `json:"route"`
 
 	// Params extracted from URL e.g. {"id": "123"}
-	Params map[string]string
+	Params map[string]string `json:"params"`
 
 	// Query string params e.g. {"sort": "date"}
-	Query map[string]string
+	Query map[string]string `json:"query"`
+
+	// Path is the request path without the query string e.g. "/posts/123"
+	Path string `json:"path"`
+
+	// Method is the HTTP method, e.g. "GET"
+	Method string `json:"method"`
+
+	// Headers are the request headers with lower-cased names,
+	// e.g. {"accept-language": "en-US,en;q=0.9"}
+	Headers map[string]string `json:"headers"`
+
+	// Cookies are the request cookies by name
+	Cookies map[string]string `json:"cookies"`
 
-	// Path is the full request path e.g. "/posts/123?sort=date"
-	Path string
+	// IP is the client address. Taken from X-Forwarded-For only when the
+	// server is configured to trust its proxy.
+	IP string `json:"ip"`
+
+	// Locale is the best match for Accept-Language among the configured
+	// locales, e.g. "en-US". Empty when nothing matches and no default is set.
+	Locale string `json:"locale"`
 }
 
 // PageProps is what getServerSideProps returns.
@@ -30,6 +54,67 @@ type PageProps struct {
 
 	// NotFound triggers a 404 page
 	NotFound bool `json:"notFound,omitempty"`
+
+	// Revalidate is the number of seconds the rendered page may be served
+	// from cache before it is regenerated in the background (ISR).
+	// Zero keeps the page cached until the next rebuild or purge.
+	Revalidate int `json:"revalidate,omitempty"`
+
+	// SetCookie lists cookies to set on the response.
+	SetCookie []Cookie `json:"setCookie,omitempty"`
+
+	// Headers are extra response headers, e.g. {"Content-Language": "de"}.
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Head sets the page's <title>, meta and link tags.
+	Head *html.HeadData `json:"head,omitempty"`
+
+	// Error is set by the props bridge when getServerSideProps threw or
+	// rejected; Props is then empty and must not be cached.
+	Error string `json:"error,omitempty"`
+}
+
+// Personalized reports whether the response carries per-request side effects
+// (cookies or headers) that the HTML-only page cache can't replay.
+func (p *PageProps) Personalized() bool {
+	return len(p.SetCookie) > 0 || len(p.Headers) > 0
+}
+
+// Cookie is a response cookie returned from getServerSideProps.
+type Cookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	MaxAge   int    `json:"maxAge,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+	HTTPOnly bool   `json:"httpOnly,omitempty"`
+
+	// SameSite is "lax", "strict" or "none"; anything else leaves it unset.
+	SameSite string `json:"sameSite,omitempty"`
+}
+
+// String formats the cookie as a Set-Cookie header value.
+// Invalid names yield "" and should be skipped.
+func (c Cookie) String() string {
+	hc := &http.Cookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Path:     c.Path,
+		Domain:   c.Domain,
+		MaxAge:   c.MaxAge,
+		Secure:   c.Secure,
+		HttpOnly: c.HTTPOnly,
+	}
+	switch strings.ToLower(c.SameSite) {
+	case "lax":
+		hc.SameSite = http.SameSiteLaxMode
+	case "strict":
+		hc.SameSite = http.SameSiteStrictMode
+	case "none":
+		hc.SameSite = http.SameSiteNoneMode
+	}
+	return hc.String()
 }
 
 // Redirect holds redirect target info.
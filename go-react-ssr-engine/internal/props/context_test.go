@@ -0,0 +1,40 @@
+package props
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPagePropsCookies(t *testing.T) {
+	var p PageProps
+	data := `{"props":{},"setCookie":[{"name":"session","value":"abc","path":"/","maxAge":3600,"httpOnly":true,"sameSite":"lax"}],"headers":{"X-Variant":"b"}}`
+	if err := json.Unmarshal([]byte(data), &p); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	if !p.Personalized() {
+		t.Errorf("props with cookies should be personalized")
+	}
+	want := "session=abc; Path=/; Max-Age=3600; HttpOnly; SameSite=Lax"
+	if got := p.SetCookie[0].String(); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	if (&PageProps{}).Personalized() {
+		t.Errorf("empty props should not be personalized")
+	}
+	if got := (Cookie{Name: "bad name", Value: "x"}).String(); got != "" {
+		t.Errorf("expected empty string for invalid cookie, got %q", got)
+	}
+}
+
+func TestPagePropsBridgeError(t *testing.T) {
+	// What the props bridge returns when getServerSideProps throws
+	var p PageProps
+	if err := json.Unmarshal([]byte(`{"props":{},"error":"backend down"}`), &p); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if p.Error != "backend down" {
+		t.Errorf("expected error %q, got %q", "backend down", p.Error)
+	}
+}
@@ -3,6 +3,8 @@ package props
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 
 	v8 "rogchap.com/v8go"
@@ -84,14 +86,64 @@ func LoadProps(ctx *PageContext, iso *v8.Isolate, v8ctx *v8.Context, bundle stri
 
 // CacheKey generates a unique cache key from route + params.
 // Two requests to /posts/:id with different ids get different cache keys.
-// Format: "/posts/:id|id=123" — simple, deterministic, no hash collisions.
+// Format: "/posts/:id|id=123&" — simple, deterministic, no hash collisions.
+// Params are sorted so the same request always maps to the same key —
+// on-demand revalidation rebuilds keys and must hit the cached entry.
+// Query args are included as "query_<name>".
 func CacheKey(ctx *PageContext) string {
+	values := make(map[string]string, len(ctx.Params)+len(ctx.Query))
+	for k, v := range ctx.Params {
+		values[k] = v
+	}
+	for k, v := range ctx.Query {
+		values["query_"+k] = v
+	}
+
 	key := ctx.Route
-	if len(ctx.Params) > 0 {
+	if len(values) > 0 {
+		names := make([]string, 0, len(values))
+		for k := range values {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+
 		key += "|"
-		for k, v := range ctx.Params {
-			key += k + "=" + v + "&"
+		for _, k := range names {
+			key += k + "=" + values[k] + "&"
 		}
 	}
 	return key
 }
+
+// VarySeparator splits the route part of a cache key from its Vary part.
+// Purging a path removes the base key and every key starting with
+// base + VarySeparator.
+const VarySeparator = "|vary|"
+
+// Vary lists the request cookies and headers a page's output depends on,
+// e.g. a "theme" cookie or Accept-Language. Their values are added to the
+// cache key so personalized variants don't share one entry.
+type Vary struct {
+	Cookies []string
+	Headers []string
+}
+
+// CacheKey extends the package-level CacheKey with the allow-listed cookie
+// and header values. Missing values still count, as "", so a request without
+// the cookie never reuses the page rendered for one with it.
+func (v *Vary) CacheKey(ctx *PageContext) string {
+	key := CacheKey(ctx)
+	if v == nil || len(v.Cookies)+len(v.Headers) == 0 {
+		return key
+	}
+
+	key += VarySeparator
+	for _, name := range v.Cookies {
+		key += "cookie." + name + "=" + ctx.Cookies[name] + "&"
+	}
+	for _, name := range v.Headers {
+		name = strings.ToLower(name)
+		key += "header." + name + "=" + ctx.Headers[name] + "&"
+	}
+	return key
+}
@@ -0,0 +1,72 @@
+package props
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCacheKeyDeterministic(t *testing.T) {
+	ctx := &PageContext{
+		Route:  "/users/:uid/posts/:pid",
+		Params: map[string]string{"uid": "alice", "pid": "7", "query_sort": "date"},
+	}
+
+	want := "/users/:uid/posts/:pid|pid=7&query_sort=date&uid=alice&"
+	for i := 0; i < 20; i++ {
+		if got := CacheKey(ctx); got != want {
+			t.Fatalf("expected %s, got %s", want, got)
+		}
+	}
+}
+
+func TestCacheKeyStatic(t *testing.T) {
+	if got := CacheKey(&PageContext{Route: "/about"}); got != "/about" {
+		t.Errorf("expected /about, got %s", got)
+	}
+}
+
+func TestCacheKeyIncludesQuery(t *testing.T) {
+	ctx := &PageContext{
+		Route:  "/posts/:id",
+		Params: map[string]string{"id": "1"},
+		Query:  map[string]string{"sort": "date"},
+	}
+	if got, want := CacheKey(ctx), "/posts/:id|id=1&query_sort=date&"; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestVaryCacheKey(t *testing.T) {
+	vary := &Vary{Cookies: []string{"theme"}, Headers: []string{"Accept-Language"}}
+
+	dark := &PageContext{
+		Route:   "/about",
+		Cookies: map[string]string{"theme": "dark", "session": "abc"},
+		Headers: map[string]string{"accept-language": "de"},
+	}
+	want := "/about|vary|cookie.theme=dark&header.accept-language=de&"
+	if got := vary.CacheKey(dark); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+
+	// Cookies outside the allow-list don't split the cache
+	other := &PageContext{
+		Route:   "/about",
+		Cookies: map[string]string{"theme": "dark", "session": "xyz"},
+		Headers: map[string]string{"accept-language": "de"},
+	}
+	if vary.CacheKey(other) != want {
+		t.Errorf("session cookie should not affect the key, got %s", vary.CacheKey(other))
+	}
+
+	// Missing values still produce a distinct key
+	anon := &PageContext{Route: "/about"}
+	if got := vary.CacheKey(anon); got == want || !strings.HasPrefix(got, CacheKey(anon)+VarySeparator) {
+		t.Errorf("unexpected anonymous key %s", got)
+	}
+
+	// No allow-list: same as CacheKey
+	if got := (&Vary{}).CacheKey(dark); got != "/about" {
+		t.Errorf("expected /about, got %s", got)
+	}
+}
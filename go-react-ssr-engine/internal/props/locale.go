@@ -0,0 +1,83 @@
+package props
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NegotiateLocale picks the locale for a request from its Accept-Language
+// header. With supported locales configured, the first entry is the default
+// and the best-ranked language that matches one of them wins — exactly
+// ("de-AT") or by primary subtag ("de" matches "de-DE"). Without them the
+// client's top preference is used as-is.
+func NegotiateLocale(acceptLanguage string, supported []string) string {
+	prefs := parseAcceptLanguage(acceptLanguage)
+
+	if len(supported) == 0 {
+		if len(prefs) == 0 {
+			return ""
+		}
+		return prefs[0]
+	}
+
+	for _, pref := range prefs {
+		for _, loc := range supported {
+			if strings.EqualFold(pref, loc) {
+				return loc
+			}
+		}
+		base := primarySubtag(pref)
+		for _, loc := range supported {
+			if strings.EqualFold(base, primarySubtag(loc)) {
+				return loc
+			}
+		}
+	}
+	return supported[0]
+}
+
+// parseAcceptLanguage returns language tags ordered by q-value, highest first.
+// "*" and tags with q=0 are dropped.
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var langs []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+		langs = append(langs, weighted{tag: tag, q: q})
+	}
+
+	// Stable: equal weights keep the client's order
+	sort.SliceStable(langs, func(i, j int) bool { return langs[i].q > langs[j].q })
+
+	tags := make([]string, len(langs))
+	for i, l := range langs {
+		tags[i] = l.tag
+	}
+	return tags
+}
+
+func primarySubtag(tag string) string {
+	base, _, _ := strings.Cut(tag, "-")
+	return base
+}
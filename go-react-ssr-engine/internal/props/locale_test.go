@@ -0,0 +1,26 @@
+package props
+
+import "testing"
+
+func TestNegotiateLocale(t *testing.T) {
+	tests := []struct {
+		header    string
+		supported []string
+		want      string
+	}{
+		{"de-AT,de;q=0.9,en;q=0.8", nil, "de-AT"},
+		{"", nil, ""},
+		{"", []string{"en-US", "de"}, "en-US"},
+		{"de-AT,de;q=0.9,en;q=0.8", []string{"en-US", "de"}, "de"},
+		{"fr;q=0.5,en-GB;q=0.8", []string{"en-US", "fr"}, "en-US"},
+		{"fr, en;q=0", []string{"en", "de"}, "en"},
+		{"*", []string{"de", "en"}, "de"},
+		{"EN-us", []string{"en-US"}, "en-US"},
+	}
+
+	for _, tt := range tests {
+		if got := NegotiateLocale(tt.header, tt.supported); got != tt.want {
+			t.Errorf("%q %v: expected %q, got %q", tt.header, tt.supported, tt.want, got)
+		}
+	}
+}
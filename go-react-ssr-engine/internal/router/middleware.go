@@ -5,6 +5,7 @@ import (
 	"crypto/sha1"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"log"
 	"strings"
 	"sync"
@@ -18,6 +19,17 @@ type HandlerFunc func(ctx *RequestContext) (string, error)
 // Middleware wraps a HandlerFunc and returns a new one.
 type Middleware func(HandlerFunc) HandlerFunc
 
+// BodyWriter is the response sink for streamed bodies.
+// Flush pushes everything written so far to the client.
+type BodyWriter interface {
+	io.Writer
+	Flush() error
+}
+
+// StreamFunc writes a response body incrementally. It runs after the
+// middleware chain has returned and the status line and headers are sent.
+type StreamFunc func(w BodyWriter) error
+
 // RequestContext carries per-request data through the middleware chain.
 type RequestContext struct {
 	Path       string
@@ -27,6 +39,10 @@ type RequestContext struct {
 	StatusCode int
 	Headers    map[string]string
 
+	// ClientIP is the client address, from X-Forwarded-For when the
+	// server trusts its proxy. Used to key per-client rate limits.
+	ClientIP string
+
 	// RequestID is a unique identifier for tracing this request through logs.
 	// Format: unix_nano in base36 — short, unique enough, zero allocation.
 	RequestID string
@@ -38,6 +54,14 @@ type RequestContext struct {
 	// SkipCache signals to the render handler to bypass cache.
 	// Set via ?nocache=1 query param or Cache-Control: no-cache header.
 	SkipCache bool
+
+	// Stream, when set by the handler, produces the body instead of the
+	// returned string. Middlewares that transform the body wrap it.
+	Stream StreamFunc
+
+	// SetCookies are Set-Cookie header values. Kept apart from Headers
+	// because a response can carry several of them.
+	SetCookies []string
 }
 
 func NewRequestContext(path string) *RequestContext {
@@ -128,12 +152,21 @@ func ETag() Middleware {
 				return html, err
 			}
 
+			// A streamed body isn't known until it has been sent,
+			// so there is nothing to hash. The response goes out without an ETag.
+			if ctx.Stream != nil {
+				return html, nil
+			}
+
 			// Generate ETag from content hash
 			hash := sha1.Sum([]byte(html))
 			etag := `W/"` + hex.EncodeToString(hash[:6]) + `"`
 
 			ctx.Headers["ETag"] = etag
-			ctx.Headers["Cache-Control"] = "public, max-age=0, must-revalidate"
+			// Handlers that chose their own caching policy (API routes) keep it.
+			if _, ok := ctx.Headers["Cache-Control"]; !ok {
+				ctx.Headers["Cache-Control"] = "public, max-age=0, must-revalidate"
+			}
 
 			return html, nil
 		}
@@ -167,6 +200,20 @@ func Gzip() Middleware {
 				return html, err
 			}
 
+			// Already encoded by the handler — don't compress twice.
+			if _, ok := ctx.Headers["Content-Encoding"]; ok {
+				return html, nil
+			}
+
+			// Streamed bodies are compressed on the fly. Size is unknown
+			// up front, but a streamed page is never a tiny response.
+			if ctx.Stream != nil {
+				ctx.Stream = gzipStream(ctx.Stream)
+				ctx.Headers["Content-Encoding"] = "gzip"
+				ctx.Headers["Vary"] = "Accept-Encoding"
+				return html, nil
+			}
+
 			// Skip compression for small responses
 			if len(html) < 1024 {
 				return html, nil
@@ -209,6 +256,42 @@ func compressGzip(data string) (string, error) {
 	return buf.String(), nil
 }
 
+// gzipStream wraps a StreamFunc so everything it writes is compressed.
+// Each Flush from the inner stream flushes the gzip block too — otherwise
+// early chunks would sit in the compressor and defeat streaming.
+func gzipStream(inner StreamFunc) StreamFunc {
+	return func(w BodyWriter) error {
+		gz := gzipWriterPool.Get().(*gzip.Writer)
+		gz.Reset(w)
+		defer gzipWriterPool.Put(gz)
+
+		err := inner(&gzipBodyWriter{gz: gz, w: w})
+		if closeErr := gz.Close(); err == nil {
+			err = closeErr
+		}
+		if flushErr := w.Flush(); err == nil {
+			err = flushErr
+		}
+		return err
+	}
+}
+
+type gzipBodyWriter struct {
+	gz *gzip.Writer
+	w  BodyWriter
+}
+
+func (g *gzipBodyWriter) Write(p []byte) (int, error) {
+	return g.gz.Write(p)
+}
+
+func (g *gzipBodyWriter) Flush() error {
+	if err := g.gz.Flush(); err != nil {
+		return err
+	}
+	return g.w.Flush()
+}
+
 // --- Request Timing ---
 
 // Timing adds a Server-Timing header with total render duration.
@@ -228,59 +311,3 @@ func Timing() Middleware {
 		}
 	}
 }
-
-// --- Rate Limiter ---
-
-// RateLimiter is a simple token bucket per-path rate limiter.
-// Prevents a single hot path from monopolizing the V8 worker pool.
-// Not per-IP (that's the reverse proxy's job) — this protects the engine itself.
-type RateLimiter struct {
-	mu       sync.Mutex
-	counters map[string]*rateBucket
-	limit    int           // max requests per window
-	window   time.Duration // window size
-}
-
-type rateBucket struct {
-	count  int
-	resets time.Time
-}
-
-func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
-	return &RateLimiter{
-		counters: make(map[string]*rateBucket),
-		limit:    limit,
-		window:   window,
-	}
-}
-
-// RateLimit rejects requests that exceed the per-path rate limit.
-// Returns 429 Too Many Requests with a Retry-After header.
-func (rl *RateLimiter) RateLimit() Middleware {
-	return func(next HandlerFunc) HandlerFunc {
-		return func(ctx *RequestContext) (string, error) {
-			if rl.isLimited(ctx.Path) {
-				ctx.StatusCode = 429
-				ctx.Headers["Retry-After"] = fmt.Sprintf("%d", int(rl.window.Seconds()))
-				return "<h1>429 Too Many Requests</h1>", nil
-			}
-			return next(ctx)
-		}
-	}
-}
-
-func (rl *RateLimiter) isLimited(path string) bool {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
-	now := time.Now()
-	bucket, exists := rl.counters[path]
-
-	if !exists || now.After(bucket.resets) {
-		rl.counters[path] = &rateBucket{count: 1, resets: now.Add(rl.window)}
-		return false
-	}
-
-	bucket.count++
-	return bucket.count > rl.limit
-}
@@ -1,6 +1,9 @@
 package router
 
 import (
+	"bytes"
+	"compress/gzip"
+	"io"
 	"testing"
 )
 
@@ -68,3 +71,69 @@ func TestRecoveryMiddleware(t *testing.T) {
 		t.Error("expected error HTML, got empty")
 	}
 }
+
+type flushRecorder struct {
+	bytes.Buffer
+	flushes int
+}
+
+func (f *flushRecorder) Flush() error {
+	f.flushes++
+	return nil
+}
+
+func TestStreamSkipsETag(t *testing.T) {
+	handler := func(ctx *RequestContext) (string, error) {
+		ctx.Stream = func(w BodyWriter) error { return nil }
+		return "", nil
+	}
+
+	ctx := NewRequestContext("/stream")
+	ETag()(handler)(ctx)
+
+	if _, ok := ctx.Headers["ETag"]; ok {
+		t.Error("expected no ETag on a streamed response")
+	}
+}
+
+func TestGzipStream(t *testing.T) {
+	handler := func(ctx *RequestContext) (string, error) {
+		ctx.Stream = func(w BodyWriter) error {
+			io.WriteString(w, "<head>")
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			io.WriteString(w, "<body>")
+			return w.Flush()
+		}
+		return "", nil
+	}
+
+	ctx := NewRequestContext("/stream")
+	ctx.AcceptGzip = true
+	Gzip()(handler)(ctx)
+
+	if ctx.Headers["Content-Encoding"] != "gzip" {
+		t.Fatalf("expected gzip encoding, got %q", ctx.Headers["Content-Encoding"])
+	}
+
+	out := &flushRecorder{}
+	if err := ctx.Stream(out); err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	if out.flushes < 2 {
+		t.Errorf("expected each chunk to be flushed through, got %d flushes", out.flushes)
+	}
+
+	r, err := gzip.NewReader(&out.Buffer)
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("decompress: %v", err)
+	}
+	if string(body) != "<head><body>" {
+		t.Errorf("expected <head><body>, got %q", body)
+	}
+}
@@ -0,0 +1,305 @@
+package router
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Limiter decides whether a request may proceed. Implementations keep
+// their own state per key (path, client IP, ...) and must be safe for
+// concurrent use.
+type Limiter interface {
+	Allow(ctx *RequestContext) Decision
+}
+
+// Decision is a Limiter's answer for one request, with what the
+// RateLimit-* response headers report.
+type Decision struct {
+	Allowed bool
+
+	// Limit is the request quota, Remaining what is left of it.
+	Limit     int
+	Remaining int
+
+	// Reset is how long until the quota is fully restored.
+	Reset time.Duration
+
+	// RetryAfter is how long a rejected client should wait.
+	RetryAfter time.Duration
+
+	// Policy describes the quota for the RateLimit-Policy header,
+	// e.g. "100;w=1".
+	Policy string
+}
+
+// RateLimit rejects requests the limiter denies with 429 Too Many Requests
+// and a Retry-After header. Every response carries RateLimit-Limit,
+// RateLimit-Remaining and RateLimit-Reset so clients can pace themselves.
+func RateLimit(l Limiter) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *RequestContext) (string, error) {
+			d := l.Allow(ctx)
+			ctx.Headers["RateLimit-Limit"] = strconv.Itoa(d.Limit)
+			ctx.Headers["RateLimit-Remaining"] = strconv.Itoa(d.Remaining)
+			ctx.Headers["RateLimit-Reset"] = strconv.Itoa(ceilSeconds(d.Reset))
+			if d.Policy != "" {
+				ctx.Headers["RateLimit-Policy"] = d.Policy
+			}
+
+			if !d.Allowed {
+				ctx.StatusCode = 429
+				ctx.Headers["Retry-After"] = strconv.Itoa(max(1, ceilSeconds(d.RetryAfter)))
+				return "<h1>429 Too Many Requests</h1>", nil
+			}
+			return next(ctx)
+		}
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// --- Keys ---
+
+// KeyFunc picks the bucket a request counts against.
+type KeyFunc func(ctx *RequestContext) string
+
+// KeyByPath shares one quota between all clients of a path.
+// Protects the V8 pool from a single hot route.
+func KeyByPath(ctx *RequestContext) string { return ctx.Path }
+
+// KeyByIP gives every client its own quota across all paths.
+func KeyByIP(ctx *RequestContext) string { return ctx.ClientIP }
+
+// KeyByIPAndPath gives every client its own quota per path.
+func KeyByIPAndPath(ctx *RequestContext) string { return ctx.ClientIP + " " + ctx.Path }
+
+// KeyFuncByName returns the KeyFunc for a config value:
+// "path", "ip" or "ip+path".
+func KeyFuncByName(name string) (KeyFunc, error) {
+	switch name {
+	case "", "path":
+		return KeyByPath, nil
+	case "ip":
+		return KeyByIP, nil
+	case "ip+path":
+		return KeyByIPAndPath, nil
+	}
+	return nil, fmt.Errorf("rate limit: unknown key %q (want path, ip or ip+path)", name)
+}
+
+// --- Token bucket ---
+
+// TokenBucket allows bursts of up to burst requests per key, refilled at
+// rate requests per second. A bucket that has refilled completely is the
+// same as no bucket at all, so idle buckets are evicted in the background
+// and memory tracks only the keys that were active recently.
+type TokenBucket struct {
+	rate  float64 // tokens per second
+	burst float64
+	key   KeyFunc
+
+	mu      sync.Mutex
+	buckets map[string]*tokens
+
+	rejected atomic.Uint64 // requests denied, for metrics
+
+	stop      chan struct{}
+	closeOnce sync.Once
+
+	// now is swapped in tests.
+	now func() time.Time
+}
+
+type tokens struct {
+	available float64
+	updated   time.Time
+}
+
+// NewTokenBucket creates a limiter and starts its eviction loop.
+// Call Close to stop it.
+func NewTokenBucket(rate float64, burst int, key KeyFunc) *TokenBucket {
+	tb := &TokenBucket{
+		rate:    rate,
+		burst:   float64(burst),
+		key:     key,
+		buckets: make(map[string]*tokens),
+		stop:    make(chan struct{}),
+		now:     time.Now,
+	}
+	go tb.evictLoop(max(tb.fillTime(), time.Second))
+	return tb
+}
+
+// Allow takes one token from the request's bucket.
+func (tb *TokenBucket) Allow(ctx *RequestContext) Decision {
+	key := tb.key(ctx)
+
+	tb.mu.Lock()
+	now := tb.now()
+	b, ok := tb.buckets[key]
+	if !ok {
+		b = &tokens{available: tb.burst, updated: now}
+		tb.buckets[key] = b
+	} else {
+		elapsed := now.Sub(b.updated).Seconds()
+		b.available = math.Min(tb.burst, b.available+elapsed*tb.rate)
+		b.updated = now
+	}
+
+	allowed := b.available >= 1
+	if allowed {
+		b.available--
+	}
+	available := b.available
+	tb.mu.Unlock()
+
+	d := Decision{
+		Allowed:   allowed,
+		Limit:     int(tb.burst),
+		Remaining: int(available),
+		Reset:     tb.secondsFor(tb.burst - available),
+		Policy:    fmt.Sprintf("%d;w=%d", int(tb.burst), max(1, ceilSeconds(tb.fillTime()))),
+	}
+	if !allowed {
+		tb.rejected.Add(1)
+		d.RetryAfter = tb.secondsFor(1 - available)
+	}
+	return d
+}
+
+// Rejected returns how many requests were denied.
+func (tb *TokenBucket) Rejected() uint64 {
+	return tb.rejected.Load()
+}
+
+// Len returns the number of tracked buckets. For metrics/debug.
+func (tb *TokenBucket) Len() int {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	return len(tb.buckets)
+}
+
+// Close stops the eviction loop. Safe to call more than once.
+func (tb *TokenBucket) Close() {
+	tb.closeOnce.Do(func() { close(tb.stop) })
+}
+
+// fillTime is how long an empty bucket takes to refill completely.
+func (tb *TokenBucket) fillTime() time.Duration {
+	return tb.secondsFor(tb.burst)
+}
+
+func (tb *TokenBucket) secondsFor(n float64) time.Duration {
+	return time.Duration(n / tb.rate * float64(time.Second))
+}
+
+func (tb *TokenBucket) evictLoop(every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			tb.evict()
+		case <-tb.stop:
+			return
+		}
+	}
+}
+
+// evict drops buckets that would have refilled completely by now.
+func (tb *TokenBucket) evict() {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	now := tb.now()
+	for key, b := range tb.buckets {
+		if b.available+now.Sub(b.updated).Seconds()*tb.rate >= tb.burst {
+			delete(tb.buckets, key)
+		}
+	}
+}
+
+// --- Fixed window ---
+
+// RateLimiter is a fixed-window per-path rate limiter: at most limit
+// requests per path in each window. Cheaper than TokenBucket but lets a
+// burst of 2x limit through across a window boundary.
+type RateLimiter struct {
+	mu        sync.Mutex
+	counters  map[string]*rateBucket
+	limit     int           // max requests per window
+	window    time.Duration // window size
+	lastSweep time.Time
+
+	rejected atomic.Uint64 // requests answered with 429, for metrics
+}
+
+type rateBucket struct {
+	count  int
+	resets time.Time
+}
+
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		counters:  make(map[string]*rateBucket),
+		limit:     limit,
+		window:    window,
+		lastSweep: time.Now(),
+	}
+}
+
+// RateLimit rejects requests that exceed the per-path rate limit.
+// Returns 429 Too Many Requests with a Retry-After header.
+func (rl *RateLimiter) RateLimit() Middleware {
+	return RateLimit(rl)
+}
+
+// Rejected returns how many requests were rate limited.
+func (rl *RateLimiter) Rejected() uint64 {
+	return rl.rejected.Load()
+}
+
+// Allow counts the request against its path's current window.
+func (rl *RateLimiter) Allow(ctx *RequestContext) Decision {
+	rl.mu.Lock()
+	now := time.Now()
+
+	// Windows that have ended are dead weight; drop them once per window
+	// so paths seen once don't stay in the map forever.
+	if now.Sub(rl.lastSweep) >= rl.window {
+		for path, b := range rl.counters {
+			if now.After(b.resets) {
+				delete(rl.counters, path)
+			}
+		}
+		rl.lastSweep = now
+	}
+
+	bucket, exists := rl.counters[ctx.Path]
+	if !exists || now.After(bucket.resets) {
+		bucket = &rateBucket{resets: now.Add(rl.window)}
+		rl.counters[ctx.Path] = bucket
+	}
+	bucket.count++
+	count, resets := bucket.count, bucket.resets
+	rl.mu.Unlock()
+
+	d := Decision{
+		Allowed:   count <= rl.limit,
+		Limit:     rl.limit,
+		Remaining: max(0, rl.limit-count),
+		Reset:     resets.Sub(now),
+		Policy:    fmt.Sprintf("%d;w=%d", rl.limit, max(1, ceilSeconds(rl.window))),
+	}
+	if !d.Allowed {
+		rl.rejected.Add(1)
+		d.RetryAfter = d.Reset
+	}
+	return d
+}
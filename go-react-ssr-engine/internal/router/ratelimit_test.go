@@ -0,0 +1,161 @@
+package router
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock drives a TokenBucket without sleeping.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestBucket(rate float64, burst int, key KeyFunc) (*TokenBucket, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	tb := NewTokenBucket(rate, burst, key)
+	tb.now = clock.now
+	return tb, clock
+}
+
+func TestTokenBucketBurstAndRefill(t *testing.T) {
+	tb, clock := newTestBucket(2, 3, KeyByPath)
+	defer tb.Close()
+	ctx := &RequestContext{Path: "/"}
+
+	for i := 0; i < 3; i++ {
+		if d := tb.Allow(ctx); !d.Allowed || d.Remaining != 2-i {
+			t.Fatalf("request %d: %+v, want allowed with %d remaining", i, d, 2-i)
+		}
+	}
+
+	d := tb.Allow(ctx)
+	if d.Allowed {
+		t.Fatal("4th request should be rejected")
+	}
+	if d.RetryAfter != 500*time.Millisecond {
+		t.Errorf("RetryAfter = %v, want 500ms", d.RetryAfter)
+	}
+	if d.Reset != 1500*time.Millisecond {
+		t.Errorf("Reset = %v, want 1.5s", d.Reset)
+	}
+
+	// Half a second refills one token at 2/s.
+	clock.advance(500 * time.Millisecond)
+	if d := tb.Allow(ctx); !d.Allowed {
+		t.Errorf("request after refill rejected: %+v", d)
+	}
+	if n := tb.Rejected(); n != 1 {
+		t.Errorf("Rejected() = %d, want 1", n)
+	}
+}
+
+func TestTokenBucketKeys(t *testing.T) {
+	tb, _ := newTestBucket(1, 1, KeyByIP)
+	defer tb.Close()
+
+	a := &RequestContext{Path: "/x", ClientIP: "10.0.0.1"}
+	b := &RequestContext{Path: "/x", ClientIP: "10.0.0.2"}
+
+	if !tb.Allow(a).Allowed || !tb.Allow(b).Allowed {
+		t.Fatal("each client should get its own bucket")
+	}
+	if tb.Allow(&RequestContext{Path: "/y", ClientIP: "10.0.0.1"}).Allowed {
+		t.Error("ip key should share the bucket across paths")
+	}
+}
+
+func TestTokenBucketEvictsIdle(t *testing.T) {
+	tb, clock := newTestBucket(10, 10, KeyByPath)
+	defer tb.Close()
+
+	tb.Allow(&RequestContext{Path: "/idle"})
+	clock.advance(500 * time.Millisecond)
+	for i := 0; i < 10; i++ {
+		tb.Allow(&RequestContext{Path: "/busy"})
+	}
+
+	// /idle has refilled after 1s; /busy needs another 0.5s.
+	clock.advance(600 * time.Millisecond)
+	tb.evict()
+	if n := tb.Len(); n != 1 {
+		t.Fatalf("Len() = %d after evict, want 1", n)
+	}
+
+	clock.advance(time.Second)
+	tb.evict()
+	if n := tb.Len(); n != 0 {
+		t.Errorf("Len() = %d after evict, want 0", n)
+	}
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	tb, _ := newTestBucket(1, 1, KeyByPath)
+	defer tb.Close()
+	handler := RateLimit(tb)(func(ctx *RequestContext) (string, error) {
+		return "ok", nil
+	})
+
+	ctx := NewRequestContext("/")
+	if body, _ := handler(ctx); body != "ok" {
+		t.Fatalf("first request body = %q", body)
+	}
+	for header, want := range map[string]string{
+		"RateLimit-Limit":     "1",
+		"RateLimit-Remaining": "0",
+		"RateLimit-Reset":     "1",
+		"RateLimit-Policy":    "1;w=1",
+	} {
+		if got := ctx.Headers[header]; got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	ctx = NewRequestContext("/")
+	handler(ctx)
+	if ctx.StatusCode != 429 || ctx.Headers["Retry-After"] != "1" {
+		t.Errorf("second request: status %d, Retry-After %q", ctx.StatusCode, ctx.Headers["Retry-After"])
+	}
+}
+
+func TestRateLimiterFixedWindow(t *testing.T) {
+	rl := NewRateLimiter(2, time.Minute)
+	handler := rl.RateLimit()(func(ctx *RequestContext) (string, error) {
+		return "ok", nil
+	})
+
+	for i := 0; i < 2; i++ {
+		ctx := NewRequestContext("/")
+		handler(ctx)
+		if ctx.StatusCode != 200 {
+			t.Fatalf("request %d rejected", i)
+		}
+	}
+
+	ctx := NewRequestContext("/")
+	handler(ctx)
+	if ctx.StatusCode != 429 || rl.Rejected() != 1 {
+		t.Errorf("3rd request: status %d, rejected %d", ctx.StatusCode, rl.Rejected())
+	}
+}
+
+func TestKeyFuncByName(t *testing.T) {
+	ctx := &RequestContext{Path: "/p", ClientIP: "1.2.3.4"}
+	for name, want := range map[string]string{
+		"":        "/p",
+		"path":    "/p",
+		"ip":      "1.2.3.4",
+		"ip+path": "1.2.3.4 /p",
+	} {
+		fn, err := KeyFuncByName(name)
+		if err != nil {
+			t.Fatalf("%q: %v", name, err)
+		}
+		if got := fn(ctx); got != want {
+			t.Errorf("%q key = %q, want %q", name, got, want)
+		}
+	}
+	if _, err := KeyFuncByName("cookie"); err == nil {
+		t.Error("unknown key should fail")
+	}
+}
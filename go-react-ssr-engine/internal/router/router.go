@@ -14,19 +14,39 @@ import (
 // Uses atomic.Pointer so the tree can be swapped on hot reload
 // without any locking in the request path.
 type Router struct {
-	cfg  *config.Config
-	tree atomic.Pointer[Tree]
+	cfg    *config.Config
+	tables atomic.Pointer[routeTables]
+}
+
+// routeTables is everything built from one scan of pagesDir.
+// Swapped as a unit so pages, API routes and error pages never disagree.
+type routeTables struct {
+	tree *Tree
+
+	// api holds pages/api handlers. Separate tree so "/api/..." pages and
+	// handlers never shadow each other and page-only code paths ignore them.
+	api *Tree
+
+	// errorPages maps a status code to its custom page (pages/404.tsx,
+	// pages/500.tsx). Error pages are rendered, never matched by URL.
+	errorPages map[int]*Route
+}
+
+// errorPageCodes are the status codes that can have a custom page.
+var errorPageCodes = map[string]int{
+	"/404": 404,
+	"/500": 500,
 }
 
 // New creates a router and builds the initial route tree from pagesDir.
 func New(cfg *config.Config) (*Router, error) {
 	r := &Router{cfg: cfg}
 
-	tree, err := r.buildTree()
+	tables, err := r.buildTree()
 	if err != nil {
 		return nil, err
 	}
-	r.tree.Store(tree)
+	r.tables.Store(tables)
 
 	return r, nil
 }
@@ -35,11 +55,11 @@ func New(cfg *config.Config) (*Router, error) {
 // In-flight requests keep using the old tree. New requests get the new one.
 // No locks, no blocking, no race conditions.
 func (r *Router) Rebuild() error {
-	tree, err := r.buildTree()
+	tables, err := r.buildTree()
 	if err != nil {
 		return err
 	}
-	r.tree.Store(tree)
+	r.tables.Store(tables)
 	return nil
 }
 
@@ -47,7 +67,18 @@ func (r *Router) Rebuild() error {
 // Returns the route, extracted params, and whether a match was found.
 // This is called on every HTTP request — must be fast.
 func (r *Router) Match(path string) (*Route, map[string]string, bool) {
-	tree := r.tree.Load()
+	tree := r.tables.Load().tree
+	route, params := tree.Match(path)
+	if route == nil {
+		return nil, nil, false
+	}
+	return route, params, true
+}
+
+// MatchAPI finds a pages/api handler for the given URL path.
+// Same semantics as Match, against the API route tree.
+func (r *Router) MatchAPI(path string) (*Route, map[string]string, bool) {
+	tree := r.tables.Load().api
 	route, params := tree.Match(path)
 	if route == nil {
 		return nil, nil, false
@@ -55,16 +86,33 @@ func (r *Router) Match(path string) (*Route, map[string]string, bool) {
 	return route, params, true
 }
 
+// ErrorPage returns the custom page for a status code, e.g. pages/404.tsx.
+// Its Pattern ("/404") is the route to render in the server bundle.
+func (r *Router) ErrorPage(code int) (*Route, bool) {
+	route, ok := r.tables.Load().errorPages[code]
+	return route, ok
+}
+
 // Routes returns all registered routes. Used for debug/logging only.
 func (r *Router) Routes() []string {
-	return r.collectRoutes()
+	return collectRoutes(r.tables.Load().tree)
+}
+
+// APIRoutes returns all registered API routes. Used for debug/logging only.
+func (r *Router) APIRoutes() []string {
+	return collectRoutes(r.tables.Load().api)
 }
 
-// buildTree scans pagesDir and constructs a new Tree.
+// buildTree scans pagesDir and constructs new page and API trees.
 // Same file-to-route logic as the bundler so routes always match bundles.
-func (r *Router) buildTree() (*Tree, error) {
-	tree := NewTree()
-	count := 0
+func (r *Router) buildTree() (*routeTables, error) {
+	tables := &routeTables{
+		tree:       NewTree(),
+		api:        NewTree(),
+		errorPages: make(map[int]*Route),
+	}
+	apiDir := filepath.Join(r.cfg.PagesDir, "api")
+	count, apiCount := 0, 0
 
 	err := filepath.Walk(r.cfg.PagesDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -74,18 +122,35 @@ func (r *Router) buildTree() (*Tree, error) {
 			return nil
 		}
 
+		// _app, _layout and helpers are composed by the bundler, not routed
+		base := filepath.Base(path)
+		if strings.HasPrefix(base, "_") {
+			return nil
+		}
+
 		ext := filepath.Ext(path)
-		if ext != ".tsx" && ext != ".jsx" {
+
+		// pages/api/** are request handlers — any JS/TS module
+		if strings.HasPrefix(path, apiDir+string(filepath.Separator)) {
+			switch ext {
+			case ".ts", ".js", ".tsx", ".jsx":
+				tables.api.Insert(filePathToRoute(path, r.cfg.PagesDir), path)
+				apiCount++
+			}
 			return nil
 		}
 
-		base := filepath.Base(path)
-		if strings.HasPrefix(base, "_") {
+		if ext != ".tsx" && ext != ".jsx" {
 			return nil
 		}
 
 		route := filePathToRoute(path, r.cfg.PagesDir)
-		tree.Insert(route, path)
+		if code, ok := errorPageCodes[route]; ok {
+			tables.errorPages[code] = &Route{Pattern: route, PagePath: path}
+			return nil
+		}
+
+		tables.tree.Insert(route, path)
 		count++
 
 		return nil
@@ -95,14 +160,13 @@ func (r *Router) buildTree() (*Tree, error) {
 		return nil, fmt.Errorf("router: scan failed: %w", err)
 	}
 
-	fmt.Printf("router: %d routes registered\n", count)
-	return tree, nil
+	fmt.Printf("router: %d routes registered, %d api routes\n", count, apiCount)
+	return tables, nil
 }
 
 // collectRoutes does an in-order traversal for debug output.
-func (r *Router) collectRoutes() []string {
+func collectRoutes(tree *Tree) []string {
 	var routes []string
-	tree := r.tree.Load()
 	collectNode(tree.root, "", &routes)
 	return routes
 }
@@ -117,6 +181,9 @@ func collectNode(n *node, prefix string, routes *[]string) {
 	if n.paramChild != nil {
 		collectNode(n.paramChild, prefix+"/"+n.paramChild.segment, routes)
 	}
+	if n.catchAllChild != nil {
+		collectNode(n.catchAllChild, prefix+"/"+n.catchAllChild.segment, routes)
+	}
 }
 
 // filePathToRoute mirrors bundler's logic exactly.
@@ -126,6 +193,9 @@ func filePathToRoute(filePath string, pagesDir string) string {
 	route := strings.TrimPrefix(filePath, pagesDir)
 	route = strings.TrimSuffix(route, filepath.Ext(route))
 	route = filepath.ToSlash(route)
+	route = strings.ReplaceAll(route, "[[...", "*")
+	route = strings.ReplaceAll(route, "]]", "?")
+	route = strings.ReplaceAll(route, "[...", "*")
 	route = strings.ReplaceAll(route, "[", ":")
 	route = strings.ReplaceAll(route, "]", "")
 
@@ -0,0 +1,84 @@
+package router
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thutasann/go-react-ssr-engine/internal/config"
+)
+
+func TestRouterSeparatesAPIRoutes(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		"index.tsx",
+		"api/hello.ts",
+		"api/users/[id].ts",
+		"api/_helpers.ts",
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, f)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte("export default function() {}"), 0644)
+	}
+
+	rt, err := New(&config.Config{PagesDir: dir})
+	if err != nil {
+		t.Fatalf("router: %v", err)
+	}
+
+	if _, _, ok := rt.Match("/api/hello"); ok {
+		t.Errorf("api handler should not be matched as a page")
+	}
+	if _, _, ok := rt.MatchAPI("/"); ok {
+		t.Errorf("page should not be matched as an api route")
+	}
+	if _, _, ok := rt.MatchAPI("/api/_helpers"); ok {
+		t.Errorf("underscore files should not be routed")
+	}
+
+	route, params, ok := rt.MatchAPI("/api/users/42")
+	if !ok {
+		t.Fatalf("expected /api/users/42 to match")
+	}
+	if route.Pattern != "/api/users/:id" || params["id"] != "42" {
+		t.Errorf("expected /api/users/:id with id=42, got %s %v", route.Pattern, params)
+	}
+
+	if got := len(rt.APIRoutes()); got != 2 {
+		t.Errorf("expected 2 api routes, got %d: %v", got, rt.APIRoutes())
+	}
+}
+
+func TestRouterErrorPages(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"index.tsx", "404.tsx", "_app.tsx", "docs/_layout.tsx", "docs/[[...slug]].tsx"} {
+		path := filepath.Join(dir, f)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte("export default function() {}"), 0644)
+	}
+
+	rt, err := New(&config.Config{PagesDir: dir})
+	if err != nil {
+		t.Fatalf("router: %v", err)
+	}
+
+	route, ok := rt.ErrorPage(404)
+	if !ok || route.Pattern != "/404" {
+		t.Fatalf("expected custom 404 page, got %v", route)
+	}
+	if _, ok := rt.ErrorPage(500); ok {
+		t.Errorf("expected no 500 page")
+	}
+
+	// Error pages, _app and _layout are never matched by URL
+	for _, path := range []string{"/404", "/_app", "/docs/_layout"} {
+		if route, _, ok := rt.Match(path); ok && route.Pattern != "/docs/*slug?" {
+			t.Errorf("%s: unexpected match %s", path, route.Pattern)
+		}
+	}
+
+	if route, _, ok := rt.Match("/docs"); !ok || route.Pattern != "/docs/*slug?" {
+		t.Errorf("/docs: expected optional catch-all match")
+	}
+}
@@ -19,6 +19,13 @@ type node struct {
 	// paramChild handles :param segments. Only one per level allowedd
 	// (same as Next.js - can't have [id].tsx and [slug].tsx
 	paramChild *node
+
+	// catchAllChild handles *slug ([...slug]) and *slug? ([[...slug]])
+	// segments. Always a leaf: it swallows every remaining segment.
+	catchAllChild *node
+
+	// optional is set on catch-all nodes that also match zero segments.
+	optional bool
 }
 
 // Route holds everything needed to render a matched page.
@@ -31,6 +38,10 @@ type Route struct {
 
 	// Params are the dynamic segment names in order, e.g. ["id"]
 	Params []string
+
+	// CatchAll is the name of the trailing catch-all param, e.g. "slug"
+	// for "/docs/*slug". Its value is the remaining path joined by "/".
+	CatchAll string
 }
 
 // Tree is the top-level router. Thread-safe for reads after Build().
@@ -51,13 +62,31 @@ func NewTree() *Tree {
 
 // Insert adds a route pattern to the tree.
 // Pattern format: "/posts/:id" (already converted from [id] by bundler).
+// Catch-alls are "/docs/*slug" and, optional, "/docs/*slug?"; anything
+// after a catch-all segment is ignored since it could never match.
 func (t *Tree) Insert(pattern string, pagePath string) {
 	segments := splitPath(pattern)
 	current := t.root
 
 	var params []string
+	var catchAll string
 
 	for _, seg := range segments {
+		if strings.HasPrefix(seg, "*") {
+			// Catch-all segment — terminal, store in catchAllChild
+			catchAll = strings.TrimSuffix(seg[1:], "?")
+			params = append(params, catchAll)
+			if current.catchAllChild == nil {
+				current.catchAllChild = &node{
+					segment:  seg,
+					children: make(map[string]*node),
+					optional: strings.HasSuffix(seg, "?"),
+				}
+			}
+			current = current.catchAllChild
+			break
+		}
+
 		if strings.HasPrefix(seg, ":") {
 			// Dynamic segment — store in paramChild
 			params = append(params, seg[1:]) // strip the ":"
@@ -87,52 +116,72 @@ func (t *Tree) Insert(pattern string, pagePath string) {
 		Pattern:  pattern,
 		PagePath: pagePath,
 		Params:   params,
+		CatchAll: catchAll,
 	}
 }
 
 // Match finds the route for a given URL path and extracts param values.
 // Returns nil if no route matches. Zero allocations on static routes.
+//
+// Precedence per level is static > :param > *catch-all. A more specific
+// branch that dead-ends falls back to the next one, so "/docs/a/b" reaches
+// "/docs/*slug" even when "/docs/:section" exists.
 func (t *Tree) Match(path string) (*Route, map[string]string) {
-	segments := splitPath(path)
-	current := t.root
-
-	// paramValues collected during traversal, allocated only if needed
-	var paramValues []string
+	route, paramValues := t.root.match(splitPath(path), nil)
+	if route == nil {
+		return nil, nil
+	}
 
-	for _, seg := range segments {
-		// Try static child first — exact match is always preferred
-		if child, exists := current.children[seg]; exists {
-			current = child
-			continue
+	// Build param map only if there are dynamic segments
+	var params map[string]string
+	if len(route.Params) > 0 {
+		params = make(map[string]string, len(route.Params))
+		for i, name := range route.Params {
+			if i < len(paramValues) {
+				params[name] = paramValues[i]
+			}
 		}
+	}
 
-		// Fall back to param child
-		if current.paramChild != nil {
-			current = current.paramChild
-			paramValues = append(paramValues, seg)
-			continue
-		}
+	return route, params
+}
 
-		// No match at this level
+// match walks segments below n, collecting dynamic values in order.
+func (n *node) match(segments []string, values []string) (*Route, []string) {
+	if len(segments) == 0 {
+		if n.handler != nil {
+			return n.handler, values
+		}
+		// [[...slug]] also matches its parent path, with an empty value
+		if c := n.catchAllChild; c != nil && c.optional {
+			return c.handler, append(values, "")
+		}
 		return nil, nil
 	}
 
-	if current.handler == nil {
-		return nil, nil
+	seg := segments[0]
+
+	// Try static child first — exact match is always preferred
+	if child, exists := n.children[seg]; exists {
+		if route, v := child.match(segments[1:], values); route != nil {
+			return route, v
+		}
 	}
 
-	// Build param map only if there are dynamic segments
-	var params map[string]string
-	if len(current.handler.Params) > 0 {
-		params = make(map[string]string, len(current.handler.Params))
-		for i, name := range current.handler.Params {
-			if i < len(paramValues) {
-				params[name] = paramValues[i]
-			}
+	// Fall back to param child
+	if n.paramChild != nil {
+		if route, v := n.paramChild.match(segments[1:], append(values, seg)); route != nil {
+			return route, v
 		}
 	}
 
-	return current.handler, params
+	// Catch-all takes whatever is left
+	if c := n.catchAllChild; c != nil {
+		return c.handler, append(values, strings.Join(segments, "/"))
+	}
+
+	// No match at this level
+	return nil, nil
 }
 
 // splitPath turns "/posts/123" into ["posts", "123"].
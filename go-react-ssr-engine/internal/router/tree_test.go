@@ -87,6 +87,53 @@ func TestStaticOverParam(t *testing.T) {
 	}
 }
 
+func TestCatchAllRoutes(t *testing.T) {
+	tree := NewTree()
+	tree.Insert("/docs/*slug", "pages/docs/[...slug].tsx")
+	tree.Insert("/docs/:section/intro", "pages/docs/[section]/intro.tsx")
+	tree.Insert("/shop/*path?", "pages/shop/[[...path]].tsx")
+
+	tests := []struct {
+		path     string
+		wantPage string
+		param    string
+		value    string
+	}{
+		{"/docs/a", "pages/docs/[...slug].tsx", "slug", "a"},
+		{"/docs/a/b/c", "pages/docs/[...slug].tsx", "slug", "a/b/c"},
+		// Param branch wins when it matches fully...
+		{"/docs/guide/intro", "pages/docs/[section]/intro.tsx", "section", "guide"},
+		// ...and falls back to the catch-all when it dead-ends
+		{"/docs/guide/outro", "pages/docs/[...slug].tsx", "slug", "guide/outro"},
+		{"/shop", "pages/shop/[[...path]].tsx", "path", ""},
+		{"/shop/men/shoes", "pages/shop/[[...path]].tsx", "path", "men/shoes"},
+	}
+
+	for _, tt := range tests {
+		route, params := tree.Match(tt.path)
+		if route == nil {
+			t.Errorf("%s: expected match", tt.path)
+			continue
+		}
+		if route.PagePath != tt.wantPage {
+			t.Errorf("%s: expected page %s, got %s", tt.path, tt.wantPage, route.PagePath)
+		}
+		if got, ok := params[tt.param]; !ok || got != tt.value {
+			t.Errorf("%s: expected %s=%q, got %v", tt.path, tt.param, tt.value, params)
+		}
+	}
+
+	// Required catch-all needs at least one segment
+	if route, _ := tree.Match("/docs"); route != nil {
+		t.Errorf("/docs: expected no match, got %s", route.PagePath)
+	}
+
+	route, _ := tree.Match("/docs/x")
+	if route.CatchAll != "slug" {
+		t.Errorf("expected CatchAll=slug, got %q", route.CatchAll)
+	}
+}
+
 func BenchmarkTreeMatch(b *testing.B) {
 	tree := NewTree()
 	// Simulate a medium-size app with 50 routes
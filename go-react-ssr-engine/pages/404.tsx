@@ -0,0 +1,12 @@
+import React from 'react';
+
+// Rendered with status 404 for unmatched paths and notFound props.
+export default function NotFound() {
+  return (
+    <div style={{ padding: '2rem', fontFamily: 'system-ui' }}>
+      <h1>Page not found</h1>
+      <p>There is nothing at this address.</p>
+      <a href='/'>← Home</a>
+    </div>
+  );
+}
@@ -1,8 +1,13 @@
 import React from 'react';
+import Head from 'reactgo/head';
 
 export default function About(props) {
   return (
     <div style={{ padding: '2rem', fontFamily: 'system-ui' }}>
+      <Head>
+        <title>About · reactgo</title>
+        <meta name='description' content='Go-powered React SSR with SPA transitions.' />
+      </Head>
       <h1>About</h1>
       <p>Go-powered React SSR with SPA transitions.</p>
       <a href='/'>← Home</a>
@@ -0,0 +1,9 @@
+// GET /api/hello?name=world -> {"message":"hello world"}
+export default function handler(req, res) {
+  if (req.method !== 'GET') {
+    res.setHeader('Allow', 'GET');
+    return res.status(405).json({ error: 'method not allowed' });
+  }
+  res.setHeader('Cache-Control', 'no-store');
+  res.json({ message: 'hello ' + (req.query.name || 'world') });
+}
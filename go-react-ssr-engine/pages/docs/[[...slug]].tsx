@@ -0,0 +1,16 @@
+import React from 'react';
+
+// Matches /docs, /docs/routing, /docs/routing/catch-all, ...
+export default function Docs(props) {
+  const slug = props.slug || [];
+  return (
+    <div>
+      <h1>{slug.length ? slug.join(' / ') : 'Docs'}</h1>
+      <p>Segments: {JSON.stringify(slug)}</p>
+    </div>
+  );
+}
+
+export function getServerSideProps(context) {
+  return { props: { slug: context.params.slug } };
+}
@@ -0,0 +1,15 @@
+import React from 'react';
+
+// Wraps every page under pages/docs/.
+export default function DocsLayout({ children }) {
+  return (
+    <div style={{ display: 'flex', gap: '2rem', padding: '2rem', fontFamily: 'system-ui' }}>
+      <nav style={{ display: 'flex', flexDirection: 'column', gap: '0.5rem' }}>
+        <a href='/docs'>Overview</a>
+        <a href='/docs/routing'>Routing</a>
+        <a href='/docs/routing/catch-all'>Catch-all routes</a>
+      </nav>
+      <main>{children}</main>
+    </div>
+  );
+}
@@ -15,10 +15,25 @@ export default function Post(props) {
 }
 
 export function getServerSideProps(context) {
+  const id = context.params ? context.params.id : 'unknown';
   return {
     props: {
-      id: context.params ? context.params.id : 'unknown',
+      id,
       timestamp: new Date().toISOString(),
     },
+    head: {
+      title: `Post ${id}`,
+      meta: [
+        { name: 'description', content: `Post number ${id}` },
+        { property: 'og:title', content: `Post ${id}` },
+      ],
+    },
+  };
+}
+
+// Used by `make export` to prerender these posts as static HTML.
+export function getStaticPaths() {
+  return {
+    paths: [{ params: { id: '1' } }, { params: { id: '2' } }, '/posts/42'],
   };
 }
@@ -1,6 +1,7 @@
 package html
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 )
@@ -25,6 +26,15 @@ type Document struct {
 	PropsJSON    string // Serialized props for client hydration
 	ClientScript string // path to client JS bundle
 	Lang         string
+
+	// ClientOnly marks a shell whose body the server couldn't render
+	// (e.g. the render timed out). The client renders from scratch
+	// instead of hydrating whatever is in the root div.
+	ClientOnly bool
+
+	// HeadJSON is the page head from getServerSideProps, embedded so the
+	// client runtime can keep <head> in sync on navigation. Set by SetPageHead.
+	HeadJSON string
 }
 
 // NewDocument creats a document with defaults.
@@ -35,6 +45,18 @@ func NewDocument() *Document {
 	}
 }
 
+// SetPageHead applies head content returned by getServerSideProps and
+// records it for the client.
+func (d *Document) SetPageHead(h *HeadData) {
+	if h == nil {
+		return
+	}
+	d.Head.Apply(h)
+	if data, err := json.Marshal(h); err == nil {
+		d.HeadJSON = string(data)
+	}
+}
+
 // Render produces the final HTML string sent to the browser.
 // String builder — no html/template parsing overhead.
 // Benchmarks at ~2μs for a typical page.
@@ -42,6 +64,20 @@ func (d *Document) Render() string {
 	var sb strings.Builder
 	sb.Grow(4096) // most pages fit in 4KB
 
+	sb.WriteString(d.RenderHead())
+	sb.WriteString(d.BodyHTML)
+	sb.WriteString(d.RenderTail())
+
+	return sb.String()
+}
+
+// RenderHead produces everything before the SSR HTML: doctype, <head>,
+// and the opening root div. Streaming responses flush this immediately so
+// the browser can fetch stylesheets and preloads while React is rendering.
+func (d *Document) RenderHead() string {
+	var sb strings.Builder
+	sb.Grow(1024)
+
 	sb.WriteString("<!DOCTYPE html>\n")
 	sb.WriteString(fmt.Sprintf("<html lang=\"%s\">\n", d.Lang))
 
@@ -57,7 +93,16 @@ func (d *Document) Render() string {
 	// Root div — React hydrate targets this element.
 	// ID must match the client hydration script below.
 	sb.WriteString("  <div id=\"__reactgo\">")
-	sb.WriteString(d.BodyHTML)
+
+	return sb.String()
+}
+
+// RenderTail produces everything after the SSR HTML: the closing root div,
+// hydration data, client bundle, and closing tags.
+func (d *Document) RenderTail() string {
+	var sb strings.Builder
+	sb.Grow(512)
+
 	sb.WriteString("</div>\n")
 
 	// Hydration data — embedded as JSON in a script tag.
@@ -68,6 +113,14 @@ func (d *Document) Render() string {
 		sb.WriteString(d.PropsJSON)
 		sb.WriteString("</script>\n")
 	}
+	if d.HeadJSON != "" {
+		sb.WriteString("  <script>window.__REACTGO_HEAD__=")
+		sb.WriteString(d.HeadJSON)
+		sb.WriteString("</script>\n")
+	}
+	if d.ClientOnly {
+		sb.WriteString("  <script>window.__REACTGO_CSR__=true</script>\n")
+	}
 
 	// Client bundle — type=module for ES module format (tree-shaking, modern syntax).
 	// Loaded after SSR HTML is already painted — user sees content instantly,
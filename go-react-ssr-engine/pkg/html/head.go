@@ -2,9 +2,17 @@ package html
 
 import (
 	"fmt"
+	stdhtml "html"
 	"strings"
 )
 
+// DefaultTitle is the <title> of pages that don't set one.
+const DefaultTitle = "reactgo"
+
+// ManagedAttr marks tags set by a page. The client runtime owns them:
+// it updates or removes them by key on client-side navigation.
+const ManagedAttr = "data-reactgo-head"
+
 // Head manages <head> tag contents per-request.
 // Each page can set its own title, meta tags, scripts, styles.
 // Accumulated during render, flushed once into the HTML document.
@@ -17,9 +25,13 @@ type Head struct {
 }
 
 type MetaTag struct {
-	Name     string // "description", "viewport", etc.
-	Property string // "og:title", "og:image" — used instead of Name for OpenGraph
-	Content  string
+	Name     string `json:"name,omitempty"`     // "description", "viewport", etc.
+	Property string `json:"property,omitempty"` // "og:title", "og:image" — used instead of Name for OpenGraph
+	Content  string `json:"content"`
+
+	// Key identifies the tag for deduplication. Defaults to its name or
+	// property, so a page's "description" replaces any earlier one.
+	Key string `json:"key,omitempty"`
 }
 
 type Script struct {
@@ -34,21 +46,94 @@ type Style struct {
 }
 
 type LinkTag struct {
-	Rel  string // "stylesheet", "preload", "icon"
-	Href string
-	As   string // "script", "style" — for preload hints
+	Rel  string `json:"rel"` // "stylesheet", "preload", "icon"
+	Href string `json:"href"`
+	As   string `json:"as,omitempty"` // "script", "style" — for preload hints
+
+	// Key identifies the tag for deduplication. Defaults to rel and href;
+	// set it to e.g. "canonical" to let later tags replace earlier ones.
+	Key string `json:"key,omitempty"`
+}
+
+// HeadData is head content set by a page — returned as "head" from
+// getServerSideProps or rendered by <Head> components.
+type HeadData struct {
+	Title string    `json:"title,omitempty"`
+	Meta  []MetaTag `json:"meta,omitempty"`
+	Link  []LinkTag `json:"link,omitempty"`
+}
+
+// metaKey and linkKey mirror the client head runtime (see bundler),
+// which reconciles the same tags by the same keys.
+func metaKey(m MetaTag) string {
+	switch {
+	case m.Key != "":
+		return m.Key
+	case m.Property != "":
+		return "property:" + m.Property
+	default:
+		return "name:" + m.Name
+	}
+}
+
+func linkKey(l LinkTag) string {
+	if l.Key != "" {
+		return l.Key
+	}
+	return "link:" + l.Rel + ":" + l.Href
 }
 
 // NewHead returns a Head with sensible defaults every page needs.
 func NewHead() *Head {
 	return &Head{
-		Title: "reactgo",
+		Title: DefaultTitle,
 		MetaTags: []MetaTag{
 			{Name: "viewport", Content: "width=device-width, initial-scale=1"},
 		},
 	}
 }
 
+// Apply merges page head content. Later values win: a tag with the same key
+// as an existing one replaces it in place, so applying several sources in
+// order (props, then components) never duplicates a tag.
+func (h *Head) Apply(d *HeadData) {
+	if d == nil {
+		return
+	}
+	if d.Title != "" {
+		h.Title = d.Title
+	}
+
+	for _, m := range d.Meta {
+		m.Key = metaKey(m)
+		h.MetaTags = replaceOrAppend(h.MetaTags, m, metaKey)
+	}
+	for _, l := range d.Link {
+		l.Key = linkKey(l)
+		h.LinkTags = replaceOrAppend(h.LinkTags, l, linkKey)
+	}
+}
+
+func replaceOrAppend[T any](tags []T, tag T, key func(T) string) []T {
+	k := key(tag)
+	for i := range tags {
+		if key(tags[i]) == k {
+			tags[i] = tag
+			return tags
+		}
+	}
+	return append(tags, tag)
+}
+
+// managed renders the marker attribute for tags set by a page.
+// Built-in tags (viewport, preloads) have no Key and stay unmarked.
+func managed(key string) string {
+	if key == "" {
+		return ""
+	}
+	return fmt.Sprintf(" %s=\"%s\"", ManagedAttr, stdhtml.EscapeString(key))
+}
+
 // Render serializes the Head into HTML string for injection into <head>.
 // No template engine — string builder is faster and has zero allocations
 // after the initial grow.
@@ -56,26 +141,29 @@ func (h *Head) Render() string {
 	var sb strings.Builder
 	sb.Grow(512) // typical <head> is 200-400 bytes
 
+	// Values can come from page props, so everything is escaped.
+	esc := stdhtml.EscapeString
+
 	// Title
-	sb.WriteString(fmt.Sprintf("  <title>%s</title>\n", h.Title))
+	sb.WriteString(fmt.Sprintf("  <title>%s</title>\n", esc(h.Title)))
 
 	// Meta tags
 	for _, m := range h.MetaTags {
 		if m.Property != "" {
-			sb.WriteString(fmt.Sprintf("  <meta property=\"%s\" content=\"%s\">\n", m.Property, m.Content))
+			sb.WriteString(fmt.Sprintf("  <meta property=\"%s\" content=\"%s\"%s>\n", esc(m.Property), esc(m.Content), managed(m.Key)))
 		} else {
-			sb.WriteString(fmt.Sprintf("  <meta name=\"%s\" content=\"%s\">\n", m.Name, m.Content))
+			sb.WriteString(fmt.Sprintf("  <meta name=\"%s\" content=\"%s\"%s>\n", esc(m.Name), esc(m.Content), managed(m.Key)))
 		}
 	}
 
 	// Preload hints — tell browser to start downloading before parser reaches the tag.
 	// Critical for hydration JS — shaves 50-100ms on slow connections.
 	for _, l := range h.LinkTags {
-		attrs := fmt.Sprintf("rel=\"%s\" href=\"%s\"", l.Rel, l.Href)
+		attrs := fmt.Sprintf("rel=\"%s\" href=\"%s\"", esc(l.Rel), esc(l.Href))
 		if l.As != "" {
-			attrs += fmt.Sprintf(" as=\"%s\"", l.As)
+			attrs += fmt.Sprintf(" as=\"%s\"", esc(l.As))
 		}
-		sb.WriteString(fmt.Sprintf("  <link %s>\n", attrs))
+		sb.WriteString(fmt.Sprintf("  <link %s%s>\n", attrs, managed(l.Key)))
 	}
 
 	// Stylesheets
@@ -0,0 +1,58 @@
+package html
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHeadApplyDedupesByKey(t *testing.T) {
+	h := NewHead()
+	h.Apply(&HeadData{
+		Title: "From props",
+		Meta: []MetaTag{
+			{Name: "description", Content: "props"},
+			{Property: "og:title", Content: "props"},
+		},
+		Link: []LinkTag{{Rel: "canonical", Href: "/a", Key: "canonical"}},
+	})
+	h.Apply(&HeadData{
+		Title: "From component",
+		Meta: []MetaTag{
+			{Name: "description", Content: "component"},
+			{Name: "viewport", Content: "width=500"},
+		},
+		Link: []LinkTag{{Rel: "canonical", Href: "/b", Key: "canonical"}},
+	})
+
+	if h.Title != "From component" {
+		t.Errorf("expected later title to win, got %q", h.Title)
+	}
+	if len(h.MetaTags) != 3 {
+		t.Fatalf("expected viewport, description and og:title, got %+v", h.MetaTags)
+	}
+	if h.MetaTags[0].Content != "width=500" || h.MetaTags[1].Content != "component" {
+		t.Errorf("expected tags replaced in place, got %+v", h.MetaTags)
+	}
+	if len(h.LinkTags) != 1 || h.LinkTags[0].Href != "/b" {
+		t.Errorf("expected one canonical link, got %+v", h.LinkTags)
+	}
+}
+
+func TestHeadRenderEscapesAndMarksManagedTags(t *testing.T) {
+	h := NewHead()
+	h.Apply(&HeadData{
+		Title: `</title><script>alert(1)</script>`,
+		Meta:  []MetaTag{{Property: "og:title", Content: `"quoted"`}},
+	})
+	out := h.Render()
+
+	if strings.Contains(out, "<script>") {
+		t.Errorf("title not escaped: %s", out)
+	}
+	if !strings.Contains(out, `<meta property="og:title" content="&#34;quoted&#34;" data-reactgo-head="property:og:title">`) {
+		t.Errorf("expected managed og:title tag, got %s", out)
+	}
+	if !strings.Contains(out, `<meta name="viewport" content="width=device-width, initial-scale=1">`) {
+		t.Errorf("built-in viewport should stay unmarked, got %s", out)
+	}
+}
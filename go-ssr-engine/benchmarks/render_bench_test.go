@@ -1 +1,104 @@
 package benchmarks
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/thutasann/go-ssr-engine/internal/engine"
+)
+
+func compile(b *testing.B, src string) *engine.Template {
+	b.Helper()
+	tpl, err := engine.Compile([]byte(src))
+	if err != nil {
+		b.Fatal(err)
+	}
+	return tpl
+}
+
+func render(b *testing.B, tpl *engine.Template, ctx *engine.RenderContext) {
+	b.Helper()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := tpl.RenderTo(io.Discard, ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkRenderSimpleVars is the baseline: text and flat variables only.
+// Block opcodes must not slow it down.
+func BenchmarkRenderSimpleVars(b *testing.B) {
+	tpl := compile(b, "Hello {{first}} {{last}}")
+	ctx := engine.NewRenderContext(map[string]string{"first": "Ada", "last": "Lovelace"}, tpl, tpl.VarIndex())
+	render(b, tpl, &ctx)
+}
+
+func BenchmarkRenderNestedPaths(b *testing.B) {
+	tpl := compile(b, "<p>{{user.name}} ({{user.address.city}})</p>")
+	ctx := tpl.NewContext(map[string]any{
+		"user": map[string]any{
+			"name":    "Ada",
+			"address": map[string]any{"city": "London"},
+		},
+	})
+	render(b, tpl, &ctx)
+}
+
+func BenchmarkRenderIfElse(b *testing.B) {
+	tpl := compile(b, "{{#if admin}}<b>{{name}}</b>{{else}}{{name}}{{/if}}")
+	ctx := tpl.NewContext(map[string]any{"admin": true, "name": "Ada"})
+	render(b, tpl, &ctx)
+}
+
+func benchmarkEach(b *testing.B, n int) {
+	tpl := compile(b, "<ul>{{#each items}}<li>{{@index}}: {{name}} / {{../title}}</li>{{/each}}</ul>")
+	items := make([]any, n)
+	for i := range items {
+		items[i] = map[string]any{"name": fmt.Sprintf("item-%d", i)}
+	}
+	ctx := tpl.NewContext(map[string]any{"title": "List", "items": items})
+	render(b, tpl, &ctx)
+}
+
+func BenchmarkRenderEach10(b *testing.B)  { benchmarkEach(b, 10) }
+func BenchmarkRenderEach100(b *testing.B) { benchmarkEach(b, 100) }
+
+func BenchmarkRenderNestedEach(b *testing.B) {
+	tpl := compile(b, "{{#each rows}}<tr>{{#each cells}}<td>{{this}}</td>{{/each}}</tr>{{/each}}")
+	rows := make([]any, 10)
+	for i := range rows {
+		rows[i] = map[string]any{"cells": []string{"a", "b", "c", "d", "e"}}
+	}
+	ctx := tpl.NewContext(map[string]any{"rows": rows})
+	render(b, tpl, &ctx)
+}
+
+// BenchmarkNewContext measures resolving nested data into a RenderContext,
+// the per-request cost paid before rendering.
+func BenchmarkNewContext(b *testing.B) {
+	tpl := compile(b, "<ul>{{#each items}}<li>{{name}}</li>{{/each}}</ul>")
+	items := make([]any, 10)
+	for i := range items {
+		items[i] = map[string]any{"name": "item"}
+	}
+	data := map[string]any{"items": items}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = tpl.NewContext(data)
+	}
+}
+
+func BenchmarkCompile(b *testing.B) {
+	src := []byte("<h1>{{title}}</h1>{{#if user}}<p>{{user.name}}</p>{{/if}}<ul>{{#each items}}<li>{{@index}} {{name}}</li>{{else}}<li>none</li>{{/each}}</ul>")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := engine.Compile(src); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
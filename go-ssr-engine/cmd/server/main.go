@@ -1,13 +1,17 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"runtime"
 	"syscall"
+	"time"
 
-	"github.com/thutasann/go-ssr-engine/internal/engine"
+	"github.com/thutasann/go-ssr-engine/internal/cache"
 	apphttp "github.com/thutasann/go-ssr-engine/internal/http"
 	"github.com/thutasann/go-ssr-engine/internal/worker"
 )
@@ -16,15 +20,28 @@ func main() {
 	// Use all CPUS
 	runtime.GOMAXPROCS(runtime.NumCPU())
 
-	tpl, err := engine.Compile([]byte("Hello {{first}} {{last}}"))
+	dir := flag.String("templates", "templates", "template directory")
+	dev := flag.Bool("dev", false, "recompile templates when they change")
+	timeout := flag.Duration("render-timeout", time.Second, "per-request render deadline, queue wait included")
+	flag.Parse()
+
+	// Compile every template up front: a broken one fails startup
+	templates, err := cache.New(os.DirFS(*dir), ".html")
 	if err != nil {
 		log.Fatal(err)
 	}
+	log.Printf("Compiled %d templates from %s", templates.Len(), *dir)
 
-	// Build variable name -> index map from compiler
-	varNameToIndex := map[string]uint16{
-		"first": 0,
-		"last":  1,
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if *dev {
+		go templates.Watch(ctx, 250*time.Millisecond, func(err error) {
+			if err != nil {
+				log.Printf("Template reload failed, keeping previous version:\n%v", err)
+				return
+			}
+			log.Println("Templates reloaded")
+		})
 	}
 
 	// Worker pool sizing strategy:
@@ -36,12 +53,16 @@ func main() {
 	pool.Start()
 
 	handler := &apphttp.Handler{
-		Pool:           pool,
-		Tpl:            tpl,
-		VarNameToIndex: varNameToIndex,
+		Pool:      pool,
+		Templates: templates,
+		Timeout:   *timeout,
 	}
 
-	server := apphttp.NewServer(":8080", handler)
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", pool.Metrics().Handler())
+	mux.Handle("/", handler)
+
+	server := apphttp.NewServer(":8080", mux)
 
 	go func() {
 		log.Println("Server running on :8080")
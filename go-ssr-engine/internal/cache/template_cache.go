@@ -1 +1,157 @@
 package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/thutasann/go-ssr-engine/internal/engine"
+)
+
+// TemplateCache compiles every template in a directory and serves them by
+// name: the path without extension, e.g. "index" or "blog/post".
+//
+// Files whose name starts with _ are partials and layouts. They aren't
+// served, only pulled in by {{> _nav}} or {{extends "_layout"}}, and
+// are compiled into every template that uses them.
+//
+// Get is lock-free: the compiled set is swapped atomically on reload, so
+// requests see either the old templates or the new ones, never a mix.
+type TemplateCache struct {
+	fsys fs.FS
+	ext  string
+
+	templates atomic.Pointer[map[string]*engine.Template]
+
+	// reload serializes Reload and guards stamped; Get never takes it.
+	reload  sync.Mutex
+	stamped string // stamp() of the files at the last reload
+}
+
+// New compiles all templates with extension ext (".html") in fsys.
+// Fails, listing every error, if any template doesn't compile.
+func New(fsys fs.FS, ext string) (*TemplateCache, error) {
+	c := &TemplateCache{fsys: fsys, ext: ext}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Get returns the compiled template for name.
+func (c *TemplateCache) Get(name string) (*engine.Template, bool) {
+	tpl, ok := (*c.templates.Load())[name]
+	return tpl, ok
+}
+
+// Len returns the number of templates served.
+func (c *TemplateCache) Len() int {
+	return len(*c.templates.Load())
+}
+
+// Reload recompiles every template and swaps them in together. On any
+// error the current templates stay in place and the errors are returned.
+func (c *TemplateCache) Reload() error {
+	c.reload.Lock()
+	defer c.reload.Unlock()
+
+	// Taken before reading, so an edit made during the reload is seen
+	// by the next Watch tick.
+	c.stamped, _ = c.stamp()
+
+	templates := make(map[string]*engine.Template)
+	var errs []error
+	err := fs.WalkDir(c.fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(p) != c.ext || strings.HasPrefix(d.Name(), "_") {
+			return nil
+		}
+		name := strings.TrimSuffix(p, c.ext)
+		src, err := fs.ReadFile(c.fsys, p)
+		if err != nil {
+			return err
+		}
+		tpl, err := engine.CompileWith(src, c.load)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("template %q: %w", name, err))
+			return nil
+		}
+		templates[name] = tpl
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("cache: load templates: %w", err)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	c.templates.Store(&templates)
+	return nil
+}
+
+// load is the engine.Loader for partials and layouts.
+func (c *TemplateCache) load(name string) ([]byte, error) {
+	file := name + c.ext
+	if !fs.ValidPath(file) {
+		return nil, fmt.Errorf("invalid template name %q", name)
+	}
+	src, err := fs.ReadFile(c.fsys, file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("template %q not found (no %s)", name, file)
+	}
+	return src, err
+}
+
+// Watch polls for changed, added or removed template files every interval
+// and reloads when there are any, until ctx is done. For development;
+// onReload is called with the result of each reload.
+func (c *TemplateCache) Watch(ctx context.Context, interval time.Duration, onReload func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		stamp, err := c.stamp()
+		if err != nil {
+			continue
+		}
+		c.reload.Lock()
+		changed := stamp != c.stamped
+		c.reload.Unlock()
+		if changed {
+			onReload(c.Reload())
+		}
+	}
+}
+
+// stamp summarizes the name, size and modification time of every template
+// file; it changes whenever one of them does.
+func (c *TemplateCache) stamp() (string, error) {
+	var b strings.Builder
+	err := fs.WalkDir(c.fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(p) != c.ext {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, "%s %d %d\n", p, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	return b.String(), err
+}
@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func render(t *testing.T, c *TemplateCache, name string, data map[string]any) string {
+	t.Helper()
+	tpl, ok := c.Get(name)
+	if !ok {
+		t.Fatalf("template %q not found", name)
+	}
+	ctx := tpl.NewContext(data)
+	out, err := tpl.RenderBytes(&ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestPartialsAndLayouts(t *testing.T) {
+	fsys := fstest.MapFS{
+		"_layout.html":     {Data: []byte(`<title>{{#block "title"}}Site{{/block}}</title><body>{{> _nav}}{{#block "main"}}empty{{/block}}</body>`)},
+		"_nav.html":        {Data: []byte(`<a href="{{home}}">home</a>`)},
+		"_section.html":    {Data: []byte(`{{extends "_layout"}}{{#block "main"}}<section>{{#block "body"}}{{/block}}</section>{{/block}}`)},
+		"index.html":       {Data: []byte("{{extends \"_layout\"}}\n{{#block \"main\"}}<p>Hello {{name}}</p>{{/block}}\n")},
+		"blog/post.html":   {Data: []byte(`{{extends "_section"}}{{#block "title"}}{{title}}{{/block}}{{#block "body"}}{{#each tags}}{{> blog/_tag}}{{/each}}{{/block}}`)},
+		"blog/_tag.html":   {Data: []byte(`<i>{{this}}</i>`)},
+		"plain.html":       {Data: []byte(`{{> _nav}}`)},
+		"ignored.txt":      {Data: []byte(`{{`)},
+		"partials/_x.html": {Data: []byte(`{{#if}}`)}, // never compiled on its own
+	}
+	c, err := New(fsys, ".html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Len() != 3 {
+		t.Errorf("Len = %d, want 3 (index, blog/post, plain)", c.Len())
+	}
+	if _, ok := c.Get("_nav"); ok {
+		t.Error("partials must not be served")
+	}
+
+	data := map[string]any{"home": "javascript:x", "name": "<Ada>", "title": "Post", "tags": []string{"a", "b"}}
+	for name, want := range map[string]string{
+		"index":     `<title>Site</title><body><a href="about:invalid">home</a><p>Hello &lt;Ada&gt;</p></body>`,
+		"blog/post": `<title>Post</title><body><a href="about:invalid">home</a><section><i>a</i><i>b</i></section></body>`,
+		"plain":     `<a href="about:invalid">home</a>`,
+	} {
+		if got := render(t, c, name, data); got != want {
+			t.Errorf("%s:\n got %s\nwant %s", name, got, want)
+		}
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	for _, tc := range []struct {
+		files map[string]string
+		want  string
+	}{
+		{map[string]string{"a.html": `<p>{{> _missing}}</p>`}, `template "a": engine: {{> _missing}} at offset 3: template "_missing" not found (no _missing.html)`},
+		{map[string]string{"a.html": `{{extends "_nope"}}`}, `template "_nope" not found`},
+		{map[string]string{"a.html": `{{> _a}}`, "_a.html": `{{> _b}}`, "_b.html": `{{> _a}}`}, `"_a" includes itself: _a -> _b -> _a`},
+		{map[string]string{"a.html": `{{> _p}}`, "_p.html": `x {{#if y}}`}, `{{> _p}} at offset 0: unclosed {{#if}} at offset 2`},
+		{map[string]string{"a.html": `{{#if x}}{{> _p}}{{/if}}`, "_p.html": `{{else}}`}, `{{else}} outside of a block`},
+		{map[string]string{"a.html": `{{extends "_l"}}<p>{{x}}</p>`, "_l.html": ``}, `{{x}} outside {{#block}}`},
+		{map[string]string{"a.html": `{{> ../etc/passwd}}`}, `invalid template name`},
+		{map[string]string{"a.html": `<a href="{{> _p}}`, "_p.html": `">`}, ``},
+	} {
+		fsys := fstest.MapFS{}
+		for name, src := range tc.files {
+			fsys[name] = &fstest.MapFile{Data: []byte(src)}
+		}
+		_, err := New(fsys, ".html")
+		switch {
+		case tc.want == "" && err != nil:
+			t.Errorf("%v: %v", tc.files, err)
+		case tc.want != "" && (err == nil || !strings.Contains(err.Error(), tc.want)):
+			t.Errorf("%v: error %v, want %q", tc.files, err, tc.want)
+		}
+	}
+}
+
+func TestWatchReloads(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, src string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("index.html", `v1 {{> _p}}`)
+	write("_p.html", `one`)
+
+	c, err := New(os.DirFS(dir), ".html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old, _ := c.Get("index")
+
+	reloads := make(chan error, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Watch(ctx, 10*time.Millisecond, func(err error) { reloads <- err })
+
+	// A broken edit is reported and keeps the last good version.
+	write("_p.html", `{{#if x}}`)
+	if err := waitReload(t, reloads); err == nil {
+		t.Fatal("expected a compile error")
+	}
+	if tpl, _ := c.Get("index"); tpl != old {
+		t.Fatal("failed reload replaced the template")
+	}
+
+	write("_p.html", `two`)
+	if err := waitReload(t, reloads); err != nil {
+		t.Fatal(err)
+	}
+	if got := render(t, c, "index", nil); got != "v1 two" {
+		t.Fatalf("after reload: %q", got)
+	}
+	if tpl, _ := c.Get("index"); tpl == old {
+		t.Fatal("template not swapped")
+	}
+}
+
+func waitReload(t *testing.T, reloads <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-reloads:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reload")
+		return nil
+	}
+}
@@ -1,67 +1,429 @@
 package engine
 
-import "bytes"
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
 
 // Compile parses template and builds instruction list.
 //
-// Supports multiple variables {{var}} in any order.
-// Uses map to assign stable indices for each unique variable.
+// Supports:
+//
+//	{{var}} {{user.name}}            variables, dotted paths into nested data
+//	{{{html}}}                       variable written without escaping
+//	{{#if x}}...{{else}}...{{/if}}   conditional (else optional)
+//	{{#each items}}...{{/each}}      loop; the body reads from the item,
+//	                                 {{else}} renders for an empty list
+//	{{this}} {{@index}} {{../x}}     current item, its position, parent scope
+//
+// Blocks compile to jumps inside the same flat instruction list, so
+// rendering stays a single loop over a slice. Each scope assigns stable
+// indices per unique path, in order of first use.
+//
+// Variables are escaped for where they appear: element text, attribute
+// values, URLs, JavaScript or CSS. The context is worked out here from the
+// surrounding text and stored on the instruction; a block whose branches
+// leave the HTML in different contexts is an error.
 func Compile(input []byte) (*Template, error) {
-	var instructions []Instruction
-	varNames := make(map[string]uint16)
-
-	i := 0
-	start := 0
-
-	for i < len(input) {
-		if i+1 < len(input) && input[i] == '{' && input[i+1] == '{' {
-			// flush text before {{
-			if start < i {
-				instructions = append(instructions, Instruction{
-					Op:   OpText,
-					Data: append([]byte(nil), input[start:i]...),
-				})
-			}
+	return CompileWith(input, nil)
+}
+
+// CompileWith is Compile with partials and layouts, whose sources load
+// returns by name:
+//
+//	{{> nav}}                          the partial's tags, inlined here
+//	{{extends "layout"}}               first tag: render layout, with...
+//	{{#block "main"}}...{{/block}}     ...this content for its "main" block
+//
+// Includes are resolved here, so the template is still one flat
+// instruction list; rendering never looks anything up by name.
+func CompileWith(input []byte, load Loader) (*Template, error) {
+	c := &compiler{load: load, overrides: make(map[string]override)}
+	c.scopes = []*scopeBuilder{newScopeBuilder()}
 
-			i += 2
-			varStart := i
+	if err := c.file(input); err != nil {
+		return nil, fmt.Errorf("engine: %w", err)
+	}
 
-			// find closing }}
-			for i+1 < len(input) && !(input[i] == '}' && input[i+1] == '}') {
-				i++
-			}
+	root := c.scopes[0].scope
+	return &Template{
+		Instructions: c.instructions,
+		VarCount:     uint16(len(root.Vars)),
+		flat:         isFlat(c.instructions),
+		Root:         root,
+	}, nil
+}
+
+// parse compiles src[from:to]. Blocks opened in the range must close in it,
+// so a partial or block body can't reach into its surroundings.
+func (c *compiler) parse(src []byte, from, to int) error {
+	outer := c.depth
+	c.depth = len(c.blocks)
+	defer func() { c.depth = outer }()
 
-			varName := string(bytes.TrimSpace(input[varStart:i]))
+	src = src[:to]
+	start := from
+	for {
+		tagStart, tagEnd, tag, raw, err := nextTag(src, start)
+		if err != nil {
+			return err
+		}
+		if tagStart < 0 {
+			break
+		}
+
+		// flush text before {{
+		if start < tagStart {
+			c.text(src[start:tagStart])
+		}
+
+		switch {
+		case raw:
+			err = c.raw(tag)
+
+		case strings.HasPrefix(tag, ">"):
+			if err := c.partial(tag[1:]); err != nil {
+				return fmt.Errorf("{{%s}} at offset %d: %w", tag, tagStart, err)
+			}
 
-			// assign stable index
-			idx, exists := varNames[varName]
-			if !exists {
-				idx = uint16(len(varNames))
-				varNames[varName] = idx
+		case strings.HasPrefix(tag, "#block "):
+			bodyEnd, end, err := blockEnd(src, tagEnd)
+			if err != nil {
+				return fmt.Errorf("%w at offset %d", err, tagStart)
 			}
+			if err := c.block(tag[len("#block "):], src, tagEnd, bodyEnd); err != nil {
+				return err
+			}
+			tagEnd = end
+
+		case tag == "/block":
+			err = fmt.Errorf("{{/block}} without {{#block}}")
 
-			instructions = append(instructions, Instruction{
-				Op:  OpVar,
-				Idx: idx,
-			})
+		case strings.HasPrefix(tag, "extends "):
+			err = fmt.Errorf("{{extends}} must be the first tag")
 
-			i += 2
-			start = i
-			continue
+		default:
+			err = c.tag(tag, tagStart)
 		}
-		i++
+		if err != nil {
+			return fmt.Errorf("%w at offset %d", err, tagStart)
+		}
+		start = tagEnd
+	}
+
+	if len(c.blocks) > c.depth {
+		b := c.blocks[len(c.blocks)-1]
+		return fmt.Errorf("unclosed {{#%s}} at offset %d", b.kind, b.offset)
 	}
 
 	// flush remaining text
-	if start < len(input) {
-		instructions = append(instructions, Instruction{
-			Op:   OpText,
-			Data: append([]byte(nil), input[start:]...),
+	if start < len(src) {
+		c.text(src[start:])
+	}
+	return nil
+}
+
+// nextTag finds the first {{...}} or {{{...}}} at or after from.
+// tagStart is -1 when there is none.
+func nextTag(src []byte, from int) (tagStart, tagEnd int, tag string, raw bool, err error) {
+	i := bytes.Index(src[from:], []byte("{{"))
+	if i < 0 {
+		return -1, -1, "", false, nil
+	}
+	tagStart = from + i
+	i = tagStart + 2
+
+	// {{{raw}}} closes with }}}
+	close := []byte("}}")
+	raw = i < len(src) && src[i] == '{'
+	if raw {
+		i++
+		close = []byte("}}}")
+	}
+
+	end := bytes.Index(src[i:], close)
+	if end < 0 {
+		return 0, 0, "", false, fmt.Errorf("unclosed tag at offset %d", tagStart)
+	}
+	tag = string(bytes.TrimSpace(src[i : i+end]))
+	return tagStart, i + end + len(close), tag, raw, nil
+}
+
+// compiler holds parse state. Discarded after Compile.
+type compiler struct {
+	instructions []Instruction
+
+	// scopes is the stack of variable scopes; each {{#each}} body pushes one.
+	scopes []*scopeBuilder
+
+	// blocks is the stack of open {{#if}}/{{#each}} tags.
+	blocks []openBlock
+
+	// html is the HTML context after the text compiled so far.
+	html htmlContext
+
+	// depth is len(blocks) when the range being parsed began; {{else}}
+	// and closing tags can't reach blocks below it.
+	depth int
+
+	// load returns partial and layout sources; nil without CompileWith.
+	load Loader
+
+	// including is the chain of partials and layouts being compiled,
+	// to catch cycles.
+	including []string
+
+	// overrides are {{#block}} contents from templates extending a layout.
+	overrides map[string]override
+
+	// flushed is set once the OpFlush after </head> is emitted.
+	flushed bool
+}
+
+type openBlock struct {
+	kind   string // "if" or "each"
+	pc     int    // the OpIf/OpEach instruction
+	elseAt int    // OpJump before the else body, -1 without else
+	offset int    // for error messages
+
+	// HTML context at the opening tag, and at the end of the first body
+	// once {{else}} is seen.
+	start, first htmlContext
+}
+
+// scopeBuilder assigns indices within one scope.
+type scopeBuilder struct {
+	scope *Scope
+	vars  map[string]uint16
+	lists map[string]uint16
+}
+
+func newScopeBuilder() *scopeBuilder {
+	return &scopeBuilder{
+		scope: &Scope{},
+		vars:  make(map[string]uint16),
+		lists: make(map[string]uint16),
+	}
+}
+
+func (s *scopeBuilder) varIndex(path string) uint16 {
+	idx, ok := s.vars[path]
+	if !ok {
+		idx = uint16(len(s.scope.Vars))
+		s.vars[path] = idx
+		s.scope.Vars = append(s.scope.Vars, path)
+	}
+	return idx
+}
+
+func (s *scopeBuilder) listIndex(path string) (uint16, *Scope) {
+	idx, ok := s.lists[path]
+	if !ok {
+		idx = uint16(len(s.scope.Lists))
+		s.lists[path] = idx
+		s.scope.Lists = append(s.scope.Lists, path)
+		s.scope.Items = append(s.scope.Items, &Scope{})
+	}
+	return idx, s.scope.Items[idx]
+}
+
+func (c *compiler) emit(inst Instruction) int {
+	c.instructions = append(c.instructions, inst)
+	return len(c.instructions) - 1
+}
+
+// text emits static text and follows it through the HTML context.
+// The first </head> is followed by an OpFlush.
+func (c *compiler) text(data []byte) {
+	c.html.feed(data)
+	if !c.flushed {
+		if i := bytes.Index(bytes.ToLower(data), []byte("</head>")); i >= 0 {
+			c.flushed = true
+			head, body := data[:i+len("</head>")], data[i+len("</head>"):]
+			c.emit(Instruction{Op: OpText, Data: append([]byte(nil), head...)})
+			c.emit(Instruction{Op: OpFlush})
+			if len(body) == 0 {
+				return
+			}
+			data = body
+		}
+	}
+	c.emit(Instruction{
+		Op:   OpText,
+		Data: append([]byte(nil), data...),
+	})
+}
+
+func (c *compiler) pc() uint32 {
+	return uint32(len(c.instructions))
+}
+
+// tag compiles the contents of one {{...}}.
+func (c *compiler) tag(tag string, offset int) error {
+	switch {
+	case tag == "":
+		return fmt.Errorf("empty tag")
+
+	case strings.HasPrefix(tag, "#if "):
+		depth, scope, path, err := c.resolve(strings.TrimSpace(tag[len("#if "):]))
+		if err != nil {
+			return err
+		}
+		pc := c.emit(Instruction{Op: OpIf, Depth: depth, Idx: scope.varIndex(path)})
+		c.blocks = append(c.blocks, openBlock{kind: "if", pc: pc, elseAt: -1, offset: offset, start: c.html})
+		return nil
+
+	case strings.HasPrefix(tag, "#each "):
+		depth, scope, path, err := c.resolve(strings.TrimSpace(tag[len("#each "):]))
+		if err != nil {
+			return err
+		}
+		idx, items := scope.listIndex(path)
+		pc := c.emit(Instruction{Op: OpEach, Depth: depth, Idx: idx})
+		c.blocks = append(c.blocks, openBlock{kind: "each", pc: pc, elseAt: -1, offset: offset, start: c.html})
+		c.scopes = append(c.scopes, &scopeBuilder{
+			scope: items,
+			vars:  indexOf(items.Vars),
+			lists: indexOf(items.Lists),
 		})
+		return nil
+
+	case tag == "else":
+		if len(c.blocks) == c.depth {
+			return fmt.Errorf("{{else}} outside of a block")
+		}
+		b := &c.blocks[len(c.blocks)-1]
+		if b.elseAt >= 0 {
+			return fmt.Errorf("second {{else}} in {{#%s}}", b.kind)
+		}
+		if b.kind == "each" && c.html != b.start {
+			return fmt.Errorf("{{#each}} body ends in a different HTML context than it starts")
+		}
+		b.elseAt = c.emit(Instruction{Op: OpJump})
+		b.first, c.html = c.html, b.start
+		if b.kind == "each" {
+			// The else body runs when there are no items: outer scope.
+			c.instructions[b.pc].Jump = uint32(b.elseAt)
+			c.scopes = c.scopes[:len(c.scopes)-1]
+		} else {
+			c.instructions[b.pc].Jump = c.pc()
+		}
+		return nil
+
+	case tag == "/if" || tag == "/each":
+		kind := tag[1:]
+		if len(c.blocks) == c.depth {
+			return fmt.Errorf("{{/%s}} without {{#%s}}", kind, kind)
+		}
+		b := c.blocks[len(c.blocks)-1]
+		if b.kind != kind {
+			return fmt.Errorf("{{/%s}} closes {{#%s}}", kind, b.kind)
+		}
+		c.blocks = c.blocks[:len(c.blocks)-1]
+
+		// Every way through the block must leave the same context, or
+		// what follows couldn't have a single escaping.
+		want := b.start
+		if b.elseAt >= 0 && kind == "if" {
+			want = b.first
+		}
+		if c.html != want {
+			return fmt.Errorf("{{#%s}} branches end in different HTML contexts", kind)
+		}
+
+		switch {
+		case b.elseAt >= 0:
+			c.instructions[b.elseAt].Jump = c.pc()
+		case kind == "each":
+			// Every each-body ends in a jump past the block.
+			end := c.emit(Instruction{Op: OpJump})
+			c.instructions[end].Jump = c.pc()
+			c.instructions[b.pc].Jump = uint32(end)
+			c.scopes = c.scopes[:len(c.scopes)-1]
+		default:
+			c.instructions[b.pc].Jump = c.pc()
+		}
+		return nil
+
+	case strings.HasPrefix(tag, "#") || strings.HasPrefix(tag, "/"):
+		return fmt.Errorf("unknown block tag {{%s}}", tag)
 	}
 
-	return &Template{
-		Instructions: instructions,
-		VarCount:     uint16(len(varNames)),
-	}, nil
+	depth, scope, path, err := c.resolve(tag)
+	if err != nil {
+		return err
+	}
+	if path == "@index" {
+		if len(c.scopes)-1-int(depth) < 1 {
+			return fmt.Errorf("{{@index}} outside of {{#each}}")
+		}
+		// Digits only: safe in any context.
+		c.emit(Instruction{Op: OpIndex, Depth: depth})
+		c.html.wrote()
+		return nil
+	}
+	esc, err := c.html.escape()
+	if err != nil {
+		return err
+	}
+	c.emit(Instruction{Op: OpVar, Depth: depth, Idx: scope.varIndex(path), Esc: esc})
+	c.html.wrote()
+	return nil
+}
+
+// raw compiles the contents of one {{{...}}}: a variable written as is.
+// The value is trusted to leave the HTML context as it found it.
+func (c *compiler) raw(tag string) error {
+	if tag == "" || tag == "else" || strings.HasPrefix(tag, "#") || strings.HasPrefix(tag, "/") || tag == "@index" {
+		return fmt.Errorf("{{{%s}}} must be a variable", tag)
+	}
+	depth, scope, path, err := c.resolve(tag)
+	if err != nil {
+		return err
+	}
+	c.emit(Instruction{Op: OpVar, Depth: depth, Idx: scope.varIndex(path), Esc: EscapeNone})
+	c.html.wrote()
+	return nil
+}
+
+// resolve strips ../ prefixes from a name and returns how many scopes up
+// it refers to, that scope, and the path within it. "." means "this".
+func (c *compiler) resolve(name string) (uint8, *scopeBuilder, string, error) {
+	depth := 0
+	for strings.HasPrefix(name, "../") {
+		depth++
+		name = name[len("../"):]
+	}
+	if name == "" || strings.ContainsAny(name, " \t\n{}") {
+		return 0, nil, "", fmt.Errorf("invalid name %q", name)
+	}
+	if name == "." {
+		name = "this"
+	}
+	if depth >= len(c.scopes) {
+		return 0, nil, "", fmt.Errorf("../%s goes above the top-level scope", name)
+	}
+	return uint8(depth), c.scopes[len(c.scopes)-1-depth], name, nil
+}
+
+// isFlat reports whether instructions are only text, flushes and
+// top-level variables.
+func isFlat(instructions []Instruction) bool {
+	for _, inst := range instructions {
+		if inst.Op != OpText && inst.Op != OpFlush && (inst.Op != OpVar || inst.Depth != 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// indexOf rebuilds the name -> index map of an existing scope, for lists
+// that are looped over more than once.
+func indexOf(names []string) map[string]uint16 {
+	m := make(map[string]uint16, len(names))
+	for i, name := range names {
+		m[name] = uint16(i)
+	}
+	return m
 }
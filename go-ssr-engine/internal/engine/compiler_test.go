@@ -0,0 +1,134 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompileRender(t *testing.T) {
+	data := map[string]any{
+		"name":   "Ada",
+		"yes":    true,
+		"no":     false,
+		"empty":  "",
+		"falseS": "false",
+		"zero":   0,
+		"zeroS":  "0",
+		"one":    1,
+		"none":   []string{},
+		"tags":   []string{"go", "html"},
+		"user":   map[string]any{"name": "Bob", "admin": true},
+		"posts": []map[string]any{
+			{"title": "First", "tags": []string{"a", "b"}},
+			{"title": "Second", "tags": []string{}},
+		},
+	}
+
+	cases := []struct {
+		name, src, want string
+	}{
+		{"text only", `<p>hello</p>`, `<p>hello</p>`},
+		{"variable", `Hi {{name}}!`, `Hi Ada!`},
+		{"dotted path", `{{user.name}}`, `Bob`},
+		{"missing is empty", `[{{nope}}][{{user.nope}}]`, `[][]`},
+
+		{"if true", `{{#if yes}}Y{{/if}}`, `Y`},
+		{"if false", `{{#if no}}Y{{/if}}`, ``},
+		{"if else true", `{{#if yes}}Y{{else}}N{{/if}}`, `Y`},
+		{"if else false", `{{#if no}}Y{{else}}N{{/if}}`, `N`},
+		{"if nested path", `{{#if user.admin}}admin{{/if}}`, `admin`},
+		{"if missing", `{{#if nope}}Y{{else}}N{{/if}}`, `N`},
+
+		{"falsy empty string", `{{#if empty}}Y{{else}}N{{/if}}`, `N`},
+		{"falsy string false", `{{#if falseS}}Y{{else}}N{{/if}}`, `N`},
+		{"falsy int zero", `{{#if zero}}Y{{else}}N{{/if}}`, `N`},
+		{"falsy string zero", `{{#if zeroS}}Y{{else}}N{{/if}}`, `N`},
+		{"falsy empty list", `{{#if none}}Y{{else}}N{{/if}}`, `N`},
+		{"truthy int", `{{#if one}}Y{{else}}N{{/if}}`, `Y`},
+		{"truthy list", `{{#if tags}}Y{{else}}N{{/if}}`, `Y`},
+		{"truthy string", `{{#if name}}Y{{else}}N{{/if}}`, `Y`},
+
+		{"each", `{{#each tags}}<{{this}}>{{/each}}`, `<go><html>`},
+		{"each dot", `{{#each tags}}{{.}},{{/each}}`, `go,html,`},
+		{"each index", `{{#each tags}}{{@index}}={{this}} {{/each}}`, `0=go 1=html `},
+		{"each maps", `{{#each posts}}[{{title}}]{{/each}}`, `[First][Second]`},
+		{"each else with items", `{{#each tags}}{{this}}{{else}}none{{/each}}`, `gohtml`},
+		{"each else empty", `{{#each none}}{{this}}{{else}}none{{/each}}`, `none`},
+		{"each else missing", `{{#each nope}}{{this}}{{else}}none {{name}}{{/each}}`, `none Ada`},
+		{"each parent var", `{{#each tags}}{{../name}}:{{this}} {{/each}}`, `Ada:go Ada:html `},
+
+		{"nested each", `{{#each posts}}{{title}}({{#each tags}}{{this}}{{/each}}){{/each}}`, `First(ab)Second()`},
+		{"nested each depth 1", `{{#each posts}}{{#each tags}}{{../title}}.{{this}} {{/each}}{{/each}}`, `First.a First.b `},
+		{"nested each depth 2", `{{#each posts}}{{#each tags}}{{../../name}}/{{this}} {{/each}}{{/each}}`, `Ada/a Ada/b `},
+		{"nested index", `{{#each posts}}{{#each tags}}{{../@index}}.{{@index}} {{/each}}{{/each}}`, `0.0 0.1 `},
+		{"nested each else", `{{#each posts}}{{title}}:{{#each tags}}{{this}}{{else}}-{{/each}};{{/each}}`, `First:ab;Second:-;`},
+		{"if inside each", `{{#each posts}}{{#if tags}}{{title}}{{else}}({{title}}){{/if}}{{/each}}`, `First(Second)`},
+		{"each inside if", `{{#if yes}}{{#each tags}}{{this}}{{/each}}{{/if}}`, `gohtml`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := renderString(t, c.src, data); got != c.want {
+				t.Errorf("%s\n got %q\nwant %q", c.src, got, c.want)
+			}
+		})
+	}
+}
+
+func TestCompileRenderReusesContext(t *testing.T) {
+	tpl, err := Compile([]byte(`{{#each items}}{{this}}{{else}}empty{{/each}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		items []string
+		want  string
+	}{
+		{[]string{"a", "b"}, "ab"},
+		{nil, "empty"},
+		{[]string{"c"}, "c"},
+	} {
+		ctx := tpl.NewContext(map[string]any{"items": c.items})
+		out, err := tpl.RenderBytes(&ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != c.want {
+			t.Errorf("items %v: got %q, want %q", c.items, out, c.want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	cases := []struct {
+		name, src, wantErr string
+	}{
+		{"unclosed if", `{{#if x}}a`, "unclosed"},
+		{"unclosed each", `{{#each xs}}a`, "unclosed"},
+		{"unclosed tag", `a {{name`, "unclosed"},
+		{"close without open", `a{{/if}}`, "without"},
+		{"misnested", `{{#if x}}{{#each xs}}{{/if}}{{/each}}`, "closes"},
+		{"wrong close", `{{#each xs}}{{/if}}`, "closes"},
+		{"else outside block", `a{{else}}b`, "outside"},
+		{"second else", `{{#if x}}a{{else}}b{{else}}c{{/if}}`, "second"},
+		{"unknown block", `{{#with x}}{{/with}}`, "unknown"},
+		{"empty tag", `{{}}`, "empty"},
+		{"index outside each", `{{@index}}`, "outside"},
+		{"parent above top", `{{../name}}`, "above"},
+		{"parent above each", `{{#each xs}}{{../../name}}{{/each}}`, "above"},
+		{"variable in srcdoc", `<iframe srcdoc="{{page}}"></iframe>`, "srcdoc"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := Compile([]byte(c.src))
+			if err == nil {
+				t.Fatalf("Compile(%q): expected an error", c.src)
+			}
+			if !strings.Contains(err.Error(), c.wantErr) {
+				t.Errorf("Compile(%q): error %q doesn't mention %q", c.src, err, c.wantErr)
+			}
+		})
+	}
+}
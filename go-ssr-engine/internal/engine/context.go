@@ -1,5 +1,11 @@
 package engine
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // RenderContext holds runtime values for variables.
 //
 // IMPORTANT:
@@ -8,6 +14,20 @@ package engine
 // - Caller must ensure len(Values) >= VarCount
 type RenderContext struct {
 	Values [][]byte
+
+	// Lists holds one context per item for each {{#each}} list, indexed
+	// like Scope.Lists. Nil for templates without loops.
+	Lists [][]RenderContext
+
+	// Parent is the enclosing scope for ../ lookups; nil at top level.
+	Parent *RenderContext
+
+	// Index is this item's position in its list, for {{@index}}.
+	Index int
+
+	// indexText is Index formatted ahead of time, so writing it in the
+	// render loop doesn't allocate.
+	indexText []byte
 }
 
 // NewRenderContext builds RenderContext from map[string]string
@@ -23,3 +43,122 @@ func NewRenderContext(varMap map[string]string, tpl *Template, varNameToIndex ma
 	}
 	return RenderContext{Values: values}
 }
+
+// NewContext builds a RenderContext from nested data: maps for objects,
+// slices for lists, scalars for values. Dotted paths walk into maps.
+//
+// Runs once per request, before rendering. Resolving paths here is what
+// keeps RenderTo free of maps and reflection.
+func (t *Template) NewContext(data map[string]any) RenderContext {
+	ctx := buildContext(t.Root, data, nil, 0)
+	return *ctx
+}
+
+// buildContext resolves every path of a scope against data.
+// Returned by pointer so items can point back at it as their Parent.
+func buildContext(s *Scope, data any, parent *RenderContext, index int) *RenderContext {
+	ctx := &RenderContext{
+		Values: make([][]byte, len(s.Vars)),
+		Parent: parent,
+		Index:  index,
+	}
+	if parent != nil {
+		ctx.indexText = strconv.AppendInt(nil, int64(index), 10)
+	}
+	for i, path := range s.Vars {
+		ctx.Values[i] = formatValue(lookup(data, path))
+	}
+
+	if len(s.Lists) > 0 {
+		ctx.Lists = make([][]RenderContext, len(s.Lists))
+		for i, path := range s.Lists {
+			items := listItems(lookup(data, path))
+			list := make([]RenderContext, len(items))
+			for j, item := range items {
+				list[j] = *buildContext(s.Items[i], item, ctx, j)
+			}
+			ctx.Lists[i] = list
+		}
+	}
+	return ctx
+}
+
+// lookup walks a dotted path through nested maps. "this" is data itself.
+func lookup(data any, path string) any {
+	if path == "this" {
+		return data
+	}
+	for data != nil {
+		key, rest, more := strings.Cut(path, ".")
+		switch m := data.(type) {
+		case map[string]any:
+			data = m[key]
+		case map[string]string:
+			if more {
+				return nil
+			}
+			v, ok := m[key]
+			if !ok {
+				return nil
+			}
+			return v
+		default:
+			return nil
+		}
+		if !more {
+			return data
+		}
+		path = rest
+	}
+	return nil
+}
+
+// listItems returns the items of a list value; anything else has none.
+func listItems(v any) []any {
+	switch l := v.(type) {
+	case []any:
+		return l
+	case []map[string]any:
+		items := make([]any, len(l))
+		for i, item := range l {
+			items[i] = item
+		}
+		return items
+	case []string:
+		items := make([]any, len(l))
+		for i, item := range l {
+			items[i] = item
+		}
+		return items
+	}
+	return nil
+}
+
+// formatValue converts a value to the bytes {{var}} writes.
+// Missing values are empty. A list used as a value is its length, so
+// {{#if items}} is false for an empty list.
+func formatValue(v any) []byte {
+	switch x := v.(type) {
+	case nil:
+		return []byte{}
+	case string:
+		return []byte(x)
+	case []byte:
+		return x
+	case bool:
+		return strconv.AppendBool(nil, x)
+	case int:
+		return strconv.AppendInt(nil, int64(x), 10)
+	case int64:
+		return strconv.AppendInt(nil, x, 10)
+	case float64:
+		return strconv.AppendFloat(nil, x, 'f', -1, 64)
+	case fmt.Stringer:
+		return []byte(x.String())
+	case []any, []map[string]any, []string:
+		return strconv.AppendInt(nil, int64(len(listItems(x))), 10)
+	case map[string]any, map[string]string:
+		return []byte{}
+	}
+	return []byte(fmt.Sprint(v))
+}
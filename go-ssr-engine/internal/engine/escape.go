@@ -0,0 +1,261 @@
+package engine
+
+import (
+	"bytes"
+	"io"
+)
+
+// Escape is how a variable's value is escaped when written. The compiler
+// picks it from where the variable sits in the HTML, so rendering only
+// switches on a byte — no parsing at render time.
+type Escape uint8
+
+const (
+	// EscapeNone writes the value verbatim. Only {{{raw}}} compiles to it.
+	EscapeNone Escape = iota
+
+	// EscapeHTML is for element text and quoted attribute values.
+	EscapeHTML
+
+	// EscapeAttrUnquoted is for unquoted attribute values, where
+	// whitespace and = would also end the value.
+	EscapeAttrUnquoted
+
+	// EscapeURL is for a value that starts a URL attribute (href, src...).
+	// URLs with schemes other than http, https, mailto and tel are
+	// replaced, so javascript: can't be injected.
+	EscapeURL
+
+	// EscapeURLPart is for a value inside a URL, after its start:
+	// everything but unreserved characters is percent-encoded.
+	EscapeURLPart
+
+	// EscapeJS is for a value in JavaScript code. It is written as a
+	// quoted string literal, so it can only ever be data.
+	EscapeJS
+
+	// EscapeJSString is for a value inside a JavaScript string literal.
+	EscapeJSString
+
+	// EscapeCSS is for values in <style> or style="". Anything but a
+	// conservative set of characters is dropped.
+	EscapeCSS
+)
+
+// unsafeURL replaces URLs with a disallowed scheme.
+var unsafeURL = []byte("about:invalid")
+
+// writeEscaped writes v to w escaped for mode.
+func writeEscaped(w io.Writer, mode Escape, v []byte) error {
+	switch mode {
+	case EscapeHTML:
+		return writeReplaced(w, v, &htmlReplacements)
+	case EscapeAttrUnquoted:
+		return writeReplaced(w, v, &unquotedReplacements)
+	case EscapeURL:
+		if !safeURL(v) {
+			_, err := w.Write(unsafeURL)
+			return err
+		}
+		return writeReplaced(w, v, &urlReplacements)
+	case EscapeURLPart:
+		return writeReplaced(w, v, &urlPartReplacements)
+	case EscapeJS:
+		if _, err := w.Write(quote); err != nil {
+			return err
+		}
+		if err := writeJSString(w, v); err != nil {
+			return err
+		}
+		_, err := w.Write(quote)
+		return err
+	case EscapeJSString:
+		return writeJSString(w, v)
+	case EscapeCSS:
+		return writeCSS(w, v)
+	}
+	_, err := w.Write(v)
+	return err
+}
+
+var quote = []byte(`"`)
+
+// needsHTMLEscape is the fast path for the common case: text with nothing
+// to escape, written as is.
+func needsHTMLEscape(v []byte) bool {
+	for _, c := range v {
+		if htmlSpecial[c] {
+			return true
+		}
+	}
+	return false
+}
+
+// writeReplaced writes v, substituting bytes that have a replacement.
+// Runs of safe bytes go out in one Write; a value with nothing to escape
+// is a single scan and a single Write.
+func writeReplaced(w io.Writer, v []byte, table *[256][]byte) error {
+	last := 0
+	for i, c := range v {
+		repl := table[c]
+		if repl == nil {
+			continue
+		}
+		if last < i {
+			if _, err := w.Write(v[last:i]); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(repl); err != nil {
+			return err
+		}
+		last = i + 1
+	}
+	if last < len(v) {
+		_, err := w.Write(v[last:])
+		return err
+	}
+	return nil
+}
+
+// writeJSString escapes v for the inside of a JS string literal of any
+// quote style. Every escape is \uXXXX or a JSON escape, so the output is
+// also safe in HTML attributes and can't close a <script> element.
+func writeJSString(w io.Writer, v []byte) error {
+	last := 0
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		repl := jsReplacements[c]
+		n := 1
+		// U+2028 and U+2029 end lines in older JS engines.
+		if c == 0xE2 && i+2 < len(v) && v[i+1] == 0x80 && (v[i+2] == 0xA8 || v[i+2] == 0xA9) {
+			repl = lineSeparators[v[i+2]-0xA8]
+			n = 3
+		}
+		if repl == nil {
+			continue
+		}
+		if last < i {
+			if _, err := w.Write(v[last:i]); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(repl); err != nil {
+			return err
+		}
+		i += n - 1
+		last = i + 1
+	}
+	if last < len(v) {
+		_, err := w.Write(v[last:])
+		return err
+	}
+	return nil
+}
+
+var lineSeparators = [2][]byte{[]byte(`\u2028`), []byte(`\u2029`)}
+
+// writeCSS keeps only characters that can't end a declaration, a rule or
+// the <style> element, nor open url() or expression().
+func writeCSS(w io.Writer, v []byte) error {
+	last := 0
+	for i, c := range v {
+		if cssSafe[c] {
+			continue
+		}
+		if last < i {
+			if _, err := w.Write(v[last:i]); err != nil {
+				return err
+			}
+		}
+		last = i + 1
+	}
+	if last < len(v) {
+		_, err := w.Write(v[last:])
+		return err
+	}
+	return nil
+}
+
+// safeURL reports whether a URL is relative or uses an allowed scheme.
+// Browsers ignore leading whitespace and control characters, so do we.
+func safeURL(v []byte) bool {
+	v = bytes.TrimLeft(v, "\x00\x01\x02\x03\x04\x05\x06\x07\x08\t\n\v\f\r\x0e\x0f\x10\x11\x12\x13\x14\x15\x16\x17\x18\x19\x1a\x1b\x1c\x1d\x1e\x1f ")
+	colon := bytes.IndexByte(v, ':')
+	if colon < 0 {
+		return true
+	}
+	// A / ? or # before the colon makes it part of a path, not a scheme.
+	if bytes.ContainsAny(v[:colon], "/?#") {
+		return true
+	}
+	scheme := v[:colon]
+	for _, allowed := range []string{"http", "https", "mailto", "tel"} {
+		if bytes.EqualFold(scheme, []byte(allowed)) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	htmlReplacements     [256][]byte
+	htmlSpecial          [256]bool
+	unquotedReplacements [256][]byte
+	urlReplacements      [256][]byte
+	urlPartReplacements  [256][]byte
+	jsReplacements       [256][]byte
+	cssSafe              [256]bool
+)
+
+func init() {
+	for c, repl := range map[byte]string{
+		'&': "&amp;", '<': "&lt;", '>': "&gt;", '"': "&#34;", '\'': "&#39;",
+	} {
+		htmlReplacements[c] = []byte(repl)
+		htmlSpecial[c] = true
+		unquotedReplacements[c] = []byte(repl)
+	}
+	for _, c := range []byte(" \t\n\f\r=`") {
+		unquotedReplacements[c] = []byte("&#" + itoa(int(c)) + ";")
+	}
+
+	const hex = "0123456789ABCDEF"
+	percent := func(c int) []byte { return []byte{'%', hex[c>>4], hex[c&15]} }
+	for c := 0; c < 256; c++ {
+		unreserved := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+			c == '-' || c == '.' || c == '_' || c == '~'
+		if !unreserved {
+			urlPartReplacements[c] = percent(c)
+		}
+		// Whole URLs keep their structure; only what can't appear in a
+		// URL, or would end the attribute, is encoded.
+		if !unreserved && !bytes.ContainsRune([]byte(":/?#[]@!$&()*+,;=%"), rune(c)) {
+			urlReplacements[c] = percent(c)
+		}
+
+		if c < 0x20 {
+			jsReplacements[c] = []byte(`\u00` + string(hex[c>>4]) + string(hex[c&15]))
+		}
+
+		cssSafe[c] = c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+			c == ' ' || c == '#' || c == '.' || c == ',' || c == '%' || c == '-' || c == '_'
+	}
+	urlReplacements['&'] = []byte("&amp;")
+
+	jsReplacements['\\'] = []byte(`\\`)
+	jsReplacements['\n'] = []byte(`\n`)
+	jsReplacements['\r'] = []byte(`\r`)
+	jsReplacements['\t'] = []byte(`\t`)
+	for _, c := range []byte("\"'`<>&/$=") {
+		jsReplacements[c] = []byte(`\u00` + string(hex[c>>4]) + string(hex[c&15]))
+	}
+}
+
+// itoa avoids strconv for the handful of small numbers in init.
+func itoa(n int) string {
+	if n < 10 {
+		return string(rune('0' + n))
+	}
+	return itoa(n/10) + string(rune('0'+n%10))
+}
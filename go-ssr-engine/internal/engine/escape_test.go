@@ -0,0 +1,217 @@
+package engine
+
+import (
+	"encoding/json"
+	"html"
+	"net/url"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+const payload = `<b>"'&`
+
+func renderString(t testing.TB, src string, data map[string]any) string {
+	t.Helper()
+	tpl, err := Compile([]byte(src))
+	if err != nil {
+		t.Fatalf("Compile(%q): %v", src, err)
+	}
+	ctx := tpl.NewContext(data)
+	out, err := tpl.RenderBytes(&ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestContextualEscaping(t *testing.T) {
+	data := map[string]any{
+		"x":    payload,
+		"evil": "javascript:alert(1)",
+		"link": "https://example.com/?a=1&b=<",
+		"css":  "red;}</style>",
+	}
+	cases := []struct{ src, want string }{
+		{`<p>{{x}}</p>`, `<p>&lt;b&gt;&#34;&#39;&amp;</p>`},
+		{`<p>{{{x}}}</p>`, `<p>` + payload + `</p>`},
+		{`<!-- {{x}} -->`, `<!-- &lt;b&gt;&#34;&#39;&amp; -->`},
+		{`<title>{{x}}</title>`, `<title>&lt;b&gt;&#34;&#39;&amp;</title>`},
+		{`<a title="{{x}}">`, `<a title="&lt;b&gt;&#34;&#39;&amp;">`},
+		{`<a title={{x}}>`, `<a title=&lt;b&gt;&#34;&#39;&amp;>`},
+		{`<a href="{{evil}}">`, `<a href="about:invalid">`},
+		{`<a HREF='{{link}}'>`, `<a HREF='https://example.com/?a=1&amp;b=%3C'>`},
+		{`<a href="/p/{{x}}">`, `<a href="/p/%3Cb%3E%22%27%26">`},
+		{`<a href="{{link}}{{x}}">`, `<a href="https://example.com/?a=1&amp;b=%3C%3Cb%3E%22%27%26">`},
+		{`<script>var a = {{x}};</script>`, `<script>var a = "\u003Cb\u003E\u0022\u0027\u0026";</script>`},
+		{`<script>var a = '{{x}}';</script>{{x}}`, `<script>var a = '\u003Cb\u003E\u0022\u0027\u0026';</script>&lt;b&gt;&#34;&#39;&amp;`},
+		{`<script>// it's {{x}}` + "\n" + `</script>`, `<script>// it's \u003Cb\u003E\u0022\u0027\u0026` + "\n" + `</script>`},
+		{`<button onclick="go('{{x}}')">`, `<button onclick="go('\u003Cb\u003E\u0022\u0027\u0026')">`},
+		{`<style>p{color:{{css}}}</style>`, `<style>p{color:redstyle}</style>`},
+		{`<ul>{{#each items}}<li title="{{this}}">{{@index}}</li>{{/each}}</ul>`, `<ul><li title="a&amp;b">0</li></ul>`},
+	}
+	data["items"] = []string{"a&b"}
+	for _, c := range cases {
+		if got := renderString(t, c.src, data); got != c.want {
+			t.Errorf("%s\n got %s\nwant %s", c.src, got, c.want)
+		}
+	}
+}
+
+func TestContextErrors(t *testing.T) {
+	for _, src := range []string{
+		`<a {{x}}>`,
+		`{{#if a}}<a href="{{/if}}`,
+		`{{#if a}}<script>{{else}}<p>{{/if}}`,
+		`{{#each a}}<p title="{{/each}}`,
+		`{{{#if a}}}`,
+		`{{{x}}`,
+		`<iframe srcdoc="{{html}}"></iframe>`,
+		`<iframe srcdoc={{html}}></iframe>`,
+		`<iframe SRCDOC="<p>{{html}}</p>"></iframe>`,
+	} {
+		if _, err := Compile([]byte(src)); err == nil {
+			t.Errorf("Compile(%q): expected error", src)
+		}
+	}
+	if _, err := Compile([]byte(`<a {{{attrs}}}>`)); err != nil {
+		t.Errorf("raw variable inside a tag: %v", err)
+	}
+	if _, err := Compile([]byte(`<iframe srcdoc="{{{html}}}"></iframe>`)); err != nil {
+		t.Errorf("raw variable in srcdoc: %v", err)
+	}
+}
+
+// The fuzz targets render attacker-controlled values in each context and
+// check the value can't break out: it decodes back to exactly the input,
+// and never contains the bytes that would end its context.
+
+func FuzzEscapeHTML(f *testing.F) {
+	for _, s := range []string{payload, "</p><script>alert(1)</script>", "&amp;", "\x00", "é ✓"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		if !utf8.ValidString(s) {
+			t.Skip()
+		}
+		out := renderString(t, `<p title="{{x}}">{{x}}</p>`, map[string]any{"x": s})
+		attr, text, ok := strings.Cut(strings.TrimSuffix(strings.TrimPrefix(out, `<p title="`), `</p>`), `">`)
+		if !ok {
+			t.Fatalf("broke out of the attribute: %q", out)
+		}
+		for _, part := range []string{attr, text} {
+			if strings.ContainsAny(part, `<>"'`) {
+				t.Fatalf("unescaped markup in %q", part)
+			}
+			if got := html.UnescapeString(part); got != s {
+				t.Fatalf("round trip: got %q, want %q", got, s)
+			}
+		}
+	})
+}
+
+func FuzzEscapeUnquotedAttr(f *testing.F) {
+	for _, s := range []string{payload, "x onload=alert(1)", "a\tb`c"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		if !utf8.ValidString(s) {
+			t.Skip()
+		}
+		out := renderString(t, `<p title={{x}}>`, map[string]any{"x": s})
+		value := strings.TrimSuffix(strings.TrimPrefix(out, `<p title=`), `>`)
+		if strings.ContainsAny(value, " \t\n\f\r\"'=<>`") {
+			t.Fatalf("value would end the attribute: %q", value)
+		}
+		if got := html.UnescapeString(value); got != s {
+			t.Fatalf("round trip: got %q, want %q", got, s)
+		}
+	})
+}
+
+func FuzzEscapeURL(f *testing.F) {
+	for _, s := range []string{"javascript:alert(1)", " JavaScript:x", "\x01javascript:x", "data:text/html,x", "/a?b=c#d", "https://x/\"><script>", "mailto:a@b"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		out := renderString(t, `<a href="{{x}}">`, map[string]any{"x": s})
+		value := strings.TrimSuffix(strings.TrimPrefix(out, `<a href="`), `">`)
+		if strings.ContainsAny(value, "\"'<> \t\n\r\x00") {
+			t.Fatalf("unescaped byte in URL %q", value)
+		}
+		u, err := url.Parse(html.UnescapeString(value))
+		if err != nil {
+			return
+		}
+		switch strings.ToLower(u.Scheme) {
+		case "", "http", "https", "mailto", "tel":
+		default:
+			if value != "about:invalid" {
+				t.Fatalf("scheme %q let through: %q", u.Scheme, value)
+			}
+		}
+	})
+}
+
+func FuzzEscapeURLPart(f *testing.F) {
+	for _, s := range []string{payload, "../x?y#z", "a b/c"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		out := renderString(t, `<a href="/p/{{x}}">`, map[string]any{"x": s})
+		value := strings.TrimSuffix(strings.TrimPrefix(out, `<a href="/p/`), `">`)
+		if strings.ContainsAny(value, "\"'<>&/?# ") {
+			t.Fatalf("unescaped byte in URL part %q", value)
+		}
+		if got, err := url.PathUnescape(value); err != nil || got != s {
+			t.Fatalf("round trip: got %q (%v), want %q", got, err, s)
+		}
+	})
+}
+
+func FuzzEscapeJS(f *testing.F) {
+	for _, s := range []string{payload, "</script><script>alert(1)//", "\\\"; alert(1)//", "${alert(1)}", "\u2028\u2029", "\n"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		if !utf8.ValidString(s) {
+			t.Skip()
+		}
+		out := renderString(t, `<script>var a = {{x}}; var b = "{{x}}";</script><p onclick='f({{x}})'>`, map[string]any{"x": s})
+		rest, ok := strings.CutPrefix(out, `<script>var a = `)
+		if !ok {
+			t.Fatal(out)
+		}
+		code, rest, _ := strings.Cut(rest, `; var b = `)
+		str, rest, _ := strings.Cut(rest, `;</script><p onclick='f(`)
+		attr := strings.TrimSuffix(rest, `)'>`)
+
+		for _, lit := range []string{code, str, attr} {
+			if strings.ContainsAny(lit[1:len(lit)-1], "\"'`<>&\n\r\u2028\u2029") {
+				t.Fatalf("unescaped byte in JS string %q", lit)
+			}
+			// Every escape is also valid JSON, so decoding proves the
+			// literal ends exactly where the template's quotes do.
+			var got string
+			if err := json.Unmarshal([]byte(lit), &got); err != nil || got != s {
+				t.Fatalf("round trip of %s: got %q (%v), want %q", lit, got, err, s)
+			}
+		}
+	})
+}
+
+func FuzzEscapeCSS(f *testing.F) {
+	for _, s := range []string{"red", "red;}</style><script>", "url(javascript:x)", "expression(alert(1))"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		out := renderString(t, `<style>p{color:{{x}}}</style><p style="color:{{x}}">`, map[string]any{"x": s})
+		css, rest, _ := strings.Cut(strings.TrimPrefix(out, `<style>p{color:`), `}</style><p style="color:`)
+		attr := strings.TrimSuffix(rest, `">`)
+		for _, v := range []string{css, attr} {
+			if strings.ContainsAny(v, "<>\"'(){};:\\/&") {
+				t.Fatalf("unsafe byte in CSS value %q", v)
+			}
+		}
+	})
+}
@@ -0,0 +1,317 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// htmlState is where the compiler is in the HTML surrounding the tags.
+type htmlState uint8
+
+const (
+	stateText        htmlState = iota // element content
+	stateTagName                      // <div or </div
+	stateTag                          // inside a tag, between attributes
+	stateAttrName                     // <a hr
+	stateAfterName                    // <a href  (= may follow)
+	stateBeforeValue                  // <a href=
+	stateValue                        // <a href="...
+	stateComment                      // <!-- ...
+	stateRawText                      // body of <script>, <style>, <textarea>, <title>
+)
+
+// jsState tracks strings and comments in JavaScript, in <script> bodies
+// and on* attribute values.
+type jsState uint8
+
+const (
+	jsCode jsState = iota
+	jsDoubleQuoted
+	jsSingleQuoted
+	jsTemplate
+	jsLineComment
+	jsBlockComment
+)
+
+// htmlContext follows the static text of a template through a small HTML
+// tokenizer so each variable can be given an Escape for where it lands.
+// It only runs at compile time.
+//
+// It is comparable: blocks check that every branch ends in the same
+// context, which is what makes one Escape per variable correct.
+type htmlContext struct {
+	state   htmlState
+	tag     string // lower-case name of the tag being read, or whose raw text we're in
+	closing bool   // tag is an end tag
+	attr    string // lower-case name of the attribute being read
+	quote   byte   // quote around the attribute value, 0 when unquoted
+	atStart bool   // nothing written to the attribute value yet
+	js      jsState
+	escaped bool // JS: previous byte was \ in a string, / in code, or * in a comment
+}
+
+// feed advances the context over static text.
+func (h *htmlContext) feed(text []byte) {
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch h.state {
+		case stateText:
+			if c != '<' {
+				continue
+			}
+			rest := text[i+1:]
+			switch {
+			case bytes.HasPrefix(rest, []byte("!--")):
+				h.state = stateComment
+				i += 3
+			case len(rest) > 1 && rest[0] == '/' && isLetter(rest[1]):
+				h.state, h.tag, h.closing = stateTagName, "", true
+				i++
+			case len(rest) > 0 && isLetter(rest[0]):
+				h.state, h.tag, h.closing = stateTagName, "", false
+			}
+
+		case stateTagName:
+			switch {
+			case c == '>':
+				h.endTag()
+			case isSpace(c) || c == '/':
+				h.state = stateTag
+			default:
+				h.tag += string(lower(c))
+			}
+
+		case stateTag, stateAfterName:
+			switch {
+			case c == '>':
+				h.endTag()
+			case c == '=' && h.state == stateAfterName:
+				h.state = stateBeforeValue
+			case isSpace(c) || c == '/':
+			default:
+				h.state, h.attr = stateAttrName, string(lower(c))
+			}
+
+		case stateAttrName:
+			switch {
+			case c == '>':
+				h.endTag()
+			case c == '=':
+				h.state = stateBeforeValue
+			case isSpace(c):
+				h.state = stateAfterName
+			case c == '/':
+				h.state = stateTag
+			default:
+				h.attr += string(lower(c))
+			}
+
+		case stateBeforeValue:
+			switch {
+			case c == '>':
+				h.endTag()
+			case isSpace(c):
+			case c == '"' || c == '\'':
+				h.startValue(c)
+			default:
+				h.startValue(0)
+				i-- // c is the first byte of the value
+			}
+
+		case stateValue:
+			switch {
+			case h.quote != 0 && c == h.quote, h.quote == 0 && isSpace(c):
+				*h = htmlContext{state: stateTag, tag: h.tag, closing: h.closing}
+			case h.quote == 0 && c == '>':
+				h.endTag()
+			default:
+				h.atStart = false
+				if strings.HasPrefix(h.attr, "on") {
+					h.feedJS(c)
+				}
+			}
+
+		case stateComment:
+			if c == '>' && i >= 2 && text[i-1] == '-' && text[i-2] == '-' {
+				h.state = stateText
+			}
+
+		case stateRawText:
+			// The end tag closes raw text even inside a JS string.
+			if c == '<' && i+2+len(h.tag) <= len(text) && text[i+1] == '/' &&
+				strings.EqualFold(string(text[i+2:i+2+len(h.tag)]), h.tag) {
+				h.state, h.closing = stateTag, true
+				i += 1 + len(h.tag)
+				continue
+			}
+			if h.tag == "script" {
+				h.feedJS(c)
+			}
+		}
+	}
+}
+
+// startValue begins an attribute value quoted with q, or unquoted if 0.
+func (h *htmlContext) startValue(q byte) {
+	h.state, h.quote, h.atStart = stateValue, q, true
+	h.js, h.escaped = jsCode, false
+}
+
+// endTag handles the > of a tag: back to text, or into raw text for
+// elements whose content isn't HTML.
+func (h *htmlContext) endTag() {
+	if !h.closing {
+		switch h.tag {
+		case "script", "style", "textarea", "title":
+			*h = htmlContext{state: stateRawText, tag: h.tag}
+			return
+		}
+	}
+	*h = htmlContext{}
+}
+
+// feedJS advances the JavaScript string/comment state by one byte.
+// Regular expression literals aren't tracked.
+func (h *htmlContext) feedJS(c byte) {
+	switch h.js {
+	case jsCode:
+		switch c {
+		case '"':
+			h.js = jsDoubleQuoted
+		case '\'':
+			h.js = jsSingleQuoted
+		case '`':
+			h.js = jsTemplate
+		case '/':
+			// A second / or a * after the first starts a comment; the
+			// pending slash is remembered in escaped.
+			if h.escaped {
+				h.js, h.escaped = jsLineComment, false
+				return
+			}
+			h.escaped = true
+			return
+		case '*':
+			if h.escaped {
+				h.js = jsBlockComment
+			}
+		}
+		h.escaped = false
+
+	case jsDoubleQuoted, jsSingleQuoted, jsTemplate:
+		if h.escaped {
+			h.escaped = false
+			return
+		}
+		switch {
+		case c == '\\':
+			h.escaped = true
+		case c == '"' && h.js == jsDoubleQuoted,
+			c == '\'' && h.js == jsSingleQuoted,
+			c == '`' && h.js == jsTemplate:
+			h.js = jsCode
+		}
+
+	case jsLineComment:
+		if c == '\n' || c == '\r' {
+			h.js = jsCode
+		}
+
+	case jsBlockComment:
+		if c == '/' && h.escaped {
+			h.js = jsCode
+		}
+		h.escaped = c == '*'
+	}
+}
+
+// escape returns how a variable at the current position is escaped.
+func (h *htmlContext) escape() (Escape, error) {
+	switch h.state {
+	case stateText, stateComment:
+		return EscapeHTML, nil
+
+	case stateRawText:
+		switch h.tag {
+		case "script":
+			return h.jsEscape(), nil
+		case "style":
+			return EscapeCSS, nil
+		}
+		return EscapeHTML, nil
+
+	case stateBeforeValue:
+		// <a href={{url}}>: the variable starts an unquoted value.
+		h.startValue(0)
+		fallthrough
+
+	case stateValue:
+		switch {
+		case h.attr == "srcdoc":
+			// The browser decodes the value and then parses it as a
+			// document, so entity-escaped markup would run anyway.
+			return 0, fmt.Errorf("variable in a srcdoc attribute; its value is parsed as HTML, use {{{raw}}} for trusted markup")
+		case strings.HasPrefix(h.attr, "on"):
+			return h.jsEscape(), nil
+		case h.attr == "style":
+			return EscapeCSS, nil
+		case urlAttrs[h.attr]:
+			if h.atStart {
+				return EscapeURL, nil
+			}
+			return EscapeURLPart, nil
+		case h.quote == 0:
+			return EscapeAttrUnquoted, nil
+		}
+		return EscapeHTML, nil
+	}
+	return 0, fmt.Errorf("variable inside an HTML tag; put it in an attribute value or use {{{raw}}}")
+}
+
+func (h *htmlContext) jsEscape() Escape {
+	if h.js == jsCode {
+		if h.escaped {
+			// A lone / just before: division or a regex, either way code.
+			h.escaped = false
+		}
+		return EscapeJS
+	}
+	// Inside a string, or a comment, where a string's escaping keeps the
+	// value from ending the comment.
+	return EscapeJSString
+}
+
+// wrote records that a variable was written at the current position.
+func (h *htmlContext) wrote() {
+	if h.state == stateValue {
+		h.atStart = false
+	}
+}
+
+// urlAttrs are attributes whose value is a URL.
+var urlAttrs = map[string]bool{
+	"action":     true,
+	"background": true,
+	"cite":       true,
+	"data":       true,
+	"formaction": true,
+	"href":       true,
+	"icon":       true,
+	"manifest":   true,
+	"poster":     true,
+	"src":        true,
+	"srcset":     true,
+	"xlink:href": true,
+}
+
+func isLetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
+
+func isSpace(c byte) bool { return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' }
+
+func lower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
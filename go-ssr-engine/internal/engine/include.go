@@ -0,0 +1,169 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Loader returns the source of a partial or layout by name.
+type Loader func(name string) ([]byte, error)
+
+// override is a {{#block}} body from a template extending a layout:
+// src[from:to] replaces the layout's block of the same name.
+type override struct {
+	src      []byte
+	from, to int
+	file     string // template it came from, for errors
+}
+
+// file compiles a whole template: either plain content, or an
+// {{extends}} whose blocks are collected before the layout is compiled.
+func (c *compiler) file(src []byte) error {
+	tagStart, tagEnd, tag, raw, err := nextTag(src, 0)
+	if err != nil || tagStart < 0 || raw || !strings.HasPrefix(tag, "extends ") ||
+		len(bytes.TrimSpace(src[:tagStart])) > 0 {
+		return c.parse(src, 0, len(src))
+	}
+
+	layout, err := templateName(tag[len("extends "):])
+	if err != nil {
+		return fmt.Errorf("%w at offset %d", err, tagStart)
+	}
+	if err := c.collectBlocks(src, tagEnd); err != nil {
+		return err
+	}
+	if err := c.include(layout, c.file); err != nil {
+		return fmt.Errorf("{{%s}}: %w", tag, err)
+	}
+	return nil
+}
+
+// collectBlocks records the {{#block}}s after an {{extends}}. Blocks
+// already recorded came from a template further down the chain, which
+// wins. Text between blocks is ignored; other tags are an error.
+func (c *compiler) collectBlocks(src []byte, from int) error {
+	file := ""
+	if len(c.including) > 0 {
+		file = c.including[len(c.including)-1]
+	}
+	for {
+		tagStart, tagEnd, tag, _, err := nextTag(src, from)
+		if err != nil {
+			return err
+		}
+		if tagStart < 0 {
+			return nil
+		}
+		if !strings.HasPrefix(tag, "#block ") {
+			return fmt.Errorf("{{%s}} outside {{#block}} in a template that extends a layout, at offset %d", tag, tagStart)
+		}
+		name, err := templateName(tag[len("#block "):])
+		if err != nil {
+			return fmt.Errorf("%w at offset %d", err, tagStart)
+		}
+		bodyEnd, end, err := blockEnd(src, tagEnd)
+		if err != nil {
+			return fmt.Errorf("%w at offset %d", err, tagStart)
+		}
+		if _, ok := c.overrides[name]; !ok {
+			c.overrides[name] = override{src: src, from: tagEnd, to: bodyEnd, file: file}
+		}
+		from = end
+	}
+}
+
+// block compiles a layout's {{#block}}: the override from an extending
+// template if there is one, else its own body, src[from:to].
+func (c *compiler) block(nameArg string, src []byte, from, to int) error {
+	name, err := templateName(nameArg)
+	if err != nil {
+		return fmt.Errorf("%w at offset %d", err, from)
+	}
+	o, ok := c.overrides[name]
+	if !ok {
+		return c.parse(src, from, to)
+	}
+	// Compiled once: a nested layout block of the same name gets the
+	// layout's default.
+	delete(c.overrides, name)
+	defer func() { c.overrides[name] = o }()
+
+	if err := c.parse(o.src, o.from, o.to); err != nil {
+		if o.file != "" {
+			return fmt.Errorf("block %q from %q: %w", name, o.file, err)
+		}
+		return fmt.Errorf("block %q: %w", name, err)
+	}
+	return nil
+}
+
+// partial compiles {{> name}} in place: same scope, same HTML context.
+func (c *compiler) partial(nameArg string) error {
+	name, err := templateName(nameArg)
+	if err != nil {
+		return err
+	}
+	return c.include(name, func(src []byte) error {
+		return c.parse(src, 0, len(src))
+	})
+}
+
+// include loads a partial or layout and compiles it with compile.
+func (c *compiler) include(name string, compile func([]byte) error) error {
+	if c.load == nil {
+		return fmt.Errorf("no loader for %q: partials and layouts need CompileWith", name)
+	}
+	if slices.Contains(c.including, name) {
+		return fmt.Errorf("%q includes itself: %s -> %s", name, strings.Join(c.including, " -> "), name)
+	}
+	src, err := c.load(name)
+	if err != nil {
+		return err
+	}
+
+	c.including = append(c.including, name)
+	defer func() { c.including = c.including[:len(c.including)-1] }()
+
+	return compile(src)
+}
+
+// blockEnd finds the {{/block}} matching a {{#block}} whose body starts
+// at from: the body ends at bodyEnd, the closing tag at end.
+func blockEnd(src []byte, from int) (bodyEnd, end int, err error) {
+	nested := 0
+	for {
+		tagStart, tagEnd, tag, raw, err := nextTag(src, from)
+		if err != nil {
+			return 0, 0, err
+		}
+		if tagStart < 0 {
+			return 0, 0, fmt.Errorf("unclosed {{#block}}")
+		}
+		switch {
+		case raw:
+		case strings.HasPrefix(tag, "#block "):
+			nested++
+		case tag == "/block":
+			if nested == 0 {
+				return tagStart, tagEnd, nil
+			}
+			nested--
+		}
+		from = tagEnd
+	}
+}
+
+// templateName parses the name in {{> name}}, {{extends "name"}} or
+// {{#block "name"}}. Quotes are optional.
+func templateName(arg string) (string, error) {
+	name := strings.TrimSpace(arg)
+	if len(name) >= 2 && name[0] == '"' && name[len(name)-1] == '"' {
+		name = name[1 : len(name)-1]
+	}
+	if name == "" || strings.ContainsAny(name, " \t\n\"{}") {
+		return "", fmt.Errorf("invalid template name %q", arg)
+	}
+	return name, nil
+}
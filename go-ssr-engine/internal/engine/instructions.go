@@ -11,6 +11,27 @@ const (
 	// OpVar writes a variable value by index lookup.
 	// Index referes to position in RenderContext.Values.
 	OpVar
+
+	// OpIf tests variable Idx. When falsy, execution jumps to Jump
+	// (the {{else}} body, or the end of the block).
+	OpIf
+
+	// OpEach runs the body (next instruction up to Jump) once per item
+	// of list Idx, with the item as context. Jump is the block's OpJump;
+	// an empty list continues after it, into the {{else}} body if any.
+	OpEach
+
+	// OpJump continues execution at Jump. Ends a then-body that has an
+	// {{else}}, and terminates every each-body.
+	OpJump
+
+	// OpIndex writes the current item's position inside {{#each}}.
+	OpIndex
+
+	// OpFlush sends what has been rendered so far, if the writer is a
+	// Flusher. Placed after </head> so the browser can fetch styles and
+	// scripts while the body renders.
+	OpFlush
 )
 
 // Instruction is a single compiled template operation.
@@ -23,13 +44,19 @@ const (
 //
 // Memory layout:
 //
-//	Op   -> 1 byte
-//	Data -> slice header (24 bytes)
-//	Idx  -> 2 bytes
+//	Op    -> 1 byte
+//	Depth -> 1 byte
+//	Idx   -> 2 bytes
+//	Jump  -> 4 bytes
+//	Esc   -> 1 byte (+7 padding)
+//	Data  -> slice header (24 bytes)
 //
-// Total small enough to stay cache friendly.
+// 40 bytes total.
 type Instruction struct {
-	Op   OpCode // operation type
-	Data []byte // static text (used when OpText)
-	Idx  uint16 // variable index (used when OpVar)
+	Op    OpCode // operation type
+	Depth uint8  // scopes to walk up via ../ (OpVar, OpIf, OpEach, OpIndex)
+	Idx   uint16 // variable index (OpVar, OpIf) or list index (OpEach)
+	Jump  uint32 // target instruction (OpIf, OpEach, OpJump)
+	Esc   Escape // how OpVar escapes its value, from where it sits in the HTML
+	Data  []byte // static text (used when OpText)
 }
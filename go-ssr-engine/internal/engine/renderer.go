@@ -1,6 +1,15 @@
 package engine
 
-import "io"
+import (
+	"io"
+	"strconv"
+)
+
+// Flusher is a writer that can send what has been written so far on to
+// the client, like *bufio.Writer. RenderTo flushes it after </head>.
+type Flusher interface {
+	Flush() error
+}
 
 // RenderTo renders template directly to io.Writer.
 //
@@ -11,7 +20,17 @@ import "io"
 //
 // Hot path must remain extremely simple for branch prediction.
 func (t *Template) RenderTo(w io.Writer, ctx *RenderContext) error {
-	for _, inst := range t.Instructions {
+	if t.flat {
+		return t.renderFlat(w, ctx)
+	}
+	return t.exec(w, 0, len(t.Instructions), ctx)
+}
+
+// renderFlat is the loop for templates of only text and top-level
+// variables — the common case, kept free of jumps and scope walks.
+func (t *Template) renderFlat(w io.Writer, ctx *RenderContext) error {
+	for i := range t.Instructions {
+		inst := &t.Instructions[i]
 		switch inst.Op {
 		case OpText:
 			// Static write
@@ -24,7 +43,12 @@ func (t *Template) RenderTo(w io.Writer, ctx *RenderContext) error {
 			if int(inst.Idx) >= len(ctx.Values) {
 				continue
 			}
-			if _, err := w.Write(ctx.Values[inst.Idx]); err != nil {
+			if err := writeVar(w, inst.Esc, ctx.Values[inst.Idx]); err != nil {
+				return err
+			}
+
+		case OpFlush:
+			if err := flush(w); err != nil {
 				return err
 			}
 		}
@@ -32,6 +56,125 @@ func (t *Template) RenderTo(w io.Writer, ctx *RenderContext) error {
 	return nil
 }
 
+// exec runs instructions [pc, end) against ctx. Blocks are jumps within
+// the range; only {{#each}} recurses, once per item, over its body.
+func (t *Template) exec(w io.Writer, pc, end int, ctx *RenderContext) error {
+	ins := t.Instructions[:end]
+	for pc < len(ins) {
+		inst := &ins[pc]
+		switch inst.Op {
+		case OpText:
+			// Static write
+			if _, err := w.Write(inst.Data); err != nil {
+				return err
+			}
+
+		case OpVar:
+			// Indexed variable lookup. Depth is almost always 0;
+			// skip the scope walk for it.
+			c := ctx
+			if inst.Depth != 0 {
+				if c = ctx.up(inst.Depth); c == nil {
+					break
+				}
+			}
+			if int(inst.Idx) >= len(c.Values) {
+				break
+			}
+			if err := writeVar(w, inst.Esc, c.Values[inst.Idx]); err != nil {
+				return err
+			}
+
+		case OpIf:
+			c := ctx.up(inst.Depth)
+			if c == nil || int(inst.Idx) >= len(c.Values) || !truthy(c.Values[inst.Idx]) {
+				pc = int(inst.Jump)
+				continue
+			}
+
+		case OpEach:
+			var items []RenderContext
+			if c := ctx.up(inst.Depth); c != nil && int(inst.Idx) < len(c.Lists) {
+				items = c.Lists[inst.Idx]
+			}
+			if len(items) == 0 {
+				// Skip the body and its terminating jump: the else body
+				// follows, or the end of the block.
+				pc = int(inst.Jump) + 1
+				continue
+			}
+			for i := range items {
+				if err := t.exec(w, pc+1, int(inst.Jump), &items[i]); err != nil {
+					return err
+				}
+			}
+			// Land on the terminating jump, which skips any else body.
+			pc = int(inst.Jump)
+			continue
+
+		case OpJump:
+			pc = int(inst.Jump)
+			continue
+
+		case OpIndex:
+			c := ctx.up(inst.Depth)
+			if c == nil {
+				break
+			}
+			text := c.indexText
+			if text == nil {
+				// Context built by hand rather than by NewContext.
+				text = strconv.AppendInt(nil, int64(c.Index), 10)
+			}
+			if _, err := w.Write(text); err != nil {
+				return err
+			}
+
+		case OpFlush:
+			if err := flush(w); err != nil {
+				return err
+			}
+		}
+		pc++
+	}
+	return nil
+}
+
+// flush flushes w if it can be.
+func flush(w io.Writer) error {
+	if f, ok := w.(Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// writeVar writes a variable's value with the instruction's escaping.
+func writeVar(w io.Writer, esc Escape, v []byte) error {
+	if esc == EscapeNone || esc == EscapeHTML && !needsHTMLEscape(v) {
+		_, err := w.Write(v)
+		return err
+	}
+	return writeEscaped(w, esc, v)
+}
+
+// up walks depth scopes out via ../. Nil if there aren't that many.
+func (ctx *RenderContext) up(depth uint8) *RenderContext {
+	for ; depth > 0 && ctx != nil; depth-- {
+		ctx = ctx.Parent
+	}
+	return ctx
+}
+
+// truthy reports whether a value passes {{#if}}: non-empty, and not
+// "false" or "0" (how bools and empty lists are formatted).
+func truthy(v []byte) bool {
+	switch string(v) {
+	case "", "false", "0":
+		return false
+	}
+	return true
+}
+
 // RenderBytes renders template into pooled buffer and returns bytes.
 //
 // Caller must not retain returned slice after next pool reuse.
@@ -7,4 +7,33 @@ package engine
 type Template struct {
 	Instructions []Instruction
 	VarCount     uint16
+
+	// flat is true when every instruction is OpText or a top-level OpVar,
+	// so RenderTo can skip jump and scope handling.
+	flat bool
+
+	// Root lists the variables and lists the template reads at top level.
+	// Used to build a RenderContext from data; never touched while rendering.
+	Root *Scope
+}
+
+// Scope describes what one level of a template reads: the top level, or
+// the body of an {{#each}} block, which reads from the current item.
+//
+// Paths are dotted (user.name) relative to the scope's data. Their
+// positions are the indexes instructions use, so a RenderContext built
+// from a Scope lines up with the compiled instructions.
+type Scope struct {
+	Vars  []string // Values[i] holds Vars[i]
+	Lists []string // Lists[i] holds the items of Lists[i]
+	Items []*Scope // scope of each list's items, parallel to Lists
+}
+
+// VarIndex returns top-level variable path -> index in RenderContext.Values.
+func (t *Template) VarIndex() map[string]uint16 {
+	m := make(map[string]uint16, len(t.Root.Vars))
+	for i, name := range t.Root.Vars {
+		m[name] = uint16(i)
+	}
+	return m
 }
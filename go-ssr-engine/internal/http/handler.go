@@ -1,37 +1,69 @@
 package http
 
 import (
+	"context"
+	"errors"
+	"log"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 
+	"github.com/thutasann/go-ssr-engine/internal/cache"
 	"github.com/thutasann/go-ssr-engine/internal/engine"
 	"github.com/thutasann/go-ssr-engine/internal/worker"
 )
 
-// Handler wires HTTP to worker pool with dynamic variable injection
+// Handler wires HTTP to worker pool with dynamic variable injection.
+//
+// The URL path picks the template: / is "index", /blog/post is "blog/post".
 type Handler struct {
-	Pool           *worker.WorkerPool
-	Tpl            *engine.Template
-	VarNameToIndex map[string]uint16 // mapping from compiler
+	Pool      *worker.WorkerPool
+	Templates *cache.TemplateCache
+
+	// Timeout bounds each request's render, time in the queue included.
+	// Zero leaves only the client going away to stop it.
+	Timeout time.Duration
 }
 
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.Trim(r.URL.Path, "/")
+	if name == "" {
+		name = "index"
+	}
+	// Looked up per request: a dev reload may have swapped it.
+	tpl, ok := h.Templates.Get(name)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Read query parameters as template variables, in the order the
+	// compiler assigned them
+	query := r.URL.Query()
+	values := make([][]byte, tpl.VarCount)
+	for idx, path := range tpl.Root.Vars {
+		values[idx] = []byte(query.Get(path))
+	}
+	ctx := engine.RenderContext{Values: values}
 
-	// Read query parameters as template variables
-	varMap := map[string]string{}
-	for key := range h.VarNameToIndex {
-		val := r.URL.Query().Get(key)
-		varMap[key] = val
+	// Canceled when the client disconnects, too
+	jobCtx := r.Context()
+	if h.Timeout > 0 {
+		var cancel context.CancelFunc
+		jobCtx, cancel = context.WithTimeout(jobCtx, h.Timeout)
+		defer cancel()
 	}
 
-	ctx := engine.NewRenderContext(varMap, h.Tpl, h.VarNameToIndex)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 
-	done := make(chan struct{})
+	done := make(chan error, 1)
 	job := worker.Job{
-		Tpl:  h.Tpl,
-		Ctx:  ctx,
-		Res:  w,
-		Done: done,
+		Tpl:     tpl,
+		Ctx:     ctx,
+		Res:     w,
+		Context: jobCtx,
+		Done:    done,
 	}
 
 	err := h.Pool.Submit(job, 50*time.Millisecond)
@@ -41,5 +73,29 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Wait for worker to finish
-	<-done
+	if err := <-done; err != nil {
+		renderFailed(w, r, err)
+	}
+}
+
+// renderFailed answers a failed render. Until the worker has sent part of
+// the page there's still a status to set. After, the connection is aborted
+// so the client sees a broken response rather than a truncated page that
+// looks complete.
+func renderFailed(w http.ResponseWriter, r *http.Request, err error) {
+	var jobErr *worker.JobError
+	if errors.As(err, &jobErr) && jobErr.Sent {
+		log.Printf("%s %s: %v (response already started, aborting)", r.Method, r.URL.Path, err)
+		panic(http.ErrAbortHandler)
+	}
+
+	switch {
+	case r.Context().Err() != nil:
+		// Client went away; nobody to answer.
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		http.Error(w, "Render Timeout", http.StatusGatewayTimeout)
+	default:
+		log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+		http.Error(w, "Render Failed", http.StatusInternalServerError)
+	}
 }
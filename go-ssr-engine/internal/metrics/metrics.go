@@ -1 +1,125 @@
 package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics counts what happens to render jobs.
+//
+// Every field is updated with atomics: workers never share a lock to
+// record a job.
+type Metrics struct {
+	QueueDepth atomic.Int64 // jobs submitted but not yet picked up by a worker
+	Rejected   atomic.Int64 // jobs refused because the queue stayed full
+	Failed     atomic.Int64 // jobs whose render returned an error, timeouts included
+	TimedOut   atomic.Int64 // jobs that hit their deadline, queued or rendering
+
+	RenderLatency *Histogram // time from a worker picking a job up to its last flush
+}
+
+// New returns zeroed Metrics with DefaultBuckets for render latency.
+func New() *Metrics {
+	return &Metrics{RenderLatency: NewHistogram(DefaultBuckets)}
+}
+
+// DefaultBuckets are render latency bucket bounds in seconds, from half a
+// millisecond to a second.
+var DefaultBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1}
+
+// Histogram counts durations into fixed buckets.
+type Histogram struct {
+	bounds []float64       // upper bounds in seconds, ascending
+	counts []atomic.Uint64 // per bucket, plus one for above the last bound
+	sum    atomic.Int64    // nanoseconds
+}
+
+// NewHistogram returns a histogram with the given ascending upper bounds.
+func NewHistogram(bounds []float64) *Histogram {
+	return &Histogram{
+		bounds: bounds,
+		counts: make([]atomic.Uint64, len(bounds)+1),
+	}
+}
+
+// Observe records one duration.
+func (h *Histogram) Observe(d time.Duration) {
+	s := d.Seconds()
+	i := 0
+	for i < len(h.bounds) && s > h.bounds[i] {
+		i++
+	}
+	h.counts[i].Add(1)
+	h.sum.Add(int64(d))
+}
+
+// Count returns how many durations were observed.
+func (h *Histogram) Count() uint64 {
+	var n uint64
+	for i := range h.counts {
+		n += h.counts[i].Load()
+	}
+	return n
+}
+
+// WriteTo writes the metrics in the Prometheus text format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+
+	gauge(cw, "ssr_queue_depth", "Render jobs waiting for a worker.", m.QueueDepth.Load())
+	counter(cw, "ssr_jobs_rejected_total", "Render jobs rejected because the queue was full.", m.Rejected.Load())
+	counter(cw, "ssr_render_errors_total", "Render jobs that failed, including timeouts.", m.Failed.Load())
+	counter(cw, "ssr_render_timeouts_total", "Render jobs that hit their deadline.", m.TimedOut.Load())
+
+	h := m.RenderLatency
+	const name = "ssr_render_duration_seconds"
+	fmt.Fprintf(cw, "# HELP %s Time to render a page, from pickup by a worker to the last flush.\n# TYPE %s histogram\n", name, name)
+	var cumulative uint64
+	for i, bound := range h.bounds {
+		cumulative += h.counts[i].Load()
+		fmt.Fprintf(cw, "%s_bucket{le=%q} %d\n", name, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+	}
+	cumulative += h.counts[len(h.bounds)].Load()
+	fmt.Fprintf(cw, "%s_bucket{le=\"+Inf\"} %d\n", name, cumulative)
+	fmt.Fprintf(cw, "%s_sum %s\n", name, strconv.FormatFloat(time.Duration(h.sum.Load()).Seconds(), 'g', -1, 64))
+	fmt.Fprintf(cw, "%s_count %d\n", name, cumulative)
+
+	return cw.n, cw.err
+}
+
+// Handler serves the metrics for a Prometheus scrape.
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.WriteTo(w)
+	})
+}
+
+func gauge(w io.Writer, name, help string, v int64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %d\n", name, help, name, name, v)
+}
+
+func counter(w io.Writer, name, help string, v int64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, v)
+}
+
+// countingWriter keeps the byte count and first error for WriteTo.
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteTo(t *testing.T) {
+	m := New()
+	m.QueueDepth.Add(3)
+	m.Rejected.Add(2)
+	m.RenderLatency.Observe(300 * time.Microsecond)
+	m.RenderLatency.Observe(20 * time.Millisecond)
+	m.RenderLatency.Observe(5 * time.Second)
+
+	var b strings.Builder
+	if _, err := m.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"# TYPE ssr_queue_depth gauge\nssr_queue_depth 3\n",
+		"ssr_jobs_rejected_total 2\n",
+		`ssr_render_duration_seconds_bucket{le="0.0005"} 1` + "\n",
+		`ssr_render_duration_seconds_bucket{le="0.01"} 1` + "\n",
+		`ssr_render_duration_seconds_bucket{le="0.025"} 2` + "\n",
+		`ssr_render_duration_seconds_bucket{le="1"} 2` + "\n",
+		`ssr_render_duration_seconds_bucket{le="+Inf"} 3` + "\n",
+		"ssr_render_duration_seconds_sum 5.0203\n",
+		"ssr_render_duration_seconds_count 3\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
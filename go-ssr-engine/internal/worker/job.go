@@ -1,6 +1,7 @@
 package worker
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/thutasann/go-ssr-engine/internal/engine"
@@ -23,5 +24,30 @@ type Job struct {
 	Ctx engine.RenderContext
 	Res http.ResponseWriter
 
-	Done chan struct{} // signal completion
+	// Context bounds the job. If it ends while the job is queued the job
+	// is dropped; while rendering, the next write fails and rendering
+	// stops. Its deadline also applies to writes to a slow client.
+	// Nil means no limit.
+	Context context.Context
+
+	// Done receives the job's result: nil, or a *JobError.
+	// Must be buffered so the worker never blocks on it.
+	Done chan error
+}
+
+// JobError is a job that failed.
+type JobError struct {
+	Err error
+
+	// Sent reports whether part of the response already went out, so
+	// the status code can no longer be changed.
+	Sent bool
+}
+
+func (e *JobError) Error() string {
+	return "worker: render: " + e.Err.Error()
+}
+
+func (e *JobError) Unwrap() error {
+	return e.Err
 }
@@ -3,9 +3,13 @@ package worker
 import (
 	"context"
 	"errors"
+	"net/http"
+	"os"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/thutasann/go-ssr-engine/internal/metrics"
 )
 
 // WorkerPool is a bounded goroutine pool.
@@ -24,8 +28,9 @@ type WorkerPool struct {
 	wg sync.WaitGroup
 
 	// atomic counters (avoid mutex)
-	active   int64
-	rejected int64
+	active int64
+
+	metrics *metrics.Metrics
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -41,6 +46,7 @@ func New(workers, queueSize int) *WorkerPool {
 	return &WorkerPool{
 		queue:   make(chan Job, queueSize),
 		workers: workers,
+		metrics: metrics.New(),
 		ctx:     ctx,
 		cancel:  cancel,
 	}
@@ -65,29 +71,80 @@ func (p *WorkerPool) workerLoop() {
 			return
 
 		case job := <-p.queue:
+			p.metrics.QueueDepth.Add(-1)
 			atomic.AddInt64(&p.active, 1)
 
-			_ = job.Tpl.RenderTo(job.Res, &job.Ctx)
+			err := p.run(job)
 
 			atomic.AddInt64(&p.active, -1)
 
 			// Signal completion
-			close(job.Done)
+			job.Done <- err
 		}
 	}
 }
 
+// run renders one job into its response.
+func (p *WorkerPool) run(job Job) error {
+	ctx := job.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// Deadline passed (or client gone) while queued: don't start.
+	if err := ctx.Err(); err != nil {
+		p.metrics.Failed.Add(1)
+		p.countTimeout(err)
+		return &JobError{Err: err}
+	}
+
+	w := getWriter(ctx, job.Res)
+	defer putWriter(w)
+
+	// A client that stops reading blocks writes; the deadline fails
+	// them instead of holding the worker. Unsupported writers are
+	// only bounded by the context checks.
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = http.NewResponseController(job.Res).SetWriteDeadline(deadline)
+	}
+
+	start := time.Now()
+	err := job.Tpl.RenderTo(w, &job.Ctx)
+	if err == nil {
+		err = w.Flush()
+	}
+	p.metrics.RenderLatency.Observe(time.Since(start))
+
+	if err != nil {
+		p.metrics.Failed.Add(1)
+		p.countTimeout(err)
+		return &JobError{Err: err, Sent: w.sent}
+	}
+	return nil
+}
+
+// countTimeout counts err if it is a context or write deadline.
+func (p *WorkerPool) countTimeout(err error) {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) {
+		p.metrics.TimedOut.Add(1)
+	}
+}
+
 // Submit enqueues job with backpressure control.
 //
 // timeout defines how long caller waits before rejecting
 func (p *WorkerPool) Submit(job Job, timeout time.Duration) error {
+	// Counted before the send so a worker's decrement can't come first.
+	p.metrics.QueueDepth.Add(1)
 	select {
 	case p.queue <- job:
 		return nil
 	case <-time.After(timeout):
-		atomic.AddInt64(&p.rejected, 1)
+		p.metrics.QueueDepth.Add(-1)
+		p.metrics.Rejected.Add(1)
 		return errors.New("queue full")
 	case <-p.ctx.Done():
+		p.metrics.QueueDepth.Add(-1)
 		return errors.New("worker pool shutting down")
 	}
 }
@@ -108,5 +165,10 @@ func (p *WorkerPool) Active() int64 {
 
 // Rejected returns number of rejectd jobs.
 func (p *WorkerPool) Rejected() int64 {
-	return atomic.LoadInt64(&p.rejected)
+	return p.metrics.Rejected.Load()
+}
+
+// Metrics returns the pool's job metrics.
+func (p *WorkerPool) Metrics() *metrics.Metrics {
+	return p.metrics
 }
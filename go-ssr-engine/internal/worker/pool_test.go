@@ -0,0 +1,122 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thutasann/go-ssr-engine/internal/engine"
+)
+
+// recorder is a ResponseWriter that records where flushes happened and
+// can be slow, like a client that reads slowly.
+type recorder struct {
+	header  http.Header
+	body    strings.Builder
+	flushes []int // body length at each flush
+	delay   time.Duration
+}
+
+func (r *recorder) Header() http.Header {
+	if r.header == nil {
+		r.header = http.Header{}
+	}
+	return r.header
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	time.Sleep(r.delay)
+	return r.body.Write(p)
+}
+
+func (r *recorder) WriteHeader(int) {}
+
+func (r *recorder) Flush() { r.flushes = append(r.flushes, r.body.Len()) }
+
+func startPool(t *testing.T) *WorkerPool {
+	t.Helper()
+	p := New(2, 10)
+	p.Start()
+	t.Cleanup(p.Shutdown)
+	return p
+}
+
+func run(t *testing.T, p *WorkerPool, ctx context.Context, src string, data map[string]any, res http.ResponseWriter) error {
+	t.Helper()
+	tpl, err := engine.Compile([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	job := Job{Tpl: tpl, Ctx: tpl.NewContext(data), Res: res, Context: ctx, Done: done}
+	if err := p.Submit(job, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	return <-done
+}
+
+func TestFlushAfterHead(t *testing.T) {
+	p := startPool(t)
+	res := &recorder{}
+	err := run(t, p, context.Background(), `<html><head><title>{{t}}</title></head><body>{{b}}</body></html>`,
+		map[string]any{"t": "T", "b": "B"}, res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head := len("<html><head><title>T</title></head>")
+	if len(res.flushes) != 2 || res.flushes[0] != head || res.flushes[1] != res.body.Len() {
+		t.Fatalf("flushes at %v, want [%d %d]", res.flushes, head, res.body.Len())
+	}
+
+	m := p.Metrics()
+	if m.RenderLatency.Count() != 1 || m.Failed.Load() != 0 || m.QueueDepth.Load() != 0 {
+		t.Fatalf("metrics: count=%d failed=%d depth=%d", m.RenderLatency.Count(), m.Failed.Load(), m.QueueDepth.Load())
+	}
+}
+
+func TestDeadlineAbortsRender(t *testing.T) {
+	p := startPool(t)
+	items := make([]any, 100)
+	for i := range items {
+		items[i] = strings.Repeat("x", 5000) // each bypasses the buffer
+	}
+	res := &recorder{delay: 20 * time.Millisecond}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := run(t, p, ctx, `<head></head>{{#each items}}{{this}}{{/each}}`, map[string]any{"items": items}, res)
+
+	var jobErr *JobError
+	if !errors.As(err, &jobErr) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want a JobError for the deadline", err)
+	}
+	if !jobErr.Sent {
+		t.Error("Sent = false after the head was flushed")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("render ran %v past its deadline", elapsed)
+	}
+	if got := p.Metrics().TimedOut.Load(); got != 1 {
+		t.Errorf("TimedOut = %d, want 1", got)
+	}
+}
+
+func TestExpiredJobNotRendered(t *testing.T) {
+	p := startPool(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	res := &recorder{}
+	err := run(t, p, ctx, `hello`, nil, res)
+	var jobErr *JobError
+	if !errors.As(err, &jobErr) || jobErr.Sent || !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want an unsent JobError", err)
+	}
+	if res.body.Len() != 0 {
+		t.Fatalf("wrote %q for a canceled job", res.body.String())
+	}
+}
@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"sync"
+)
+
+// jobWriter is what a job renders into.
+//
+// Writes are buffered and go to the client when the buffer fills, at the
+// template's flush point (after </head>) and at the end of the job. Once
+// the job's context is done every write fails, which stops rendering.
+type jobWriter struct {
+	ctx  context.Context
+	buf  *bufio.Writer
+	res  http.ResponseWriter
+	sent bool // anything reached res
+}
+
+var writerPool = sync.Pool{
+	New: func() any {
+		w := &jobWriter{}
+		w.buf = bufio.NewWriterSize((*responseSink)(w), 4096)
+		return w
+	},
+}
+
+func getWriter(ctx context.Context, res http.ResponseWriter) *jobWriter {
+	w := writerPool.Get().(*jobWriter)
+	w.ctx, w.res, w.sent = ctx, res, false
+	w.buf.Reset((*responseSink)(w))
+	return w
+}
+
+// putWriter returns w to the pool, dropping its references to the request.
+func putWriter(w *jobWriter) {
+	w.ctx, w.res = nil, nil
+	writerPool.Put(w)
+}
+
+func (w *jobWriter) Write(p []byte) (int, error) {
+	// Non-blocking check; no lock unlike ctx.Err().
+	select {
+	case <-w.ctx.Done():
+		return 0, w.ctx.Err()
+	default:
+	}
+	return w.buf.Write(p)
+}
+
+// Flush sends buffered output to the client now. Implements engine.Flusher.
+func (w *jobWriter) Flush() error {
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+	// Not every ResponseWriter can flush (httptest's can't always);
+	// output still goes out when the handler returns.
+	if err := http.NewResponseController(w.res).Flush(); err != nil && err != http.ErrNotSupported {
+		return err
+	}
+	return nil
+}
+
+// responseSink is the buffer's destination: the response, noting that
+// something was sent.
+type responseSink jobWriter
+
+func (s *responseSink) Write(p []byte) (int, error) {
+	s.sent = true
+	return s.res.Write(p)
+}
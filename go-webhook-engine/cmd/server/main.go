@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -14,13 +15,17 @@ import (
 	http2 "github.com/thutasann/go-webhook-engine/internal/delivery/http"
 	"github.com/thutasann/go-webhook-engine/internal/queue"
 	"github.com/thutasann/go-webhook-engine/internal/repository"
+	"github.com/thutasann/go-webhook-engine/internal/service"
 	"github.com/thutasann/go-webhook-engine/internal/worker"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func main() {
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
 
 	// Root context for entire app lifecycle
 	rootCtx, rootCancel := context.WithCancel(context.Background())
@@ -38,6 +43,16 @@ func main() {
 		log.Fatal(err)
 	}
 
+	subRepo := repository.NewMongoSubscriptionRepository(db, "subscriptions")
+	if err := subRepo.EnsureIndexes(rootCtx); err != nil {
+		log.Fatal(err)
+	}
+
+	attemptRepo := repository.NewMongoAttemptRepository(db, "delivery_attempts")
+	if err := attemptRepo.EnsureIndexes(rootCtx); err != nil {
+		log.Fatal(err)
+	}
+
 	// ----- Redis -----
 	redisClient := redis.NewClient(&redis.Options{
 		Addr:     cfg.RedisAddr,
@@ -45,31 +60,80 @@ func main() {
 		DB:       cfg.RedisDB,
 	})
 
-	queue := queue.NewRedisQueue(redisClient, "webhook:events")
+	var q queue.Queue
+	switch cfg.QueueBackend {
+	case "memory":
+		q = queue.NewMemoryQueue(cfg.VisibilityTimeout)
+	default:
+		q = queue.NewRedisQueue(redisClient, "webhook:events", cfg.VisibilityTimeout)
+	}
 
 	// ----- Rate Limiter ----
 	rl := worker.NewRateLimiter(5)
 	defer rl.Stop()
 
+	// ----- Delivery -----
+	deliveryClient := &http.Client{
+		Timeout: cfg.DeliveryTimeout,
+	}
+	if !cfg.AllowPrivateURLs {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		// Through a proxy the dialer would only ever see the proxy's address
+		transport.Proxy = nil
+		transport.DialContext = (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Control:   service.PublicDialControl,
+		}).DialContext
+		deliveryClient.Transport = transport
+	}
+	processor := service.NewProcessor(subRepo, attemptRepo, deliveryClient)
+
 	// ----- Worker Pool -----
 	pool := worker.NewPool(
 		5,  // minWorkers
 		20, // maxWorkers
 		5,  // initialWorkers
-		queue,
+		q,
 		repo,
 		rl,
+		processor,
+		worker.Backoff{
+			Base:       cfg.RetryBaseDelay,
+			Max:        cfg.RetryMaxDelay,
+			Multiplier: cfg.RetryMultiplier,
+			Jitter:     cfg.RetryJitter,
+		},
+		worker.Maintenance{
+			PromoteInterval: cfg.PromoteInterval,
+			ReapInterval:    cfg.ReapInterval,
+			StaleAfter:      cfg.StaleProcessingAfter,
+			RenewInterval:   cfg.VisibilityTimeout / 3,
+		},
 	)
 	pool.Start(rootCtx)
 
 	log.Println("worker pool started")
 
 	// ----- HTTP -----
-	handler := http2.NewHandler(repo, queue)
+	handler := http2.NewHandler(repo, q)
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/webhook", handler.Webhook)
 
+	// Subscriptions and the admin API (DLQ inspection, replay and purge)
+	// both need the admin token; without one neither is served
+	if cfg.AdminToken != "" {
+		subscriptions := http2.NewSubscriptionHandler(subRepo, cfg.AllowPrivateURLs)
+		mux.Handle("/subscriptions", http2.RequireToken(cfg.AdminToken, http.HandlerFunc(subscriptions.Subscriptions)))
+
+		admin := http.NewServeMux()
+		http2.NewDLQHandler(repo, attemptRepo, q).Register(admin)
+		mux.Handle("/admin/", http2.RequireToken(cfg.AdminToken, admin))
+	} else {
+		log.Println("subscription and admin APIs disabled: set ADMIN_TOKEN to enable them")
+	}
+
 	server := &http.Server{
 		Addr:    ":8080",
 		Handler: mux,
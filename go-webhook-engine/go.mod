@@ -2,7 +2,12 @@ module github.com/thutasann/go-webhook-engine
 
 go 1.25.5
 
-require go.mongodb.org/mongo-driver v1.17.9
+require (
+	github.com/alicebob/miniredis/v2 v2.39.0
+	go.mongodb.org/mongo-driver v1.17.9
+)
+
+require github.com/yuin/gopher-lua v1.1.1 // indirect
 
 require (
 	github.com/cespare/xxhash/v2 v2.3.0 // indirect
@@ -10,7 +15,7 @@ require (
 	github.com/golang/snappy v0.0.4 // indirect
 	github.com/klauspost/compress v1.16.7 // indirect
 	github.com/montanaflynn/stats v0.7.1 // indirect
-	github.com/redis/go-redis/v9 v9.18.0 // indirect
+	github.com/redis/go-redis/v9 v9.18.0
 	github.com/xdg-go/pbkdf2 v1.0.0 // indirect
 	github.com/xdg-go/scram v1.1.2 // indirect
 	github.com/xdg-go/stringprep v1.0.4 // indirect
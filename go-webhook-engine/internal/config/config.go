@@ -1,6 +1,11 @@
 package config
 
-import "os"
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
 
 type Config struct {
 	MongoURI      string
@@ -8,16 +13,114 @@ type Config struct {
 	RedisAddr     string
 	RedisPassword string
 	RedisDB       int
+
+	// Per-request timeout for outbound webhook POSTs
+	DeliveryTimeout time.Duration
+
+	// Retry backoff: RetryBaseDelay * RetryMultiplier^(retry-1), capped
+	// at RetryMaxDelay, minus up to RetryJitter (0..1) of it at random
+	RetryBaseDelay  time.Duration
+	RetryMaxDelay   time.Duration
+	RetryMultiplier float64
+	RetryJitter     float64
+
+	// How often scheduled retries that are due move to the ready queue
+	PromoteInterval time.Duration
+
+	// "redis", or "memory" for a single process without Redis
+	QueueBackend string
+
+	// A dequeued event not acked within VisibilityTimeout is redelivered.
+	// ReapInterval must be shorter: it also keeps the worker's heartbeat.
+	VisibilityTimeout time.Duration
+	ReapInterval      time.Duration
+
+	// PROCESSING for this long without an update counts as stuck
+	StaleProcessingAfter time.Duration
+
+	// Bearer token for the /admin and /subscriptions APIs; both are off
+	// when empty
+	AdminToken string
+
+	// Allow subscriptions to loopback, private and link-local addresses,
+	// e.g. for local development
+	AllowPrivateURLs bool
 }
 
-func Load() *Config {
-	return &Config{
+// Load reads the config from the environment. Unset or unparsable
+// variables take their defaults; values that parse but can't work are an
+// error.
+func Load() (*Config, error) {
+	cfg := &Config{
 		MongoURI:      getEnv("MONGO_URI", "mongodb://localhost:27017"),
 		MongoDBName:   getEnv("MONGO_DB", "go-webhook-engine"),
 		RedisAddr:     getEnv("REDIS_ADDR", "localhost:6379"),
 		RedisPassword: getEnv("REDIS_PASSWORD", ""),
 		RedisDB:       0,
+
+		DeliveryTimeout: getEnvDuration("DELIVERY_TIMEOUT", 10*time.Second),
+
+		RetryBaseDelay:  getEnvDuration("RETRY_BASE_DELAY", 5*time.Second),
+		RetryMaxDelay:   getEnvDuration("RETRY_MAX_DELAY", 1*time.Hour),
+		RetryMultiplier: getEnvFloat("RETRY_MULTIPLIER", 2),
+		RetryJitter:     getEnvFloat("RETRY_JITTER", 0.2),
+		PromoteInterval: getEnvDuration("PROMOTE_INTERVAL", 1*time.Second),
+
+		QueueBackend: getEnv("QUEUE_BACKEND", "redis"),
+
+		VisibilityTimeout:    getEnvDuration("VISIBILITY_TIMEOUT", 5*time.Minute),
+		ReapInterval:         getEnvDuration("REAP_INTERVAL", 30*time.Second),
+		StaleProcessingAfter: getEnvDuration("STALE_PROCESSING_AFTER", 15*time.Minute),
+
+		AdminToken:       getEnv("ADMIN_TOKEN", ""),
+		AllowPrivateURLs: getEnvBool("ALLOW_PRIVATE_WEBHOOK_URLS", false),
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
+	return cfg, nil
+}
+
+// Validate rejects settings that would panic or make retries misbehave.
+func (c *Config) Validate() error {
+	positive := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"DELIVERY_TIMEOUT", c.DeliveryTimeout},
+		{"RETRY_BASE_DELAY", c.RetryBaseDelay},
+		{"RETRY_MAX_DELAY", c.RetryMaxDelay},
+		{"PROMOTE_INTERVAL", c.PromoteInterval},
+		{"VISIBILITY_TIMEOUT", c.VisibilityTimeout},
+		{"REAP_INTERVAL", c.ReapInterval},
+	}
+	for _, p := range positive {
+		if p.value <= 0 {
+			return fmt.Errorf("%s must be positive, got %s", p.name, p.value)
+		}
+	}
+
+	if c.RetryMaxDelay < c.RetryBaseDelay {
+		return fmt.Errorf("RETRY_MAX_DELAY (%s) must not be shorter than RETRY_BASE_DELAY (%s)", c.RetryMaxDelay, c.RetryBaseDelay)
+	}
+	// Reap refreshes the instance heartbeat, which lasts one visibility
+	// timeout; reaping less often lets other instances sweep this one
+	if c.ReapInterval >= c.VisibilityTimeout {
+		return fmt.Errorf("REAP_INTERVAL (%s) must be shorter than VISIBILITY_TIMEOUT (%s)", c.ReapInterval, c.VisibilityTimeout)
+	}
+	if c.StaleProcessingAfter <= c.VisibilityTimeout {
+		return fmt.Errorf("STALE_PROCESSING_AFTER (%s) must be longer than VISIBILITY_TIMEOUT (%s)", c.StaleProcessingAfter, c.VisibilityTimeout)
+	}
+
+	if c.RetryMultiplier < 1 {
+		return fmt.Errorf("RETRY_MULTIPLIER must be at least 1, got %g", c.RetryMultiplier)
+	}
+	if c.RetryJitter < 0 || c.RetryJitter > 1 {
+		return fmt.Errorf("RETRY_JITTER must be between 0 and 1, got %g", c.RetryJitter)
+	}
+
+	return nil
 }
 
 func getEnv(key, fallback string) string {
@@ -27,3 +130,27 @@ func getEnv(key, fallback string) string {
 	}
 	return val
 }
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return d
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	f, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return fallback
+	}
+	return f
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	b, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return b
+}
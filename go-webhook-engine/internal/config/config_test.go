@@ -0,0 +1,87 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.PromoteInterval != time.Second || cfg.RetryMultiplier != 2 {
+		t.Errorf("unexpected defaults %+v", cfg)
+	}
+}
+
+func TestLoadRejectsInvalidValues(t *testing.T) {
+	cases := []struct {
+		name, key, value, wantErr string
+	}{
+		{"zero promote interval", "PROMOTE_INTERVAL", "0s", "PROMOTE_INTERVAL"},
+		{"negative promote interval", "PROMOTE_INTERVAL", "-1s", "PROMOTE_INTERVAL"},
+		{"zero base delay", "RETRY_BASE_DELAY", "0s", "RETRY_BASE_DELAY"},
+		{"zero max delay", "RETRY_MAX_DELAY", "0s", "RETRY_MAX_DELAY"},
+		{"max below base", "RETRY_MAX_DELAY", "1s", "RETRY_MAX_DELAY"},
+		{"zero delivery timeout", "DELIVERY_TIMEOUT", "0s", "DELIVERY_TIMEOUT"},
+		{"shrinking multiplier", "RETRY_MULTIPLIER", "0.5", "RETRY_MULTIPLIER"},
+		{"negative jitter", "RETRY_JITTER", "-0.1", "RETRY_JITTER"},
+		{"jitter above one", "RETRY_JITTER", "1.5", "RETRY_JITTER"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Setenv(c.key, c.value)
+
+			_, err := Load()
+			if err == nil {
+				t.Fatalf("%s=%s: expected an error", c.key, c.value)
+			}
+			if !strings.Contains(err.Error(), c.wantErr) {
+				t.Errorf("error %q doesn't name %s", err, c.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadAcceptsBoundaryValues(t *testing.T) {
+	t.Setenv("RETRY_MULTIPLIER", "1")
+	t.Setenv("RETRY_JITTER", "1")
+	t.Setenv("RETRY_MAX_DELAY", "5s")
+
+	if _, err := Load(); err != nil {
+		t.Errorf("Load: %v", err)
+	}
+}
+
+func TestLoadRejectsLeaseTimings(t *testing.T) {
+	cases := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{"reap as long as visibility", map[string]string{"VISIBILITY_TIMEOUT": "30s", "REAP_INTERVAL": "30s"}, "REAP_INTERVAL"},
+		{"reap longer than visibility", map[string]string{"VISIBILITY_TIMEOUT": "1m", "REAP_INTERVAL": "2m"}, "REAP_INTERVAL"},
+		{"zero reap interval", map[string]string{"REAP_INTERVAL": "0s"}, "REAP_INTERVAL"},
+		{"zero visibility", map[string]string{"VISIBILITY_TIMEOUT": "0s"}, "VISIBILITY_TIMEOUT"},
+		{"stale before visibility", map[string]string{"STALE_PROCESSING_AFTER": "1m"}, "STALE_PROCESSING_AFTER"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			for k, v := range c.env {
+				t.Setenv(k, v)
+			}
+
+			_, err := Load()
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), c.wantErr) {
+				t.Errorf("error %q doesn't name %s", err, c.wantErr)
+			}
+		})
+	}
+}
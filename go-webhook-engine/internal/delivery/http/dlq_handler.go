@@ -0,0 +1,348 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/thutasann/go-webhook-engine/internal/domain"
+	"github.com/thutasann/go-webhook-engine/internal/queue"
+	"github.com/thutasann/go-webhook-engine/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	defaultDLQLimit = 50
+	maxDLQLimit     = 500
+
+	// dlqBatch is how many events a batch replay or purge handles per query
+	dlqBatch = 100
+)
+
+// DLQHandler is the admin API for dead-lettered events: events that ran
+// out of retries and are FAILED.
+type DLQHandler struct {
+	events   repository.EventRepository
+	attempts repository.AttemptRepository
+	queue    queue.Queue
+}
+
+func NewDLQHandler(events repository.EventRepository, attempts repository.AttemptRepository, q queue.Queue) *DLQHandler {
+	return &DLQHandler{
+		events:   events,
+		attempts: attempts,
+		queue:    q,
+	}
+}
+
+// Register adds the admin routes to mux.
+func (h *DLQHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /admin/dlq", h.List)
+	mux.HandleFunc("POST /admin/dlq/replay", h.ReplayMatching)
+	mux.HandleFunc("DELETE /admin/dlq", h.PurgeMatching)
+	mux.HandleFunc("POST /admin/dlq/{id}/replay", h.Replay)
+	mux.HandleFunc("DELETE /admin/dlq/{id}", h.Purge)
+	mux.HandleFunc("GET /admin/events/{id}", h.Event)
+}
+
+// eventView renders an event with its payload as JSON rather than base64.
+type eventView struct {
+	*domain.Event
+	Payload json.RawMessage `json:"payload"`
+}
+
+func newEventView(e *domain.Event) eventView {
+	return eventView{Event: e, Payload: e.Payload}
+}
+
+type DLQListResponse struct {
+	Events []eventView `json:"events"`
+	Total  int64       `json:"total"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+}
+
+type EventResponse struct {
+	Event    eventView                 `json:"event"`
+	Attempts []*domain.DeliveryAttempt `json:"attempts"` // oldest first
+}
+
+// List handles GET /admin/dlq?type=&from=&to=&limit=&offset=, newest first.
+func (h *DLQHandler) List(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseDLQFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit, err := queryInt(r, "limit", defaultDLQLimit)
+	if err != nil || limit < 1 || limit > maxDLQLimit {
+		http.Error(w, "limit must be 1-"+strconv.Itoa(maxDLQLimit), http.StatusBadRequest)
+		return
+	}
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil || offset < 0 {
+		http.Error(w, "invalid offset", http.StatusBadRequest)
+		return
+	}
+
+	events, total, err := h.events.ListFailed(r.Context(), filter, limit, offset)
+	if err != nil {
+		http.Error(w, "failed to list events", http.StatusInternalServerError)
+		return
+	}
+
+	resp := DLQListResponse{
+		Events: make([]eventView, 0, len(events)),
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}
+	for _, e := range events {
+		resp.Events = append(resp.Events, newEventView(e))
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Event handles GET /admin/events/{id}: any event, with its attempt history.
+func (h *DLQHandler) Event(w http.ResponseWriter, r *http.Request) {
+	event, ok := h.getEvent(w, r)
+	if !ok {
+		return
+	}
+
+	attempts, err := h.attempts.ListByEvent(r.Context(), event.ID.Hex())
+	if err != nil {
+		http.Error(w, "failed to list attempts", http.StatusInternalServerError)
+		return
+	}
+	if attempts == nil {
+		attempts = []*domain.DeliveryAttempt{}
+	}
+
+	writeJSON(w, http.StatusOK, EventResponse{Event: newEventView(event), Attempts: attempts})
+}
+
+// Replay handles POST /admin/dlq/{id}/replay. 409 if the event isn't in
+// the DLQ, including when it was already replayed.
+func (h *DLQHandler) Replay(w http.ResponseWriter, r *http.Request) {
+	event, ok := h.getEvent(w, r)
+	if !ok {
+		return
+	}
+
+	replayed, err := h.replay(r.Context(), event.ID.Hex())
+	if err != nil {
+		http.Error(w, "failed to replay event", http.StatusInternalServerError)
+		return
+	}
+	if !replayed {
+		http.Error(w, "event is not in the DLQ", http.StatusConflict)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]int{"replayed": 1})
+}
+
+// ReplayMatching handles POST /admin/dlq/replay?type=&from=&to=, replaying
+// every DLQ event matching the filter.
+func (h *DLQHandler) ReplayMatching(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseDLQFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// An event that fails again while the batch runs is back in the DLQ;
+	// seen keeps it from being replayed twice by one request.
+	seen := make(map[string]bool)
+	replayed := 0
+	for {
+		events, _, err := h.events.ListFailed(r.Context(), filter, dlqBatch, 0)
+		if err != nil {
+			http.Error(w, "failed to list events", http.StatusInternalServerError)
+			return
+		}
+
+		progressed := false
+		for _, e := range events {
+			id := e.ID.Hex()
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			progressed = true
+
+			ok, err := h.replay(r.Context(), id)
+			if err != nil {
+				http.Error(w, "failed to replay event "+id+" after replaying "+strconv.Itoa(replayed), http.StatusInternalServerError)
+				return
+			}
+			if ok {
+				replayed++
+			}
+		}
+		if !progressed {
+			break
+		}
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]int{"replayed": replayed})
+}
+
+// replay puts a FAILED event back on the queue as a fresh PENDING event
+// with its retries reset. It keeps its ID and idempotency key, and the
+// processor skips subscriptions it already reached, so a replay only
+// delivers to the ones that never got it. False if the event wasn't
+// FAILED, which makes replaying the same event twice a no-op.
+func (h *DLQHandler) replay(ctx context.Context, id string) (bool, error) {
+	ok, err := h.events.ResetFailed(ctx, id)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	if err := h.queue.Enqueue(ctx, id); err != nil {
+		// Back to the DLQ rather than PENDING with nothing to deliver it
+		_ = h.events.UpdateStatus(ctx, id, domain.StatusFailed)
+		return false, err
+	}
+
+	_ = h.queue.RemoveDLQ(ctx, id)
+	return true, nil
+}
+
+// Purge handles DELETE /admin/dlq/{id}: deletes a DLQ event and its
+// attempt history. 409 if the event isn't in the DLQ.
+func (h *DLQHandler) Purge(w http.ResponseWriter, r *http.Request) {
+	event, ok := h.getEvent(w, r)
+	if !ok {
+		return
+	}
+
+	purged, err := h.purge(r.Context(), []string{event.ID.Hex()})
+	if err != nil {
+		http.Error(w, "failed to purge event", http.StatusInternalServerError)
+		return
+	}
+	if purged == 0 {
+		http.Error(w, "event is not in the DLQ", http.StatusConflict)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]int{"purged": purged})
+}
+
+// PurgeMatching handles DELETE /admin/dlq?type=&from=&to=, purging every
+// DLQ event matching the filter.
+func (h *DLQHandler) PurgeMatching(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseDLQFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	purged := 0
+	for {
+		events, _, err := h.events.ListFailed(r.Context(), filter, dlqBatch, 0)
+		if err != nil {
+			http.Error(w, "failed to list events", http.StatusInternalServerError)
+			return
+		}
+		if len(events) == 0 {
+			break
+		}
+
+		ids := make([]string, 0, len(events))
+		for _, e := range events {
+			ids = append(ids, e.ID.Hex())
+		}
+
+		n, err := h.purge(r.Context(), ids)
+		purged += n
+		if err != nil {
+			http.Error(w, "failed to purge events after purging "+strconv.Itoa(purged), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]int{"purged": purged})
+}
+
+// purge deletes the events that are still FAILED, then their attempts and
+// DLQ entries, and returns how many were deleted. Their idempotency keys
+// are free again afterwards.
+func (h *DLQHandler) purge(ctx context.Context, ids []string) (int, error) {
+	deleted, err := h.events.DeleteFailed(ctx, ids)
+	if len(deleted) > 0 {
+		if _, err := h.attempts.DeleteByEvents(ctx, deleted); err != nil {
+			return len(deleted), err
+		}
+		if err := h.queue.RemoveDLQ(ctx, deleted...); err != nil {
+			return len(deleted), err
+		}
+	}
+
+	return len(deleted), err
+}
+
+// getEvent loads the event named by the {id} path value, writing a 400 or
+// 404 and returning false when there is none.
+func (h *DLQHandler) getEvent(w http.ResponseWriter, r *http.Request) (*domain.Event, bool) {
+	id := r.PathValue("id")
+	if !primitive.IsValidObjectID(id) {
+		http.Error(w, "invalid event id", http.StatusBadRequest)
+		return nil, false
+	}
+
+	event, err := h.events.GetByID(r.Context(), id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		http.Error(w, "event not found", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, "failed to load event", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return event, true
+}
+
+// parseDLQFilter reads type, from and to from the query. Dates are RFC 3339
+// or YYYY-MM-DD (midnight UTC); to is exclusive.
+func parseDLQFilter(r *http.Request) (repository.DLQFilter, error) {
+	q := r.URL.Query()
+	filter := repository.DLQFilter{Type: q.Get("type")}
+
+	var err error
+	if filter.From, err = parseDate(q.Get("from")); err != nil {
+		return filter, errors.New("invalid from: use RFC 3339 or YYYY-MM-DD")
+	}
+	if filter.To, err = parseDate(q.Get("to")); err != nil {
+		return filter, errors.New("invalid to: use RFC 3339 or YYYY-MM-DD")
+	}
+
+	return filter, nil
+}
+
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, s)
+}
+
+func queryInt(r *http.Request, key string, fallback int) (int, error) {
+	s := r.URL.Query().Get(key)
+	if s == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(s)
+}
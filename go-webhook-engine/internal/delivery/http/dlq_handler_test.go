@@ -0,0 +1,557 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/thutasann/go-webhook-engine/internal/domain"
+	"github.com/thutasann/go-webhook-engine/internal/queue"
+	"github.com/thutasann/go-webhook-engine/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeEvents is an in-memory EventRepository with the Mongo semantics the
+// admin API relies on.
+type fakeEvents struct {
+	mu         sync.Mutex
+	events     map[string]*domain.Event
+	lastFilter repository.DLQFilter
+
+	// onReset runs after ResetFailed, e.g. to fail the event again at once
+	onReset func(e *domain.Event)
+}
+
+func newFakeEvents(events ...*domain.Event) *fakeEvents {
+	f := &fakeEvents{events: make(map[string]*domain.Event)}
+	for _, e := range events {
+		f.events[e.ID.Hex()] = e
+	}
+	return f
+}
+
+func (f *fakeEvents) Create(ctx context.Context, event *domain.Event) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events[event.ID.Hex()] = event
+	return nil
+}
+
+func (f *fakeEvents) GetByID(ctx context.Context, id string) (*domain.Event, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	e, ok := f.events[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return e, nil
+}
+
+func (f *fakeEvents) UpdateStatus(ctx context.Context, id string, status domain.Status) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if e, ok := f.events[id]; ok {
+		e.Status = status
+	}
+	return nil
+}
+
+func (f *fakeEvents) IncrementRetry(ctx context.Context, id string) error {
+	return nil
+}
+
+func (f *fakeEvents) Touch(ctx context.Context, id string) error {
+	return nil
+}
+
+func (f *fakeEvents) ResetStaleProcessing(ctx context.Context, before time.Time) ([]string, error) {
+	return nil, nil
+}
+
+func (f *fakeEvents) ListFailed(ctx context.Context, filter repository.DLQFilter, limit, offset int) ([]*domain.Event, int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lastFilter = filter
+
+	var matched []*domain.Event
+	for _, e := range f.events {
+		if e.Status != domain.StatusFailed ||
+			(filter.Type != "" && e.Type != filter.Type) ||
+			(!filter.From.IsZero() && e.CreatedAt.Before(filter.From)) ||
+			(!filter.To.IsZero() && !e.CreatedAt.Before(filter.To)) {
+			continue
+		}
+		matched = append(matched, e)
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].CreatedAt.After(matched[j].CreatedAt) })
+
+	total := int64(len(matched))
+	if offset > len(matched) {
+		offset = len(matched)
+	}
+	matched = matched[offset:]
+	if len(matched) > limit {
+		matched = matched[:limit]
+	}
+	return matched, total, nil
+}
+
+func (f *fakeEvents) ResetFailed(ctx context.Context, id string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	e, ok := f.events[id]
+	if !ok || e.Status != domain.StatusFailed {
+		return false, nil
+	}
+	e.Status = domain.StatusPending
+	e.RetryCount = 0
+	if f.onReset != nil {
+		f.onReset(e)
+	}
+	return true, nil
+}
+
+func (f *fakeEvents) DeleteFailed(ctx context.Context, ids []string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var deleted []string
+	for _, id := range ids {
+		if e, ok := f.events[id]; ok && e.Status == domain.StatusFailed {
+			delete(f.events, id)
+			deleted = append(deleted, id)
+		}
+	}
+	return deleted, nil
+}
+
+type fakeAttempts struct {
+	mu       sync.Mutex
+	attempts map[string][]*domain.DeliveryAttempt // by event ID
+}
+
+func (f *fakeAttempts) Create(ctx context.Context, attempt *domain.DeliveryAttempt) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.attempts == nil {
+		f.attempts = make(map[string][]*domain.DeliveryAttempt)
+	}
+	id := attempt.EventID.Hex()
+	f.attempts[id] = append(f.attempts[id], attempt)
+	return nil
+}
+
+func (f *fakeAttempts) ListByEvent(ctx context.Context, eventID string) ([]*domain.DeliveryAttempt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.attempts[eventID], nil
+}
+
+func (f *fakeAttempts) DeleteByEvents(ctx context.Context, eventIDs []string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var n int64
+	for _, id := range eventIDs {
+		n += int64(len(f.attempts[id]))
+		delete(f.attempts, id)
+	}
+	return n, nil
+}
+
+const testToken = "admin-token"
+
+// dlqFixture serves the admin API over httptest.
+type dlqFixture struct {
+	*httptest.Server
+	events   *fakeEvents
+	attempts *fakeAttempts
+	queue    *queue.MemoryQueue
+}
+
+func newDLQFixture(t *testing.T, events ...*domain.Event) *dlqFixture {
+	f := &dlqFixture{
+		events:   newFakeEvents(events...),
+		attempts: &fakeAttempts{},
+		queue:    queue.NewMemoryQueue(time.Minute),
+	}
+
+	mux := http.NewServeMux()
+	NewDLQHandler(f.events, f.attempts, f.queue).Register(mux)
+	f.Server = httptest.NewServer(RequireToken(testToken, mux))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// do sends an authenticated request and decodes a JSON response into out.
+func (f *dlqFixture) do(t *testing.T, method, path string, out any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, f.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil && resp.StatusCode < 300 {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: decode: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// queued drains the ready queue.
+func (f *dlqFixture) queued(t *testing.T) []string {
+	t.Helper()
+	var ids []string
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		id, err := f.queue.Dequeue(ctx)
+		cancel()
+		if err != nil {
+			return ids
+		}
+		ids = append(ids, id)
+	}
+}
+
+var dlqEpoch = time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+// failedEvent is a FAILED event of type created days after dlqEpoch.
+func failedEvent(eventType string, days int) *domain.Event {
+	return &domain.Event{
+		ID:         primitive.NewObjectID(),
+		Type:       eventType,
+		Payload:    []byte(`{"n":1}`),
+		Status:     domain.StatusFailed,
+		RetryCount: 5,
+		MaxRetries: 5,
+		CreatedAt:  dlqEpoch.AddDate(0, 0, days),
+	}
+}
+
+func TestDLQRequiresToken(t *testing.T) {
+	f := newDLQFixture(t)
+
+	resp, err := http.Get(f.URL + "/admin/dlq")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("status %d without a token, want 401", resp.StatusCode)
+	}
+}
+
+func TestDLQListPagination(t *testing.T) {
+	var events []*domain.Event
+	for i := 0; i < 7; i++ {
+		events = append(events, failedEvent("user.created", i))
+	}
+	delivered := failedEvent("user.created", 10)
+	delivered.Status = domain.StatusSuccess
+	f := newDLQFixture(t, append(events, delivered)...)
+
+	var page DLQListResponse
+	if code := f.do(t, http.MethodGet, "/admin/dlq?limit=3&offset=2", &page); code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	if page.Total != 7 || page.Limit != 3 || page.Offset != 2 || len(page.Events) != 3 {
+		t.Fatalf("unexpected page: total=%d limit=%d offset=%d len=%d", page.Total, page.Limit, page.Offset, len(page.Events))
+	}
+	// Newest first: days 6, 5 are skipped
+	for i, want := range []*domain.Event{events[4], events[3], events[2]} {
+		if page.Events[i].ID != want.ID {
+			t.Errorf("event %d = %s, want %s", i, page.Events[i].ID.Hex(), want.ID.Hex())
+		}
+	}
+	if string(page.Events[0].Payload) != `{"n":1}` {
+		t.Errorf("payload rendered as %s, want raw JSON", page.Events[0].Payload)
+	}
+
+	var last DLQListResponse
+	f.do(t, http.MethodGet, "/admin/dlq?offset=6", &last)
+	if last.Limit != defaultDLQLimit || len(last.Events) != 1 {
+		t.Errorf("last page: limit=%d len=%d, want %d and 1", last.Limit, len(last.Events), defaultDLQLimit)
+	}
+
+	var past DLQListResponse
+	f.do(t, http.MethodGet, "/admin/dlq?offset=100", &past)
+	if past.Events == nil || len(past.Events) != 0 || past.Total != 7 {
+		t.Errorf("past the end: events=%v total=%d, want [] and 7", past.Events, past.Total)
+	}
+}
+
+func TestDLQListLimitBounds(t *testing.T) {
+	f := newDLQFixture(t, failedEvent("user.created", 0))
+
+	cases := []struct {
+		query string
+		want  int
+	}{
+		{"limit=1", http.StatusOK},
+		{fmt.Sprintf("limit=%d", maxDLQLimit), http.StatusOK},
+		{fmt.Sprintf("limit=%d", maxDLQLimit+1), http.StatusBadRequest},
+		{"limit=0", http.StatusBadRequest},
+		{"limit=-5", http.StatusBadRequest},
+		{"limit=ten", http.StatusBadRequest},
+		{"offset=0", http.StatusOK},
+		{"offset=-1", http.StatusBadRequest},
+		{"offset=x", http.StatusBadRequest},
+	}
+
+	for _, c := range cases {
+		if code := f.do(t, http.MethodGet, "/admin/dlq?"+c.query, nil); code != c.want {
+			t.Errorf("?%s: status %d, want %d", c.query, code, c.want)
+		}
+	}
+}
+
+func TestDLQFilterParsing(t *testing.T) {
+	f := newDLQFixture(t)
+
+	cases := []struct {
+		query string
+		want  repository.DLQFilter
+	}{
+		{"", repository.DLQFilter{}},
+		{"type=user.created", repository.DLQFilter{Type: "user.created"}},
+		{"from=2026-10-01&to=2026-10-08", repository.DLQFilter{
+			From: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+			To:   time.Date(2026, 10, 8, 0, 0, 0, 0, time.UTC),
+		}},
+		{"from=2026-10-01T12:30:00%2B02:00", repository.DLQFilter{
+			From: time.Date(2026, 10, 1, 10, 30, 0, 0, time.UTC),
+		}},
+	}
+
+	for _, c := range cases {
+		if code := f.do(t, http.MethodGet, "/admin/dlq?"+c.query, nil); code != http.StatusOK {
+			t.Errorf("?%s: status %d", c.query, code)
+			continue
+		}
+		got := f.events.lastFilter
+		if got.Type != c.want.Type || !got.From.Equal(c.want.From) || !got.To.Equal(c.want.To) {
+			t.Errorf("?%s: filter %+v, want %+v", c.query, got, c.want)
+		}
+	}
+
+	for _, query := range []string{"from=yesterday", "to=2026-13-01", "from=01/10/2026"} {
+		for _, method := range []string{http.MethodGet, http.MethodDelete} {
+			if code := f.do(t, method, "/admin/dlq?"+query, nil); code != http.StatusBadRequest {
+				t.Errorf("%s ?%s: status %d, want 400", method, query, code)
+			}
+		}
+		if code := f.do(t, http.MethodPost, "/admin/dlq/replay?"+query, nil); code != http.StatusBadRequest {
+			t.Errorf("replay ?%s: status %d, want 400", query, code)
+		}
+	}
+}
+
+func TestDLQListFilters(t *testing.T) {
+	created := failedEvent("user.created", 1)
+	deleted := failedEvent("user.deleted", 2)
+	late := failedEvent("user.created", 9)
+	f := newDLQFixture(t, created, deleted, late)
+
+	var page DLQListResponse
+	f.do(t, http.MethodGet, "/admin/dlq?type=user.created&from=2026-10-01&to=2026-10-05", &page)
+	if page.Total != 1 || len(page.Events) != 1 || page.Events[0].ID != created.ID {
+		t.Errorf("expected only %s, got total=%d", created.ID.Hex(), page.Total)
+	}
+}
+
+func TestDLQReplay(t *testing.T) {
+	event := failedEvent("user.created", 0)
+	f := newDLQFixture(t, event)
+	path := "/admin/dlq/" + event.ID.Hex() + "/replay"
+
+	var resp map[string]int
+	if code := f.do(t, http.MethodPost, path, &resp); code != http.StatusAccepted || resp["replayed"] != 1 {
+		t.Fatalf("first replay: status %d, body %v", code, resp)
+	}
+	if event.Status != domain.StatusPending || event.RetryCount != 0 {
+		t.Errorf("replayed event is %s with %d retries, want PENDING with 0", event.Status, event.RetryCount)
+	}
+	if q := f.queued(t); len(q) != 1 || q[0] != event.ID.Hex() {
+		t.Errorf("queue = %v, want the replayed event once", q)
+	}
+
+	// Not in the DLQ anymore: replaying again must not enqueue it twice
+	if code := f.do(t, http.MethodPost, path, nil); code != http.StatusConflict {
+		t.Errorf("second replay: status %d, want 409", code)
+	}
+	if q := f.queued(t); len(q) != 0 {
+		t.Errorf("second replay enqueued %v", q)
+	}
+}
+
+func TestDLQReplayErrors(t *testing.T) {
+	f := newDLQFixture(t)
+
+	if code := f.do(t, http.MethodPost, "/admin/dlq/not-an-id/replay", nil); code != http.StatusBadRequest {
+		t.Errorf("invalid id: status %d, want 400", code)
+	}
+	if code := f.do(t, http.MethodPost, "/admin/dlq/"+primitive.NewObjectID().Hex()+"/replay", nil); code != http.StatusNotFound {
+		t.Errorf("unknown id: status %d, want 404", code)
+	}
+}
+
+func TestDLQReplayMatching(t *testing.T) {
+	var matching []*domain.Event
+	for i := 0; i < 2*dlqBatch+5; i++ {
+		matching = append(matching, failedEvent("user.created", 0))
+	}
+	other := failedEvent("order.paid", 0)
+	f := newDLQFixture(t, append(matching, other)...)
+
+	var resp map[string]int
+	if code := f.do(t, http.MethodPost, "/admin/dlq/replay?type=user.created", &resp); code != http.StatusAccepted {
+		t.Fatalf("status %d", code)
+	}
+	if resp["replayed"] != len(matching) {
+		t.Errorf("replayed %d, want %d", resp["replayed"], len(matching))
+	}
+	if q := f.queued(t); len(q) != len(matching) {
+		t.Errorf("%d events queued, want %d", len(q), len(matching))
+	}
+	if other.Status != domain.StatusFailed {
+		t.Errorf("event of another type was replayed")
+	}
+
+	// Nothing left to replay
+	f.do(t, http.MethodPost, "/admin/dlq/replay?type=user.created", &resp)
+	if resp["replayed"] != 0 {
+		t.Errorf("second batch replayed %d, want 0", resp["replayed"])
+	}
+}
+
+func TestDLQReplayMatchingSkipsSeenEvents(t *testing.T) {
+	flaky := failedEvent("user.created", 0)
+	steady := failedEvent("user.created", 1)
+	f := newDLQFixture(t, flaky, steady)
+
+	// flaky fails again as soon as it is replayed, so it is back in the
+	// DLQ while the batch is still listing it
+	f.events.onReset = func(e *domain.Event) {
+		if e.ID == flaky.ID {
+			e.Status = domain.StatusFailed
+		}
+	}
+
+	var resp map[string]int
+	if code := f.do(t, http.MethodPost, "/admin/dlq/replay", &resp); code != http.StatusAccepted {
+		t.Fatalf("status %d", code)
+	}
+	if resp["replayed"] != 2 {
+		t.Errorf("replayed %d, want 2", resp["replayed"])
+	}
+
+	count := make(map[string]int)
+	for _, id := range f.queued(t) {
+		count[id]++
+	}
+	if count[flaky.ID.Hex()] != 1 || count[steady.ID.Hex()] != 1 {
+		t.Errorf("each event should be enqueued once, got %v", count)
+	}
+}
+
+func TestDLQPurge(t *testing.T) {
+	event := failedEvent("user.created", 0)
+	pending := failedEvent("user.created", 0)
+	pending.Status = domain.StatusPending
+	f := newDLQFixture(t, event, pending)
+
+	ctx := context.Background()
+	f.queue.EnqueueDLQ(ctx, event.ID.Hex())
+	for i := 0; i < 3; i++ {
+		f.attempts.Create(ctx, &domain.DeliveryAttempt{EventID: event.ID, Attempt: i + 1})
+	}
+	f.attempts.Create(ctx, &domain.DeliveryAttempt{EventID: pending.ID, Attempt: 1})
+
+	var resp map[string]int
+	if code := f.do(t, http.MethodDelete, "/admin/dlq/"+event.ID.Hex(), &resp); code != http.StatusOK || resp["purged"] != 1 {
+		t.Fatalf("purge: status %d, body %v", code, resp)
+	}
+	if _, err := f.events.GetByID(ctx, event.ID.Hex()); err != mongo.ErrNoDocuments {
+		t.Errorf("purged event still exists")
+	}
+	if a, _ := f.attempts.ListByEvent(ctx, event.ID.Hex()); len(a) != 0 {
+		t.Errorf("purged event kept %d attempts", len(a))
+	}
+
+	// Only DLQ events can be purged
+	if code := f.do(t, http.MethodDelete, "/admin/dlq/"+pending.ID.Hex(), nil); code != http.StatusConflict {
+		t.Errorf("purging a PENDING event: status %d, want 409", code)
+	}
+	if a, _ := f.attempts.ListByEvent(ctx, pending.ID.Hex()); len(a) != 1 {
+		t.Errorf("attempts of a kept event were deleted")
+	}
+	if code := f.do(t, http.MethodDelete, "/admin/dlq/"+event.ID.Hex(), nil); code != http.StatusNotFound {
+		t.Errorf("purging twice: status %d, want 404", code)
+	}
+}
+
+func TestDLQPurgeMatching(t *testing.T) {
+	ctx := context.Background()
+	var old []*domain.Event
+	for i := 0; i < dlqBatch+3; i++ {
+		old = append(old, failedEvent("user.created", 0))
+	}
+	recent := failedEvent("user.created", 10)
+	f := newDLQFixture(t, append(old, recent)...)
+
+	for _, e := range append(old, recent) {
+		f.attempts.Create(ctx, &domain.DeliveryAttempt{EventID: e.ID})
+	}
+
+	var resp map[string]int
+	if code := f.do(t, http.MethodDelete, "/admin/dlq?to=2026-10-05", &resp); code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	if resp["purged"] != len(old) {
+		t.Errorf("purged %d, want %d", resp["purged"], len(old))
+	}
+
+	for _, e := range old {
+		if _, err := f.events.GetByID(ctx, e.ID.Hex()); err != mongo.ErrNoDocuments {
+			t.Fatalf("event %s survived the purge", e.ID.Hex())
+		}
+		if a, _ := f.attempts.ListByEvent(ctx, e.ID.Hex()); len(a) != 0 {
+			t.Fatalf("event %s kept its attempts", e.ID.Hex())
+		}
+	}
+	if _, err := f.events.GetByID(ctx, recent.ID.Hex()); err != nil {
+		t.Errorf("event outside the filter was purged")
+	}
+	if a, _ := f.attempts.ListByEvent(ctx, recent.ID.Hex()); len(a) != 1 {
+		t.Errorf("attempts outside the filter were purged")
+	}
+}
+
+func TestDLQEvent(t *testing.T) {
+	ctx := context.Background()
+	event := failedEvent("user.created", 0)
+	f := newDLQFixture(t, event)
+	f.attempts.Create(ctx, &domain.DeliveryAttempt{EventID: event.ID, Attempt: 1, StatusCode: 500})
+	f.attempts.Create(ctx, &domain.DeliveryAttempt{EventID: event.ID, Attempt: 2, StatusCode: 502})
+
+	var resp EventResponse
+	if code := f.do(t, http.MethodGet, "/admin/events/"+event.ID.Hex(), &resp); code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	if resp.Event.ID != event.ID || len(resp.Attempts) != 2 || resp.Attempts[0].Attempt != 1 {
+		t.Errorf("unexpected response %+v", resp)
+	}
+}
@@ -0,0 +1,121 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/thutasann/go-webhook-engine/internal/domain"
+	"github.com/thutasann/go-webhook-engine/internal/repository"
+	"github.com/thutasann/go-webhook-engine/internal/service"
+)
+
+type SubscriptionHandler struct {
+	subs repository.SubscriptionRepository
+
+	// Accept URLs on loopback, private and link-local hosts
+	allowPrivate bool
+}
+
+func NewSubscriptionHandler(subs repository.SubscriptionRepository, allowPrivate bool) *SubscriptionHandler {
+	return &SubscriptionHandler{
+		subs:         subs,
+		allowPrivate: allowPrivate,
+	}
+}
+
+type CreateSubscriptionRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Secret     string   `json:"secret"` // generated when empty
+}
+
+// Subscriptions handles GET (list) and POST (create) on /subscriptions.
+func (h *SubscriptionHandler) Subscriptions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.list(w, r)
+	case http.MethodPost:
+		h.create(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *SubscriptionHandler) create(w http.ResponseWriter, r *http.Request) {
+	var req CreateSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		http.Error(w, "url must be an absolute http(s) URL", http.StatusBadRequest)
+		return
+	}
+
+	if !h.allowPrivate {
+		if err := service.CheckEndpointHost(r.Context(), u.Hostname()); err != nil {
+			if errors.Is(err, service.ErrPrivateAddress) {
+				http.Error(w, "url must not point to a loopback, private or other non-public address", http.StatusBadRequest)
+			} else {
+				http.Error(w, "url host does not resolve", http.StatusBadRequest)
+			}
+			return
+		}
+	}
+
+	if len(req.EventTypes) == 0 {
+		http.Error(w, "missing event_types", http.StatusBadRequest)
+		return
+	}
+
+	if req.Secret == "" {
+		req.Secret, err = service.NewSecret()
+		if err != nil {
+			http.Error(w, "failed to generate secret", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	sub := &domain.Subscription{
+		URL:        req.URL,
+		EventTypes: req.EventTypes,
+		Secret:     req.Secret,
+		Active:     true,
+	}
+
+	if err := h.subs.Create(r.Context(), sub); err != nil {
+		http.Error(w, "failed to persist subscription", http.StatusInternalServerError)
+		return
+	}
+
+	// The secret is only returned here, on creation
+	writeJSON(w, http.StatusCreated, sub)
+}
+
+func (h *SubscriptionHandler) list(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.subs.List(r.Context())
+	if err != nil {
+		http.Error(w, "failed to list subscriptions", http.StatusInternalServerError)
+		return
+	}
+
+	for _, sub := range subs {
+		sub.Secret = ""
+	}
+	if subs == nil {
+		subs = []*domain.Subscription{}
+	}
+
+	writeJSON(w, http.StatusOK, subs)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/thutasann/go-webhook-engine/internal/domain"
+)
+
+type fakeSubscriptions struct {
+	subs []*domain.Subscription
+}
+
+func (f *fakeSubscriptions) Create(ctx context.Context, sub *domain.Subscription) error {
+	f.subs = append(f.subs, sub)
+	return nil
+}
+
+func (f *fakeSubscriptions) GetByID(ctx context.Context, id string) (*domain.Subscription, error) {
+	return nil, nil
+}
+
+func (f *fakeSubscriptions) List(ctx context.Context) ([]*domain.Subscription, error) {
+	return f.subs, nil
+}
+
+func (f *fakeSubscriptions) ListByEventType(ctx context.Context, eventType string) ([]*domain.Subscription, error) {
+	return nil, nil
+}
+
+func (f *fakeSubscriptions) SetActive(ctx context.Context, id string, active bool) error {
+	return nil
+}
+
+func TestSubscriptionsRequireToken(t *testing.T) {
+	h := RequireToken("s3cret", http.HandlerFunc(NewSubscriptionHandler(&fakeSubscriptions{}, false).Subscriptions))
+
+	for _, auth := range []string{"", "Bearer wrong", "s3cret"} {
+		req := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: status %d, want 401", auth, rec.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("valid token: status %d, want 200", rec.Code)
+	}
+}
+
+func TestCreateSubscriptionRejectsPrivateHosts(t *testing.T) {
+	cases := []struct {
+		url          string
+		allowPrivate bool
+		want         int
+	}{
+		{"http://127.0.0.1:9000/hook", false, http.StatusBadRequest},
+		{"http://localhost/hook", false, http.StatusBadRequest},
+		{"http://[::1]/hook", false, http.StatusBadRequest},
+		{"http://10.0.0.8/hook", false, http.StatusBadRequest},
+		{"http://169.254.169.254/latest/meta-data", false, http.StatusBadRequest},
+		{"https://93.184.216.34/hook", false, http.StatusCreated},
+		{"http://127.0.0.1:9000/hook", true, http.StatusCreated},
+		{"ftp://93.184.216.34/hook", false, http.StatusBadRequest},
+	}
+
+	for _, c := range cases {
+		subs := &fakeSubscriptions{}
+		h := NewSubscriptionHandler(subs, c.allowPrivate)
+
+		body := `{"url":"` + c.url + `","event_types":["user.created"]}`
+		rec := httptest.NewRecorder()
+		h.Subscriptions(rec, httptest.NewRequest(http.MethodPost, "/subscriptions", strings.NewReader(body)))
+
+		if rec.Code != c.want {
+			t.Errorf("%s (allowPrivate=%v): status %d, want %d: %s", c.url, c.allowPrivate, rec.Code, c.want, rec.Body)
+		}
+		if created := len(subs.subs) == 1; created != (c.want == http.StatusCreated) {
+			t.Errorf("%s: persisted=%v, want %v", c.url, created, c.want == http.StatusCreated)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DeliveryAttempt records one POST of an event to one subscription.
+type DeliveryAttempt struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	EventID        primitive.ObjectID `bson:"event_id" json:"event_id"`
+	SubscriptionID primitive.ObjectID `bson:"subscription_id" json:"subscription_id"`
+	URL            string             `bson:"url" json:"url"`
+
+	Attempt    int           `bson:"attempt" json:"attempt"`         // 1 for the first try, then one per retry
+	StatusCode int           `bson:"status_code" json:"status_code"` // 0 if no response
+	Latency    time.Duration `bson:"latency" json:"latency"`
+	Response   string        `bson:"response" json:"response"` // start of the response body
+	Error      string        `bson:"error,omitempty" json:"error,omitempty"`
+	Success    bool          `bson:"success" json:"success"`
+
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
@@ -7,15 +7,15 @@ import (
 )
 
 type Event struct {
-	ID             primitive.ObjectID `bson:"_id,omitempty"`
-	IdempotencyKey string             `bson:"idempotency_key"`
-	Type           string             `bson:"type"`
-	Payload        []byte             `bson:"payload"`
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	IdempotencyKey string             `bson:"idempotency_key" json:"idempotency_key"`
+	Type           string             `bson:"type" json:"type"`
+	Payload        []byte             `bson:"payload" json:"-"` // raw JSON; views render it as json.RawMessage
 
-	Status     Status `bson:"status"`
-	RetryCount int    `bson:"retry_count"`
-	MaxRetries int    `bson:"max_retries"`
+	Status     Status `bson:"status" json:"status"`
+	RetryCount int    `bson:"retry_count" json:"retry_count"`
+	MaxRetries int    `bson:"max_retries" json:"max_retries"`
 
-	CreatedAt time.Time `bson:"created_at"`
-	UpdatedAt time.Time `bson:"updated_at"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
 }
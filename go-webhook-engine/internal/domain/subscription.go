@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Subscription is an endpoint that receives events of the given types.
+type Subscription struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	URL        string             `bson:"url" json:"url"`
+	EventTypes []string           `bson:"event_types" json:"event_types"` // "*" matches every type
+	Secret     string             `bson:"secret" json:"secret"`           // HMAC key for the signature header
+	Active     bool               `bson:"active" json:"active"`
+
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// WildcardEventType subscribes to every event type.
+const WildcardEventType = "*"
+
+// Matches reports whether the subscription wants events of eventType.
+func (s *Subscription) Matches(eventType string) bool {
+	if !s.Active {
+		return false
+	}
+	for _, t := range s.EventTypes {
+		if t == eventType || t == WildcardEventType {
+			return true
+		}
+	}
+	return false
+}
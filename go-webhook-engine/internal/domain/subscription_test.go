@@ -0,0 +1,28 @@
+package domain
+
+import "testing"
+
+func TestSubscriptionMatches(t *testing.T) {
+	cases := []struct {
+		name      string
+		sub       Subscription
+		eventType string
+		want      bool
+	}{
+		{"listed type", Subscription{Active: true, EventTypes: []string{"user.created", "user.deleted"}}, "user.deleted", true},
+		{"other type", Subscription{Active: true, EventTypes: []string{"user.created"}}, "order.paid", false},
+		{"wildcard", Subscription{Active: true, EventTypes: []string{WildcardEventType}}, "order.paid", true},
+		{"inactive", Subscription{Active: false, EventTypes: []string{"user.created"}}, "user.created", false},
+		{"inactive wildcard", Subscription{Active: false, EventTypes: []string{WildcardEventType}}, "user.created", false},
+		{"no types", Subscription{Active: true}, "user.created", false},
+		{"prefix is not a match", Subscription{Active: true, EventTypes: []string{"user"}}, "user.created", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.sub.Matches(c.eventType); got != c.want {
+				t.Errorf("Matches(%q) = %v, want %v", c.eventType, got, c.want)
+			}
+		})
+	}
+}
@@ -1,10 +1,47 @@
 package queue
 
-import "context"
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrLeaseLost is returned by Extend when the event is no longer leased:
+// it was acked, or its lease expired and it was reaped.
+var ErrLeaseLost = errors.New("queue: lease lost")
 
 type Queue interface {
 	Enqueue(ctx context.Context, eventID string) error
 
-	// Blocking dequeue
+	// Blocking dequeue. The event is leased, not removed: unless Ack is
+	// called within the visibility timeout, Reap makes it ready again.
 	Dequeue(ctx context.Context) (string, error)
+
+	// Ack removes a dequeued event for good, once it has been handled.
+	Ack(ctx context.Context, eventID string) error
+
+	// Extend renews a dequeued event's lease for another visibility
+	// timeout, so a long delivery isn't redelivered while still running.
+	Extend(ctx context.Context, eventID string) error
+
+	// Reap returns events whose lease expired at or before now to the
+	// ready queue, e.g. because the worker holding them crashed, and
+	// returns how many. Safe to run concurrently from several processes.
+	Reap(ctx context.Context, now time.Time) (int, error)
+
+	// EnqueueAt schedules eventID to become ready at the given time.
+	// It isn't dequeued before PromoteDue moves it to the ready queue.
+	EnqueueAt(ctx context.Context, eventID string, at time.Time) error
+
+	// PromoteDue moves scheduled events due at or before now to the
+	// ready queue, returning how many moved. Safe to run concurrently
+	// from several processes: each event moves once.
+	PromoteDue(ctx context.Context, now time.Time) (int, error)
+
+	// EnqueueDLQ parks an event that ran out of retries. The event's
+	// FAILED status is the record to query; this keeps the queue's view.
+	EnqueueDLQ(ctx context.Context, eventID string) error
+
+	// RemoveDLQ drops events from the DLQ, once replayed or purged.
+	RemoveDLQ(ctx context.Context, eventIDs ...string) error
 }
@@ -0,0 +1,169 @@
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryQueue is an in-process Queue for development and single-instance
+// setups. Events are lost when the process exits.
+type MemoryQueue struct {
+	mu       sync.Mutex
+	ready    []string
+	delayed  delayedHeap
+	inflight map[string]time.Time // dequeued, not acked: event ID -> lease expiry
+	dlq      map[string]struct{}
+
+	visibility time.Duration
+
+	// notify wakes one blocked Dequeue after an Enqueue
+	notify chan struct{}
+}
+
+func NewMemoryQueue(visibility time.Duration) *MemoryQueue {
+	return &MemoryQueue{
+		inflight:   make(map[string]time.Time),
+		dlq:        make(map[string]struct{}),
+		visibility: visibility,
+		notify:     make(chan struct{}, 1),
+	}
+}
+
+func (q *MemoryQueue) Enqueue(ctx context.Context, eventID string) error {
+	q.mu.Lock()
+	q.ready = append(q.ready, eventID)
+	q.mu.Unlock()
+
+	q.wake()
+	return nil
+}
+
+func (q *MemoryQueue) Dequeue(ctx context.Context) (string, error) {
+	for {
+		q.mu.Lock()
+		if len(q.ready) > 0 {
+			eventID := q.ready[0]
+			q.ready[0] = ""
+			q.ready = q.ready[1:]
+			q.inflight[eventID] = time.Now().Add(q.visibility)
+			more := len(q.ready) > 0
+			q.mu.Unlock()
+
+			// Pass the wake-up on so another waiter takes the rest
+			if more {
+				q.wake()
+			}
+			return eventID, nil
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-q.notify:
+		}
+	}
+}
+
+func (q *MemoryQueue) Ack(ctx context.Context, eventID string) error {
+	q.mu.Lock()
+	delete(q.inflight, eventID)
+	q.mu.Unlock()
+	return nil
+}
+
+func (q *MemoryQueue) Extend(ctx context.Context, eventID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.inflight[eventID]; !ok {
+		return ErrLeaseLost
+	}
+	q.inflight[eventID] = time.Now().Add(q.visibility)
+	return nil
+}
+
+func (q *MemoryQueue) Reap(ctx context.Context, now time.Time) (int, error) {
+	q.mu.Lock()
+	n := 0
+	for eventID, expiry := range q.inflight {
+		if !expiry.After(now) {
+			delete(q.inflight, eventID)
+			q.ready = append(q.ready, eventID)
+			n++
+		}
+	}
+	q.mu.Unlock()
+
+	if n > 0 {
+		q.wake()
+	}
+	return n, nil
+}
+
+func (q *MemoryQueue) EnqueueAt(ctx context.Context, eventID string, at time.Time) error {
+	q.mu.Lock()
+	heap.Push(&q.delayed, delayedEvent{id: eventID, due: at})
+	q.mu.Unlock()
+	return nil
+}
+
+func (q *MemoryQueue) PromoteDue(ctx context.Context, now time.Time) (int, error) {
+	q.mu.Lock()
+	n := 0
+	for len(q.delayed) > 0 && !q.delayed[0].due.After(now) {
+		q.ready = append(q.ready, heap.Pop(&q.delayed).(delayedEvent).id)
+		n++
+	}
+	q.mu.Unlock()
+
+	if n > 0 {
+		q.wake()
+	}
+	return n, nil
+}
+
+func (q *MemoryQueue) EnqueueDLQ(ctx context.Context, eventID string) error {
+	q.mu.Lock()
+	q.dlq[eventID] = struct{}{}
+	q.mu.Unlock()
+	return nil
+}
+
+func (q *MemoryQueue) RemoveDLQ(ctx context.Context, eventIDs ...string) error {
+	q.mu.Lock()
+	for _, id := range eventIDs {
+		delete(q.dlq, id)
+	}
+	q.mu.Unlock()
+	return nil
+}
+
+func (q *MemoryQueue) wake() {
+	select {
+	case q.notify <- struct{}{}:
+	default: // a wake-up is already pending
+	}
+}
+
+type delayedEvent struct {
+	id  string
+	due time.Time
+}
+
+// delayedHeap is a min-heap on due time.
+type delayedHeap []delayedEvent
+
+func (h delayedHeap) Len() int           { return len(h) }
+func (h delayedHeap) Less(i, j int) bool { return h[i].due.Before(h[j].due) }
+func (h delayedHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *delayedHeap) Push(x any)        { *h = append(*h, x.(delayedEvent)) }
+
+func (h *delayedHeap) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
@@ -0,0 +1,189 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// dequeueNow returns the next ready event, or "" if none is ready.
+func dequeueNow(t *testing.T, q *MemoryQueue) string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	id, err := q.Dequeue(ctx)
+	if err == context.DeadlineExceeded {
+		return ""
+	}
+	if err != nil {
+		t.Fatalf("Dequeue: %v", err)
+	}
+	return id
+}
+
+func TestMemoryQueueFIFO(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue(time.Minute)
+
+	for _, id := range []string{"a", "b", "c"} {
+		q.Enqueue(ctx, id)
+	}
+	for _, want := range []string{"a", "b", "c"} {
+		if got := dequeueNow(t, q); got != want {
+			t.Errorf("Dequeue() = %q, want %q", got, want)
+		}
+	}
+	if got := dequeueNow(t, q); got != "" {
+		t.Errorf("expected an empty queue, got %q", got)
+	}
+}
+
+func TestMemoryQueueDelayedNotReadyBeforeDue(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue(time.Minute)
+	now := time.Now()
+
+	q.EnqueueAt(ctx, "later", now.Add(time.Minute))
+
+	if got := dequeueNow(t, q); got != "" {
+		t.Fatalf("delayed event dequeued before promotion: %q", got)
+	}
+	if n, _ := q.PromoteDue(ctx, now); n != 0 {
+		t.Fatalf("PromoteDue before due moved %d events", n)
+	}
+	if got := dequeueNow(t, q); got != "" {
+		t.Fatalf("delayed event dequeued before it was due: %q", got)
+	}
+
+	if n, _ := q.PromoteDue(ctx, now.Add(time.Minute)); n != 1 {
+		t.Fatalf("PromoteDue at due time moved %d events, want 1", n)
+	}
+	if got := dequeueNow(t, q); got != "later" {
+		t.Errorf("Dequeue() = %q, want later", got)
+	}
+}
+
+func TestMemoryQueuePromotesInDueOrder(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue(time.Minute)
+	now := time.Now()
+
+	// Scheduled out of order
+	q.EnqueueAt(ctx, "third", now.Add(3*time.Second))
+	q.EnqueueAt(ctx, "first", now.Add(1*time.Second))
+	q.EnqueueAt(ctx, "fourth", now.Add(4*time.Second))
+	q.EnqueueAt(ctx, "second", now.Add(2*time.Second))
+
+	if n, _ := q.PromoteDue(ctx, now.Add(3*time.Second)); n != 3 {
+		t.Fatalf("PromoteDue moved %d events, want 3", n)
+	}
+	for _, want := range []string{"first", "second", "third"} {
+		if got := dequeueNow(t, q); got != want {
+			t.Errorf("Dequeue() = %q, want %q", got, want)
+		}
+	}
+	if got := dequeueNow(t, q); got != "" {
+		t.Errorf("event not yet due was dequeued: %q", got)
+	}
+
+	q.PromoteDue(ctx, now.Add(time.Hour))
+	if got := dequeueNow(t, q); got != "fourth" {
+		t.Errorf("Dequeue() = %q, want fourth", got)
+	}
+}
+
+func TestMemoryQueuePromoteWakesDequeue(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue(time.Minute)
+	q.EnqueueAt(ctx, "a", time.Now())
+
+	got := make(chan string, 1)
+	go func() {
+		id, _ := q.Dequeue(ctx)
+		got <- id
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	q.PromoteDue(ctx, time.Now())
+
+	select {
+	case id := <-got:
+		if id != "a" {
+			t.Errorf("Dequeue() = %q, want a", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("blocked Dequeue was not woken by PromoteDue")
+	}
+}
+
+func TestMemoryQueueAckRemovesLease(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue(time.Minute)
+	q.Enqueue(ctx, "a")
+
+	if got := dequeueNow(t, q); got != "a" {
+		t.Fatalf("Dequeue() = %q, want a", got)
+	}
+	q.Ack(ctx, "a")
+
+	// Acked events are never redelivered, however late the reap
+	if n, _ := q.Reap(ctx, time.Now().Add(time.Hour)); n != 0 {
+		t.Errorf("Reap requeued %d acked events", n)
+	}
+	if got := dequeueNow(t, q); got != "" {
+		t.Errorf("acked event dequeued again: %q", got)
+	}
+	if err := q.Extend(ctx, "a"); err != ErrLeaseLost {
+		t.Errorf("Extend after Ack = %v, want ErrLeaseLost", err)
+	}
+}
+
+func TestMemoryQueueReap(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue(time.Minute)
+	q.Enqueue(ctx, "a")
+
+	start := time.Now()
+	dequeueNow(t, q)
+
+	if n, _ := q.Reap(ctx, start.Add(30*time.Second)); n != 0 {
+		t.Fatalf("Reap before the lease expired requeued %d events", n)
+	}
+	if got := dequeueNow(t, q); got != "" {
+		t.Fatalf("leased event dequeued again before expiry: %q", got)
+	}
+
+	if n, _ := q.Reap(ctx, start.Add(2*time.Minute)); n != 1 {
+		t.Fatalf("Reap after the lease expired requeued %d events, want 1", n)
+	}
+	if got := dequeueNow(t, q); got != "a" {
+		t.Errorf("Dequeue() after reap = %q, want a", got)
+	}
+}
+
+func TestMemoryQueueExtend(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue(50 * time.Millisecond)
+	q.Enqueue(ctx, "a")
+	dequeueNow(t, q)
+
+	time.Sleep(30 * time.Millisecond)
+	if err := q.Extend(ctx, "a"); err != nil {
+		t.Fatalf("Extend: %v", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	// 60ms after Dequeue, but only 30ms after Extend
+	if n, _ := q.Reap(ctx, time.Now()); n != 0 {
+		t.Errorf("Reap requeued %d events with a renewed lease", n)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if n, _ := q.Reap(ctx, time.Now()); n != 1 {
+		t.Fatalf("Reap after the renewed lease expired requeued %d events, want 1", n)
+	}
+	if err := q.Extend(ctx, "a"); err != ErrLeaseLost {
+		t.Errorf("Extend after Reap = %v, want ErrLeaseLost", err)
+	}
+}
@@ -2,22 +2,66 @@ package queue
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// promoteBatch bounds how many events one PromoteDue or Reap script call
+// moves, so the script never blocks Redis for long.
+const promoteBatch = 500
+
+// dequeueBlock is how long Dequeue waits for an event before returning
+// redis.Nil, so callers get to notice their context was canceled.
+const dequeueBlock = 5 * time.Second
+
+// RedisQueue is a reliable queue on Redis lists. Dequeue atomically moves
+// an event from the ready list to this process's processing list and
+// leases it for the visibility timeout; Ack removes it. An event whose
+// lease expires, or whose process stops reaping, is pushed back to the
+// ready list by Reap on any instance.
+//
+// The Reap scripts touch processing lists named inside the leases set,
+// so all keys must live on one Redis node.
 type RedisQueue struct {
-	client *redis.Client
-	key    string
-	dlqKey string
+	client     *redis.Client
+	key        string
+	dlqKey     string
+	delayedKey string // sorted set: member = event ID, score = due time in Unix ms
+
+	visibility    time.Duration
+	processingKey string // list: events this process has dequeued and not acked
+	leasesKey     string // sorted set: member = processing list|event ID, score = lease expiry in Unix ms
+	consumersKey  string // set: processing lists of every process that has reaped
 }
 
-func NewRedisQueue(client *redis.Client, key string) *RedisQueue {
+func NewRedisQueue(client *redis.Client, key string, visibility time.Duration) *RedisQueue {
 	return &RedisQueue{
-		client: client,
-		key:    key,
-		dlqKey: key + ":dlq",
+		client:     client,
+		key:        key,
+		dlqKey:     key + ":dlq",
+		delayedKey: key + ":delayed",
+
+		visibility:    visibility,
+		processingKey: key + ":processing:" + consumerName(),
+		leasesKey:     key + ":leases",
+		consumersKey:  key + ":consumers",
+	}
+}
+
+// consumerName identifies this process among the queue's consumers.
+func consumerName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
 	}
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%s:%d:%s", host, os.Getpid(), hex.EncodeToString(b))
 }
 
 func (q *RedisQueue) Enqueue(ctx context.Context, eventID string) error {
@@ -25,16 +69,168 @@ func (q *RedisQueue) Enqueue(ctx context.Context, eventID string) error {
 }
 
 func (q *RedisQueue) Dequeue(ctx context.Context) (string, error) {
-	// 0 = block forever
-	result, err := q.client.BRPop(ctx, 0, q.key).Result()
+	// Oldest first: Enqueue pushes on the left
+	eventID, err := q.client.BLMove(ctx, q.key, q.processingKey, "RIGHT", "LEFT", dequeueBlock).Result()
+	if err != nil {
+		return "", err
+	}
+
+	err = q.client.ZAdd(ctx, q.leasesKey, redis.Z{
+		Score:  float64(time.Now().Add(q.visibility).UnixMilli()),
+		Member: q.lease(eventID),
+	}).Err()
 	if err != nil {
+		// Unleased, only a dead-consumer sweep would find it: put it back
+		_, _ = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.LRem(ctx, q.processingKey, 1, eventID)
+			pipe.RPush(ctx, q.key, eventID)
+			return nil
+		})
 		return "", err
 	}
 
-	// BRPop returns [key, value]
-	return result[1], nil
+	return eventID, nil
+}
+
+func (q *RedisQueue) Ack(ctx context.Context, eventID string) error {
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LRem(ctx, q.processingKey, 1, eventID)
+		pipe.ZRem(ctx, q.leasesKey, q.lease(eventID))
+		return nil
+	})
+	return err
+}
+
+func (q *RedisQueue) Extend(ctx context.Context, eventID string) error {
+	// XX: never recreate a lease that was acked or reaped
+	n, err := q.client.ZAddArgs(ctx, q.leasesKey, redis.ZAddArgs{
+		XX: true,
+		Ch: true,
+		Members: []redis.Z{{
+			Score:  float64(time.Now().Add(q.visibility).UnixMilli()),
+			Member: q.lease(eventID),
+		}},
+	}).Result()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func (q *RedisQueue) lease(eventID string) string {
+	return q.processingKey + "|" + eventID
 }
 
 func (q *RedisQueue) EnqueueDLQ(ctx context.Context, eventID string) error {
 	return q.client.LPush(ctx, q.dlqKey, eventID).Err()
 }
+
+func (q *RedisQueue) RemoveDLQ(ctx context.Context, eventIDs ...string) error {
+	if len(eventIDs) == 0 {
+		return nil
+	}
+	_, err := q.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, id := range eventIDs {
+			pipe.LRem(ctx, q.dlqKey, 0, id)
+		}
+		return nil
+	})
+	return err
+}
+
+func (q *RedisQueue) EnqueueAt(ctx context.Context, eventID string, at time.Time) error {
+	return q.client.ZAdd(ctx, q.delayedKey, redis.Z{
+		Score:  float64(at.UnixMilli()),
+		Member: eventID,
+	}).Err()
+}
+
+// promoteScript moves up to ARGV[2] members with score <= ARGV[1] from the
+// delayed set KEYS[1] to the ready list KEYS[2], in due order. Atomic, so
+// concurrent promoters never move an event twice.
+var promoteScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, id in ipairs(due) do
+	redis.call('ZREM', KEYS[1], id)
+	redis.call('LPUSH', KEYS[2], id)
+end
+return #due
+`)
+
+func (q *RedisQueue) PromoteDue(ctx context.Context, now time.Time) (int, error) {
+	total := 0
+	for {
+		n, err := promoteScript.Run(ctx, q.client, []string{q.delayedKey, q.key}, now.UnixMilli(), promoteBatch).Int()
+		total += n
+		if err != nil || n < promoteBatch {
+			return total, err
+		}
+	}
+}
+
+// reapScript returns events whose lease (in KEYS[1]) expired at or before
+// ARGV[1] from their processing list to the consuming end of the ready
+// list KEYS[2], up to ARGV[2] leases per call. A lease whose event is no
+// longer in its list was acked or already swept, and is just dropped.
+// Returns {leases looked at, events requeued}.
+var reapScript = redis.NewScript(`
+local expired = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+local n = 0
+for _, lease in ipairs(expired) do
+	redis.call('ZREM', KEYS[1], lease)
+	local list, id = string.match(lease, '^(.*)|(.*)$')
+	if redis.call('LREM', list, 1, id) > 0 then
+		redis.call('RPUSH', KEYS[2], id)
+		n = n + 1
+	end
+end
+return {#expired, n}
+`)
+
+// sweepScript empties the processing list of every consumer in KEYS[1]
+// whose heartbeat key (list .. ':alive') has expired into the consuming
+// end of the ready list KEYS[2], and forgets the consumer. This recovers
+// events a process dequeued but crashed before leasing.
+var sweepScript = redis.NewScript(`
+local n = 0
+for _, list in ipairs(redis.call('SMEMBERS', KEYS[1])) do
+	if redis.call('EXISTS', list .. ':alive') == 0 then
+		while redis.call('LMOVE', list, KEYS[2], 'RIGHT', 'RIGHT') do
+			n = n + 1
+		end
+		redis.call('SREM', KEYS[1], list)
+	end
+end
+return n
+`)
+
+// Reap also keeps this process's heartbeat alive for one visibility
+// timeout, so it must run more often than that.
+func (q *RedisQueue) Reap(ctx context.Context, now time.Time) (int, error) {
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SAdd(ctx, q.consumersKey, q.processingKey)
+		pipe.Set(ctx, q.processingKey+":alive", 1, q.visibility)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for {
+		res, err := reapScript.Run(ctx, q.client, []string{q.leasesKey, q.key}, now.UnixMilli(), promoteBatch).Int64Slice()
+		if err != nil {
+			return total, err
+		}
+		total += int(res[1])
+		if res[0] < promoteBatch {
+			break
+		}
+	}
+
+	n, err := sweepScript.Run(ctx, q.client, []string{q.consumersKey, q.key}).Int()
+	return total + n, err
+}
@@ -0,0 +1,208 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedisQueue(t *testing.T, visibility time.Duration) (*RedisQueue, *miniredis.Miniredis, *redis.Client) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisQueue(client, "test:events", visibility), mr, client
+}
+
+func TestRedisQueueDelayedNotReadyBeforeDue(t *testing.T) {
+	ctx := context.Background()
+	q, _, client := newTestRedisQueue(t, time.Minute)
+	now := time.Now()
+
+	if err := q.EnqueueAt(ctx, "later", now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := client.ZCard(ctx, "test:events:delayed").Result(); n != 1 {
+		t.Fatalf("delayed set has %d members, want 1", n)
+	}
+
+	if n, err := q.PromoteDue(ctx, now); err != nil || n != 0 {
+		t.Fatalf("PromoteDue before due = %d, %v; want 0", n, err)
+	}
+	if n, _ := client.LLen(ctx, "test:events").Result(); n != 0 {
+		t.Fatalf("event ready before it was due")
+	}
+
+	if n, err := q.PromoteDue(ctx, now.Add(time.Minute)); err != nil || n != 1 {
+		t.Fatalf("PromoteDue at due time = %d, %v; want 1", n, err)
+	}
+	if id, err := q.Dequeue(ctx); err != nil || id != "later" {
+		t.Errorf("Dequeue() = %q, %v; want later", id, err)
+	}
+	if n, _ := client.ZCard(ctx, "test:events:delayed").Result(); n != 0 {
+		t.Errorf("promoted event left in the delayed set")
+	}
+}
+
+func TestRedisQueuePromotesInDueOrder(t *testing.T) {
+	ctx := context.Background()
+	q, _, _ := newTestRedisQueue(t, time.Minute)
+	now := time.Now()
+
+	q.EnqueueAt(ctx, "third", now.Add(3*time.Second))
+	q.EnqueueAt(ctx, "first", now.Add(1*time.Second))
+	q.EnqueueAt(ctx, "fourth", now.Add(4*time.Second))
+	q.EnqueueAt(ctx, "second", now.Add(2*time.Second))
+
+	if n, err := q.PromoteDue(ctx, now.Add(3*time.Second)); err != nil || n != 3 {
+		t.Fatalf("PromoteDue = %d, %v; want 3", n, err)
+	}
+	for _, want := range []string{"first", "second", "third"} {
+		if got, err := q.Dequeue(ctx); err != nil || got != want {
+			t.Errorf("Dequeue() = %q, %v; want %q", got, err, want)
+		}
+	}
+}
+
+func TestRedisQueuePromoteBatches(t *testing.T) {
+	ctx := context.Background()
+	q, _, client := newTestRedisQueue(t, time.Minute)
+	now := time.Now()
+
+	for i := 0; i < promoteBatch+7; i++ {
+		q.EnqueueAt(ctx, fmt.Sprintf("e%d", i), now)
+	}
+	if n, err := q.PromoteDue(ctx, now); err != nil || n != promoteBatch+7 {
+		t.Fatalf("PromoteDue = %d, %v; want %d", n, err, promoteBatch+7)
+	}
+	if n, _ := client.LLen(ctx, "test:events").Result(); n != promoteBatch+7 {
+		t.Errorf("ready list has %d events, want %d", n, promoteBatch+7)
+	}
+}
+
+func TestRedisQueueAck(t *testing.T) {
+	ctx := context.Background()
+	q, _, client := newTestRedisQueue(t, time.Minute)
+
+	q.Enqueue(ctx, "a")
+	if _, err := q.Dequeue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := client.LLen(ctx, q.processingKey).Result(); n != 1 {
+		t.Fatalf("dequeued event not in the processing list")
+	}
+
+	if err := q.Ack(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := client.LLen(ctx, q.processingKey).Result(); n != 0 {
+		t.Errorf("acked event left in the processing list")
+	}
+	if n, _ := client.ZCard(ctx, q.leasesKey).Result(); n != 0 {
+		t.Errorf("acked event left leased")
+	}
+	if n, _ := q.Reap(ctx, time.Now().Add(time.Hour)); n != 0 {
+		t.Errorf("Reap requeued %d acked events", n)
+	}
+	if err := q.Extend(ctx, "a"); err != ErrLeaseLost {
+		t.Errorf("Extend after Ack = %v, want ErrLeaseLost", err)
+	}
+}
+
+func TestRedisQueueReap(t *testing.T) {
+	ctx := context.Background()
+	q, _, client := newTestRedisQueue(t, time.Minute)
+
+	q.Enqueue(ctx, "a")
+	start := time.Now()
+	if _, err := q.Dequeue(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := q.Reap(ctx, start.Add(30*time.Second)); err != nil || n != 0 {
+		t.Fatalf("Reap before the lease expired = %d, %v; want 0", n, err)
+	}
+	if n, _ := client.LLen(ctx, "test:events").Result(); n != 0 {
+		t.Fatalf("leased event ready again before expiry")
+	}
+
+	if n, err := q.Reap(ctx, start.Add(2*time.Minute)); err != nil || n != 1 {
+		t.Fatalf("Reap after the lease expired = %d, %v; want 1", n, err)
+	}
+	if id, err := q.Dequeue(ctx); err != nil || id != "a" {
+		t.Errorf("Dequeue() after reap = %q, %v; want a", id, err)
+	}
+}
+
+func TestRedisQueueExtend(t *testing.T) {
+	ctx := context.Background()
+	q, _, _ := newTestRedisQueue(t, time.Minute)
+
+	q.Enqueue(ctx, "a")
+	start := time.Now()
+	if _, err := q.Dequeue(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Extend renews from now; Reap is told time has moved on instead
+	time.Sleep(20 * time.Millisecond)
+	if err := q.Extend(ctx, "a"); err != nil {
+		t.Fatalf("Extend: %v", err)
+	}
+	if n, _ := q.Reap(ctx, start.Add(time.Minute+10*time.Millisecond)); n != 0 {
+		t.Errorf("Reap requeued an event with a renewed lease")
+	}
+	if n, _ := q.Reap(ctx, start.Add(2*time.Minute)); n != 1 {
+		t.Fatalf("Reap after the renewed lease expired requeued %d events, want 1", n)
+	}
+	if err := q.Extend(ctx, "a"); err != ErrLeaseLost {
+		t.Errorf("Extend after Reap = %v, want ErrLeaseLost", err)
+	}
+}
+
+func TestRedisQueueSweepsDeadConsumer(t *testing.T) {
+	ctx := context.Background()
+	q, mr, client := newTestRedisQueue(t, time.Minute)
+	dead := NewRedisQueue(client, "test:events", time.Minute)
+
+	dead.Enqueue(ctx, "a")
+	if _, err := dead.Dequeue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// Register dead's heartbeat, then lose the lease as if it crashed
+	// between BLMOVE and ZADD
+	dead.Reap(ctx, time.Now())
+	client.ZRem(ctx, dead.leasesKey, dead.lease("a"))
+
+	if n, _ := q.Reap(ctx, time.Now()); n != 0 {
+		t.Fatalf("swept a consumer whose heartbeat is alive")
+	}
+
+	mr.FastForward(2 * time.Minute)
+	if n, err := q.Reap(ctx, time.Now()); err != nil || n != 1 {
+		t.Fatalf("Reap after the heartbeat lapsed = %d, %v; want 1", n, err)
+	}
+	if id, err := q.Dequeue(ctx); err != nil || id != "a" {
+		t.Errorf("Dequeue() after sweep = %q, %v; want a", id, err)
+	}
+	if ok, _ := client.SIsMember(ctx, "test:events:consumers", dead.processingKey).Result(); ok {
+		t.Errorf("dead consumer still registered")
+	}
+}
+
+func TestRedisQueueDLQ(t *testing.T) {
+	ctx := context.Background()
+	q, _, client := newTestRedisQueue(t, time.Minute)
+
+	q.EnqueueDLQ(ctx, "a")
+	q.EnqueueDLQ(ctx, "b")
+	if err := q.RemoveDLQ(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if ids, _ := client.LRange(ctx, "test:events:dlq", 0, -1).Result(); len(ids) != 1 || ids[0] != "b" {
+		t.Errorf("DLQ = %v, want [b]", ids)
+	}
+}
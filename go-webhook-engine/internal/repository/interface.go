@@ -2,10 +2,19 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/thutasann/go-webhook-engine/internal/domain"
 )
 
+// DLQFilter selects dead-lettered (FAILED) events. Zero fields match
+// every event.
+type DLQFilter struct {
+	Type string
+	From time.Time // created at or after
+	To   time.Time // created before
+}
+
 type EventRepository interface {
 	Create(ctx context.Context, event *domain.Event) error
 
@@ -14,4 +23,44 @@ type EventRepository interface {
 	UpdateStatus(ctx context.Context, id string, status domain.Status) error
 
 	IncrementRetry(ctx context.Context, id string) error
+
+	// Refreshes updated_at of an event still PROCESSING, so a delivery
+	// that is still running isn't taken for stuck
+	Touch(ctx context.Context, id string) error
+
+	// Moves events left PROCESSING since before cutoff back to PENDING
+	// and returns their IDs, so they can be enqueued again
+	ResetStaleProcessing(ctx context.Context, before time.Time) ([]string, error)
+
+	// FAILED events matching filter, newest first, and the total matching
+	ListFailed(ctx context.Context, filter DLQFilter, limit, offset int) ([]*domain.Event, int64, error)
+
+	// Moves a FAILED event back to PENDING with its retry count reset.
+	// False if it wasn't FAILED, e.g. because it was already replayed
+	ResetFailed(ctx context.Context, id string) (bool, error)
+
+	// Deletes the given events that are still FAILED and returns their IDs
+	DeleteFailed(ctx context.Context, ids []string) ([]string, error)
+}
+
+type SubscriptionRepository interface {
+	Create(ctx context.Context, sub *domain.Subscription) error
+
+	GetByID(ctx context.Context, id string) (*domain.Subscription, error)
+
+	List(ctx context.Context) ([]*domain.Subscription, error)
+
+	// Active subscriptions whose event types include eventType or "*"
+	ListByEventType(ctx context.Context, eventType string) ([]*domain.Subscription, error)
+
+	SetActive(ctx context.Context, id string, active bool) error
+}
+
+type AttemptRepository interface {
+	Create(ctx context.Context, attempt *domain.DeliveryAttempt) error
+
+	// Oldest first
+	ListByEvent(ctx context.Context, eventID string) ([]*domain.DeliveryAttempt, error)
+
+	DeleteByEvents(ctx context.Context, eventIDs []string) (int64, error)
 }
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/thutasann/go-webhook-engine/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoAttemptRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoAttemptRepository(db *mongo.Database, collectionName string) *MongoAttemptRepository {
+	return &MongoAttemptRepository{
+		collection: db.Collection(collectionName),
+	}
+}
+
+func (r *MongoAttemptRepository) Create(ctx context.Context, attempt *domain.DeliveryAttempt) error {
+	if attempt.CreatedAt.IsZero() {
+		attempt.CreatedAt = time.Now()
+	}
+
+	result, err := r.collection.InsertOne(ctx, attempt)
+	if err != nil {
+		return err
+	}
+
+	if id, ok := result.InsertedID.(primitive.ObjectID); ok {
+		attempt.ID = id
+	}
+	return nil
+}
+
+func (r *MongoAttemptRepository) ListByEvent(ctx context.Context, eventID string) ([]*domain.DeliveryAttempt, error) {
+	objID, err := primitive.ObjectIDFromHex(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"event_id": objID}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var attempts []*domain.DeliveryAttempt
+	if err := cursor.All(ctx, &attempts); err != nil {
+		return nil, err
+	}
+
+	return attempts, nil
+}
+
+func (r *MongoAttemptRepository) DeleteByEvents(ctx context.Context, eventIDs []string) (int64, error) {
+	objIDs := make([]primitive.ObjectID, 0, len(eventIDs))
+	for _, id := range eventIDs {
+		objID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return 0, err
+		}
+		objIDs = append(objIDs, objID)
+	}
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"event_id": bson.M{"$in": objIDs}})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
+
+func (r *MongoAttemptRepository) EnsureIndexes(ctx context.Context) error {
+	indexModel := mongo.IndexModel{
+		Keys: bson.D{{Key: "event_id", Value: 1}, {Key: "created_at", Value: 1}},
+	}
+	_, err := r.collection.Indexes().CreateOne(ctx, indexModel)
+	return err
+}
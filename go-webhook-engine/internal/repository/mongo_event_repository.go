@@ -28,8 +28,16 @@ func (r *MongoEventRepository) Create(ctx context.Context, event *domain.Event)
 	event.UpdatedAt = now
 	event.Status = domain.StatusPending
 
-	_, err := r.collection.InsertOne(ctx, event)
-	return err
+	result, err := r.collection.InsertOne(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	// The handler enqueues event.ID, so it must be the generated one
+	if id, ok := result.InsertedID.(primitive.ObjectID); ok {
+		event.ID = id
+	}
+	return nil
 }
 
 func (r *MongoEventRepository) GetByID(ctx context.Context, id string) (*domain.Event, error) {
@@ -91,11 +99,172 @@ func (r *MongoEventRepository) IncrementRetry(ctx context.Context, id string) er
 	return nil
 }
 
+func (r *MongoEventRepository) Touch(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{
+		"_id":    objID,
+		"status": domain.StatusProcessing,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"updated_at": time.Now(),
+		},
+	}
+
+	_, err = r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// staleBatch bounds how many stale events one ResetStaleProcessing call resets.
+const staleBatch = 500
+
+func (r *MongoEventRepository) ResetStaleProcessing(ctx context.Context, before time.Time) ([]string, error) {
+	filter := bson.M{
+		"status":     domain.StatusProcessing,
+		"updated_at": bson.M{"$lt": before},
+	}
+
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 1}).
+		SetLimit(staleBatch)
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &stale); err != nil {
+		return nil, err
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":     domain.StatusPending,
+			"updated_at": time.Now(),
+		},
+	}
+
+	var ids []string
+	for _, e := range stale {
+		// Same filter again: a worker may have picked the event up since
+		filter["_id"] = e.ID
+		result, err := r.collection.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return ids, err
+		}
+		if result.ModifiedCount > 0 {
+			ids = append(ids, e.ID.Hex())
+		}
+	}
+
+	return ids, nil
+}
+
+func (r *MongoEventRepository) ListFailed(ctx context.Context, filter DLQFilter, limit, offset int) ([]*domain.Event, int64, error) {
+	query := bson.M{"status": domain.StatusFailed}
+	if filter.Type != "" {
+		query["type"] = filter.Type
+	}
+	created := bson.M{}
+	if !filter.From.IsZero() {
+		created["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		created["$lt"] = filter.To
+	}
+	if len(created) > 0 {
+		query["created_at"] = created
+	}
+
+	total, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var events []*domain.Event
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+func (r *MongoEventRepository) ResetFailed(ctx context.Context, id string) (bool, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, err
+	}
+
+	// Conditional on FAILED, so replaying twice only enqueues once
+	filter := bson.M{"_id": objID, "status": domain.StatusFailed}
+	update := bson.M{
+		"$set": bson.M{
+			"status":      domain.StatusPending,
+			"retry_count": 0,
+			"updated_at":  time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount > 0, nil
+}
+
+func (r *MongoEventRepository) DeleteFailed(ctx context.Context, ids []string) ([]string, error) {
+	var deleted []string
+	for _, id := range ids {
+		objID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return deleted, err
+		}
+
+		// One at a time so the caller knows exactly which went: one
+		// replayed meanwhile must keep its attempt history
+		result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objID, "status": domain.StatusFailed})
+		if err != nil {
+			return deleted, err
+		}
+		if result.DeletedCount > 0 {
+			deleted = append(deleted, id)
+		}
+	}
+
+	return deleted, nil
+}
+
 func (r *MongoEventRepository) EnsureIndexes(ctx context.Context) error {
-	indexModel := mongo.IndexModel{
-		Keys:    bson.D{{Key: "idempotency_key", Value: 1}},
-		Options: options.Index().SetUnique(true),
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "idempotency_key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			// ResetStaleProcessing
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "updated_at", Value: 1}},
+		},
+		{
+			// ListFailed
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "type", Value: 1}, {Key: "created_at", Value: -1}},
+		},
 	}
-	_, err := r.collection.Indexes().CreateOne(ctx, indexModel)
+	_, err := r.collection.Indexes().CreateMany(ctx, indexModels)
 	return err
 }
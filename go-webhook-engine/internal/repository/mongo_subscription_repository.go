@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/thutasann/go-webhook-engine/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoSubscriptionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoSubscriptionRepository(db *mongo.Database, collectionName string) *MongoSubscriptionRepository {
+	return &MongoSubscriptionRepository{
+		collection: db.Collection(collectionName),
+	}
+}
+
+func (r *MongoSubscriptionRepository) Create(ctx context.Context, sub *domain.Subscription) error {
+	now := time.Now()
+	sub.CreatedAt = now
+	sub.UpdatedAt = now
+
+	result, err := r.collection.InsertOne(ctx, sub)
+	if err != nil {
+		return err
+	}
+
+	if id, ok := result.InsertedID.(primitive.ObjectID); ok {
+		sub.ID = id
+	}
+	return nil
+}
+
+func (r *MongoSubscriptionRepository) GetByID(ctx context.Context, id string) (*domain.Subscription, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var sub domain.Subscription
+	err = r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&sub)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sub, nil
+}
+
+func (r *MongoSubscriptionRepository) List(ctx context.Context) ([]*domain.Subscription, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *MongoSubscriptionRepository) ListByEventType(ctx context.Context, eventType string) ([]*domain.Subscription, error) {
+	filter := bson.M{
+		"active":      true,
+		"event_types": bson.M{"$in": bson.A{eventType, domain.WildcardEventType}},
+	}
+	return r.find(ctx, filter)
+}
+
+func (r *MongoSubscriptionRepository) SetActive(ctx context.Context, id string, active bool) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"active":     active,
+			"updated_at": time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateByID(ctx, objID, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errors.New("subscription not found")
+	}
+
+	return nil
+}
+
+func (r *MongoSubscriptionRepository) find(ctx context.Context, filter bson.M) ([]*domain.Subscription, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var subs []*domain.Subscription
+	if err := cursor.All(ctx, &subs); err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+func (r *MongoSubscriptionRepository) EnsureIndexes(ctx context.Context) error {
+	indexModel := mongo.IndexModel{
+		Keys: bson.D{{Key: "event_types", Value: 1}, {Key: "active", Value: 1}},
+	}
+	_, err := r.collection.Indexes().CreateOne(ctx, indexModel)
+	return err
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"syscall"
+)
+
+// ErrPrivateAddress is returned for endpoints on loopback, private or
+// other non-public addresses.
+var ErrPrivateAddress = errors.New("address is not public")
+
+// nonPublic lists ranges that aren't public beyond those the netip.Addr
+// predicates cover.
+var nonPublic = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"),  // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // reserved, and broadcast
+	netip.MustParsePrefix("64:ff9b:1::/48"), // local-use NAT64
+	netip.MustParsePrefix("100::/64"),       // discard-only
+	netip.MustParsePrefix("2001:db8::/32"),  // documentation
+}
+
+// IPv6 ranges that embed an IPv4 address, which decides where they lead.
+var (
+	nat64     = netip.MustParsePrefix("64:ff9b::/96") // IPv4 in the last 4 bytes
+	sixToFour = netip.MustParsePrefix("2002::/16")    // IPv4 in bytes 2-5
+)
+
+// IsPublicIP reports whether ip may receive deliveries. Internal addresses
+// are refused so a subscription can't be used to reach services behind
+// the engine, such as cloud metadata at 169.254.169.254.
+func IsPublicIP(ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return false
+	}
+	return isPublicAddr(addr.Unmap())
+}
+
+func isPublicAddr(addr netip.Addr) bool {
+	if addr.IsLoopback() ||
+		addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsMulticast() ||
+		addr.IsUnspecified() {
+		return false
+	}
+	for _, p := range nonPublic {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+
+	b := addr.As16()
+	switch {
+	case nat64.Contains(addr):
+		return isPublicAddr(netip.AddrFrom4([4]byte(b[12:16])))
+	case sixToFour.Contains(addr):
+		return isPublicAddr(netip.AddrFrom4([4]byte(b[2:6])))
+	}
+	return true
+}
+
+// CheckEndpointHost resolves a subscription URL's host and returns
+// ErrPrivateAddress if any of its addresses isn't public.
+func CheckEndpointHost(ctx context.Context, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if !IsPublicIP(ip) {
+			return ErrPrivateAddress
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if !IsPublicIP(addr.IP) {
+			return ErrPrivateAddress
+		}
+	}
+	return nil
+}
+
+// PublicDialControl is a net.Dialer Control that refuses connections to
+// addresses that aren't public. Checking at subscribe time isn't enough:
+// the host may resolve somewhere else by the time an event is delivered.
+func PublicDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !IsPublicIP(ip) {
+		return fmt.Errorf("dial %s: %w", address, ErrPrivateAddress)
+	}
+	return nil
+}
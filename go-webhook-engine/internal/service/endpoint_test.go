@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	cases := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"0.1.2.3", false},
+		{"100.64.0.1", false},
+		{"100.127.255.254", false},
+		{"100.63.255.255", true},
+		{"100.128.0.1", true},
+		{"224.0.0.251", false},
+		{"239.255.255.250", false},
+		{"255.255.255.255", false},
+		{"ff02::1", false},
+		{"ff0e::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:93.184.216.34", true},
+		{"64:ff9b::a00:1", false},     // NAT64 of 10.0.0.1
+		{"64:ff9b::a9fe:a9fe", false}, // NAT64 of 169.254.169.254
+		{"64:ff9b::5db8:d822", true},  // NAT64 of 93.184.216.34
+		{"2002:7f00:1::1", false},     // 6to4 of 127.0.0.1
+		{"2002:c0a8:101::1", false},   // 6to4 of 192.168.1.1
+		{"2002:5db8:d822::1", true},   // 6to4 of 93.184.216.34
+		{"64:ff9b:1::1", false},
+		{"2001:db8::1", false},
+	}
+
+	for _, c := range cases {
+		if got := IsPublicIP(net.ParseIP(c.ip)); got != c.want {
+			t.Errorf("IsPublicIP(%s) = %v, want %v", c.ip, got, c.want)
+		}
+	}
+}
+
+func TestCheckEndpointHost(t *testing.T) {
+	for _, host := range []string{"127.0.0.1", "::1", "10.0.0.5", "169.254.169.254", "localhost"} {
+		if err := CheckEndpointHost(context.Background(), host); !errors.Is(err, ErrPrivateAddress) {
+			t.Errorf("CheckEndpointHost(%s) = %v, want ErrPrivateAddress", host, err)
+		}
+	}
+	if err := CheckEndpointHost(context.Background(), "93.184.216.34"); err != nil {
+		t.Errorf("CheckEndpointHost(public IP) = %v", err)
+	}
+}
+
+func TestPublicDialControl(t *testing.T) {
+	if err := PublicDialControl("tcp", "127.0.0.1:8080", nil); !errors.Is(err, ErrPrivateAddress) {
+		t.Errorf("expected loopback dial to be refused, got %v", err)
+	}
+	if err := PublicDialControl("tcp", "[fe80::1]:443", nil); !errors.Is(err, ErrPrivateAddress) {
+		t.Errorf("expected link-local dial to be refused, got %v", err)
+	}
+	if err := PublicDialControl("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("expected public dial to be allowed, got %v", err)
+	}
+}
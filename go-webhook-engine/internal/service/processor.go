@@ -1 +1,147 @@
 package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/thutasann/go-webhook-engine/internal/domain"
+	"github.com/thutasann/go-webhook-engine/internal/repository"
+)
+
+// responseSnippetSize is how much of a response body an attempt keeps.
+const responseSnippetSize = 512
+
+// Processor delivers events to their subscribers.
+type Processor struct {
+	subs     repository.SubscriptionRepository
+	attempts repository.AttemptRepository
+	client   *http.Client
+}
+
+func NewProcessor(subs repository.SubscriptionRepository, attempts repository.AttemptRepository, client *http.Client) *Processor {
+	return &Processor{
+		subs:     subs,
+		attempts: attempts,
+		client:   client,
+	}
+}
+
+// Process POSTs the event's payload to every active subscription for its
+// type and records each attempt. Subscriptions that already received the
+// event on an earlier try are skipped, so a retry only goes to the ones
+// that failed, and an event redelivered after a worker crash isn't sent
+// twice to those it reached.
+//
+// Returns an error if any delivery failed; the event should be retried.
+func (p *Processor) Process(ctx context.Context, event *domain.Event) error {
+	subs, err := p.subs.ListByEventType(ctx, event.Type)
+	if err != nil {
+		return fmt.Errorf("list subscriptions: %w", err)
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+
+	delivered, err := p.delivered(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, sub := range subs {
+		if delivered[sub.ID.Hex()] {
+			continue
+		}
+		if err := p.deliver(ctx, event, sub); err != nil {
+			errs = append(errs, fmt.Errorf("subscription %s: %w", sub.ID.Hex(), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// delivered returns the subscriptions that already have a successful
+// attempt for event. Checked on first tries too: a crashed worker may
+// have delivered before it could record the outcome on the event.
+func (p *Processor) delivered(ctx context.Context, event *domain.Event) (map[string]bool, error) {
+	attempts, err := p.attempts.ListByEvent(ctx, event.ID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("list attempts: %w", err)
+	}
+
+	delivered := make(map[string]bool, len(attempts))
+	for _, a := range attempts {
+		if a.Success {
+			delivered[a.SubscriptionID.Hex()] = true
+		}
+	}
+	return delivered, nil
+}
+
+// deliver makes one signed POST and records it. Non-2xx is a failure.
+func (p *Processor) deliver(ctx context.Context, event *domain.Event, sub *domain.Subscription) error {
+	attempt := &domain.DeliveryAttempt{
+		EventID:        event.ID,
+		SubscriptionID: sub.ID,
+		URL:            sub.URL,
+		Attempt:        event.RetryCount + 1,
+		CreatedAt:      time.Now(),
+	}
+
+	err := p.post(ctx, event, sub, attempt)
+	attempt.Latency = time.Since(attempt.CreatedAt)
+	attempt.Success = err == nil
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+
+	if recErr := p.attempts.Create(ctx, attempt); recErr != nil {
+		// A delivered event must not be retried just because the record
+		// failed; only report it when the delivery failed too.
+		if err == nil {
+			return nil
+		}
+		return errors.Join(err, fmt.Errorf("record attempt: %w", recErr))
+	}
+
+	return err
+}
+
+func (p *Processor) post(ctx context.Context, event *domain.Event, sub *domain.Subscription, attempt *domain.DeliveryAttempt) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(event.Payload))
+	if err != nil {
+		return err
+	}
+
+	timestamp := attempt.CreatedAt.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-webhook-engine")
+	req.Header.Set(HeaderEventID, event.ID.Hex())
+	req.Header.Set(HeaderEventType, event.Type)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, timestamp, event.Payload))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	attempt.StatusCode = resp.StatusCode
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, responseSnippetSize))
+	attempt.Response = string(snippet)
+
+	// Drain a little more so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("endpoint returned %d", resp.StatusCode)
+	}
+	return nil
+}
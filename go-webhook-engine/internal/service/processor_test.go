@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/thutasann/go-webhook-engine/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeSubscriptions serves a fixed list; ListByEventType uses Matches.
+type fakeSubscriptions struct {
+	subs []*domain.Subscription
+}
+
+func (f *fakeSubscriptions) Create(ctx context.Context, sub *domain.Subscription) error {
+	f.subs = append(f.subs, sub)
+	return nil
+}
+
+func (f *fakeSubscriptions) GetByID(ctx context.Context, id string) (*domain.Subscription, error) {
+	for _, s := range f.subs {
+		if s.ID.Hex() == id {
+			return s, nil
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+func (f *fakeSubscriptions) List(ctx context.Context) ([]*domain.Subscription, error) {
+	return f.subs, nil
+}
+
+func (f *fakeSubscriptions) ListByEventType(ctx context.Context, eventType string) ([]*domain.Subscription, error) {
+	var out []*domain.Subscription
+	for _, s := range f.subs {
+		if s.Matches(eventType) {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeSubscriptions) SetActive(ctx context.Context, id string, active bool) error {
+	return nil
+}
+
+// fakeAttempts keeps attempts in memory. createErr makes Create fail.
+type fakeAttempts struct {
+	mu        sync.Mutex
+	attempts  []*domain.DeliveryAttempt
+	createErr error
+}
+
+func (f *fakeAttempts) Create(ctx context.Context, attempt *domain.DeliveryAttempt) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.createErr != nil {
+		return f.createErr
+	}
+	f.attempts = append(f.attempts, attempt)
+	return nil
+}
+
+func (f *fakeAttempts) ListByEvent(ctx context.Context, eventID string) ([]*domain.DeliveryAttempt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []*domain.DeliveryAttempt
+	for _, a := range f.attempts {
+		if a.EventID.Hex() == eventID {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeAttempts) DeleteByEvents(ctx context.Context, eventIDs []string) (int64, error) {
+	return 0, nil
+}
+
+// endpoint is a test receiver that counts requests and replies with
+// status and body.
+type endpoint struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newEndpoint(t *testing.T, status int, body string) *endpoint {
+	e := &endpoint{}
+	e.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		e.mu.Lock()
+		e.requests = append(e.requests, r)
+		e.bodies = append(e.bodies, b)
+		e.mu.Unlock()
+
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(e.Close)
+	return e
+}
+
+func (e *endpoint) count() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.requests)
+}
+
+func newSubscription(url string) *domain.Subscription {
+	return &domain.Subscription{
+		ID:         primitive.NewObjectID(),
+		URL:        url,
+		EventTypes: []string{"user.created"},
+		Secret:     "whsec_test",
+		Active:     true,
+	}
+}
+
+func newEvent() *domain.Event {
+	return &domain.Event{
+		ID:         primitive.NewObjectID(),
+		Type:       "user.created",
+		Payload:    []byte(`{"user_id":42}`),
+		MaxRetries: 5,
+	}
+}
+
+func TestProcessDeliversSignedPayload(t *testing.T) {
+	ep := newEndpoint(t, http.StatusOK, "ok")
+	sub := newSubscription(ep.URL)
+	attempts := &fakeAttempts{}
+	p := NewProcessor(&fakeSubscriptions{subs: []*domain.Subscription{sub}}, attempts, ep.Client())
+
+	event := newEvent()
+	if err := p.Process(context.Background(), event); err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+
+	if ep.count() != 1 {
+		t.Fatalf("expected 1 request, got %d", ep.count())
+	}
+	req, body := ep.requests[0], ep.bodies[0]
+	if string(body) != string(event.Payload) {
+		t.Errorf("body = %s, want %s", body, event.Payload)
+	}
+	if got := req.Header.Get(HeaderEventID); got != event.ID.Hex() {
+		t.Errorf("%s = %q, want %q", HeaderEventID, got, event.ID.Hex())
+	}
+	if got := req.Header.Get(HeaderEventType); got != event.Type {
+		t.Errorf("%s = %q, want %q", HeaderEventType, got, event.Type)
+	}
+	ts, err := strconv.ParseInt(req.Header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("bad timestamp header: %v", err)
+	}
+	if !Verify(sub.Secret, ts, body, req.Header.Get(HeaderSignature)) {
+		t.Error("signature does not verify with the subscription secret")
+	}
+
+	if len(attempts.attempts) != 1 {
+		t.Fatalf("expected 1 attempt recorded, got %d", len(attempts.attempts))
+	}
+	a := attempts.attempts[0]
+	if !a.Success || a.StatusCode != http.StatusOK || a.Response != "ok" || a.Error != "" {
+		t.Errorf("unexpected attempt %+v", a)
+	}
+	if a.EventID != event.ID || a.SubscriptionID != sub.ID || a.URL != sub.URL || a.Attempt != 1 {
+		t.Errorf("attempt does not identify the delivery: %+v", a)
+	}
+}
+
+func TestProcessSkipsDeliveredSubscriptions(t *testing.T) {
+	done := newEndpoint(t, http.StatusOK, "")
+	pending := newEndpoint(t, http.StatusOK, "")
+	doneSub, pendingSub := newSubscription(done.URL), newSubscription(pending.URL)
+
+	event := newEvent()
+	event.RetryCount = 1
+	attempts := &fakeAttempts{attempts: []*domain.DeliveryAttempt{
+		{EventID: event.ID, SubscriptionID: doneSub.ID, Success: true},
+		{EventID: event.ID, SubscriptionID: pendingSub.ID, Success: false},
+	}}
+	subs := &fakeSubscriptions{subs: []*domain.Subscription{doneSub, pendingSub}}
+
+	if err := NewProcessor(subs, attempts, http.DefaultClient).Process(context.Background(), event); err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+
+	if done.count() != 0 {
+		t.Errorf("delivered subscription got %d more requests", done.count())
+	}
+	if pending.count() != 1 {
+		t.Errorf("failed subscription got %d requests, want 1", pending.count())
+	}
+	last := attempts.attempts[len(attempts.attempts)-1]
+	if last.SubscriptionID != pendingSub.ID || last.Attempt != 2 {
+		t.Errorf("unexpected retry attempt %+v", last)
+	}
+}
+
+func TestProcessNon2xxFails(t *testing.T) {
+	for _, status := range []int{http.StatusMovedPermanently, http.StatusBadRequest, http.StatusInternalServerError} {
+		t.Run(strconv.Itoa(status), func(t *testing.T) {
+			ep := newEndpoint(t, status, "nope")
+			ok := newEndpoint(t, http.StatusNoContent, "")
+			attempts := &fakeAttempts{}
+			subs := &fakeSubscriptions{subs: []*domain.Subscription{newSubscription(ep.URL), newSubscription(ok.URL)}}
+
+			// Don't follow redirects: a 3xx is reported as is
+			client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			}}
+
+			err := NewProcessor(subs, attempts, client).Process(context.Background(), newEvent())
+			if err == nil || !strings.Contains(err.Error(), strconv.Itoa(status)) {
+				t.Fatalf("expected an error naming status %d, got %v", status, err)
+			}
+			if ok.count() != 1 {
+				t.Error("a failing subscription must not stop delivery to the others")
+			}
+
+			if len(attempts.attempts) != 2 {
+				t.Fatalf("expected 2 attempts, got %d", len(attempts.attempts))
+			}
+			failed := attempts.attempts[0]
+			if failed.Success || failed.StatusCode != status || failed.Response != "nope" || failed.Error == "" {
+				t.Errorf("unexpected failed attempt %+v", failed)
+			}
+			if !attempts.attempts[1].Success {
+				t.Errorf("expected the 2xx attempt to succeed: %+v", attempts.attempts[1])
+			}
+		})
+	}
+}
+
+func TestProcessTruncatesResponseSnippet(t *testing.T) {
+	ep := newEndpoint(t, http.StatusOK, strings.Repeat("x", 2*responseSnippetSize))
+	attempts := &fakeAttempts{}
+	subs := &fakeSubscriptions{subs: []*domain.Subscription{newSubscription(ep.URL)}}
+
+	if err := NewProcessor(subs, attempts, ep.Client()).Process(context.Background(), newEvent()); err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+
+	if got := len(attempts.attempts[0].Response); got != responseSnippetSize {
+		t.Errorf("response snippet is %d bytes, want %d", got, responseSnippetSize)
+	}
+}
+
+func TestProcessRecordsUnreachableEndpoint(t *testing.T) {
+	ep := newEndpoint(t, http.StatusOK, "")
+	url := ep.URL
+	ep.Close()
+
+	attempts := &fakeAttempts{}
+	subs := &fakeSubscriptions{subs: []*domain.Subscription{newSubscription(url)}}
+	client := &http.Client{Timeout: time.Second}
+
+	if err := NewProcessor(subs, attempts, client).Process(context.Background(), newEvent()); err == nil {
+		t.Fatal("expected an error for an unreachable endpoint")
+	}
+	a := attempts.attempts[0]
+	if a.Success || a.StatusCode != 0 || a.Error == "" {
+		t.Errorf("unexpected attempt %+v", a)
+	}
+}
+
+func TestProcessRecordFailure(t *testing.T) {
+	recErr := errors.New("mongo down")
+
+	// Delivered: the failed record doesn't cause a retry
+	ok := newEndpoint(t, http.StatusOK, "")
+	subs := &fakeSubscriptions{subs: []*domain.Subscription{newSubscription(ok.URL)}}
+	if err := NewProcessor(subs, &fakeAttempts{createErr: recErr}, ok.Client()).Process(context.Background(), newEvent()); err != nil {
+		t.Errorf("expected success despite the record failing, got %v", err)
+	}
+
+	// Not delivered: both errors are reported
+	bad := newEndpoint(t, http.StatusInternalServerError, "")
+	subs = &fakeSubscriptions{subs: []*domain.Subscription{newSubscription(bad.URL)}}
+	err := NewProcessor(subs, &fakeAttempts{createErr: recErr}, bad.Client()).Process(context.Background(), newEvent())
+	if !errors.Is(err, recErr) || !strings.Contains(err.Error(), "500") {
+		t.Errorf("expected delivery and record errors, got %v", err)
+	}
+}
+
+func TestProcessWithoutSubscriptions(t *testing.T) {
+	ep := newEndpoint(t, http.StatusOK, "")
+	sub := newSubscription(ep.URL)
+	sub.EventTypes = []string{"order.paid"}
+	attempts := &fakeAttempts{}
+
+	if err := NewProcessor(&fakeSubscriptions{subs: []*domain.Subscription{sub}}, attempts, ep.Client()).Process(context.Background(), newEvent()); err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if ep.count() != 0 || len(attempts.attempts) != 0 {
+		t.Errorf("expected no delivery, got %d requests and %d attempts", ep.count(), len(attempts.attempts))
+	}
+}
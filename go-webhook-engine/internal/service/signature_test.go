@@ -0,0 +1,63 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSignFormat(t *testing.T) {
+	sig := Sign("secret", 1700000000, []byte(`{"a":1}`))
+	if !strings.HasPrefix(sig, "sha256=") || len(sig) != len("sha256=")+64 {
+		t.Fatalf("unexpected signature %q", sig)
+	}
+	if again := Sign("secret", 1700000000, []byte(`{"a":1}`)); again != sig {
+		t.Errorf("Sign is not deterministic: %q != %q", again, sig)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"user_id":42}`)
+	sig := Sign("whsec_a", 1700000000, body)
+
+	cases := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      []byte
+		signature string
+		want      bool
+	}{
+		{"valid", "whsec_a", 1700000000, body, sig, true},
+		{"wrong secret", "whsec_b", 1700000000, body, sig, false},
+		{"replayed with new timestamp", "whsec_a", 1700000001, body, sig, false},
+		{"tampered body", "whsec_a", 1700000000, []byte(`{"user_id":43}`), sig, false},
+		{"missing prefix", "whsec_a", 1700000000, body, strings.TrimPrefix(sig, "sha256="), false},
+		{"empty signature", "whsec_a", 1700000000, body, "", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := Verify(c.secret, c.timestamp, c.body, c.signature); got != c.want {
+				t.Errorf("Verify() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	a, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(a, "whsec_") || len(a) != len("whsec_")+64 {
+		t.Errorf("unexpected secret %q", a)
+	}
+	if a == b {
+		t.Error("expected two secrets to differ")
+	}
+}
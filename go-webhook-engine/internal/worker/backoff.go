@@ -0,0 +1,35 @@
+package worker
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff is the delay policy between delivery retries:
+// Base * Multiplier^(retry-1), capped at Max, then up to Jitter of it
+// taken off at random so retries of events that failed together spread out.
+type Backoff struct {
+	Base       time.Duration
+	Max        time.Duration
+	Multiplier float64
+	Jitter     float64 // 0..1, fraction of the delay that is randomized
+}
+
+// Delay returns how long to wait before retry number retry (1 = first retry).
+func (b Backoff) Delay(retry int) time.Duration {
+	if retry < 1 {
+		retry = 1
+	}
+
+	d := float64(b.Base) * math.Pow(b.Multiplier, float64(retry-1))
+	if d > float64(b.Max) || math.IsInf(d, 0) || math.IsNaN(d) {
+		d = float64(b.Max)
+	}
+
+	if b.Jitter > 0 {
+		d -= d * b.Jitter * rand.Float64()
+	}
+
+	return time.Duration(d)
+}
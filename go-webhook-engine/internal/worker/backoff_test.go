@@ -0,0 +1,75 @@
+package worker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{Base: time.Second, Max: time.Minute, Multiplier: 2}
+
+	cases := []struct {
+		name  string
+		retry int
+		want  time.Duration
+	}{
+		{"first retry is the base", 1, time.Second},
+		{"second doubles", 2, 2 * time.Second},
+		{"third doubles again", 3, 4 * time.Second},
+		{"below the cap", 6, 32 * time.Second},
+		{"capped at max", 7, time.Minute},
+		{"far past the cap", 100, time.Minute},
+		{"overflowing exponent", 10000, time.Minute},
+		{"zero is the first retry", 0, time.Second},
+		{"negative is the first retry", -3, time.Second},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := b.Delay(c.retry); got != c.want {
+				t.Errorf("Delay(%d) = %s, want %s", c.retry, got, c.want)
+			}
+		})
+	}
+}
+
+func TestBackoffMultiplierOne(t *testing.T) {
+	b := Backoff{Base: 3 * time.Second, Max: time.Minute, Multiplier: 1}
+	for retry := 1; retry <= 5; retry++ {
+		if got := b.Delay(retry); got != 3*time.Second {
+			t.Errorf("Delay(%d) = %s, want a constant 3s", retry, got)
+		}
+	}
+}
+
+func TestBackoffJitterBounds(t *testing.T) {
+	cases := []struct {
+		name   string
+		retry  int
+		jitter float64
+		full   time.Duration // delay before jitter
+	}{
+		{"uncapped", 3, 0.2, 4 * time.Second},
+		{"capped", 20, 0.2, time.Minute},
+		{"full jitter", 2, 1, 2 * time.Second},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			b := Backoff{Base: time.Second, Max: time.Minute, Multiplier: 2, Jitter: c.jitter}
+			low := c.full - time.Duration(float64(c.full)*c.jitter)
+
+			seen := make(map[time.Duration]bool)
+			for i := 0; i < 1000; i++ {
+				d := b.Delay(c.retry)
+				if d < low || d > c.full {
+					t.Fatalf("Delay(%d) = %s, outside [%s, %s]", c.retry, d, low, c.full)
+				}
+				seen[d] = true
+			}
+			if len(seen) < 2 {
+				t.Error("expected jitter to vary the delay")
+			}
+		})
+	}
+}
@@ -8,8 +8,28 @@ import (
 
 	"github.com/thutasann/go-webhook-engine/internal/queue"
 	"github.com/thutasann/go-webhook-engine/internal/repository"
+	"github.com/thutasann/go-webhook-engine/internal/service"
 )
 
+// Maintenance sets the pool's background loops.
+type Maintenance struct {
+	// How often scheduled retries that are due move to the ready queue
+	PromoteInterval time.Duration
+
+	// How often expired leases are reaped and stale events reset.
+	// Must be shorter than the queue's visibility timeout.
+	ReapInterval time.Duration
+
+	// An event PROCESSING without an update for this long is considered
+	// lost and reset to PENDING. Longer than the visibility timeout, so
+	// the queue's own redelivery gets there first.
+	StaleAfter time.Duration
+
+	// How often a worker renews the lease of the event it is handling.
+	// Must be shorter than the queue's visibility timeout.
+	RenewInterval time.Duration
+}
+
 type Pool struct {
 	workerCount int
 	minWorkers  int
@@ -17,6 +37,9 @@ type Pool struct {
 	queue       queue.Queue
 	repo        repository.EventRepository
 	rateLimiter *RateLimiter
+	processor   *service.Processor
+	backoff     Backoff
+	maintenance Maintenance
 
 	jobs chan string
 
@@ -33,6 +56,9 @@ func NewPool(
 	q queue.Queue,
 	r repository.EventRepository,
 	rl *RateLimiter,
+	proc *service.Processor,
+	backoff Backoff,
+	maintenance Maintenance,
 ) *Pool {
 
 	if workerCount < minWorkers {
@@ -50,6 +76,9 @@ func NewPool(
 		queue:        q,
 		repo:         r,
 		rateLimiter:  rl,
+		processor:    proc,
+		backoff:      backoff,
+		maintenance:  maintenance,
 		jobs:         make(chan string, 100),
 		activeWorker: workerCount,
 	}
@@ -68,6 +97,68 @@ func (p *Pool) Start(ctx context.Context) {
 		p.wg.Add(1)
 		go p.scaler(ctx)
 	}
+
+	// Scheduled retries -> ready queue
+	p.wg.Add(1)
+	go p.promoter(ctx)
+
+	// Expired leases and stale events -> ready queue
+	p.wg.Add(1)
+	go p.reaper(ctx)
+}
+
+// promoter moves scheduled retries whose time has come to the ready queue
+func (p *Pool) promoter(ctx context.Context) {
+	defer p.wg.Done()
+	ticker := time.NewTicker(p.maintenance.PromoteInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := p.queue.PromoteDue(ctx, now); err != nil && ctx.Err() == nil {
+				log.Printf("promote due retries: %v\n", err)
+			}
+		}
+	}
+}
+
+// reaper recovers events whose worker died: queue leases that expired
+// without an ack, and events Mongo still shows PROCESSING long after
+// anyone could be working on them
+func (p *Pool) reaper(ctx context.Context) {
+	defer p.wg.Done()
+	ticker := time.NewTicker(p.maintenance.ReapInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			n, err := p.queue.Reap(ctx, now)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("reap expired leases: %v\n", err)
+			}
+			if n > 0 {
+				log.Printf("requeued %d events with expired leases\n", n)
+			}
+
+			ids, err := p.repo.ResetStaleProcessing(ctx, now.Add(-p.maintenance.StaleAfter))
+			if err != nil && ctx.Err() == nil {
+				log.Printf("reset stale events: %v\n", err)
+			}
+			for _, id := range ids {
+				if err := p.queue.Enqueue(ctx, id); err != nil {
+					log.Printf("event %s: requeue stale: %v\n", id, err)
+					continue
+				}
+				log.Printf("event %s was stuck PROCESSING, requeued\n", id)
+			}
+		}
+	}
 }
 
 // scaler monitors queue length and adjusts workers dynamically
@@ -0,0 +1,176 @@
+package worker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/thutasann/go-webhook-engine/internal/domain"
+	"github.com/thutasann/go-webhook-engine/internal/queue"
+	"github.com/thutasann/go-webhook-engine/internal/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// recordingQueue is a MemoryQueue that records scheduling, DLQ and ack
+// calls.
+type recordingQueue struct {
+	*queue.MemoryQueue
+
+	mu        sync.Mutex
+	scheduled []time.Time
+	dlq       []string
+	acked     []string
+}
+
+func (q *recordingQueue) EnqueueAt(ctx context.Context, eventID string, at time.Time) error {
+	q.mu.Lock()
+	q.scheduled = append(q.scheduled, at)
+	q.mu.Unlock()
+	return q.MemoryQueue.EnqueueAt(ctx, eventID, at)
+}
+
+func (q *recordingQueue) EnqueueDLQ(ctx context.Context, eventID string) error {
+	q.mu.Lock()
+	q.dlq = append(q.dlq, eventID)
+	q.mu.Unlock()
+	return q.MemoryQueue.EnqueueDLQ(ctx, eventID)
+}
+
+func (q *recordingQueue) Ack(ctx context.Context, eventID string) error {
+	q.mu.Lock()
+	q.acked = append(q.acked, eventID)
+	q.mu.Unlock()
+	return q.MemoryQueue.Ack(ctx, eventID)
+}
+
+type fakeSubscriptions struct {
+	subs []*domain.Subscription
+}
+
+func (f *fakeSubscriptions) Create(ctx context.Context, sub *domain.Subscription) error { return nil }
+
+func (f *fakeSubscriptions) GetByID(ctx context.Context, id string) (*domain.Subscription, error) {
+	return nil, nil
+}
+
+func (f *fakeSubscriptions) List(ctx context.Context) ([]*domain.Subscription, error) {
+	return f.subs, nil
+}
+
+func (f *fakeSubscriptions) ListByEventType(ctx context.Context, eventType string) ([]*domain.Subscription, error) {
+	return f.subs, nil
+}
+
+func (f *fakeSubscriptions) SetActive(ctx context.Context, id string, active bool) error { return nil }
+
+type fakeAttempts struct {
+	mu       sync.Mutex
+	attempts []*domain.DeliveryAttempt
+}
+
+func (f *fakeAttempts) Create(ctx context.Context, attempt *domain.DeliveryAttempt) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts = append(f.attempts, attempt)
+	return nil
+}
+
+func (f *fakeAttempts) ListByEvent(ctx context.Context, eventID string) ([]*domain.DeliveryAttempt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []*domain.DeliveryAttempt
+	for _, a := range f.attempts {
+		if a.EventID.Hex() == eventID {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeAttempts) DeleteByEvents(ctx context.Context, eventIDs []string) (int64, error) {
+	return 0, nil
+}
+
+// newFailingPool returns a pool whose only subscriber always answers 500.
+func newFailingPool(t *testing.T, repo *fakeRepo, q *recordingQueue, backoff Backoff) *Pool {
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(endpoint.Close)
+
+	subs := &fakeSubscriptions{subs: []*domain.Subscription{{
+		ID:         primitive.NewObjectID(),
+		URL:        endpoint.URL,
+		EventTypes: []string{domain.WildcardEventType},
+		Active:     true,
+	}}}
+	processor := service.NewProcessor(subs, &fakeAttempts{}, endpoint.Client())
+
+	// Not stopped: Stop closes the bucket under the refill goroutine
+	rl := NewRateLimiter(1000)
+
+	return NewPool(1, 1, 1, q, repo, rl, processor, backoff, Maintenance{RenewInterval: time.Minute})
+}
+
+func TestFailedDeliveryIsScheduledWithBackoff(t *testing.T) {
+	ctx := context.Background()
+	event := &domain.Event{ID: primitive.NewObjectID(), Type: "user.created", Status: domain.StatusPending, MaxRetries: 3}
+	id := event.ID.Hex()
+	repo := newFakeRepo(event)
+	q := &recordingQueue{MemoryQueue: queue.NewMemoryQueue(time.Minute)}
+	backoff := Backoff{Base: time.Minute, Max: time.Hour, Multiplier: 2}
+	p := newFailingPool(t, repo, q, backoff)
+
+	for retry := 1; retry <= 2; retry++ {
+		q.Enqueue(ctx, id)
+		if _, err := q.Dequeue(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		before := time.Now()
+		p.handleEvent(ctx, id)
+		after := time.Now()
+
+		if len(q.scheduled) != retry {
+			t.Fatalf("retry %d: %d retries scheduled", retry, len(q.scheduled))
+		}
+		// Not retried right away: due after the backoff delay
+		at, delay := q.scheduled[retry-1], backoff.Delay(retry)
+		if at.Before(before.Add(delay)) || at.After(after.Add(delay)) {
+			t.Errorf("retry %d scheduled %s from now, want %s", retry, at.Sub(before).Round(time.Second), delay)
+		}
+
+		if e := repo.event(id); e.Status != domain.StatusPending || e.RetryCount != retry {
+			t.Errorf("retry %d: event is %s with %d retries, want PENDING with %d", retry, e.Status, e.RetryCount, retry)
+		}
+		if len(q.acked) != retry {
+			t.Errorf("retry %d: the failed try was not acked", retry)
+		}
+		if n, _ := q.PromoteDue(ctx, time.Now()); n != 0 {
+			t.Errorf("retry %d: promoted before its delay passed", retry)
+		}
+	}
+
+	// The last try goes to the DLQ instead of being scheduled again
+	q.Enqueue(ctx, id)
+	if _, err := q.Dequeue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	p.handleEvent(ctx, id)
+
+	if len(q.scheduled) != 2 {
+		t.Errorf("last try scheduled another retry")
+	}
+	if len(q.dlq) != 1 || q.dlq[0] != id {
+		t.Errorf("DLQ = %v, want [%s]", q.dlq, id)
+	}
+	if e := repo.event(id); e.Status != domain.StatusFailed {
+		t.Errorf("event is %s after its last try, want FAILED", e.Status)
+	}
+	if len(q.acked) != 3 {
+		t.Errorf("the last try was not acked")
+	}
+}
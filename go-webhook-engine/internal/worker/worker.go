@@ -2,12 +2,19 @@ package worker
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"time"
 
 	"github.com/thutasann/go-webhook-engine/internal/domain"
 	"github.com/thutasann/go-webhook-engine/internal/queue"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// ackTimeout bounds an ack made after the pool's context is canceled.
+const ackTimeout = 5 * time.Second
+
 func (p *Pool) worker(ctx context.Context) {
 	defer p.wg.Done()
 
@@ -26,47 +33,142 @@ func (p *Pool) worker(ctx context.Context) {
 	}
 }
 
+// handleEvent delivers one dequeued event and acks it once its outcome is
+// recorded. Returning without an ack leaves the event leased: the reaper
+// makes it ready again when the lease expires. The lease is renewed while
+// the event is handled, however long its delivery takes.
 func (p *Pool) handleEvent(ctx context.Context, eventID string) {
+	stopRenewing := p.renewLease(ctx, eventID)
+	done := p.process(ctx, eventID)
+	stopRenewing()
+
+	if done {
+		p.ack(ctx, eventID)
+	}
+}
+
+// process handles one event and reports whether it is done with, so it
+// can be acked.
+func (p *Pool) process(ctx context.Context, eventID string) bool {
 	// Wait for rate limiter token
 	if err := p.rateLimiter.Wait(ctx); err != nil {
-		return
+		return false
 	}
 
 	event, err := p.repo.GetByID(ctx, eventID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		log.Printf("event %s not found, dropping\n", eventID)
+		return true
+	}
 	if err != nil {
-		return
+		log.Printf("event %s: load: %v\n", eventID, err)
+		return false
+	}
+
+	// Redelivered after it was handled but before the ack landed
+	if event.Status == domain.StatusSuccess || event.Status == domain.StatusFailed {
+		return true
 	}
 
 	_ = p.repo.UpdateStatus(ctx, eventID, domain.StatusProcessing)
 
-	err = processEvent(event)
+	err = p.processor.Process(ctx, event)
 
 	if err != nil {
-		p.retryOrDLQ(ctx, eventID, event)
-		return
+		if ctx.Err() != nil {
+			// Shutting down mid-delivery: left leased, it is redelivered
+			return false
+		}
+		log.Printf("event %s delivery failed: %v\n", eventID, err)
+		if err := p.retryOrDLQ(ctx, eventID, event); err != nil {
+			log.Printf("event %s: %v\n", eventID, err)
+			return false
+		}
+		return true
 	}
 
 	_ = p.repo.UpdateStatus(ctx, eventID, domain.StatusSuccess)
+	return true
+}
+
+// renewLease extends eventID's lease every RenewInterval until the
+// returned stop is called; stop waits for any renewal in progress. Each
+// renewal also touches the event, so the reaper doesn't reset it to
+// PENDING as stuck while it is still being delivered.
+func (p *Pool) renewLease(ctx context.Context, eventID string) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(p.maintenance.RenewInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := p.queue.Extend(ctx, eventID)
+				if errors.Is(err, queue.ErrLeaseLost) {
+					// Reaped already: another worker may deliver it too
+					log.Printf("event %s: lease lost mid-delivery\n", eventID)
+					return
+				}
+				if err != nil && ctx.Err() == nil {
+					log.Printf("event %s: renew lease: %v\n", eventID, err)
+				}
+
+				if err := p.repo.Touch(ctx, eventID); err != nil && ctx.Err() == nil {
+					log.Printf("event %s: touch: %v\n", eventID, err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// ack removes a handled event from the queue. It runs even when ctx was
+// canceled mid-delivery, so a finished event isn't delivered again.
+func (p *Pool) ack(ctx context.Context, eventID string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), ackTimeout)
+	defer cancel()
+
+	if err := p.queue.Ack(ctx, eventID); err != nil {
+		log.Printf("event %s: ack: %v\n", eventID, err)
+	}
 }
 
-func (p *Pool) retryOrDLQ(ctx context.Context, eventID string, event *domain.Event) {
+// retryOrDLQ schedules the event's next retry, or moves it to the DLQ
+// when it has none left. An error means the event is in neither place.
+func (p *Pool) retryOrDLQ(ctx context.Context, eventID string, event *domain.Event) error {
 	_ = p.repo.IncrementRetry(ctx, eventID)
 
 	if event.RetryCount+1 >= event.MaxRetries {
 		_ = p.repo.UpdateStatus(ctx, eventID, domain.StatusFailed)
 
-		if redisQueue, ok := p.queue.(*queue.RedisQueue); ok {
-			_ = redisQueue.EnqueueDLQ(ctx, eventID)
+		// Still listed by the admin API from its FAILED status if this fails
+		if err := p.queue.EnqueueDLQ(ctx, eventID); err != nil {
+			log.Printf("event %s: enqueue DLQ: %v\n", eventID, err)
 		}
 
 		log.Printf("event %s moved to DLQ\n", eventID)
-		return
+		return nil
 	}
 
-	_ = p.queue.Enqueue(ctx, eventID)
-}
+	// Back off instead of hammering a failing endpoint: the promoter
+	// moves the event to the ready queue once the delay has passed
+	delay := p.backoff.Delay(event.RetryCount + 1)
+	_ = p.repo.UpdateStatus(ctx, eventID, domain.StatusPending)
+
+	if err := p.queue.EnqueueAt(ctx, eventID, time.Now().Add(delay)); err != nil {
+		return fmt.Errorf("schedule retry: %w", err)
+	}
 
-func processEvent(event *domain.Event) error {
-	// simulate real processing
+	log.Printf("event %s retry %d/%d in %s\n", eventID, event.RetryCount+1, event.MaxRetries-1, delay.Round(time.Millisecond))
 	return nil
 }
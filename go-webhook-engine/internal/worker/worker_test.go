@@ -0,0 +1,161 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/thutasann/go-webhook-engine/internal/domain"
+	"github.com/thutasann/go-webhook-engine/internal/queue"
+	"github.com/thutasann/go-webhook-engine/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeRepo is an in-memory EventRepository with the Mongo semantics the
+// pool relies on.
+type fakeRepo struct {
+	mu     sync.Mutex
+	events map[string]*domain.Event
+}
+
+func newFakeRepo(events ...*domain.Event) *fakeRepo {
+	r := &fakeRepo{events: make(map[string]*domain.Event)}
+	for _, e := range events {
+		r.events[e.ID.Hex()] = e
+	}
+	return r
+}
+
+func (r *fakeRepo) event(id string) domain.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return *r.events[id]
+}
+
+func (r *fakeRepo) Create(ctx context.Context, event *domain.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events[event.ID.Hex()] = event
+	return nil
+}
+
+func (r *fakeRepo) GetByID(ctx context.Context, id string) (*domain.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.events[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	copied := *e
+	return &copied, nil
+}
+
+func (r *fakeRepo) UpdateStatus(ctx context.Context, id string, status domain.Status) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e, ok := r.events[id]; ok {
+		e.Status = status
+		e.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (r *fakeRepo) IncrementRetry(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e, ok := r.events[id]; ok {
+		e.RetryCount++
+	}
+	return nil
+}
+
+func (r *fakeRepo) Touch(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e, ok := r.events[id]; ok && e.Status == domain.StatusProcessing {
+		e.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (r *fakeRepo) ResetStaleProcessing(ctx context.Context, before time.Time) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ids []string
+	for id, e := range r.events {
+		if e.Status == domain.StatusProcessing && e.UpdatedAt.Before(before) {
+			e.Status = domain.StatusPending
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (r *fakeRepo) ListFailed(ctx context.Context, filter repository.DLQFilter, limit, offset int) ([]*domain.Event, int64, error) {
+	return nil, 0, nil
+}
+
+func (r *fakeRepo) ResetFailed(ctx context.Context, id string) (bool, error) {
+	return false, nil
+}
+
+func (r *fakeRepo) DeleteFailed(ctx context.Context, ids []string) ([]string, error) {
+	return nil, nil
+}
+
+func TestRenewLeaseKeepsEventLeased(t *testing.T) {
+	ctx := context.Background()
+	q := queue.NewMemoryQueue(40 * time.Millisecond)
+	p := &Pool{queue: q, repo: newFakeRepo(), maintenance: Maintenance{RenewInterval: 10 * time.Millisecond}}
+
+	q.Enqueue(ctx, "a")
+	if _, err := q.Dequeue(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// A delivery running for several visibility timeouts
+	stop := p.renewLease(ctx, "a")
+	time.Sleep(150 * time.Millisecond)
+	if n, _ := q.Reap(ctx, time.Now()); n != 0 {
+		t.Fatalf("event reaped while its lease was being renewed")
+	}
+
+	stop()
+	time.Sleep(60 * time.Millisecond)
+	if n, _ := q.Reap(ctx, time.Now()); n != 1 {
+		t.Errorf("expected the lease to expire once renewal stopped, reaped %d", n)
+	}
+}
+
+func TestRenewLeaseKeepsEventFromGoingStale(t *testing.T) {
+	ctx := context.Background()
+	event := &domain.Event{
+		ID:        primitive.NewObjectID(),
+		Status:    domain.StatusProcessing,
+		UpdatedAt: time.Now(),
+	}
+	id := event.ID.Hex()
+	repo := newFakeRepo(event)
+	q := queue.NewMemoryQueue(40 * time.Millisecond)
+	p := &Pool{queue: q, repo: repo, maintenance: Maintenance{RenewInterval: 10 * time.Millisecond}}
+
+	q.Enqueue(ctx, id)
+	if _, err := q.Dequeue(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Delivering for longer than StaleAfter (50ms)
+	stop := p.renewLease(ctx, id)
+	time.Sleep(150 * time.Millisecond)
+	if ids, _ := repo.ResetStaleProcessing(ctx, time.Now().Add(-50*time.Millisecond)); len(ids) != 0 {
+		t.Fatalf("event reset as stale while still being delivered")
+	}
+
+	stop()
+	time.Sleep(60 * time.Millisecond)
+	if ids, _ := repo.ResetStaleProcessing(ctx, time.Now().Add(-50*time.Millisecond)); len(ids) != 1 {
+		t.Errorf("expected the event to go stale once renewal stopped")
+	}
+}